@@ -0,0 +1,414 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"greatestworks/internal/domain/inventory"
+	"greatestworks/internal/infrastructure/datamanager"
+	"greatestworks/internal/infrastructure/persistence"
+)
+
+// 拍卖行错误
+var (
+	ErrAuctionNotFound        = errors.New("auction not found")
+	ErrAuctionClosed          = errors.New("auction no longer active")
+	ErrAuctionChanged         = errors.New("auction changed, please retry")
+	ErrAuctionOwnListing      = errors.New("cannot bid on own auction")
+	ErrAuctionInvalidPrice    = errors.New("invalid auction price")
+	ErrAuctionInvalidDuration = errors.New("invalid auction duration")
+	ErrAuctionTooManyListings = errors.New("too many active auctions")
+	ErrAuctionBidTooLow       = errors.New("bid too low")
+	ErrAuctionNoBidding       = errors.New("auction is buyout only")
+	ErrAuctionNoBuyout        = errors.New("auction has no buyout price")
+	ErrAuctionHasBids         = errors.New("cannot cancel auction with bids")
+)
+
+const (
+	auctionSender        = "Auction House"
+	auctionSweepInterval = 5 * time.Second // 到期结算扫描间隔
+	auctionSweepBatch    = 100             // 每次最多结算的拍卖数
+	auctionSearchLimit   = 50              // 每页最多返回的拍卖数
+)
+
+// AuctionConfig 拍卖行规则
+type AuctionConfig struct {
+	Durations       []time.Duration // 可选的上架时长
+	ListingFeeRate  float64         // 上架手续费比例（按起拍价与一口价较高者计算，不退还）
+	MinListingFee   int64           // 最低上架手续费
+	SalesTaxRate    float64         // 成交税率（从卖家所得中扣除）
+	MinBidIncrement float64         // 相对当前出价的最小加价比例
+	MaxListings     int64           // 每名角色同时上架的拍卖数上限
+	MailExpireDays  int             // 拍卖邮件保留天数
+}
+
+// AuctionStore 拍卖持久化（由 AuctionRepository 实现，写操作需支持事务上下文）
+type AuctionStore interface {
+	NextAuctionID(ctx context.Context) (int64, error)
+	Create(ctx context.Context, doc *persistence.AuctionDocument) error
+	FindByID(ctx context.Context, auctionID int64) (*persistence.AuctionDocument, error)
+	Search(ctx context.Context, filter persistence.AuctionFilter) ([]*persistence.AuctionDocument, error)
+	CountActiveBySeller(ctx context.Context, sellerID int64) (int64, error)
+	FindExpired(ctx context.Context, now time.Time, limit int64) ([]*persistence.AuctionDocument, error)
+	PlaceBid(ctx context.Context, auctionID, prevBid, bidderID, bid int64) (bool, error)
+	Close(ctx context.Context, auctionID, prevBid int64, status int32, buyerID, price, tax int64) (bool, error)
+}
+
+// AuctionListing 上架参数
+type AuctionListing struct {
+	ItemUID  int64
+	Count    int32
+	StartBid int64 // 0 表示仅一口价
+	Buyout   int64 // 0 表示仅竞拍
+	Duration time.Duration
+}
+
+// AuctionView 拍卖快照
+type AuctionView struct {
+	AuctionID int64 `json:"auction_id"`
+	SellerID  int64 `json:"seller_id"`
+	ItemID    int32 `json:"item_id"`
+	Count     int32 `json:"count"`
+	StartBid  int64 `json:"start_bid,omitempty"`
+	Buyout    int64 `json:"buyout,omitempty"`
+	Bid       int64 `json:"bid,omitempty"`
+	BidderID  int64 `json:"bidder_id,omitempty"`
+	MinBid    int64 `json:"min_bid,omitempty"` // 下次出价的最低金额
+	Status    int32 `json:"status"`
+	ExpireAt  int64 `json:"expire_at"` // 毫秒时间戳
+}
+
+// AuctionService 拍卖行：上架扣除物品与手续费、竞拍与一口价、被超价退款与到期结算；物品与金币统一通过邮件交付
+type AuctionService struct {
+	config    AuctionConfig
+	store     AuctionStore
+	items     *ItemService
+	wallet    WalletStore
+	mail      *MailService
+	sweepWait time.Duration
+}
+
+// NewAuctionService 创建拍卖行服务
+func NewAuctionService(config AuctionConfig, store AuctionStore, items *ItemService, wallet WalletStore, mail *MailService) *AuctionService {
+	return &AuctionService{
+		config: config,
+		store:  store,
+		items:  items,
+		wallet: wallet,
+		mail:   mail,
+	}
+}
+
+// List 上架：在同一事务内从背包取出物品、扣除手续费并创建拍卖
+func (s *AuctionService) List(ctx context.Context, sellerID int64, listing AuctionListing) (*AuctionView, error) {
+	if listing.StartBid < 0 || listing.Buyout < 0 || (listing.StartBid == 0 && listing.Buyout == 0) ||
+		(listing.Buyout > 0 && listing.Buyout < listing.StartBid) {
+		return nil, ErrAuctionInvalidPrice
+	}
+	if !slices.Contains(s.config.Durations, listing.Duration) {
+		return nil, ErrAuctionInvalidDuration
+	}
+	if s.config.MaxListings > 0 {
+		n, err := s.store.CountActiveBySeller(ctx, sellerID)
+		if err != nil {
+			return nil, err
+		}
+		if n >= s.config.MaxListings {
+			return nil, ErrAuctionTooManyListings
+		}
+	}
+	auctionID, err := s.store.NextAuctionID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	fee := s.listingFee(listing.StartBid, listing.Buyout)
+	var doc *persistence.AuctionDocument
+	err = s.items.Transact(ctx, []int64{sellerID}, func(txCtx context.Context, txs map[int64]*InventoryTx) error {
+		item, err := txs[sellerID].Withdraw(listing.ItemUID, listing.Count)
+		if err != nil {
+			return err
+		}
+		def := datamanager.GetInstance().GetItem(item.ItemID)
+		if def == nil {
+			return &inventory.OpError{Op: "auction", ItemID: item.ItemID, ItemUID: listing.ItemUID, Err: inventory.ErrItemNotFound}
+		}
+		if fee > 0 {
+			if _, err := s.wallet.Apply(txCtx, WalletChange{
+				CharacterID: sellerID,
+				Currency:    CurrencyGold,
+				Delta:       -fee,
+				Reason:      WalletReasonAuctionFee,
+				Source:      fmt.Sprintf("auction:%d", auctionID),
+				Key:         fmt.Sprintf("auction:%d:fee", auctionID),
+			}); err != nil {
+				return err
+			}
+		}
+		doc = &persistence.AuctionDocument{
+			AuctionID: auctionID,
+			SellerID:  sellerID,
+			ItemID:    item.ItemID,
+			Count:     listing.Count,
+			ItemType:  def.Type,
+			Quality:   def.Quality,
+			Level:     def.RequiredLevel,
+			StartBid:  listing.StartBid,
+			Buyout:    listing.Buyout,
+			Fee:       fee,
+			Status:    persistence.AuctionStatusActive,
+			ExpireAt:  time.Now().Add(listing.Duration),
+		}
+		return s.store.Create(txCtx, doc)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.view(doc), nil
+}
+
+// Search 检索上架中的拍卖
+func (s *AuctionService) Search(ctx context.Context, filter persistence.AuctionFilter) ([]*AuctionView, error) {
+	if filter.Limit <= 0 || filter.Limit > auctionSearchLimit {
+		filter.Limit = auctionSearchLimit
+	}
+	docs, err := s.store.Search(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	views := make([]*AuctionView, 0, len(docs))
+	for _, doc := range docs {
+		views = append(views, s.view(doc))
+	}
+	return views, nil
+}
+
+// Bid 竞拍：扣除出价并记为最高出价，被超过的出价者通过邮件退款；出价达到一口价时按一口价成交
+func (s *AuctionService) Bid(ctx context.Context, bidderID, auctionID, amount int64) (*AuctionView, error) {
+	doc, err := s.active(ctx, auctionID)
+	if err != nil {
+		return nil, err
+	}
+	if doc.SellerID == bidderID {
+		return nil, ErrAuctionOwnListing
+	}
+	if doc.StartBid == 0 {
+		return nil, ErrAuctionNoBidding
+	}
+	if doc.Buyout > 0 && amount >= doc.Buyout {
+		return s.Buyout(ctx, bidderID, auctionID)
+	}
+	if amount < s.minBid(doc) {
+		return nil, ErrAuctionBidTooLow
+	}
+
+	err = s.items.Transact(ctx, nil, func(txCtx context.Context, _ map[int64]*InventoryTx) error {
+		if _, err := s.wallet.Apply(txCtx, WalletChange{
+			CharacterID: bidderID,
+			Currency:    CurrencyGold,
+			Delta:       -amount,
+			Reason:      WalletReasonAuctionBid,
+			Source:      fmt.Sprintf("auction:%d", auctionID),
+			Key:         fmt.Sprintf("auction:%d:bid:%d", auctionID, amount), // 出价严格递增，同一金额只会成功一次
+		}); err != nil {
+			return err
+		}
+		ok, err := s.store.PlaceBid(txCtx, auctionID, doc.Bid, bidderID, amount)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrAuctionChanged
+		}
+		return s.refund(txCtx, doc, "Outbid")
+	})
+	if err != nil {
+		return nil, err
+	}
+	doc.Bid, doc.BidderID = amount, bidderID
+	return s.view(doc), nil
+}
+
+// Buyout 一口价购买：扣除一口价后立即成交，物品与货款通过邮件交付，原最高出价者获得退款
+func (s *AuctionService) Buyout(ctx context.Context, buyerID, auctionID int64) (*AuctionView, error) {
+	doc, err := s.active(ctx, auctionID)
+	if err != nil {
+		return nil, err
+	}
+	if doc.SellerID == buyerID {
+		return nil, ErrAuctionOwnListing
+	}
+	if doc.Buyout == 0 {
+		return nil, ErrAuctionNoBuyout
+	}
+
+	err = s.items.Transact(ctx, nil, func(txCtx context.Context, _ map[int64]*InventoryTx) error {
+		if _, err := s.wallet.Apply(txCtx, WalletChange{
+			CharacterID: buyerID,
+			Currency:    CurrencyGold,
+			Delta:       -doc.Buyout,
+			Reason:      WalletReasonAuctionBuyout,
+			Source:      fmt.Sprintf("auction:%d", auctionID),
+			Key:         fmt.Sprintf("auction:%d:buyout", auctionID),
+		}); err != nil {
+			return err
+		}
+		if err := s.settle(txCtx, doc, buyerID, doc.Buyout); err != nil {
+			return err
+		}
+		return s.refund(txCtx, doc, "Auction sold")
+	})
+	if err != nil {
+		return nil, err
+	}
+	doc.Status, doc.BuyerID, doc.Price = persistence.AuctionStatusSold, buyerID, doc.Buyout
+	return s.view(doc), nil
+}
+
+// Cancel 卖家下架（已有出价时不可下架，手续费不退还），物品通过邮件退回
+func (s *AuctionService) Cancel(ctx context.Context, sellerID, auctionID int64) (*AuctionView, error) {
+	doc, err := s.active(ctx, auctionID)
+	if err != nil {
+		return nil, err
+	}
+	if doc.SellerID != sellerID {
+		return nil, ErrAuctionNotFound
+	}
+	if doc.BidderID != 0 {
+		return nil, ErrAuctionHasBids
+	}
+	if err := s.closeUnsold(ctx, doc, persistence.AuctionStatusCancelled); err != nil {
+		return nil, err
+	}
+	doc.Status = persistence.AuctionStatusCancelled
+	return s.view(doc), nil
+}
+
+// Update 定时结算到期拍卖：有出价的按最高出价成交，否则流拍并退回物品
+func (s *AuctionService) Update(ctx context.Context, dt time.Duration) error {
+	s.sweepWait -= dt
+	if s.sweepWait > 0 {
+		return nil
+	}
+	s.sweepWait = auctionSweepInterval
+
+	docs, err := s.store.FindExpired(ctx, time.Now(), auctionSweepBatch)
+	if err != nil {
+		return err
+	}
+	for _, doc := range docs {
+		if doc.BidderID == 0 {
+			err = s.closeUnsold(ctx, doc, persistence.AuctionStatusExpired)
+		} else {
+			err = s.items.Transact(ctx, nil, func(txCtx context.Context, _ map[int64]*InventoryTx) error {
+				return s.settle(txCtx, doc, doc.BidderID, doc.Bid)
+			})
+		}
+		// 已被其他节点或玩家操作结算的拍卖直接跳过
+		if err != nil && !errors.Is(err, ErrAuctionChanged) {
+			return err
+		}
+	}
+	return nil
+}
+
+// active 加载上架中且未到期的拍卖
+func (s *AuctionService) active(ctx context.Context, auctionID int64) (*persistence.AuctionDocument, error) {
+	doc, err := s.store.FindByID(ctx, auctionID)
+	if err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return nil, ErrAuctionNotFound
+	}
+	if doc.Status != persistence.AuctionStatusActive || !time.Now().Before(doc.ExpireAt) {
+		return nil, ErrAuctionClosed
+	}
+	return doc, nil
+}
+
+// settle 成交（调用方在事务内）：物品邮寄给买家，扣税后的货款邮寄给卖家
+func (s *AuctionService) settle(txCtx context.Context, doc *persistence.AuctionDocument, buyerID, price int64) error {
+	tax := int64(float64(price) * s.config.SalesTaxRate)
+	ok, err := s.store.Close(txCtx, doc.AuctionID, doc.Bid, persistence.AuctionStatusSold, buyerID, price, tax)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrAuctionChanged
+	}
+	item := []persistence.DbAttachment{{ItemID: doc.ItemID, Count: doc.Count}}
+	if _, err := s.mail.SendGoldMail(txCtx, buyerID, auctionSender, "Auction won",
+		fmt.Sprintf("You won auction #%d.", doc.AuctionID), item, 0, s.config.MailExpireDays); err != nil {
+		return err
+	}
+	_, err = s.mail.SendGoldMail(txCtx, doc.SellerID, auctionSender, "Auction sold",
+		fmt.Sprintf("Auction #%d sold for %d gold (tax %d).", doc.AuctionID, price, tax), nil, price-tax, s.config.MailExpireDays)
+	return err
+}
+
+// refund 将出价邮寄退还给原最高出价者（调用方在事务内）
+func (s *AuctionService) refund(txCtx context.Context, doc *persistence.AuctionDocument, title string) error {
+	if doc.BidderID == 0 {
+		return nil
+	}
+	_, err := s.mail.SendGoldMail(txCtx, doc.BidderID, auctionSender, title,
+		fmt.Sprintf("Your bid on auction #%d has been returned.", doc.AuctionID), nil, doc.Bid, s.config.MailExpireDays)
+	return err
+}
+
+// closeUnsold 下架或流拍：关闭拍卖并将物品邮寄退回卖家
+func (s *AuctionService) closeUnsold(ctx context.Context, doc *persistence.AuctionDocument, status int32) error {
+	return s.items.Transact(ctx, nil, func(txCtx context.Context, _ map[int64]*InventoryTx) error {
+		ok, err := s.store.Close(txCtx, doc.AuctionID, doc.Bid, status, 0, 0, 0)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrAuctionChanged
+		}
+		item := []persistence.DbAttachment{{ItemID: doc.ItemID, Count: doc.Count}}
+		_, err = s.mail.SendGoldMail(txCtx, doc.SellerID, auctionSender, "Auction returned",
+			fmt.Sprintf("Auction #%d ended without a sale.", doc.AuctionID), item, 0, s.config.MailExpireDays)
+		return err
+	})
+}
+
+// listingFee 上架手续费：按起拍价与一口价较高者计算，不低于最低手续费
+func (s *AuctionService) listingFee(startBid, buyout int64) int64 {
+	return max(int64(float64(max(startBid, buyout))*s.config.ListingFeeRate), s.config.MinListingFee)
+}
+
+// minBid 下次出价的最低金额
+func (s *AuctionService) minBid(doc *persistence.AuctionDocument) int64 {
+	if doc.StartBid == 0 {
+		return 0
+	}
+	if doc.BidderID == 0 {
+		return doc.StartBid
+	}
+	return doc.Bid + max(int64(float64(doc.Bid)*s.config.MinBidIncrement), 1)
+}
+
+// view 拍卖快照
+func (s *AuctionService) view(doc *persistence.AuctionDocument) *AuctionView {
+	v := &AuctionView{
+		AuctionID: doc.AuctionID,
+		SellerID:  doc.SellerID,
+		ItemID:    doc.ItemID,
+		Count:     doc.Count,
+		StartBid:  doc.StartBid,
+		Buyout:    doc.Buyout,
+		Bid:       doc.Bid,
+		BidderID:  doc.BidderID,
+		Status:    doc.Status,
+		ExpireAt:  doc.ExpireAt.UnixMilli(),
+	}
+	if doc.Status == persistence.AuctionStatusActive {
+		v.MinBid = s.minBid(doc)
+	}
+	return v
+}
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"testing"
+
+	"greatestworks/internal/infrastructure/persistence"
+)
+
+func TestAuctionListingFee(t *testing.T) {
+	s := &AuctionService{config: AuctionConfig{ListingFeeRate: 0.05, MinListingFee: 10}}
+	tests := []struct {
+		name             string
+		startBid, buyout int64
+		want             int64
+	}{
+		{"rate on start bid", 1000, 0, 50},
+		{"rate on higher buyout", 1000, 4000, 200},
+		{"minimum fee", 20, 0, 10},
+	}
+	for _, tt := range tests {
+		if got := s.listingFee(tt.startBid, tt.buyout); got != tt.want {
+			t.Errorf("%s: fee %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAuctionMinBid(t *testing.T) {
+	s := &AuctionService{config: AuctionConfig{MinBidIncrement: 0.05}}
+	tests := []struct {
+		name string
+		doc  persistence.AuctionDocument
+		want int64
+	}{
+		{"buyout only", persistence.AuctionDocument{Buyout: 500}, 0},
+		{"first bid at start bid", persistence.AuctionDocument{StartBid: 100}, 100},
+		{"increment on current bid", persistence.AuctionDocument{StartBid: 100, Bid: 1000, BidderID: 7}, 1050},
+		{"increment at least one", persistence.AuctionDocument{StartBid: 1, Bid: 3, BidderID: 7}, 4},
+	}
+	for _, tt := range tests {
+		if got := s.minBid(&tt.doc); got != tt.want {
+			t.Errorf("%s: min bid %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
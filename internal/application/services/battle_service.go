@@ -0,0 +1,601 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"greatestworks/internal/domain/battle"
+	"greatestworks/internal/domain/character"
+	"greatestworks/internal/domain/mapmanager"
+	"greatestworks/internal/domain/player"
+	"greatestworks/internal/infrastructure/datamanager"
+)
+
+// 回合制战斗错误
+var (
+	ErrAlreadyInBattle   = errors.New("already in a battle")
+	ErrNotInBattle       = errors.New("not in a battle")
+	ErrUnknownOpponent   = errors.New("unknown battle opponent")
+	ErrUnknownBattleMove = errors.New("unknown battle action")
+)
+
+// aiPlayerPrefix AI 参战者的ID前缀（后接单位ID与序号）
+const aiPlayerPrefix = "ai-"
+
+// BattleConfig 回合制战斗规则
+type BattleConfig struct {
+	TurnTimeout time.Duration // 每次行动时限，超时由系统代为行动
+	MaxRounds   int           // 回合上限，达到后按剩余生命判定胜负
+}
+
+// BattleParticipantView 推送给参与者的参战者状态
+type BattleParticipantView struct {
+	PlayerID  string `json:"player_id"`
+	Team      int    `json:"team"`
+	HP        int    `json:"hp"`
+	MaxHP     int    `json:"max_hp"`
+	MP        int    `json:"mp"`
+	IsAlive   bool   `json:"is_alive"`
+	IsAI      bool   `json:"is_ai,omitempty"`
+	Defending bool   `json:"defending,omitempty"`
+}
+
+// BattleActionView 行动结果
+type BattleActionView struct {
+	ActionID   string    `json:"action_id"`
+	ActorID    string    `json:"actor_id"`
+	TargetID   string    `json:"target_id,omitempty"`
+	ActionType string    `json:"action_type"`
+	SkillID    string    `json:"skill_id,omitempty"`
+	Damage     int       `json:"damage,omitempty"`
+	Healing    int       `json:"healing,omitempty"`
+	Critical   bool      `json:"critical,omitempty"`
+	Auto       bool      `json:"auto,omitempty"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+// BattleView 战斗状态快照（topic: battle_state）
+type BattleView struct {
+	BattleID     string                   `json:"battle_id"`
+	Status       string                   `json:"status"`
+	Round        int                      `json:"round"`
+	MaxRounds    int                      `json:"max_rounds"`
+	CurrentTurn  string                   `json:"current_turn,omitempty"`
+	TurnEndsIn   float32                  `json:"turn_ends_in,omitempty"` // 当前行动剩余秒数
+	Participants []*BattleParticipantView `json:"participants"`
+	Winner       string                   `json:"winner,omitempty"`
+}
+
+// BattleActionNotice 行动通知（topic: battle_action）
+type BattleActionNotice struct {
+	BattleID string            `json:"battle_id"`
+	Action   *BattleActionView `json:"action"`
+}
+
+// BattleResultNotice 战斗结算通知（topic: battle_result），下发给每名玩家自己的奖励
+type BattleResultNotice struct {
+	BattleID string `json:"battle_id"`
+	Winner   string `json:"winner,omitempty"`
+	Victory  bool   `json:"victory"`
+	Exp      int64  `json:"exp"`
+	Gold     int64  `json:"gold"`
+	Level    int32  `json:"level,omitempty"`
+}
+
+// activeBattle 进行中战斗的会话信息
+type activeBattle struct {
+	id       battle.BattleID
+	players  []int32          // 参战玩家实体ID（用于推送）
+	units    map[string]int32 // AI 参战者ID -> 单位ID（用于击败奖励）
+	deadline time.Time        // 下次需要推进战斗的时间
+}
+
+// BattleService 回合制战斗服务：管理玩家与 AI 的回合制战斗，推送战斗状态，按行动时限代为行动并在结束时发放奖励
+type BattleService struct {
+	mu           sync.Mutex // 串行化同一战斗的行动与推进
+	battles      *battle.Service
+	mapService   *MapService
+	rewards      RewardStore
+	broadcast    mapmanager.BroadcastFn
+	active       map[string]*activeBattle
+	playerBattle map[int32]string // 玩家实体ID -> 所在战斗ID
+}
+
+// NewBattleService 创建回合制战斗服务
+func NewBattleService(repo battle.Repository, mapService *MapService, rewards RewardStore, cfg BattleConfig) *BattleService {
+	domain := battle.NewService(repo)
+	if cfg.TurnTimeout <= 0 {
+		cfg.TurnTimeout = battle.DefaultTurnTimeout
+	}
+	if cfg.MaxRounds <= 0 {
+		cfg.MaxRounds = battle.DefaultMaxRounds
+	}
+	domain.SetTurnRules(cfg.TurnTimeout, cfg.MaxRounds)
+	return &BattleService{
+		battles:      domain,
+		mapService:   mapService,
+		rewards:      rewards,
+		active:       make(map[string]*activeBattle),
+		playerBattle: make(map[int32]string),
+	}
+}
+
+// SetBroadcaster 设置推送函数（由接口层注入）
+func (s *BattleService) SetBroadcaster(fn mapmanager.BroadcastFn) {
+	s.mu.Lock()
+	s.broadcast = fn
+	s.mu.Unlock()
+}
+
+// ParseBattleAction 解析客户端行动类型
+func ParseBattleAction(name string) (battle.ActionType, error) {
+	switch name {
+	case "attack", "":
+		return battle.ActionTypeAttack, nil
+	case "skill":
+		return battle.ActionTypeSkill, nil
+	case "defend":
+		return battle.ActionTypeDefend, nil
+	case "heal":
+		return battle.ActionTypeHeal, nil
+	case "escape":
+		return battle.ActionTypeEscape, nil
+	}
+	return 0, ErrUnknownBattleMove
+}
+
+// CreateBattle 创建战斗并以队伍1加入；指定 vsUnitID 时对手为该单位的 AI，战斗立即开始
+func (s *BattleService) CreateBattle(ctx context.Context, entityID int32, vsUnitID int32) (*BattleView, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.playerBattle[entityID]; ok {
+		return nil, ErrAlreadyInBattle
+	}
+	combatant, err := s.playerCombatant(entityID, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	battleType := battle.BattleTypePvP
+	var unit *datamanager.UnitDefine
+	if vsUnitID != 0 {
+		if unit = datamanager.GetInstance().GetUnit(vsUnitID); unit == nil {
+			return nil, ErrUnknownOpponent
+		}
+		battleType = battle.BattleTypePvE
+	}
+
+	b, err := s.battles.CreateBattle(ctx, battleType)
+	if err != nil {
+		return nil, err
+	}
+	ab := &activeBattle{id: b.ID(), units: make(map[string]int32)}
+	if err := s.battles.AddCombatant(ctx, b.ID(), combatant); err != nil {
+		return nil, err
+	}
+	s.track(ab, entityID)
+
+	if unit != nil {
+		aiID := player.PlayerIDFromString(aiPlayerPrefix + strconv.Itoa(int(unit.ID)) + "-1")
+		if err := s.battles.AddCombatant(ctx, b.ID(), battle.Combatant{
+			PlayerID: aiID,
+			Team:     2,
+			HP:       int(unit.MaxHP),
+			MP:       int(unit.MaxMP),
+			Attack:   int(unit.AD),
+			IsAI:     true,
+		}); err != nil {
+			s.untrack(ab, entityID)
+			delete(s.active, ab.id.String())
+			return nil, err
+		}
+		ab.units[aiID.String()] = unit.ID
+		view, err := s.startLocked(ctx, ab)
+		if err != nil {
+			s.untrack(ab, entityID)
+			delete(s.active, ab.id.String())
+			return nil, err
+		}
+		return view, nil
+	}
+	return s.viewLocked(ctx, ab)
+}
+
+// JoinBattle 加入等待中的战斗（team 为 0 时加入队伍2）
+func (s *BattleService) JoinBattle(ctx context.Context, entityID int32, battleID string, team int) (*BattleView, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.playerBattle[entityID]; ok {
+		return nil, ErrAlreadyInBattle
+	}
+	ab, ok := s.active[battleID]
+	if !ok {
+		return nil, battle.ErrBattleNotFound
+	}
+	if team <= 0 {
+		team = 2
+	}
+	combatant, err := s.playerCombatant(entityID, team)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.battles.AddCombatant(ctx, ab.id, combatant); err != nil {
+		return nil, err
+	}
+	s.track(ab, entityID)
+	view, err := s.viewLocked(ctx, ab)
+	if err != nil {
+		return nil, err
+	}
+	s.push(ab.players, "battle_state", view)
+	return view, nil
+}
+
+// StartBattle 由参战玩家开始战斗
+func (s *BattleService) StartBattle(ctx context.Context, entityID int32) (*BattleView, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ab, err := s.battleOf(entityID)
+	if err != nil {
+		return nil, err
+	}
+	return s.startLocked(ctx, ab)
+}
+
+// Act 执行当前玩家的行动，并推进随后轮到的 AI 行动
+func (s *BattleService) Act(ctx context.Context, entityID int32, actionType battle.ActionType, targetID string, skillID string) (*BattleActionView, *BattleView, error) {
+	if actionType == battle.ActionTypeEscape {
+		view, err := s.Leave(ctx, entityID)
+		return nil, view, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ab, err := s.battleOf(entityID)
+	if err != nil {
+		return nil, nil, err
+	}
+	actorID := playerIDOf(entityID)
+	var target *player.PlayerID
+	if targetID != "" {
+		id := player.PlayerIDFromString(targetID)
+		target = &id
+	}
+
+	var action *battle.BattleAction
+	if actionType == battle.ActionTypeSkill {
+		action, err = s.battles.ExecuteSkill(ctx, ab.id, actorID, target, skillID)
+	} else {
+		action, err = s.battles.ExecuteAction(ctx, ab.id, actorID, target, actionType)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	s.push(ab.players, "battle_action", &BattleActionNotice{BattleID: ab.id.String(), Action: toBattleActionView(action)})
+	view, err := s.advanceLocked(ctx, ab, time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+	return toBattleActionView(action), view, nil
+}
+
+// Leave 认输离开战斗（登出时同样调用）
+func (s *BattleService) Leave(ctx context.Context, entityID int32) (*BattleView, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ab, err := s.battleOf(entityID)
+	if err != nil {
+		return nil, err
+	}
+	b, err := s.battles.GetBattleStatus(ctx, ab.id)
+	if err != nil {
+		return nil, err
+	}
+	if b.Status() == battle.BattleStatusWaiting {
+		// 未开始的战斗直接离开，最后一名玩家离开时取消战斗
+		s.untrack(ab, entityID)
+		if len(ab.players) == 0 {
+			_ = s.battles.EndBattle(ctx, ab.id)
+			delete(s.active, ab.id.String())
+		}
+		return buildBattleView(b, time.Now()), nil
+	}
+	if _, err := s.battles.Forfeit(ctx, ab.id, playerIDOf(entityID)); err != nil {
+		return nil, err
+	}
+	return s.advanceLocked(ctx, ab, time.Now())
+}
+
+// Status 查询所在战斗状态（battleID 为空时取玩家当前战斗）
+func (s *BattleService) Status(ctx context.Context, entityID int32, battleID string) (*BattleView, error) {
+	if battleID == "" {
+		s.mu.Lock()
+		battleID = s.playerBattle[entityID]
+		s.mu.Unlock()
+		if battleID == "" {
+			return nil, ErrNotInBattle
+		}
+	}
+	b, err := s.battles.GetBattleStatus(ctx, battle.BattleIDFromString(battleID))
+	if err != nil {
+		return nil, err
+	}
+	return buildBattleView(b, time.Now()), nil
+}
+
+// Update 推进到期的战斗：行动超时的玩家由系统代为行动
+func (s *BattleService) Update(ctx context.Context, _ time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for _, ab := range s.active {
+		if ab.deadline.IsZero() || now.Before(ab.deadline) {
+			continue
+		}
+		if _, err := s.advanceLocked(ctx, ab, now); err != nil {
+			// 推进失败时延后重试，避免每帧重复访问仓储
+			ab.deadline = now.Add(time.Second)
+		}
+	}
+	return nil
+}
+
+// startLocked 开始战斗并推进首个 AI 行动
+func (s *BattleService) startLocked(ctx context.Context, ab *activeBattle) (*BattleView, error) {
+	if err := s.battles.StartBattle(ctx, ab.id); err != nil {
+		return nil, err
+	}
+	return s.advanceLocked(ctx, ab, time.Now())
+}
+
+// advanceLocked 执行轮到的 AI 与超时行动，推送行动与状态；战斗结束时结算奖励
+func (s *BattleService) advanceLocked(ctx context.Context, ab *activeBattle, now time.Time) (*BattleView, error) {
+	b, actions, err := s.battles.AdvanceBattle(ctx, ab.id, now)
+	if err != nil {
+		return nil, err
+	}
+	for _, action := range actions {
+		s.push(ab.players, "battle_action", &BattleActionNotice{BattleID: ab.id.String(), Action: toBattleActionView(action)})
+	}
+	view := buildBattleView(b, now)
+	s.push(ab.players, "battle_state", view)
+	if b.IsFinished() {
+		s.finishLocked(ctx, ab, b)
+		return view, nil
+	}
+	ab.deadline = b.TurnDeadline()
+	return view, nil
+}
+
+// finishLocked 结算战斗奖励：所有玩家获得参战奖励，获胜方额外平分被击败 AI 单位的击杀奖励
+func (s *BattleService) finishLocked(ctx context.Context, ab *activeBattle, b *battle.Battle) {
+	delete(s.active, ab.id.String())
+	players := append([]int32(nil), ab.players...)
+	for _, id := range players {
+		delete(s.playerBattle, id)
+	}
+	if b.Status() != battle.BattleStatusFinished {
+		return
+	}
+
+	rewards, err := s.battles.CalculateBattleRewards(ctx, ab.id)
+	if err != nil {
+		rewards = nil
+	}
+	winnerTeam := 0
+	var winner string
+	if w := b.Winner(); w != nil {
+		winner = w.String()
+	}
+	var unitExp, unitGold int64
+	var winners int64
+	for _, p := range b.Participants() {
+		if p.PlayerID.String() == winner {
+			winnerTeam = p.Team
+		}
+	}
+	for _, p := range b.Participants() {
+		if unitID, ok := ab.units[p.PlayerID.String()]; ok && p.Team != winnerTeam {
+			if unit := datamanager.GetInstance().GetUnit(unitID); unit != nil {
+				unitExp += int64(unit.ExpReward)
+				unitGold += int64(unit.GoldReward)
+			}
+		}
+		if p.Team == winnerTeam && !p.IsAI {
+			winners++
+		}
+	}
+
+	for _, p := range b.Participants() {
+		if p.IsAI {
+			continue
+		}
+		entityID, err := strconv.Atoi(p.PlayerID.String())
+		if err != nil {
+			continue
+		}
+		notice := &BattleResultNotice{BattleID: ab.id.String(), Winner: winner, Victory: p.Team == winnerTeam}
+		if reward := rewards[p.PlayerID]; reward != nil {
+			notice.Exp, notice.Gold = reward.Exp, reward.Gold
+		}
+		if notice.Victory && winners > 0 {
+			notice.Exp += unitExp / winners
+			notice.Gold += unitGold / winners
+		}
+		if s.rewards != nil && (notice.Exp > 0 || notice.Gold > 0) {
+			if grant, err := s.rewards.GrantReward(ctx, int64(entityID), notice.Exp, notice.Gold, "battle:"+ab.id.String(), fmt.Sprintf("reward:battle:%s:%d", ab.id.String(), entityID)); err == nil {
+				notice.Level = grant.NewLevel
+				s.applyLevelUp(int32(entityID), grant)
+			}
+		}
+		s.push([]int32{int32(entityID)}, "battle_result", notice)
+	}
+}
+
+// applyLevelUp 同步升级到地图中的角色
+func (s *BattleService) applyLevelUp(entityID int32, grant *RewardGrant) {
+	if s.mapService == nil {
+		return
+	}
+	if _, actor, err := s.mapService.LocateActor(entityID); err == nil && grant.NewLevel > actor.Level() {
+		actor.ApplyLevelUp(grant.NewLevel, grant.Growth)
+	}
+}
+
+// playerCombatant 按地图中角色的当前属性构造参战者
+func (s *BattleService) playerCombatant(entityID int32, team int) (battle.Combatant, error) {
+	c := battle.Combatant{PlayerID: playerIDOf(entityID), Team: team}
+	if s.mapService == nil {
+		return c, fmt.Errorf("map service not ready")
+	}
+	_, actor, err := s.mapService.LocateActor(entityID)
+	if err != nil {
+		return c, err
+	}
+	if actor.IsDeath() {
+		return c, ErrCasterDead
+	}
+	c.HP = int(actor.HP())
+	c.MP = int(actor.MP())
+	c.Attack = int(actor.GetAttributeManager().Final().AD)
+	return c, nil
+}
+
+// battleOf 玩家所在战斗
+func (s *BattleService) battleOf(entityID int32) (*activeBattle, error) {
+	ab, ok := s.active[s.playerBattle[entityID]]
+	if !ok {
+		return nil, ErrNotInBattle
+	}
+	return ab, nil
+}
+
+// viewLocked 读取战斗状态快照
+func (s *BattleService) viewLocked(ctx context.Context, ab *activeBattle) (*BattleView, error) {
+	b, err := s.battles.GetBattleStatus(ctx, ab.id)
+	if err != nil {
+		return nil, err
+	}
+	return buildBattleView(b, time.Now()), nil
+}
+
+// track 记录玩家参战
+func (s *BattleService) track(ab *activeBattle, entityID int32) {
+	s.active[ab.id.String()] = ab
+	ab.players = append(ab.players, entityID)
+	s.playerBattle[entityID] = ab.id.String()
+}
+
+// untrack 移除玩家参战记录
+func (s *BattleService) untrack(ab *activeBattle, entityID int32) {
+	delete(s.playerBattle, entityID)
+	for i, id := range ab.players {
+		if id == entityID {
+			ab.players = append(ab.players[:i], ab.players[i+1:]...)
+			break
+		}
+	}
+}
+
+// push 向参战玩家推送
+func (s *BattleService) push(players []int32, topic string, payload interface{}) {
+	if s.broadcast == nil || len(players) == 0 {
+		return
+	}
+	recipients := make([]character.EntityID, 0, len(players))
+	for _, id := range players {
+		recipients = append(recipients, character.EntityID(id))
+	}
+	s.broadcast(recipients, topic, payload)
+}
+
+// playerIDOf 玩家实体ID对应的参战者ID
+func playerIDOf(entityID int32) player.PlayerID {
+	return player.PlayerIDFromString(strconv.Itoa(int(entityID)))
+}
+
+// buildBattleView 构造战斗状态快照
+func buildBattleView(b *battle.Battle, now time.Time) *BattleView {
+	view := &BattleView{
+		BattleID:  b.ID().String(),
+		Status:    battleStatusName(b.Status()),
+		Round:     b.CurrentRound(),
+		MaxRounds: b.MaxRounds(),
+	}
+	if b.Status() == battle.BattleStatusInProgress {
+		if current := b.CurrentActor(); current != nil {
+			view.CurrentTurn = current.PlayerID.String()
+			if remain := b.TurnDeadline().Sub(now); remain > 0 {
+				view.TurnEndsIn = float32(remain.Seconds())
+			}
+		}
+	}
+	if w := b.Winner(); w != nil {
+		view.Winner = w.String()
+	}
+	for _, p := range b.Participants() {
+		view.Participants = append(view.Participants, &BattleParticipantView{
+			PlayerID:  p.PlayerID.String(),
+			Team:      p.Team,
+			HP:        p.CurrentHP,
+			MaxHP:     p.MaxHP,
+			MP:        p.CurrentMP,
+			IsAlive:   p.IsAlive,
+			IsAI:      p.IsAI,
+			Defending: p.Defending,
+		})
+	}
+	return view
+}
+
+// toBattleActionView 行动结果转换
+func toBattleActionView(a *battle.BattleAction) *BattleActionView {
+	view := &BattleActionView{
+		ActionID:   a.ActionID,
+		ActorID:    a.ActorID.String(),
+		ActionType: battleActionName(a.ActionType),
+		Damage:     a.Damage,
+		Healing:    a.Healing,
+		Critical:   a.Critical,
+		Auto:       a.Auto,
+		Timestamp:  a.Timestamp,
+	}
+	if a.TargetID != nil {
+		view.TargetID = a.TargetID.String()
+	}
+	if a.SkillID != nil {
+		view.SkillID = *a.SkillID
+	}
+	return view
+}
+
+// battleStatusName 战斗状态名称
+func battleStatusName(status battle.BattleStatus) string {
+	switch status {
+	case battle.BattleStatusWaiting:
+		return "waiting"
+	case battle.BattleStatusInProgress:
+		return "in_progress"
+	case battle.BattleStatusFinished:
+		return "finished"
+	}
+	return "cancelled"
+}
+
+// battleActionName 行动类型名称
+func battleActionName(actionType battle.ActionType) string {
+	switch actionType {
+	case battle.ActionTypeSkill:
+		return "skill"
+	case battle.ActionTypeDefend:
+		return "defend"
+	case battle.ActionTypeHeal:
+		return "heal"
+	case battle.ActionTypeEscape:
+		return "escape"
+	}
+	return "attack"
+}
@@ -16,6 +16,7 @@ type CharacterService struct {
 	characterRepo *persistence.CharacterRepository
 	itemRepo      *persistence.ItemRepository
 	questRepo     *persistence.QuestRepository
+	wallet        WalletStore
 }
 
 // NewCharacterService 创建角色服务
@@ -23,11 +24,13 @@ func NewCharacterService(
 	characterRepo *persistence.CharacterRepository,
 	itemRepo *persistence.ItemRepository,
 	questRepo *persistence.QuestRepository,
+	wallet WalletStore,
 ) *CharacterService {
 	return &CharacterService{
 		characterRepo: characterRepo,
 		itemRepo:      itemRepo,
 		questRepo:     questRepo,
+		wallet:        wallet,
 	}
 }
 
@@ -51,7 +54,7 @@ func (s *CharacterService) CreateCharacter(ctx context.Context, userID int64, na
 		Class:       class,
 		Level:       1,
 		Exp:         0,
-		Gold:        1000, // 初始金币
+		Gold:        1000, // 初始金币（首次使用钱包时转入）
 
 		MapID:     1, // 默认地图
 		PositionX: 0.0,
@@ -130,6 +133,8 @@ func (s *CharacterService) LoadCharacter(ctx context.Context, characterID int64)
 
 	// 设置属性
 	player.SetLevel(dbChar.Level)
+	// 阵营战区域按种族划分阵营
+	player.SetFaction(dbChar.Race)
 
 	// 设置基础属性到属性管理器
 	am := player.GetAttributeManager()
@@ -151,9 +156,20 @@ func (s *CharacterService) LoadCharacter(ctx context.Context, characterID int64)
 	}
 	am.SetBase(base)
 
-	// 设置当前HP/MP（以增量方式设置到目标数值）
-	if dbChar.HP > 0 {
-		player.ChangeHP(float32(dbChar.HP))
+	// 加载物品：装备栏中的物品恢复到装备管理器（先于设置HP/MP，使装备加成计入上限）
+	items, err := s.itemRepo.FindByCharacterID(ctx, characterID)
+	if err == nil {
+		RestoreEquipment(player.Actor, items)
+		// TODO: 加载物品到背包
+	}
+
+	// 设置当前HP/MP（以增量方式设置到目标数值）；死亡中下线的角色保持死亡，未记录死亡的 0 生命按满血处理
+	if dbChar.DeadAt.IsZero() {
+		hp := float32(dbChar.HP)
+		if hp <= 0 {
+			hp = am.Final().MaxHP
+		}
+		player.ChangeHP(hp)
 	}
 	if dbChar.MP > 0 {
 		player.ChangeMP(float32(dbChar.MP))
@@ -162,11 +178,10 @@ func (s *CharacterService) LoadCharacter(ctx context.Context, characterID int64)
 	// 设置基础属性
 	// 由于当前领域模型未包含STR/INT/AGI/VIT/SPR等细分属性，暂不映射这些字段
 
-	// 加载物品
-	items, err := s.itemRepo.FindByCharacterID(ctx, characterID)
-	if err == nil {
-		// TODO: 加载物品到背包
-		_ = items
+	// 学习职业技能与技能书学会的技能
+	LearnUnitSkills(player.Actor)
+	for _, skillID := range dbChar.Skills {
+		LearnSkill(player.Actor, skillID)
 	}
 
 	// 加载任务
@@ -179,45 +194,112 @@ func (s *CharacterService) LoadCharacter(ctx context.Context, characterID int64)
 	return player, nil
 }
 
-// SaveCharacter 保存角色到数据库
-func (s *CharacterService) SaveCharacter(ctx context.Context, player *character.Player) error {
-	// 读取最终属性用于持久化
-	attrs := player.GetAttributeManager().Final()
+// RecordDeath 持久化角色死亡状态（未复活前重新上线仍为死亡）
+func (s *CharacterService) RecordDeath(ctx context.Context, characterID int64, deadAt time.Time) error {
+	return s.characterRepo.UpdateDeath(ctx, characterID, deadAt)
+}
 
-	dbChar := &persistence.DbCharacter{
-		CharacterID: player.CharacterID(),
-		Name:        player.GetName(),
-		Race:        player.GetRace(),
-		Class:       player.GetClass(),
-		Level:       player.Level(),
-		Exp:         player.GetExp(),
-		Gold:        player.GetGold(),
+// RecordRespawn 持久化复活：按当前等级升级经验的比例扣除经验（不降级），返回扣除的经验
+func (s *CharacterService) RecordRespawn(ctx context.Context, characterID int64, expPenalty float64, hp, mp int32) (int64, error) {
+	dbChar, err := s.characterRepo.FindByID(ctx, characterID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load character: %w", err)
+	}
+	lost := int64(float64(character.LevelUpExp(dbChar.Level)) * expPenalty)
+	lost = min(max(lost, 0), dbChar.Exp)
+	if err := s.characterRepo.UpdateRespawn(ctx, characterID, dbChar.Exp-lost, hp, mp); err != nil {
+		return 0, err
+	}
+	return lost, nil
+}
 
-		HP:    int32(player.HP()),
-		MP:    int32(player.MP()),
-		MaxHP: int32(attrs.MaxHP),
-		MaxMP: int32(attrs.MaxMP),
+// RewardGrant 奖励发放结果
+type RewardGrant struct {
+	OldLevel int32
+	NewLevel int32
+	Exp      int64                 // 发放后当前等级的经验
+	Growth   character.LevelGrowth // 升级带来的基础属性成长
+}
 
-		// 领域模型暂不包含以下基础属性的独立管理，使用0占位
-		STR: 0,
-		INT: 0,
-		AGI: 0,
-		VIT: 0,
-		SPR: 0,
+// GrantReward 发放经验与金币：经验足够时连续升级（不超过最大等级），按职业成长提升基础属性并回满生命魔法；
+// 金币经钱包入账，source 记入流水，key 为流水幂等键。经验与金币在同一事务中提交（可在事务内调用）；
+// 等级与经验只经此处（及复活扣除）写库，内存中的 Player 不持有权威经验
+func (s *CharacterService) GrantReward(ctx context.Context, characterID int64, exp, gold int64, source, key string) (*RewardGrant, error) {
+	var grant *RewardGrant
+	err := s.itemRepo.WithTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		grant, err = s.grantReward(txCtx, characterID, exp, gold, source, key)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return grant, nil
+}
 
-		AD:  int32(attrs.AD),
-		AP:  int32(attrs.AP),
-		DEF: int32(attrs.Def),
-		RES: int32(attrs.MDef),
-		SPD: int32(attrs.Speed),
+func (s *CharacterService) grantReward(ctx context.Context, characterID int64, exp, gold int64, source, key string) (*RewardGrant, error) {
+	dbChar, err := s.characterRepo.FindByID(ctx, characterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load character: %w", err)
+	}
+
+	grant := &RewardGrant{OldLevel: dbChar.Level}
+	dbChar.Exp += exp
+	for dbChar.Level < character.MaxLevel && dbChar.Exp >= character.LevelUpExp(dbChar.Level) {
+		dbChar.Exp -= character.LevelUpExp(dbChar.Level)
+		dbChar.Level++
+	}
+	grant.NewLevel, grant.Exp = dbChar.Level, dbChar.Exp
+
+	if levels := grant.NewLevel - grant.OldLevel; levels > 0 {
+		grant.Growth = UnitLevelGrowth(datamanager.GetInstance().GetUnit(dbChar.Class), levels)
+		dbChar.MaxHP += int32(grant.Growth.MaxHP)
+		dbChar.MaxMP += int32(grant.Growth.MaxMP)
+		dbChar.AD += int32(grant.Growth.AD)
+		dbChar.AP += int32(grant.Growth.AP)
+		dbChar.DEF += int32(grant.Growth.Def)
+		dbChar.RES += int32(grant.Growth.MDef)
+		dbChar.HP, dbChar.MP = dbChar.MaxHP, dbChar.MaxMP
+	}
 
-		CRI:     int32(attrs.Cri * 1000),     // 与Load时的占位换算保持一致
-		CRID:    int32(attrs.Crd * 100),      // 占位
-		HitRate: int32(attrs.HitRate * 1000), // 占位
-		Dodge:   int32(attrs.DodgeRate * 1000),
+	if err := s.characterRepo.Update(ctx, dbChar); err != nil {
+		return nil, err
+	}
+	if gold > 0 {
+		if _, err := s.wallet.Apply(ctx, WalletChange{
+			CharacterID: characterID,
+			Currency:    CurrencyGold,
+			Delta:       gold,
+			Reason:      WalletReasonReward,
+			Source:      source,
+			Key:         key,
+		}); err != nil {
+			return nil, err
+		}
 	}
+	return grant, nil
+}
 
-	return s.characterRepo.Update(ctx, dbChar)
+// LearnSkill 持久化学会的技能（可在事务内调用），已学会时返回 false
+func (s *CharacterService) LearnSkill(ctx context.Context, characterID int64, skillID int32) (bool, error) {
+	return s.characterRepo.AddSkill(ctx, characterID, skillID)
+}
+
+// BindPoint 查询绑定位置，未绑定时 ok 为 false
+func (s *CharacterService) BindPoint(ctx context.Context, characterID int64) (mapID int32, pos character.Vector3, ok bool, err error) {
+	dbChar, err := s.characterRepo.FindByID(ctx, characterID)
+	if err != nil {
+		return 0, character.Vector3{}, false, fmt.Errorf("failed to load character: %w", err)
+	}
+	if dbChar.BindMapID == 0 {
+		return 0, character.Vector3{}, false, nil
+	}
+	return dbChar.BindMapID, character.NewVector3(dbChar.BindX, dbChar.BindY, dbChar.BindZ), true, nil
+}
+
+// SetBindPoint 设置绑定位置（回城道具的目的地）
+func (s *CharacterService) SetBindPoint(ctx context.Context, characterID int64, mapID int32, pos character.Vector3) error {
+	return s.characterRepo.UpdateBindPoint(ctx, characterID, mapID, pos.X, pos.Y, pos.Z)
 }
 
 // UpdatePosition 更新角色位置
@@ -230,21 +312,3 @@ func (s *CharacterService) UpdateLastLocation(ctx context.Context, characterID i
 	// 直接调用底层仓储更新位置，direction 传0即可
 	return s.characterRepo.UpdatePosition(ctx, characterID, mapID, x, y, z, 0)
 }
-
-// AddExp 添加经验
-func (s *CharacterService) AddExp(ctx context.Context, player *character.Player, exp int64) error {
-	player.AddExp(exp)
-
-	// 检查升级
-	for player.CanLevelUp() {
-		player.LevelUp()
-	}
-
-	return s.SaveCharacter(ctx, player)
-}
-
-// AddGold 添加金币
-func (s *CharacterService) AddGold(ctx context.Context, player *character.Player, gold int64) error {
-	player.AddGold(gold)
-	return s.SaveCharacter(ctx, player)
-}
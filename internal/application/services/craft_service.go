@@ -0,0 +1,500 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"greatestworks/internal/domain/character"
+	"greatestworks/internal/domain/inventory/synthesis"
+	"greatestworks/internal/domain/mapmanager"
+	"greatestworks/internal/infrastructure/datamanager"
+	"greatestworks/internal/infrastructure/persistence"
+)
+
+// Profession 制造专业
+type Profession int32
+
+const (
+	ProfessionAlchemy       Profession = 1 // 炼金
+	ProfessionBlacksmithing Profession = 2 // 锻造
+)
+
+// 制造错误
+var (
+	ErrCraftSkillTooLow = errors.New("profession skill too low")
+	ErrCraftInCombat    = errors.New("cannot craft in combat")
+	ErrCraftUserDead    = errors.New("cannot craft while dead")
+	ErrNotCrafting      = errors.New("not crafting")
+)
+
+const (
+	craftMaxCount                   = 20   // 单次批量制造的最大数量
+	craftMoveTolerance      float32 = 0.5  // 制造中允许的位移，超出即打断
+	craftSkillMax           int32   = 300  // 专业技能上限
+	craftSkillGainWindow    int32   = 25   // 技能高出配方要求该值后制造不再提升技能
+	craftSkillBonusPerPoint float64 = 0.01 // 技能每高出配方要求一点增加的成功率
+	craftHistoryLimit       int64   = 20   // 默认查询的制造记录条数
+	craftReasonCompleted            = "completed"
+	craftReasonInterrupted          = "interrupted"
+)
+
+// CraftStore 制造持久化（由 CraftingRepository 实现，RaiseSkill 与 SaveRecord 需支持事务上下文）
+type CraftStore interface {
+	GetSkills(ctx context.Context, characterID int64) (map[int32]int32, error)
+	RaiseSkill(ctx context.Context, characterID int64, profession, skill int32) error
+	SaveRecord(ctx context.Context, doc *persistence.CraftRecordDocument) error
+	FindRecords(ctx context.Context, characterID int64, limit int64) ([]*persistence.CraftRecordDocument, error)
+}
+
+// CraftItemView 制造消耗或产出的物品
+type CraftItemView struct {
+	ItemID int32 `json:"item_id"`
+	Count  int32 `json:"count"`
+}
+
+// CraftRecipeView 配方及角色的制造条件
+type CraftRecipeView struct {
+	RecipeID      int32           `json:"recipe_id"`
+	Name          string          `json:"name"`
+	Profession    Profession      `json:"profession"`
+	SkillLevel    int32           `json:"skill_level"`
+	RequiredLevel int32           `json:"required_level"`
+	Skill         int32           `json:"skill"`        // 角色当前专业技能
+	SuccessRate   float64         `json:"success_rate"` // 计入技能加成后的成功率
+	CraftTime     float32         `json:"craft_time"`
+	Materials     []CraftItemView `json:"materials"`
+	Outputs       []CraftItemView `json:"outputs"`
+}
+
+// CraftStatus 进行中的制造
+type CraftStatus struct {
+	RecipeID    int32   `json:"recipe_id"`
+	Total       int32   `json:"total"`
+	Done        int32   `json:"done"`
+	Succeeded   int32   `json:"succeeded"`
+	Elapsed     float32 `json:"elapsed"`  // 当前一件已进行的秒数
+	Duration    float32 `json:"duration"` // 每件耗时（秒）
+	SuccessRate float64 `json:"success_rate"`
+}
+
+// CraftNotice 下发给制造者的进度通知
+type CraftNotice struct {
+	RecipeID  int32           `json:"recipe_id"`
+	Done      int32           `json:"done"`
+	Total     int32           `json:"total"`
+	Succeeded int32           `json:"succeeded"`
+	Success   bool            `json:"success"`         // craft_progress: 本件是否成功
+	Items     []CraftItemView `json:"items,omitempty"` // craft_progress: 本件获得的物品
+	Skill     int32           `json:"skill,omitempty"`
+	Reason    string          `json:"reason,omitempty"` // craft_end: completed/interrupted 或失败原因
+}
+
+// craftJob 进行中的批量制造
+type craftJob struct {
+	define    *datamanager.RecipeDefine
+	recipe    *synthesis.Recipe // 已计入技能加成的领域配方
+	total     int32
+	done      int32
+	succeeded int32
+	elapsed   time.Duration
+	duration  time.Duration
+	origin    character.Vector3
+}
+
+// CraftService 制造服务：配方来自 recipes.json，按配方耗时逐件制造，移动、进入战斗或死亡会打断；
+// 每件在同一事务内按合成领域规则扣除背包材料、掷骰、发放产出、提升专业技能并记录
+type CraftService struct {
+	mu         sync.Mutex
+	mapService *MapService
+	items      *ItemService
+	store      CraftStore
+	synthesis  *synthesis.SynthesisService
+	jobs       map[int32]*craftJob            // 实体ID -> 制造
+	skills     map[int32]map[Profession]int32 // 实体ID -> 专业技能缓存
+}
+
+// NewCraftService 创建制造服务
+func NewCraftService(mapService *MapService, items *ItemService, store CraftStore) *CraftService {
+	return &CraftService{
+		mapService: mapService,
+		items:      items,
+		store:      store,
+		synthesis:  synthesis.NewSynthesisService(),
+		jobs:       make(map[int32]*craftJob),
+		skills:     make(map[int32]map[Profession]int32),
+	}
+}
+
+// Recipes 全部配方及角色的技能与成功率
+func (s *CraftService) Recipes(ctx context.Context, entityID int32) ([]CraftRecipeView, error) {
+	_, actor, err := s.mapService.LocateActor(entityID)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	skills, err := s.skillsLocked(ctx, entityID)
+	if err != nil {
+		return nil, err
+	}
+	var views []CraftRecipeView
+	for _, def := range datamanager.GetInstance().GetRecipes() {
+		skill := skills[Profession(def.Profession)]
+		views = append(views, CraftRecipeView{
+			RecipeID:      def.ID,
+			Name:          def.Name,
+			Profession:    Profession(def.Profession),
+			SkillLevel:    def.SkillLevel,
+			RequiredLevel: def.RequiredLevel,
+			Skill:         skill,
+			SuccessRate:   s.successRate(def, skill, actor.Level()),
+			CraftTime:     def.CraftTime,
+			Materials:     craftItemViews(def.Materials),
+			Outputs:       craftItemViews(def.Outputs),
+		})
+	}
+	return views, nil
+}
+
+// Start 开始批量制造：校验等级、专业技能与背包材料，之后每件耗时 craft_time 秒
+func (s *CraftService) Start(ctx context.Context, entityID, recipeID, count int32) (*CraftStatus, error) {
+	def := datamanager.GetInstance().GetRecipe(recipeID)
+	if def == nil {
+		return nil, synthesis.ErrRecipeNotFound
+	}
+	if count <= 0 || count > craftMaxCount {
+		return nil, synthesis.ErrInvalidQuantity
+	}
+	_, actor, err := s.mapService.LocateActor(entityID)
+	if err != nil {
+		return nil, err
+	}
+	if actor.IsDeath() {
+		return nil, ErrCraftUserDead
+	}
+	if actor.InCombat() {
+		return nil, ErrCraftInCombat
+	}
+	if actor.Level() < def.RequiredLevel {
+		return nil, synthesis.ErrInsufficientLevel
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, busy := s.jobs[entityID]; busy {
+		return nil, synthesis.ErrCraftingInProgress
+	}
+	skills, err := s.skillsLocked(ctx, entityID)
+	if err != nil {
+		return nil, err
+	}
+	skill := skills[Profession(def.Profession)]
+	if skill < def.SkillLevel {
+		return nil, ErrCraftSkillTooLow
+	}
+	recipe := s.newRecipe(def, skill, actor.Level())
+	if err := s.synthesis.ValidateRecipe(recipe); err != nil {
+		return nil, err
+	}
+
+	// 预检第一件的材料（实际扣除在每件完成时进行）
+	bag, err := s.items.GetCharacterItems(ctx, int64(entityID))
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[int32]int32)
+	for _, item := range bag {
+		if item.Location == ItemLocationBag {
+			counts[item.ItemID] += item.Count
+		}
+	}
+	agg := newCraftAggregate(entityID, recipe, def, func(itemID int32) int32 { return counts[itemID] })
+	if err := agg.CanSynthesize(recipe.GetID()); err != nil {
+		return nil, err
+	}
+
+	job := &craftJob{
+		define:   def,
+		recipe:   recipe,
+		total:    count,
+		duration: s.synthesis.CalculateCraftTime(time.Duration(def.CraftTime*float32(time.Second)), nil),
+		origin:   actor.Position(),
+	}
+	s.jobs[entityID] = job
+	return job.status(), nil
+}
+
+// Cancel 取消制造（已完成的物品保留）
+func (s *CraftService) Cancel(entityID int32) (*CraftStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[entityID]
+	if !ok {
+		return nil, ErrNotCrafting
+	}
+	delete(s.jobs, entityID)
+	return job.status(), nil
+}
+
+// Status 当前制造进度
+func (s *CraftService) Status(entityID int32) (*CraftStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[entityID]
+	if !ok {
+		return nil, ErrNotCrafting
+	}
+	return job.status(), nil
+}
+
+// History 按时间倒序查询制造记录
+func (s *CraftService) History(ctx context.Context, entityID int32, limit int64) ([]*persistence.CraftRecordDocument, error) {
+	if limit <= 0 || limit > craftHistoryLimit {
+		limit = craftHistoryLimit
+	}
+	return s.store.FindRecords(ctx, int64(entityID), limit)
+}
+
+// Forget 玩家下线时放弃制造并清除技能缓存
+func (s *CraftService) Forget(entityID int32) {
+	s.mu.Lock()
+	delete(s.jobs, entityID)
+	delete(s.skills, entityID)
+	s.mu.Unlock()
+}
+
+// craftDue 到时待结算的一件
+type craftDue struct {
+	entityID int32
+	gameMap  *mapmanager.Map
+	actor    *character.Actor
+	job      *craftJob
+	skill    int32
+}
+
+// Update 推进制造计时：移动、进入战斗或死亡时打断；到时的一件在锁内收集，释放锁后逐件结算
+func (s *CraftService) Update(ctx context.Context, dt time.Duration) error {
+	var due []craftDue
+	s.mu.Lock()
+	for entityID, job := range s.jobs {
+		gameMap, actor, err := s.mapService.LocateActor(entityID)
+		if err != nil {
+			delete(s.jobs, entityID)
+			continue
+		}
+		if actor.IsDeath() || actor.InCombat() || actor.Position().Distance(job.origin) > craftMoveTolerance {
+			s.endLocked(gameMap, actor, job, craftReasonInterrupted)
+			continue
+		}
+		job.elapsed += dt
+		if job.elapsed < job.duration {
+			continue
+		}
+		job.elapsed = 0
+		skill := s.skills[entityID][Profession(job.define.Profession)]
+		due = append(due, craftDue{entityID: entityID, gameMap: gameMap, actor: actor, job: job, skill: skill})
+	}
+	s.mu.Unlock()
+
+	for _, d := range due {
+		result, newSkill, err := s.complete(ctx, d.entityID, d.job, d.skill)
+		s.mu.Lock()
+		s.finishLocked(d, result, newSkill, err)
+		s.mu.Unlock()
+	}
+	return nil
+}
+
+// finishLocked 记录一件的结算结果并通知制造者；结算期间制造已被取消时只通知已完成的这一件
+func (s *CraftService) finishLocked(d craftDue, result *synthesis.SynthesisResult, newSkill int32, err error) {
+	current := s.jobs[d.entityID] == d.job
+	if err != nil {
+		if current {
+			s.endLocked(d.gameMap, d.actor, d.job, err.Error())
+		}
+		return
+	}
+	job := d.job
+	job.done++
+	success := result.GetSuccessCount() > 0
+	if success {
+		job.succeeded++
+	}
+	if skills, ok := s.skills[d.entityID]; ok && newSkill != d.skill {
+		skills[Profession(job.define.Profession)] = newSkill
+	}
+	d.gameMap.BroadcastTo([]character.EntityID{d.actor.ID()}, "craft_progress", &CraftNotice{
+		RecipeID:  job.define.ID,
+		Done:      job.done,
+		Total:     job.total,
+		Succeeded: job.succeeded,
+		Success:   success,
+		Items:     craftOutputs(result),
+		Skill:     newSkill,
+	})
+	if current && job.done >= job.total {
+		s.endLocked(d.gameMap, d.actor, job, craftReasonCompleted)
+	}
+}
+
+// complete 在同一事务内结算一件（不持有服务锁）：按背包数量构建合成聚合，扣除材料、发放产出、提升技能并记录
+func (s *CraftService) complete(ctx context.Context, entityID int32, job *craftJob, skill int32) (*synthesis.SynthesisResult, int32, error) {
+	characterID := int64(entityID)
+	def := job.define
+	var (
+		result   *synthesis.SynthesisResult
+		newSkill int32
+	)
+	err := s.items.Transact(ctx, []int64{characterID}, func(txCtx context.Context, txs map[int64]*InventoryTx) error {
+		tx := txs[characterID]
+		agg := newCraftAggregate(entityID, job.recipe, def, tx.Count)
+		var err error
+		if result, err = agg.Synthesize(job.recipe.GetID(), 1); err != nil {
+			return err
+		}
+		doc := &persistence.CraftRecordDocument{
+			CharacterID: characterID,
+			RecipeID:    def.ID,
+			Profession:  def.Profession,
+			Success:     result.GetSuccessCount() > 0,
+			SuccessRate: job.recipe.GetSuccessRate(),
+		}
+		for _, m := range def.Materials {
+			if err := tx.Remove(m.ItemID, m.Count); err != nil {
+				return err
+			}
+			doc.Materials = append(doc.Materials, persistence.CraftItemRecord{ItemID: m.ItemID, Count: m.Count})
+		}
+		for _, out := range craftOutputs(result) {
+			if err := tx.Add(out.ItemID, out.Count); err != nil {
+				return err
+			}
+			doc.Outputs = append(doc.Outputs, persistence.CraftItemRecord{ItemID: out.ItemID, Count: out.Count})
+		}
+		newSkill = skill
+		if doc.Success && skill < def.SkillLevel+craftSkillGainWindow && skill < craftSkillMax {
+			newSkill = skill + 1
+			if err := s.store.RaiseSkill(txCtx, characterID, def.Profession, newSkill); err != nil {
+				return err
+			}
+		}
+		doc.Skill = newSkill
+		return s.store.SaveRecord(txCtx, doc)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return result, newSkill, nil
+}
+
+// endLocked 结束制造并通知制造者
+func (s *CraftService) endLocked(gameMap *mapmanager.Map, actor *character.Actor, job *craftJob, reason string) {
+	delete(s.jobs, int32(actor.ID()))
+	gameMap.BroadcastTo([]character.EntityID{actor.ID()}, "craft_end", &CraftNotice{
+		RecipeID:  job.define.ID,
+		Done:      job.done,
+		Total:     job.total,
+		Succeeded: job.succeeded,
+		Reason:    reason,
+	})
+}
+
+// skillsLocked 获取角色专业技能（首次访问时从存储加载）
+func (s *CraftService) skillsLocked(ctx context.Context, entityID int32) (map[Profession]int32, error) {
+	if skills, ok := s.skills[entityID]; ok {
+		return skills, nil
+	}
+	stored, err := s.store.GetSkills(ctx, int64(entityID))
+	if err != nil {
+		return nil, err
+	}
+	skills := make(map[Profession]int32, len(stored))
+	for profession, skill := range stored {
+		skills[Profession(profession)] = skill
+	}
+	s.skills[entityID] = skills
+	return skills, nil
+}
+
+// successRate 计入专业技能与角色等级加成后的成功率
+func (s *CraftService) successRate(def *datamanager.RecipeDefine, skill, level int32) float64 {
+	var bonuses []*synthesis.SynthesisBonus
+	if over := skill - def.SkillLevel; over > 0 {
+		bonuses = append(bonuses, synthesis.NewSynthesisBonus(synthesis.BonusTypeSuccessRate, float64(over)*craftSkillBonusPerPoint, 0, "profession skill"))
+	}
+	return s.synthesis.CalculateEnhancedSuccessRate(def.SuccessRate, bonuses, int(level))
+}
+
+// newRecipe 由配方定义创建领域配方
+func (s *CraftService) newRecipe(def *datamanager.RecipeDefine, skill, level int32) *synthesis.Recipe {
+	recipe := synthesis.NewRecipe(def.Name, synthesis.RecipeCategory(def.Category), s.successRate(def, skill, level))
+	recipe.SetRequireLevel(int(def.RequiredLevel))
+	recipe.SetCraftTime(time.Duration(def.CraftTime * float32(time.Second)))
+	for _, m := range def.Materials {
+		recipe.AddRequirement(craftKey(m.ItemID), int(m.Count))
+	}
+	for _, out := range def.Outputs {
+		recipe.AddOutput(craftKey(out.ItemID), int(out.Count), 1)
+	}
+	for _, out := range def.FailOutputs {
+		recipe.AddFailOutput(craftKey(out.ItemID), int(out.Count), 1)
+	}
+	return recipe
+}
+
+// status 制造进度快照
+func (j *craftJob) status() *CraftStatus {
+	return &CraftStatus{
+		RecipeID:    j.define.ID,
+		Total:       j.total,
+		Done:        j.done,
+		Succeeded:   j.succeeded,
+		Elapsed:     float32(j.elapsed.Seconds()),
+		Duration:    float32(j.duration.Seconds()),
+		SuccessRate: j.recipe.GetSuccessRate(),
+	}
+}
+
+// newCraftAggregate 以背包中配方材料的数量构建合成聚合
+func newCraftAggregate(entityID int32, recipe *synthesis.Recipe, def *datamanager.RecipeDefine, count func(itemID int32) int32) *synthesis.SynthesisAggregate {
+	agg := synthesis.NewSynthesisAggregate(playerKey(entityID))
+	_ = agg.AddRecipe(recipe)
+	for _, m := range def.Materials {
+		if n := count(m.ItemID); n > 0 {
+			_ = agg.AddMaterial(synthesis.NewMaterial(craftKey(m.ItemID), "", 0, synthesis.QualityCommon, int(n)))
+		}
+	}
+	return agg
+}
+
+// craftOutputs 合成结果中的物品（成功产出或失败返还）
+func craftOutputs(result *synthesis.SynthesisResult) []CraftItemView {
+	var views []CraftItemView
+	for _, items := range []map[string]int{result.GetSuccessItems(), result.GetFailItems()} {
+		for key, count := range items {
+			itemID, err := strconv.Atoi(key)
+			if err != nil || count <= 0 {
+				continue
+			}
+			views = append(views, CraftItemView{ItemID: int32(itemID), Count: int32(count)})
+		}
+	}
+	slices.SortFunc(views, func(a, b CraftItemView) int { return int(a.ItemID - b.ItemID) })
+	return views
+}
+
+func craftItemViews(items []datamanager.RecipeItemDefine) []CraftItemView {
+	views := make([]CraftItemView, 0, len(items))
+	for _, item := range items {
+		views = append(views, CraftItemView{ItemID: item.ItemID, Count: item.Count})
+	}
+	return views
+}
+
+func craftKey(itemID int32) string {
+	return strconv.Itoa(int(itemID))
+}
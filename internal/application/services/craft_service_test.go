@@ -0,0 +1,204 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"greatestworks/internal/domain/character"
+	"greatestworks/internal/infrastructure/datamanager"
+	"greatestworks/internal/infrastructure/persistence"
+)
+
+// 测试配方：3 个草药（30002）必定制成 2 瓶生命药水，每件 1 秒
+const (
+	testRecipeID = int32(9001)
+	testHerbID   = int32(30002)
+)
+
+func loadTestRecipes(t *testing.T) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "recipes.json")
+	data := `[{"id": 9001, "name": "Test Potion", "category": 4, "profession": 1, "required_level": 1,
+		"materials": [{"item_id": 30002, "count": 3}], "outputs": [{"item_id": 10001, "count": 2}],
+		"success_rate": 1, "craft_time": 1}]`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatalf("write recipes: %v", err)
+	}
+	if err := datamanager.GetInstance().LoadRecipes(path); err != nil {
+		t.Fatalf("load recipes: %v", err)
+	}
+}
+
+// stubCraftStore 制造存储：entered 非空时保存记录前通知并等待 release
+type stubCraftStore struct {
+	mu      sync.Mutex
+	records []*persistence.CraftRecordDocument
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (s *stubCraftStore) GetSkills(context.Context, int64) (map[int32]int32, error) {
+	return map[int32]int32{}, nil
+}
+
+func (s *stubCraftStore) RaiseSkill(context.Context, int64, int32, int32) error { return nil }
+
+func (s *stubCraftStore) SaveRecord(_ context.Context, doc *persistence.CraftRecordDocument) error {
+	if s.entered != nil {
+		s.entered <- struct{}{}
+		<-s.release
+	}
+	s.mu.Lock()
+	s.records = append(s.records, doc)
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *stubCraftStore) FindRecords(context.Context, int64, int64) ([]*persistence.CraftRecordDocument, error) {
+	return nil, nil
+}
+
+// craftNotices 记录下发给制造者的通知
+type craftNotices struct {
+	mu   sync.Mutex
+	ends []string
+	done []int32
+}
+
+func (n *craftNotices) broadcast(_ []character.EntityID, topic string, payload interface{}) {
+	notice, ok := payload.(*CraftNotice)
+	if !ok {
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	switch topic {
+	case "craft_end":
+		n.ends = append(n.ends, notice.Reason)
+	case "craft_progress":
+		n.done = append(n.done, notice.Done)
+	}
+}
+
+// newCraftTest 玩家（实体1）背包内有 herbs 个草药
+func newCraftTest(t *testing.T, herbs int32) (*CraftService, *memItemStore, *stubCraftStore, *craftNotices) {
+	t.Helper()
+	loadTestRecipes(t)
+	notices := &craftNotices{}
+	ms := NewMapService()
+	ms.SetBroadcaster(notices.broadcast)
+	if err := ms.EnterMapActor(context.Background(), newTestPlayer(t, 1, 10), testVillageMapID, 100, 0, 100); err != nil {
+		t.Fatalf("enter: %v", err)
+	}
+	items := newMemItemStore(nil, ownedItem(1, 1, testHerbID, herbs, 0))
+	store := &stubCraftStore{}
+	return NewCraftService(ms, NewItemService(items), store), items, store, notices
+}
+
+// blockedUpdate 在后台推进一件制造，返回时该件停在保存记录处；调用 finish 放行并等待结算完成
+func blockedUpdate(t *testing.T, s *CraftService, store *stubCraftStore) (finish func()) {
+	t.Helper()
+	store.entered, store.release = make(chan struct{}), make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		s.Update(context.Background(), 10*time.Second)
+		close(done)
+	}()
+	select {
+	case <-store.entered:
+	case <-time.After(time.Second):
+		t.Fatalf("craft did not reach completion")
+	}
+	return func() {
+		close(store.release)
+		<-done
+		store.entered = nil
+	}
+}
+
+func TestCraftCancelDuringCompletion(t *testing.T) {
+	s, items, store, notices := newCraftTest(t, 6)
+	if _, err := s.Start(context.Background(), 1, testRecipeID, 2); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	finish := blockedUpdate(t, s, store)
+	if _, err := s.Cancel(1); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	finish()
+
+	// 已结算的一件保留并通知，取消后不再继续或发送完成
+	if got := items.holdings(1); got[testPotionID] != 2 || got[testHerbID] != 3 {
+		t.Fatalf("holdings %v, want the finished piece kept", got)
+	}
+	if len(notices.done) != 1 || len(notices.ends) != 0 {
+		t.Fatalf("progress %v ends %v, want one progress notice and no end", notices.done, notices.ends)
+	}
+	if _, err := s.Status(1); !errors.Is(err, ErrNotCrafting) {
+		t.Fatalf("status: err %v", err)
+	}
+	s.Update(context.Background(), 10*time.Second)
+	if got := items.holdings(1); got[testHerbID] != 3 {
+		t.Fatalf("cancelled craft kept consuming materials: %v", got)
+	}
+}
+
+func TestCraftRestartDuringCompletion(t *testing.T) {
+	ctx := context.Background()
+	s, _, store, notices := newCraftTest(t, 9)
+	if _, err := s.Start(ctx, 1, testRecipeID, 1); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	finish := blockedUpdate(t, s, store)
+	s.Cancel(1)
+	if _, err := s.Start(ctx, 1, testRecipeID, 2); err != nil {
+		t.Fatalf("restart: %v", err)
+	}
+	finish()
+
+	// 旧制造的最后一件结算完成时不能结束新的制造
+	status, err := s.Status(1)
+	if err != nil {
+		t.Fatalf("new craft ended by the old one: %v", err)
+	}
+	if status.Total != 2 || status.Done != 0 {
+		t.Fatalf("new craft status %+v", status)
+	}
+	if len(notices.ends) != 0 {
+		t.Fatalf("unexpected end notices %v", notices.ends)
+	}
+
+	s.Update(ctx, 10*time.Second)
+	s.Update(ctx, 10*time.Second)
+	if len(notices.ends) != 1 || notices.ends[0] != craftReasonCompleted || len(store.records) != 3 {
+		t.Fatalf("ends %v after %d records, want the new craft completed", notices.ends, len(store.records))
+	}
+}
+
+func TestCraftMaterialShortageEndsJob(t *testing.T) {
+	ctx := context.Background()
+	s, items, store, notices := newCraftTest(t, 4)
+	if _, err := s.Start(ctx, 1, testRecipeID, 3); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	s.Update(ctx, 10*time.Second)
+	if status, err := s.Status(1); err != nil || status.Done != 1 {
+		t.Fatalf("after the first piece: status %+v err %v", status, err)
+	}
+	s.Update(ctx, 10*time.Second)
+
+	if _, err := s.Status(1); !errors.Is(err, ErrNotCrafting) {
+		t.Fatalf("craft still running without materials: %v", err)
+	}
+	if len(notices.ends) != 1 || notices.ends[0] == craftReasonCompleted {
+		t.Fatalf("ends %v, want one failure end", notices.ends)
+	}
+	if got := items.holdings(1); got[testPotionID] != 2 || got[testHerbID] != 1 || len(store.records) != 1 {
+		t.Fatalf("holdings %v records %d, want only the first piece", got, len(store.records))
+	}
+}
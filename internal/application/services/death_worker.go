@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"greatestworks/internal/domain/character"
+	"greatestworks/internal/domain/mapmanager"
+	"greatestworks/internal/infrastructure/logging"
+)
+
+// deathDrainTimeout 停止时等待队列中死亡事件处理完毕的最长时间
+const deathDrainTimeout = 5 * time.Second
+
+// deathEvent 待处理的死亡事件
+type deathEvent struct {
+	gameMap *mapmanager.Map
+	actor   *character.Actor
+}
+
+// DeathWorker 死亡与奖励处理的专用有界队列：地图 tick 只负责投递，掉落、奖励、死亡记录等数据库读写在独立协程执行；
+// 队列已满时丢弃并记录错误日志，不在地图 tick 中执行
+type DeathWorker struct {
+	logger  logging.Logger
+	handler mapmanager.DeathHandler
+	ch      chan deathEvent
+	wg      sync.WaitGroup
+
+	mu      sync.RWMutex
+	started bool
+	closed  bool
+	cancel  context.CancelFunc
+}
+
+// NewDeathWorker 创建死亡处理队列（queueSize<=0 时默认 1024）
+func NewDeathWorker(logger logging.Logger, handler mapmanager.DeathHandler, queueSize int) *DeathWorker {
+	if queueSize <= 0 {
+		queueSize = 1024
+	}
+	return &DeathWorker{
+		logger:  logger,
+		handler: handler,
+		ch:      make(chan deathEvent, queueSize),
+	}
+}
+
+// Start 启动处理协程（workers<=0 时默认 1）；重复调用无效
+func (w *DeathWorker) Start(workers int) {
+	if workers <= 0 {
+		workers = 1
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.started || w.closed {
+		return
+	}
+	w.started = true
+	// 处理上下文独立于服务上下文：停止时先处理完队列中的事件再取消
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+	for i := 0; i < workers; i++ {
+		w.wg.Add(1)
+		go func() {
+			defer w.wg.Done()
+			for event := range w.ch {
+				w.run(ctx, event)
+			}
+		}()
+	}
+}
+
+// Stop 停止接收新事件，等待队列处理完毕（最多 deathDrainTimeout）
+func (w *DeathWorker) Stop() {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return
+	}
+	w.closed = true
+	close(w.ch)
+	w.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() { w.wg.Wait(); close(done) }()
+	select {
+	case <-done:
+	case <-time.After(deathDrainTimeout):
+		w.logger.Warn("Death worker stop timed out", logging.Fields{"pending": len(w.ch)})
+	}
+	if w.cancel != nil {
+		w.cancel()
+	}
+}
+
+// OnActorDeath 地图死亡回调：投递到处理队列后立即返回
+func (w *DeathWorker) OnActorDeath(_ context.Context, gameMap *mapmanager.Map, actor *character.Actor) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		w.logger.Warn("Death worker stopped, dropping death event", logging.Fields{"entity_id": int32(actor.ID())})
+		return
+	}
+	select {
+	case w.ch <- deathEvent{gameMap: gameMap, actor: actor}:
+	default:
+		w.logger.Error("Death queue full, dropping death event", nil, logging.Fields{"entity_id": int32(actor.ID()), "map_id": gameMap.ID()})
+	}
+}
+
+func (w *DeathWorker) run(ctx context.Context, event deathEvent) {
+	defer func() {
+		if r := recover(); r != nil {
+			w.logger.Error("death handler panic", nil, logging.Fields{"recover": r, "entity_id": int32(event.actor.ID())})
+		}
+	}()
+	w.handler(ctx, event.gameMap, event.actor)
+}
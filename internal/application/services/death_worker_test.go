@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"greatestworks/internal/domain/character"
+	"greatestworks/internal/domain/mapmanager"
+	"greatestworks/internal/infrastructure/logging"
+)
+
+func TestDeathWorkerIsBounded(t *testing.T) {
+	ms := NewMapService()
+	if err := ms.LoadMap(context.Background(), testArenaMapID); err != nil {
+		t.Fatalf("load map: %v", err)
+	}
+	gameMap, _ := ms.GetMap(testArenaMapID)
+	actor := newTestPlayer(t, 1, 1)
+
+	started, release := make(chan struct{}, 4), make(chan struct{})
+	var handled atomic.Int32
+	w := NewDeathWorker(logging.NewBaseLogger(logging.ErrorLevel), func(context.Context, *mapmanager.Map, *character.Actor) {
+		started <- struct{}{}
+		<-release
+		handled.Add(1)
+	}, 1)
+	w.Start(1)
+
+	// 投递总是立即返回：第一个事件占用处理协程，第二个进入队列，第三个因队列已满被丢弃而不是在调用方执行
+	deliver := func() {
+		t.Helper()
+		done := make(chan struct{})
+		go func() { w.OnActorDeath(context.Background(), gameMap, actor); close(done) }()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatalf("OnActorDeath blocked the caller")
+		}
+	}
+	deliver()
+	<-started
+	deliver()
+	deliver()
+
+	close(release)
+	w.Stop()
+	if n := handled.Load(); n != 2 {
+		t.Fatalf("handled %d events, want 2 (one dropped)", n)
+	}
+	// 停止后的事件直接丢弃
+	deliver()
+	if n := handled.Load(); n != 2 {
+		t.Fatalf("handled %d events after stop", n)
+	}
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"greatestworks/internal/domain/character"
+	"greatestworks/internal/domain/inventory"
+	"greatestworks/internal/infrastructure/datamanager"
+	"greatestworks/internal/infrastructure/persistence"
+)
+
+// 强化错误
+var (
+	ErrEnhanceNotEquipment = errors.New("item cannot be enhanced")
+	ErrEnhanceMaxLevel     = errors.New("item is at max enhance level")
+)
+
+// EnhanceResult 强化结果
+type EnhanceResult struct {
+	ItemUID   int64
+	ItemID    int32
+	Success   bool
+	Level     int32 // 强化后的等级
+	Failure   int32 // 实际生效的失败结果（见 datamanager.EnhanceFail*）
+	Protected bool  // 保护道具免除了失败惩罚
+	Gold      int64 // 消耗的金币
+}
+
+// EnhanceAnnouncement 高等级强化成功的全服公告
+type EnhanceAnnouncement struct {
+	Kind       string `json:"kind"` // enhance
+	PlayerID   int32  `json:"player_id"`
+	PlayerName string `json:"player_name"`
+	ItemID     int32  `json:"item_id"`
+	Level      int32  `json:"level"`
+}
+
+// EnhanceService 装备强化：按 enhance.json 逐级消耗材料与金币并判定成败，失败可能降级或损坏（保护道具可免除）；
+// 扣除消耗与修改物品在同一事务内完成，穿戴中的装备同步刷新属性
+type EnhanceService struct {
+	mu         sync.Mutex
+	mapService *MapService
+	items      *ItemService
+	wallet     WalletStore
+	rng        *rand.Rand
+}
+
+// NewEnhanceService 创建装备强化服务
+func NewEnhanceService(mapService *MapService, items *ItemService, wallet WalletStore) *EnhanceService {
+	return &EnhanceService{
+		mapService: mapService,
+		items:      items,
+		wallet:     wallet,
+		rng:        rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Enhance 将背包或装备栏中的装备强化一级；protect 为 true 时消耗保护道具免除失败惩罚
+func (s *EnhanceService) Enhance(ctx context.Context, entityID int32, itemUID int64, protect bool) (*EnhanceResult, error) {
+	_, actor, err := s.mapService.LocateActor(entityID)
+	if err != nil {
+		return nil, err
+	}
+	// 实体ID即角色ID（与登录绑定一致）
+	characterID := int64(entityID)
+	dm := datamanager.GetInstance()
+	// 在事务外判定成败并生成流水键，事务重试时结果不变
+	s.mu.Lock()
+	roll := s.rng.Float64()
+	s.mu.Unlock()
+	attempt := uuid.New().String()
+
+	result := &EnhanceResult{}
+	var (
+		def     *datamanager.ItemDefine
+		next    *datamanager.EnhanceDefine
+		updated persistence.DbItem
+	)
+	err = s.items.Transact(ctx, []int64{characterID}, func(txCtx context.Context, txs map[int64]*InventoryTx) error {
+		tx := txs[characterID]
+		item := tx.Find(itemUID)
+		if item == nil || (item.Location != ItemLocationBag && item.Location != ItemLocationEquip) {
+			return &inventory.OpError{Op: "enhance", ItemUID: itemUID, Err: inventory.ErrItemNotFound}
+		}
+		if def = dm.GetItem(item.ItemID); def == nil || def.Type != itemTypeEquipment {
+			return ErrEnhanceNotEquipment
+		}
+		if next = dm.GetEnhance(item.Enhance + 1); next == nil {
+			return ErrEnhanceMaxLevel
+		}
+		*result = EnhanceResult{ItemUID: itemUID, ItemID: item.ItemID, Gold: next.Gold}
+
+		for _, m := range next.Materials {
+			if err := tx.Remove(m.ItemID, m.Count); err != nil {
+				return err
+			}
+		}
+		if next.Gold > 0 {
+			if _, err := s.wallet.Apply(txCtx, WalletChange{
+				CharacterID: characterID,
+				Currency:    CurrencyGold,
+				Delta:       -next.Gold,
+				Reason:      WalletReasonEnhance,
+				Source:      fmt.Sprintf("item:%d", itemUID),
+				Key:         fmt.Sprintf("enhance:%d:%d:%s", itemUID, next.Level, attempt),
+			}); err != nil {
+				return err
+			}
+		}
+		// 仅在有失败惩罚时消耗保护道具
+		if protect && next.Failure != datamanager.EnhanceFailNone && next.ProtectItemID != 0 {
+			if err := tx.Remove(next.ProtectItemID, 1); err != nil {
+				return err
+			}
+			result.Protected = true
+		}
+
+		level := item.Enhance
+		switch {
+		case roll < next.SuccessRate:
+			result.Success, level = true, next.Level
+		case result.Protected:
+		case next.Failure == datamanager.EnhanceFailDowngrade && level > 0:
+			result.Failure, level = next.Failure, level-1
+		case next.Failure == datamanager.EnhanceFailBreak:
+			result.Failure = next.Failure
+		}
+		result.Level = level
+		updated = *item
+		updated.Enhance = level
+		if result.Failure == datamanager.EnhanceFailBreak {
+			result.Level = 0
+			return tx.Take(itemUID, 1)
+		}
+		if level != item.Enhance {
+			return tx.SetEnhance(itemUID, level)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 穿戴中的装备：损坏时卸下，等级变化时按新属性重新穿戴
+	em := actor.GetEquipmentManager()
+	slot := character.EquipSlot(updated.Slot)
+	if eq := em.Get(slot); updated.Location == ItemLocationEquip && eq != nil && eq.ItemUID == itemUID {
+		switch {
+		case result.Failure == datamanager.EnhanceFailBreak:
+			_, _ = em.Unequip(slot)
+		case result.Success || result.Failure == datamanager.EnhanceFailDowngrade:
+			_, _ = em.Equip(equipmentFromDefine(&updated, def))
+		}
+	}
+	if result.Success && next.Announce {
+		s.mapService.BroadcastToWorld("world_announce", &EnhanceAnnouncement{
+			Kind:       "enhance",
+			PlayerID:   entityID,
+			PlayerName: actor.Name(),
+			ItemID:     result.ItemID,
+			Level:      result.Level,
+		})
+	}
+	return result, nil
+}
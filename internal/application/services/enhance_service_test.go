@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"greatestworks/internal/domain/character"
+	"greatestworks/internal/infrastructure/datamanager"
+)
+
+// configs/data 中的强化材料：30004 强化石、30003 稀有材料、30005 保护符
+const (
+	testEnhanceStoneID = int32(30004)
+	testRareMaterialID = int32(30003)
+	testProtectItemID  = int32(30005)
+)
+
+// fixedRoll 固定的随机数（Float64 返回该值）
+type fixedRoll float64
+
+func (r fixedRoll) Int63() int64 { return int64(float64(r) * (1 << 63)) }
+func (fixedRoll) Seed(int64)     {}
+
+// newEnhanceTest 玩家（实体1，10000 金币）持有 +level 铁剑（唯一ID 1）与足量材料；equipped 时铁剑穿戴中
+func newEnhanceTest(t *testing.T, level int32, equipped bool, roll float64) (*EnhanceService, *memItemStore, *memWallet, *character.Actor) {
+	t.Helper()
+	ms := NewMapService()
+	actor := newTestPlayer(t, 1, 10)
+	if err := ms.EnterMapActor(context.Background(), actor, testVillageMapID, 100, 0, 100); err != nil {
+		t.Fatalf("enter: %v", err)
+	}
+	sword := ownedItem(1, 1, testSwordID, 1, 0)
+	sword.Enhance = level
+	if equipped {
+		def := datamanager.GetInstance().GetItem(testSwordID)
+		sword.Location, sword.Slot = ItemLocationEquip, def.EquipSlot
+		if _, err := actor.GetEquipmentManager().Equip(equipmentFromDefine(sword, def)); err != nil {
+			t.Fatalf("equip: %v", err)
+		}
+	}
+	wallet := newMemWallet(map[int64]int64{1: 10000})
+	store := newMemItemStore(wallet, sword,
+		ownedItem(2, 1, testEnhanceStoneID, 10, 1),
+		ownedItem(3, 1, testRareMaterialID, 2, 2),
+		ownedItem(4, 1, testProtectItemID, 1, 3))
+	s := NewEnhanceService(ms, NewItemService(store), wallet)
+	s.rng = rand.New(fixedRoll(roll))
+	return s, store, wallet, actor
+}
+
+func TestEnhance(t *testing.T) {
+	tests := []struct {
+		name      string
+		level     int32
+		protect   bool
+		roll      float64
+		want      EnhanceResult
+		gold      int64 // 剩余金币
+		stones    int32 // 剩余强化石
+		protects  int32 // 剩余保护符
+		remaining int32 // 强化后的等级
+	}{
+		{"success", 0, false, 0, EnhanceResult{Success: true, Level: 1, Gold: 100}, 9900, 9, 1, 1},
+		{"success keeps the protection when no penalty applies", 0, true, 0, EnhanceResult{Success: true, Level: 1, Gold: 100}, 9900, 9, 1, 1},
+		{"failure without penalty", 2, false, 0.99, EnhanceResult{Level: 2, Gold: 300}, 9700, 9, 1, 2},
+		{"downgrade", 5, false, 0.99, EnhanceResult{Level: 4, Failure: datamanager.EnhanceFailDowngrade, Gold: 1000}, 9000, 8, 1, 4},
+		{"protected downgrade", 5, true, 0.99, EnhanceResult{Level: 5, Protected: true, Gold: 1000}, 9000, 8, 0, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, store, wallet, _ := newEnhanceTest(t, tt.level, false, tt.roll)
+			result, err := s.Enhance(context.Background(), 1, 1, tt.protect)
+			if err != nil {
+				t.Fatalf("enhance: %v", err)
+			}
+			tt.want.ItemUID, tt.want.ItemID = 1, testSwordID
+			if *result != tt.want {
+				t.Fatalf("result %+v, want %+v", *result, tt.want)
+			}
+			held := store.holdings(1)
+			if wallet.gold(1) != tt.gold || held[testEnhanceStoneID] != tt.stones || held[testProtectItemID] != tt.protects {
+				t.Fatalf("gold %d, holdings %v", wallet.gold(1), held)
+			}
+			if sword, _ := store.FindByUID(context.Background(), 1); sword.Enhance != tt.remaining {
+				t.Fatalf("sword at +%d, want +%d", sword.Enhance, tt.remaining)
+			}
+		})
+	}
+}
+
+func TestEnhanceBreakUnequips(t *testing.T) {
+	s, store, _, actor := newEnhanceTest(t, 8, true, 0.99)
+	slot := character.EquipSlot(datamanager.GetInstance().GetItem(testSwordID).EquipSlot)
+	result, err := s.Enhance(context.Background(), 1, 1, false)
+	if err != nil {
+		t.Fatalf("enhance: %v", err)
+	}
+	if result.Failure != datamanager.EnhanceFailBreak || result.Level != 0 {
+		t.Fatalf("result %+v, want a break", *result)
+	}
+	if _, err := store.FindByUID(context.Background(), 1); err == nil {
+		t.Fatalf("broken sword still stored")
+	}
+	if eq := actor.GetEquipmentManager().Get(slot); eq != nil {
+		t.Fatalf("broken sword still equipped: %+v", eq)
+	}
+}
+
+func TestEnhanceEquippedRefreshesStats(t *testing.T) {
+	s, _, _, actor := newEnhanceTest(t, 0, true, 0)
+	slot := character.EquipSlot(datamanager.GetInstance().GetItem(testSwordID).EquipSlot)
+	before := actor.GetEquipmentManager().Get(slot).Modifier.ADAdd
+	if _, err := s.Enhance(context.Background(), 1, 1, false); err != nil {
+		t.Fatalf("enhance: %v", err)
+	}
+	if after := actor.GetEquipmentManager().Get(slot).Modifier.ADAdd; after <= before {
+		t.Fatalf("equipped sword AD %v after enhancing, want more than %v", after, before)
+	}
+}
+
+func TestEnhanceMaxLevel(t *testing.T) {
+	s, store, wallet, _ := newEnhanceTest(t, 10, false, 0)
+	items := store.snapshot()
+	if _, err := s.Enhance(context.Background(), 1, 1, true); !errors.Is(err, ErrEnhanceMaxLevel) {
+		t.Fatalf("err %v, want ErrEnhanceMaxLevel", err)
+	}
+	if wallet.gold(1) != 10000 || !reflect.DeepEqual(store.snapshot(), items) {
+		t.Fatalf("max level attempt consumed gold or materials")
+	}
+}
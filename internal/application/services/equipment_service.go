@@ -0,0 +1,196 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"greatestworks/internal/domain/character"
+	"greatestworks/internal/domain/inventory"
+	"greatestworks/internal/domain/mapmanager"
+	"greatestworks/internal/infrastructure/datamanager"
+	"greatestworks/internal/infrastructure/persistence"
+)
+
+// 一级属性折算（装备的力量/智力/体质加成换算为战斗属性）
+const (
+	equipStrToAD    = float32(2)  // 每点力量的物理攻击
+	equipIntToAP    = float32(2)  // 每点智力的法术攻击
+	equipVitToMaxHP = float32(10) // 每点体质的生命上限
+)
+
+// itemTypeEquipment 装备类物品
+const itemTypeEquipment int32 = 2
+
+// EquipmentService 装备服务：校验后在背包与装备栏之间移动物品，并同步到在线角色的装备管理器
+type EquipmentService struct {
+	mu         sync.Mutex // 串行化装备变更，避免同一角色的并发穿脱交错
+	itemRepo   *persistence.ItemRepository
+	mapService *MapService
+}
+
+// NewEquipmentService 创建装备服务
+func NewEquipmentService(itemRepo *persistence.ItemRepository, mapService *MapService) *EquipmentService {
+	return &EquipmentService{
+		itemRepo:   itemRepo,
+		mapService: mapService,
+	}
+}
+
+// EquipResult 装备变更结果
+type EquipResult struct {
+	Slot     character.EquipSlot
+	Equipped *persistence.DbItem // 穿上的物品（卸下时为 nil）
+	Removed  *persistence.DbItem // 放回背包的物品（可能为 nil）
+}
+
+// equipmentFromDefine 按物品配置构造领域装备（强化等级按 enhance.json 的比例提升属性）
+func equipmentFromDefine(item *persistence.DbItem, def *datamanager.ItemDefine) *character.Equipment {
+	scale := float32(1)
+	if enhance := datamanager.GetInstance().GetEnhance(item.Enhance); enhance != nil {
+		scale += enhance.StatBonus
+	}
+	return &character.Equipment{
+		ItemUID:       item.ItemUID,
+		ItemID:        item.ItemID,
+		Slot:          character.EquipSlot(def.EquipSlot),
+		RequiredLevel: def.RequiredLevel,
+		Classes:       def.Classes,
+		Modifier: character.AttributeModifier{
+			ADAdd:    (float32(def.AdBonus) + float32(def.StrBonus)*equipStrToAD) * scale,
+			APAdd:    (float32(def.ApBonus) + float32(def.IntBonus)*equipIntToAP) * scale,
+			DefAdd:   float32(def.DefBonus) * scale,
+			MaxHPAdd: float32(def.VitBonus) * equipVitToMaxHP * scale,
+		},
+	}
+}
+
+// RestoreEquipment 加载角色时将装备栏中的物品恢复到装备管理器
+func RestoreEquipment(actor *character.Actor, items []*persistence.DbItem) {
+	var equipments []*character.Equipment
+	for _, item := range items {
+		if item.Location != ItemLocationEquip {
+			continue
+		}
+		if def := datamanager.GetInstance().GetItem(item.ItemID); def != nil {
+			equipments = append(equipments, equipmentFromDefine(item, def))
+		}
+	}
+	if len(equipments) > 0 {
+		actor.GetEquipmentManager().Restore(equipments)
+	}
+}
+
+// Equip 穿戴背包中的装备：校验类型、槽位、等级与职业，槽位已有装备时与之交换位置
+func (s *EquipmentService) Equip(ctx context.Context, entityID int32, characterID int64, itemUID int64) (*EquipResult, error) {
+	_, actor, err := s.mapService.LocateActor(entityID)
+	if err != nil {
+		return nil, err
+	}
+	if actor.IsDeath() {
+		return nil, mapmanager.ErrActorDead
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, err := s.itemRepo.FindByUID(ctx, itemUID)
+	if err != nil || item.CharacterID != characterID || item.Location != ItemLocationBag {
+		return nil, inventory.ErrItemNotFound
+	}
+	def := datamanager.GetInstance().GetItem(item.ItemID)
+	if def == nil || def.Type != itemTypeEquipment {
+		return nil, inventory.ErrItemNotEquippable
+	}
+	eq := equipmentFromDefine(item, def)
+	em := actor.GetEquipmentManager()
+	if err := em.CanEquip(eq); err != nil {
+		return nil, err
+	}
+
+	// 槽位上已穿戴的装备放回新装备原来的背包格子
+	items, err := s.itemRepo.FindByCharacterID(ctx, characterID)
+	if err != nil {
+		return nil, err
+	}
+	var worn *persistence.DbItem
+	for _, it := range items {
+		if it.Location == ItemLocationEquip && it.Slot == int32(eq.Slot) {
+			worn = it
+			break
+		}
+	}
+
+	bagSlot := item.Slot
+	item.Location, item.Slot = ItemLocationEquip, int32(eq.Slot)
+	if err := s.itemRepo.Update(ctx, item); err != nil {
+		return nil, fmt.Errorf("failed to equip item: %w", err)
+	}
+	if worn != nil {
+		worn.Location, worn.Slot = ItemLocationBag, bagSlot
+		if err := s.itemRepo.Update(ctx, worn); err != nil {
+			// 回滚新装备的位置，保持背包与装备栏一致
+			item.Location, item.Slot = ItemLocationBag, bagSlot
+			_ = s.itemRepo.Update(ctx, item)
+			return nil, fmt.Errorf("failed to unequip replaced item: %w", err)
+		}
+	}
+
+	if _, err := em.Equip(eq); err != nil {
+		return nil, err
+	}
+	return &EquipResult{Slot: eq.Slot, Equipped: item, Removed: worn}, nil
+}
+
+// Unequip 卸下装备放入背包第一个空格子；背包已满时不做修改
+func (s *EquipmentService) Unequip(ctx context.Context, entityID int32, characterID int64, slot character.EquipSlot) (*EquipResult, error) {
+	_, actor, err := s.mapService.LocateActor(entityID)
+	if err != nil {
+		return nil, err
+	}
+	if actor.IsDeath() {
+		return nil, mapmanager.ErrActorDead
+	}
+	if !slot.Valid() {
+		return nil, character.ErrInvalidEquipSlot
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items, err := s.itemRepo.FindByCharacterID(ctx, characterID)
+	if err != nil {
+		return nil, err
+	}
+	var worn *persistence.DbItem
+	used := make(map[int32]bool)
+	for _, it := range items {
+		switch {
+		case it.Location == ItemLocationEquip && it.Slot == int32(slot):
+			worn = it
+		case it.Location == ItemLocationBag:
+			used[it.Slot] = true
+		}
+	}
+	if worn == nil {
+		return nil, character.ErrEquipSlotEmpty
+	}
+	free := int32(-1)
+	for i := int32(0); i < DefaultBagCapacity; i++ {
+		if !used[i] {
+			free = i
+			break
+		}
+	}
+	if free < 0 {
+		return nil, inventory.ErrInventoryFull
+	}
+
+	worn.Location, worn.Slot = ItemLocationBag, free
+	if err := s.itemRepo.Update(ctx, worn); err != nil {
+		return nil, fmt.Errorf("failed to unequip item: %w", err)
+	}
+	// 内存中未穿戴（例如角色未随持久化加载装备）时以持久化为准
+	_, _ = actor.GetEquipmentManager().Unequip(slot)
+	return &EquipResult{Slot: slot, Removed: worn}, nil
+}
@@ -3,21 +3,50 @@ package services
 import (
 	"context"
 	"errors"
-	"math/rand/v2"
 
 	"greatestworks/internal/domain/character"
 	"greatestworks/internal/infrastructure/datamanager"
 )
 
+// 施法参数
+const (
+	basicAttackSkillID  = int32(1)     // 普通攻击
+	skillTargetSingle   = int32(1)     // 单体目标技能
+	skillTargetSelf     = int32(4)     // 自身技能
+	skillActiveWindow   = float32(0.1) // 技能激活窗口（秒）
+	skillRangeTolerance = float32(0.5) // 施法距离容差（抵消移动同步延迟）
+	missileHitRadius    = float32(0.5) // 投射物碰撞半径
+	missileRangeFactor  = float32(2)   // 投射物最大飞行距离相对施法距离的倍数（追踪移动目标留出余量）
+)
+
+// 施法错误
+var (
+	ErrSkillNotFound    = errors.New("skill not found")
+	ErrSkillNotLearned  = errors.New("skill not learned")
+	ErrSkillNotReady    = errors.New("skill is not ready")
+	ErrGlobalCooldown   = errors.New("global cooldown")
+	ErrCasting          = errors.New("another cast in progress")
+	ErrNotEnoughMP      = errors.New("not enough mp")
+	ErrCasterDead       = errors.New("caster is dead")
+	ErrTargetNotFound   = errors.New("target not found")
+	ErrTargetDead       = errors.New("target is dead")
+	ErrTargetAlive      = errors.New("target is alive")
+	ErrTargetOutOfRange = errors.New("target out of range")
+	ErrNoLineOfSight    = errors.New("target not in line of sight")
+	ErrCastFailed       = errors.New("cast failed")
+	ErrTargetNotHostile = errors.New("target is not hostile")
+	ErrTargetNotFriend  = errors.New("target is not friendly")
+	ErrBuffNotFound     = errors.New("buff not found")
+)
+
 // SkillCastResult 技能释放结果
 type SkillCastResult struct {
-	CasterID   int32
-	TargetID   int32
-	SkillID    int32
-	Damage     int32
-	IsCritical bool
-	Success    bool
-	Message    string
+	CasterID int32
+	TargetID int32
+	SkillID  int32
+	CastTime float32
+	Success  bool
+	Message  string
 }
 
 // FightService 战斗服务
@@ -43,65 +72,230 @@ func (s *FightService) CastSkill(ctx context.Context, caster *character.Actor, t
 	// 获取技能定义
 	skillDefine := datamanager.GetInstance().GetSkill(skillID)
 	if skillDefine == nil {
-		return errors.New("skill not found")
+		return ErrSkillNotFound
 	}
 
 	// 检查技能是否学会
 	skill := caster.GetSkillManager().GetSkill(skillID)
 	if skill == nil {
-		return errors.New("skill not learned")
+		return ErrSkillNotLearned
 	}
 
 	// 检查技能状态
 	if skill.State() != character.SkillStateReady {
-		return errors.New("skill is not ready")
+		return ErrSkillNotReady
 	}
 
 	// 使用施法器释放技能（此处未解析targetID，默认无目标）
 	if ok := caster.GetSpell().Cast(skillID, nil); !ok {
-		return errors.New("cast failed")
+		return ErrCastFailed
 	}
 
 	return nil
 }
 
-// CastSkillByID 基于ID的施法接口：计算伤害并返回结果（不直接应用）
-// handler 层负责广播结果；实际伤害应用可选地通过后续流程完成
-func (s *FightService) CastSkillByID(ctx context.Context, casterEntityID int32, targetEntityID int32, skillID int32) (*SkillCastResult, error) {
+// CastSkillByID 权威施法：校验技能、冷却、MP、距离与视线后通过施法器释放
+// point 为可选目标点（指定点范围技能的圆心、扇形/矩形技能的朝向）
+// 伤害在技能进入激活阶段时由地图帧更新结算，施法/命中/死亡由地图向 AOI 观察者广播
+func (s *FightService) CastSkillByID(ctx context.Context, casterEntityID int32, targetEntityID int32, skillID int32, point *character.Vector3) (*SkillCastResult, error) {
 	result := &SkillCastResult{
 		CasterID: casterEntityID,
 		TargetID: targetEntityID,
 		SkillID:  skillID,
 		Success:  false,
 	}
+	fail := func(err error) (*SkillCastResult, error) {
+		result.Message = err.Error()
+		return result, err
+	}
 
 	// 获取技能定义
 	skillDefine := datamanager.GetInstance().GetSkill(skillID)
 	if skillDefine == nil {
-		result.Message = "skill not found"
-		return result, errors.New("skill not found")
+		return fail(ErrSkillNotFound)
+	}
+	if s.mapService == nil {
+		return fail(errors.New("map service not set"))
+	}
+
+	gameMap, caster, err := s.mapService.LocateActor(casterEntityID)
+	if err != nil {
+		return fail(err)
+	}
+	if caster.IsDeath() {
+		return fail(ErrCasterDead)
+	}
+
+	// 技能是否学会及状态（冷却/吟唱中不可释放）
+	skill := caster.GetSkillManager().GetSkill(skillID)
+	if skill == nil {
+		return fail(ErrSkillNotLearned)
+	}
+	if skill.State() != character.SkillStateReady {
+		return fail(ErrSkillNotReady)
+	}
+	// 吟唱/引导中不能开始另一个吟唱或引导技能，公共冷却只限制未脱离公共冷却的技能
+	if (skillDefine.CastTime > 0 || skillDefine.Channel > 0) && caster.GetSpell().IsCasting() {
+		return fail(ErrCasting)
+	}
+	if !skillDefine.OffGCD && caster.GetSpell().GlobalCooldown() > 0 {
+		return fail(ErrGlobalCooldown)
+	}
+	if caster.MP() < float32(skillDefine.MPCost) {
+		return fail(ErrNotEnoughMP)
 	}
 
-	// 简化计算：基于技能基础伤害与固定暴击率
-	baseDamage := skillDefine.BaseDamage
-	if baseDamage == 0 {
-		baseDamage = 100 // 默认技能伤害
+	// 目标校验：同一地图实例内存活（复活技能为死亡）、在施法距离内且视线无遮挡
+	var target *character.Actor
+	if skillDefine.TargetType == skillTargetSelf {
+		target = caster
+		result.TargetID = casterEntityID
+	} else if targetEntityID != 0 && targetEntityID != casterEntityID {
+		target = gameMap.GetActor(character.EntityID(targetEntityID))
+		if target == nil {
+			return fail(ErrTargetNotFound)
+		}
+		// 复活技能只能对死亡目标释放，其余技能只能对存活目标释放
+		if resurrect := skillDefine.Resurrect > 0; target.IsDeath() != resurrect {
+			if resurrect {
+				return fail(ErrTargetAlive)
+			}
+			return fail(ErrTargetDead)
+		}
+		// 按技能效果判定敌友：对其他玩家施放有害技能需满足所在区域的 PvP 规则（标记、阵营、决斗）
+		if filter := skillTargetFilter(skillDefine); !caster.CanAffect(target, filter) {
+			switch {
+			case filter != character.TargetFilterHostile:
+				return fail(ErrTargetNotFriend)
+			case target.Type() == character.EntityTypePlayer:
+				return fail(ErrPvPNotAllowed)
+			default:
+				return fail(ErrTargetNotHostile)
+			}
+		}
+		if caster.DistanceTo(target.Entity) > skillDefine.Range+skillRangeTolerance {
+			return fail(ErrTargetOutOfRange)
+		}
+		if !gameMap.LineOfSight(caster.Position(), target.Position()) {
+			return fail(ErrNoLineOfSight)
+		}
+	} else if skillDefine.TargetType == skillTargetSingle || skillDefine.Resurrect > 0 {
+		return fail(ErrTargetNotFound)
 	}
 
-	// 暴击判定（固定10%暴击率，伤害1.5倍）
-	isCrit := (rand.Int32N(100) < 10)
-	totalDamage := baseDamage
-	if isCrit {
-		totalDamage = int32(float32(totalDamage) * 1.5)
+	// 目标点：未指定时取目标位置或自身位置；指定点范围技能的圆心需在施法距离内且视线可达
+	castPoint := caster.Position()
+	if target != nil {
+		castPoint = target.Position()
+	}
+	if point != nil {
+		castPoint = *point
+		if character.AreaShape(skillDefine.Shape) == character.AreaShapeCircleAtPoint {
+			if caster.Position().ToVector2().Distance(castPoint.ToVector2()) > skillDefine.Range+skillRangeTolerance {
+				return fail(ErrTargetOutOfRange)
+			}
+			if !gameMap.LineOfSight(caster.Position(), castPoint) {
+				return fail(ErrNoLineOfSight)
+			}
+		}
 	}
 
-	result.Damage = totalDamage
-	result.IsCritical = isCrit
+	// 通过施法器进入吟唱/激活/冷却时序（眩晕、沉默等状态在此拦截）
+	if !caster.GetSpell().CastAt(skillID, target, castPoint) {
+		return fail(ErrCastFailed)
+	}
+	if skillDefine.MPCost > 0 {
+		caster.ChangeMP(-float32(skillDefine.MPCost))
+	}
+
+	result.CastTime = skillDefine.CastTime
 	result.Success = true
-	result.Message = "skill cast calculated"
+	result.Message = "skill cast started"
 	return result, nil
 }
 
+// skillTargetFilter 按技能效果判定可作用的目标：造成伤害、附加减益或打断施法的为敌方技能，
+// 治疗、复活与附加增益的为友方技能，无效果的技能不限敌友
+func skillTargetFilter(def *datamanager.SkillDefine) character.TargetFilter {
+	var buff *datamanager.BuffDefine
+	if def.BuffID != 0 {
+		buff = datamanager.GetInstance().GetBuff(def.BuffID)
+	}
+	switch {
+	case def.BaseDamage > 0 || def.Interrupt > 0 || (buff != nil && buff.Harmful):
+		return character.TargetFilterHostile
+	case def.BaseDamage < 0 || def.Resurrect > 0 || buff != nil:
+		return character.TargetFilterFriendly
+	}
+	return character.TargetFilterAll
+}
+
+// LearnUnitSkills 按单位配置为角色学习技能（单位未配置技能时学习普通攻击），设置职业公共冷却与控制免疫
+func LearnUnitSkills(actor *character.Actor) {
+	skillIDs := []int32{basicAttackSkillID}
+	if unit := datamanager.GetInstance().GetUnit(actor.UnitID()); unit != nil {
+		if len(unit.Skills) > 0 {
+			skillIDs = unit.Skills
+		}
+		actor.GetSpell().SetGlobalCooldown(unit.GCD)
+		actor.GetBuffManager().SetCCImmune(unit.CCImmune)
+	}
+	for _, id := range skillIDs {
+		if def := datamanager.GetInstance().GetSkill(id); def != nil {
+			actor.GetSkillManager().AddSkill(newSkillFromDefine(def, actor))
+		}
+	}
+}
+
+// LearnSkill 为角色学习技能（技能不存在或已学会时返回 false）
+func LearnSkill(actor *character.Actor, skillID int32) bool {
+	def := datamanager.GetInstance().GetSkill(skillID)
+	if def == nil || actor.GetSkillManager().GetSkill(skillID) != nil {
+		return false
+	}
+	actor.GetSkillManager().AddSkill(newSkillFromDefine(def, actor))
+	return true
+}
+
+// newSkillFromDefine 按技能配置创建技能实例
+func newSkillFromDefine(def *datamanager.SkillDefine, owner *character.Actor) *character.Skill {
+	skill := character.NewSkill(def.ID, owner)
+	skill.SetDamage(float32(def.BaseDamage), def.ScaleAD, def.ScaleAP, character.DamageType(def.DamageType))
+	skill.SetTimings(def.CastTime, skillActiveWindow, def.Cooldown)
+	skill.SetArea(character.SkillArea{
+		Shape:      character.AreaShape(def.Shape),
+		Radius:     def.Radius,
+		Angle:      def.Angle,
+		Width:      def.Width,
+		MaxTargets: def.MaxTargets,
+		Filter:     character.TargetFilter(def.Filter),
+	})
+	if def.BuffID != 0 {
+		if buffDef := datamanager.GetInstance().GetBuff(def.BuffID); buffDef != nil {
+			skill.SetBuffEffects([]character.BuffConfig{buffConfigFromDefine(buffDef)})
+		}
+	}
+	if def.Resurrect > 0 {
+		skill.SetResurrect(def.Resurrect)
+	}
+	if def.Channel > 0 && def.ChannelTick > 0 {
+		skill.SetChannel(def.Channel, def.ChannelTick)
+	}
+	if def.Interrupt > 0 {
+		skill.SetInterrupt(def.Interrupt)
+	}
+	skill.SetOffGCD(def.OffGCD)
+	if def.MissileSpeed > 0 {
+		skill.SetMissile(character.MissileConfig{
+			Speed:     def.MissileSpeed,
+			Range:     def.Range * missileRangeFactor,
+			HitRadius: missileHitRadius,
+			Homing:    def.Homing,
+		})
+	}
+	return skill
+}
+
 // ApplyDamage 应用伤害
 func (s *FightService) ApplyDamage(ctx context.Context, attacker, target *character.Actor, damage int32, dmgType int32) error {
 	if target == nil {
@@ -144,17 +338,69 @@ func (s *FightService) ApplyHeal(ctx context.Context, caster, target *character.
 	return nil
 }
 
-// ApplyBuff 应用Buff
+// buffConfigFromDefine 按Buff配置构造领域配置
+func buffConfigFromDefine(def *datamanager.BuffDefine) character.BuffConfig {
+	m := def.Modifier
+	cfg := character.BuffConfig{
+		ID:       def.ID,
+		Duration: def.Duration,
+		Modifier: character.AttributeModifier{
+			MaxHPAdd: m.MaxHPAdd, MaxHPMul: m.MaxHPMul,
+			MaxMPAdd: m.MaxMPAdd, MaxMPMul: m.MaxMPMul,
+			HPRegenAdd: m.HPRegenAdd, MPRegenAdd: m.MPRegenAdd,
+			ADAdd: m.ADAdd, ADMul: m.ADMul,
+			APAdd: m.APAdd, APMul: m.APMul,
+			DefAdd: m.DefAdd, DefMul: m.DefMul,
+			MDefAdd: m.MDefAdd, MDefMul: m.MDefMul,
+			CriAdd: m.CriAdd, CrdAdd: m.CrdAdd,
+			HitRateAdd:     m.HitRateAdd,
+			DodgeRateAdd:   m.DodgeRateAdd,
+			SpeedAdd:       m.SpeedAdd,
+			SpeedMul:       m.SpeedMul,
+			AttackSpeedAdd: m.AttackSpeedAdd,
+			AttackSpeedMul: m.AttackSpeedMul,
+		},
+		Flags:        character.FlagState(def.Flags),
+		Stack:        character.BuffStackRule(def.Stack),
+		MaxStack:     def.MaxStack,
+		TickInterval: def.TickInterval,
+		TickDamage:   def.TickDamage,
+		TickHeal:     def.TickHeal,
+		DamageType:   character.DamageType(def.DamageType),
+		Harmful:      def.Harmful,
+		Category:     character.DispelCategory(def.Category),
+		Dispellable:  def.Dispellable,
+	}
+	for _, c := range def.Immune {
+		cfg.Immune = append(cfg.Immune, character.DispelCategory(c))
+	}
+	return cfg
+}
+
+// ApplyBuff 按Buff配置施加Buff（duration 大于 0 时覆盖配置的持续时间），遵循叠加与免疫规则
 func (s *FightService) ApplyBuff(ctx context.Context, caster, target *character.Actor, buffID int32, duration float32) error {
 	if target == nil {
 		return errors.New("target is nil")
 	}
+	def := datamanager.GetInstance().GetBuff(buffID)
+	if def == nil {
+		return ErrBuffNotFound
+	}
 
-	// 创建并添加Buff
-	buff := character.NewBuff(buffID, target, caster, duration)
-	target.GetBuffManager().AddBuff(buff)
+	cfg := buffConfigFromDefine(def)
+	if duration > 0 {
+		cfg.Duration = duration
+	}
+	_, err := target.GetBuffManager().Apply(character.NewBuffFromConfig(cfg, target, caster))
+	return err
+}
 
-	return nil
+// DispelBuffs 驱散目标身上指定类别的可驱散Buff（category 为 0 匹配任意类别，count 为 0 不限），返回被驱散的BuffID
+func (s *FightService) DispelBuffs(ctx context.Context, target *character.Actor, category int32, harmful bool, count int) ([]int32, error) {
+	if target == nil {
+		return nil, errors.New("target is nil")
+	}
+	return target.GetBuffManager().Dispel(character.DispelCategory(category), harmful, count), nil
 }
 
 // RemoveBuff 移除Buff
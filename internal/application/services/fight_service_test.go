@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"greatestworks/internal/domain/character"
+	"greatestworks/internal/infrastructure/datamanager"
+)
+
+// 测试技能：无伤害的眩晕（Buff 401）与无伤害的打断
+const (
+	testStunSkillID      = int32(9001)
+	testInterruptSkillID = int32(9002)
+	testHealSkillID      = int32(21)
+)
+
+func loadTestSkills(t *testing.T) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "skills.json")
+	data := `[
+		{"id": 9001, "name": "Test Stun", "cooldown": 1, "range": 10, "target_type": 1, "buff_id": 401},
+		{"id": 9002, "name": "Test Interrupt", "cooldown": 1, "range": 10, "target_type": 1, "interrupt": 2}
+	]`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatalf("write skills: %v", err)
+	}
+	if err := datamanager.GetInstance().LoadSkills(path); err != nil {
+		t.Fatalf("load skills: %v", err)
+	}
+}
+
+// newFightTest 施法者（实体1）与目标玩家（实体2）相邻进入地图
+func newFightTest(t *testing.T, mapID int32, x, z float32) (*FightService, *character.Actor, *character.Actor) {
+	t.Helper()
+	loadTestSkills(t)
+	ctx := context.Background()
+	ms := NewMapService()
+	caster, target := newTestPlayer(t, 1, 10), newTestPlayer(t, 2, 10)
+	if err := ms.EnterMapActor(ctx, caster, mapID, x, 0, z); err != nil {
+		t.Fatalf("enter caster: %v", err)
+	}
+	if err := ms.EnterMapActor(ctx, target, mapID, x+2, 0, z); err != nil {
+		t.Fatalf("enter target: %v", err)
+	}
+	for _, id := range []int32{testStunSkillID, testInterruptSkillID, testHealSkillID} {
+		if !LearnSkill(caster, id) {
+			t.Fatalf("learn skill %d", id)
+		}
+	}
+	caster.ChangeMP(1000)
+	fs := NewFightService(nil)
+	fs.SetMapService(ms)
+	return fs, caster, target
+}
+
+func TestSkillTargetFilter(t *testing.T) {
+	loadTestSkills(t)
+	dm := datamanager.GetInstance()
+	tests := []struct {
+		skillID int32
+		want    character.TargetFilter
+	}{
+		{1, character.TargetFilterHostile},                    // 伤害
+		{testStunSkillID, character.TargetFilterHostile},      // 无伤害减益
+		{testInterruptSkillID, character.TargetFilterHostile}, // 仅打断
+		{testHealSkillID, character.TargetFilterFriendly},     // 治疗
+		{22, character.TargetFilterFriendly},                  // 增益
+		{23, character.TargetFilterFriendly},                  // 复活
+	}
+	for _, tt := range tests {
+		if got := skillTargetFilter(dm.GetSkill(tt.skillID)); got != tt.want {
+			t.Errorf("skill %d: filter %d, want %d", tt.skillID, got, tt.want)
+		}
+	}
+}
+
+func TestCrowdControlRespectsPvPRules(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name    string
+		mapID   int32
+		flagged bool
+		skillID int32
+		want    error
+	}{
+		{"stun in safe zone", testVillageMapID, true, testStunSkillID, ErrPvPNotAllowed},
+		{"interrupt in safe zone", testVillageMapID, true, testInterruptSkillID, ErrPvPNotAllowed},
+		{"stun on unflagged player", testForestMapID, false, testStunSkillID, ErrPvPNotAllowed},
+		{"interrupt on unflagged player", testForestMapID, false, testInterruptSkillID, ErrPvPNotAllowed},
+		{"stun on flagged player", testForestMapID, true, testStunSkillID, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs, caster, target := newFightTest(t, tt.mapID, 500, 500)
+			caster.SetPvPFlag(tt.flagged)
+			target.SetPvPFlag(tt.flagged)
+			_, err := fs.CastSkillByID(ctx, 1, 2, tt.skillID, nil)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("err %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFriendlySkillsRejectHostileTargets(t *testing.T) {
+	ctx := context.Background()
+
+	fs, caster, target := newFightTest(t, testForestMapID, 500, 500)
+	caster.SetPvPFlag(true)
+	target.SetPvPFlag(true)
+	if _, err := fs.CastSkillByID(ctx, 1, 2, testHealSkillID, nil); !errors.Is(err, ErrTargetNotFriend) {
+		t.Fatalf("heal on hostile player: err %v, want ErrTargetNotFriend", err)
+	}
+
+	fs, _, _ = newFightTest(t, testForestMapID, 500, 500)
+	if _, err := fs.CastSkillByID(ctx, 1, 2, testHealSkillID, nil); err != nil {
+		t.Fatalf("heal on friendly player: %v", err)
+	}
+}
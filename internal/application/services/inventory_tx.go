@@ -0,0 +1,454 @@
+package services
+
+import (
+	"sort"
+
+	"greatestworks/internal/domain/inventory"
+	"greatestworks/internal/infrastructure/datamanager"
+	"greatestworks/internal/infrastructure/persistence"
+)
+
+// InventoryTx 背包工作单元：在内存中规划一组增删、移动与堆叠，全部成功后由 ItemService.Modify 在同一事务内提交
+type InventoryTx struct {
+	characterID int64
+	capacity    int32
+	items       []*persistence.DbItem
+	dirty       map[*persistence.DbItem]bool
+	created     map[*persistence.DbItem]bool
+	deleted     []*persistence.DbItem
+}
+
+// newInventoryTx 基于角色当前物品创建工作单元
+func newInventoryTx(characterID int64, capacity int32, items []*persistence.DbItem) *InventoryTx {
+	return &InventoryTx{
+		characterID: characterID,
+		capacity:    capacity,
+		items:       items,
+		dirty:       make(map[*persistence.DbItem]bool),
+		created:     make(map[*persistence.DbItem]bool),
+	}
+}
+
+// Items 当前规划后的全部物品（只读）
+func (tx *InventoryTx) Items() []*persistence.DbItem {
+	return tx.items
+}
+
+// Find 按唯一ID查找物品
+func (tx *InventoryTx) Find(itemUID int64) *persistence.DbItem {
+	for _, item := range tx.items {
+		if item.ItemUID == itemUID && itemUID != 0 {
+			return item
+		}
+	}
+	return nil
+}
+
+// Count 背包中某物品的总数
+func (tx *InventoryTx) Count(itemID int32) int32 {
+	var total int32
+	for _, item := range tx.items {
+		if item.Location == ItemLocationBag && item.ItemID == itemID {
+			total += item.Count
+		}
+	}
+	return total
+}
+
+// FreeSlots 背包剩余空格子数
+func (tx *InventoryTx) FreeSlots() int32 {
+	return int32(len(tx.freeSlots(ItemLocationBag, tx.capacity)))
+}
+
+// Add 放入背包：优先堆叠到已有的同类物品，剩余放入空格子
+func (tx *InventoryTx) Add(itemID, count int32) error {
+	return tx.add("add", itemID, count, false)
+}
+
+// AddBound 放入背包并标记为绑定（只与已绑定的同类物品堆叠）
+func (tx *InventoryTx) AddBound(itemID, count int32) error {
+	return tx.add("add", itemID, count, true)
+}
+
+func (tx *InventoryTx) add(op string, itemID, count int32, bound bool) error {
+	if count <= 0 {
+		return &inventory.OpError{Op: op, ItemID: itemID, Err: inventory.ErrInvalidQuantity}
+	}
+	maxStack, err := itemMaxStack(itemID)
+	if err != nil {
+		return &inventory.OpError{Op: op, ItemID: itemID, Err: err}
+	}
+
+	// 先确认放得下再修改
+	var room int32
+	var stacks []*persistence.DbItem
+	for _, item := range tx.items {
+		if item.Location == ItemLocationBag && item.ItemID == itemID && item.Bound == bound && item.Count < maxStack {
+			room += maxStack - item.Count
+			stacks = append(stacks, item)
+		}
+	}
+	free := tx.freeSlots(ItemLocationBag, tx.capacity)
+	if room+int32(len(free))*maxStack < count {
+		return &inventory.OpError{Op: op, ItemID: itemID, Err: inventory.ErrInventoryFull}
+	}
+
+	remaining := count
+	for _, item := range stacks {
+		if remaining <= 0 {
+			break
+		}
+		n := min(maxStack-item.Count, remaining)
+		item.Count += n
+		remaining -= n
+		tx.dirty[item] = true
+	}
+	for _, slot := range free {
+		if remaining <= 0 {
+			break
+		}
+		n := min(maxStack, remaining)
+		tx.create(itemID, n, slot, ItemLocationBag, bound)
+		remaining -= n
+	}
+	return nil
+}
+
+// Place 在指定位置的空格子新建一堆物品
+func (tx *InventoryTx) Place(itemID, count, slot, location int32) (*persistence.DbItem, error) {
+	maxStack, err := itemMaxStack(itemID)
+	if err != nil {
+		return nil, &inventory.OpError{Op: "add", ItemID: itemID, Err: err}
+	}
+	if count <= 0 {
+		return nil, &inventory.OpError{Op: "add", ItemID: itemID, Err: inventory.ErrInvalidQuantity}
+	}
+	if count > maxStack {
+		return nil, &inventory.OpError{Op: "add", ItemID: itemID, Err: inventory.ErrExceedsMaxStack}
+	}
+	if !tx.validSlot(slot, location) || tx.at(slot, location) != nil {
+		return nil, &inventory.OpError{Op: "add", ItemID: itemID, Err: inventory.ErrInvalidSlot}
+	}
+	return tx.create(itemID, count, slot, location, false), nil
+}
+
+// Remove 从背包扣除指定数量的物品（优先扣除数量少的堆叠）
+func (tx *InventoryTx) Remove(itemID, count int32) error {
+	if count <= 0 {
+		return &inventory.OpError{Op: "remove", ItemID: itemID, Err: inventory.ErrInvalidQuantity}
+	}
+	if tx.Count(itemID) < count {
+		return &inventory.OpError{Op: "remove", ItemID: itemID, Err: inventory.ErrInsufficientItems}
+	}
+	var stacks []*persistence.DbItem
+	for _, item := range tx.items {
+		if item.Location == ItemLocationBag && item.ItemID == itemID {
+			stacks = append(stacks, item)
+		}
+	}
+	sort.SliceStable(stacks, func(i, j int) bool { return stacks[i].Count < stacks[j].Count })
+
+	remaining := count
+	for _, item := range stacks {
+		if remaining <= 0 {
+			break
+		}
+		n := min(item.Count, remaining)
+		tx.take(item, n)
+		remaining -= n
+	}
+	return nil
+}
+
+// Take 从指定物品扣除数量（扣完即删除）
+func (tx *InventoryTx) Take(itemUID int64, count int32) error {
+	item := tx.Find(itemUID)
+	if item == nil {
+		return &inventory.OpError{Op: "remove", ItemUID: itemUID, Err: inventory.ErrItemNotFound}
+	}
+	if count <= 0 {
+		return &inventory.OpError{Op: "remove", ItemID: item.ItemID, ItemUID: itemUID, Err: inventory.ErrInvalidQuantity}
+	}
+	if item.Count < count {
+		return &inventory.OpError{Op: "remove", ItemID: item.ItemID, ItemUID: itemUID, Err: inventory.ErrInsufficientItems}
+	}
+	tx.take(item, count)
+	return nil
+}
+
+// Give 将背包中的物品转移给另一名角色（Extract 后 Insert）
+func (tx *InventoryTx) Give(itemUID int64, count int32, to *InventoryTx) error {
+	item, err := tx.Extract(itemUID, count)
+	if err != nil {
+		return err
+	}
+	return to.Insert(item)
+}
+
+// Extract 从背包取出可交易的物品：整堆取出时返回原物品（保留唯一ID），否则拆出新物品；取出的物品必须再 Insert 到某个背包
+func (tx *InventoryTx) Extract(itemUID int64, count int32) (*persistence.DbItem, error) {
+	item := tx.Find(itemUID)
+	if item == nil || item.Location != ItemLocationBag {
+		return nil, &inventory.OpError{Op: "extract", ItemUID: itemUID, Err: inventory.ErrItemNotFound}
+	}
+	if item.Bound {
+		return nil, &inventory.OpError{Op: "extract", ItemID: item.ItemID, ItemUID: itemUID, Err: inventory.ErrItemNotTradeable}
+	}
+	if count <= 0 || count > item.Count {
+		return nil, &inventory.OpError{Op: "extract", ItemID: item.ItemID, ItemUID: itemUID, Err: inventory.ErrInvalidQuantity}
+	}
+	if count == item.Count {
+		tx.detach(item)
+		delete(tx.created, item)
+		return item, nil
+	}
+	tx.take(item, count)
+	return &persistence.DbItem{ItemID: item.ItemID, Count: count, Expire: item.Expire}, nil
+}
+
+// Withdraw 从背包取出可交易的物品并移出背包（托管到拍卖行等背包之外的地方），返回取出部分的快照；
+// 背包之外只保存物品ID与数量，强化过的物品不能取出
+func (tx *InventoryTx) Withdraw(itemUID int64, count int32) (*persistence.DbItem, error) {
+	if item := tx.Find(itemUID); item != nil && item.Enhance > 0 {
+		return nil, &inventory.OpError{Op: "extract", ItemID: item.ItemID, ItemUID: itemUID, Err: inventory.ErrItemNotTradeable}
+	}
+	item, err := tx.Extract(itemUID, count)
+	if err != nil {
+		return nil, err
+	}
+	if item.ItemUID != 0 {
+		tx.deleted = append(tx.deleted, item)
+	}
+	return item, nil
+}
+
+// Insert 放入取出的物品：优先堆叠到同类物品，剩余部分占用一个空格子（已有唯一ID的物品转为本角色所有）
+func (tx *InventoryTx) Insert(item *persistence.DbItem) error {
+	maxStack, err := itemMaxStack(item.ItemID)
+	if err != nil {
+		return &inventory.OpError{Op: "insert", ItemID: item.ItemID, Err: err}
+	}
+	for _, other := range tx.items {
+		if item.Count == 0 {
+			break
+		}
+		if other.Location == ItemLocationBag && other.ItemID == item.ItemID && other.Bound == item.Bound && other.Count < maxStack {
+			n := min(maxStack-other.Count, item.Count)
+			other.Count += n
+			item.Count -= n
+			tx.dirty[other] = true
+		}
+	}
+	if item.Count == 0 {
+		if item.ItemUID != 0 {
+			tx.deleted = append(tx.deleted, item)
+		}
+		return nil
+	}
+	free := tx.freeSlots(ItemLocationBag, tx.capacity)
+	if len(free) == 0 {
+		return &inventory.OpError{Op: "insert", ItemID: item.ItemID, ItemUID: item.ItemUID, Err: inventory.ErrInventoryFull}
+	}
+	item.CharacterID, item.Slot, item.Location = tx.characterID, free[0], ItemLocationBag
+	tx.items = append(tx.items, item)
+	if item.ItemUID == 0 {
+		tx.created[item] = true
+	} else {
+		tx.dirty[item] = true
+	}
+	return nil
+}
+
+// SetEnhance 修改物品强化等级（背包或装备栏中的物品）
+func (tx *InventoryTx) SetEnhance(itemUID int64, level int32) error {
+	item := tx.Find(itemUID)
+	if item == nil {
+		return &inventory.OpError{Op: "enhance", ItemUID: itemUID, Err: inventory.ErrItemNotFound}
+	}
+	item.Enhance = level
+	tx.dirty[item] = true
+	return nil
+}
+
+// Move 移动物品到背包/仓库的指定格子：目标为同类可堆叠物品时合并（放不下的留在原处），否则交换位置
+func (tx *InventoryTx) Move(itemUID int64, slot, location int32) error {
+	item := tx.Find(itemUID)
+	if item == nil {
+		return &inventory.OpError{Op: "move", ItemUID: itemUID, Err: inventory.ErrItemNotFound}
+	}
+	if !tx.validSlot(slot, location) || item.Location == ItemLocationEquip {
+		return &inventory.OpError{Op: "move", ItemID: item.ItemID, ItemUID: itemUID, Err: inventory.ErrInvalidSlot}
+	}
+	other := tx.at(slot, location)
+	if other == item {
+		return nil
+	}
+	if other == nil {
+		item.Slot, item.Location = slot, location
+		tx.dirty[item] = true
+		return nil
+	}
+	if other.ItemID == item.ItemID && other.Bound == item.Bound {
+		if maxStack, err := itemMaxStack(item.ItemID); err == nil && maxStack > 1 {
+			tx.stack(item, other, maxStack)
+			return nil
+		}
+	}
+	item.Slot, item.Location, other.Slot, other.Location = other.Slot, other.Location, item.Slot, item.Location
+	tx.dirty[item], tx.dirty[other] = true, true
+	return nil
+}
+
+// Split 从物品中拆出 count 个放到指定格子（slot 为 -1 时放入第一个空格子），返回拆出的物品
+func (tx *InventoryTx) Split(itemUID int64, count, slot int32) (*persistence.DbItem, error) {
+	item := tx.Find(itemUID)
+	if item == nil {
+		return nil, &inventory.OpError{Op: "split", ItemUID: itemUID, Err: inventory.ErrItemNotFound}
+	}
+	if count <= 0 || count >= item.Count {
+		return nil, &inventory.OpError{Op: "split", ItemID: item.ItemID, ItemUID: itemUID, Err: inventory.ErrInvalidQuantity}
+	}
+	if slot < 0 {
+		free := tx.freeSlots(item.Location, tx.capacity)
+		if len(free) == 0 {
+			return nil, &inventory.OpError{Op: "split", ItemID: item.ItemID, ItemUID: itemUID, Err: inventory.ErrInventoryFull}
+		}
+		slot = free[0]
+	} else if !tx.validSlot(slot, item.Location) || tx.at(slot, item.Location) != nil {
+		return nil, &inventory.OpError{Op: "split", ItemID: item.ItemID, ItemUID: itemUID, Err: inventory.ErrInvalidSlot}
+	}
+	item.Count -= count
+	tx.dirty[item] = true
+	split := tx.create(item.ItemID, count, slot, item.Location, item.Bound)
+	split.Expire = item.Expire
+	return split, nil
+}
+
+// Merge 将 fromUID 的物品堆叠到 toUID 上（超出堆叠上限的部分留在原物品）
+func (tx *InventoryTx) Merge(fromUID, toUID int64) error {
+	from, to := tx.Find(fromUID), tx.Find(toUID)
+	if from == nil || to == nil || from == to {
+		return &inventory.OpError{Op: "merge", ItemUID: fromUID, Err: inventory.ErrItemNotFound}
+	}
+	if from.ItemID != to.ItemID || from.Bound != to.Bound {
+		return &inventory.OpError{Op: "merge", ItemID: from.ItemID, ItemUID: fromUID, Err: inventory.ErrInvalidItemType}
+	}
+	maxStack, err := itemMaxStack(from.ItemID)
+	if err != nil {
+		return &inventory.OpError{Op: "merge", ItemID: from.ItemID, ItemUID: fromUID, Err: err}
+	}
+	if to.Count >= maxStack {
+		return &inventory.OpError{Op: "merge", ItemID: from.ItemID, ItemUID: toUID, Err: inventory.ErrExceedsMaxStack}
+	}
+	tx.stack(from, to, maxStack)
+	return nil
+}
+
+// stack 将 from 尽量堆叠到 to 上
+func (tx *InventoryTx) stack(from, to *persistence.DbItem, maxStack int32) {
+	n := min(maxStack-to.Count, from.Count)
+	if n <= 0 {
+		return
+	}
+	to.Count += n
+	tx.dirty[to] = true
+	tx.take(from, n)
+}
+
+// take 扣除数量，扣完即删除
+func (tx *InventoryTx) take(item *persistence.DbItem, n int32) {
+	item.Count -= n
+	if item.Count > 0 {
+		tx.dirty[item] = true
+		return
+	}
+	tx.detach(item)
+	if tx.created[item] {
+		delete(tx.created, item)
+		return
+	}
+	tx.deleted = append(tx.deleted, item)
+}
+
+// detach 将物品移出当前规划（不记录删除）
+func (tx *InventoryTx) detach(item *persistence.DbItem) {
+	for i, it := range tx.items {
+		if it == item {
+			tx.items = append(tx.items[:i], tx.items[i+1:]...)
+			break
+		}
+	}
+	delete(tx.dirty, item)
+}
+
+// create 新建物品（唯一ID在提交时分配）
+func (tx *InventoryTx) create(itemID, count, slot, location int32, bound bool) *persistence.DbItem {
+	item := &persistence.DbItem{
+		CharacterID: tx.characterID,
+		ItemID:      itemID,
+		Count:       count,
+		Slot:        slot,
+		Location:    location,
+		Bound:       bound,
+	}
+	tx.items = append(tx.items, item)
+	tx.created[item] = true
+	return item
+}
+
+// at 指定位置格子上的物品
+func (tx *InventoryTx) at(slot, location int32) *persistence.DbItem {
+	for _, item := range tx.items {
+		if item.Location == location && item.Slot == slot {
+			return item
+		}
+	}
+	return nil
+}
+
+// validSlot 格子是否在背包/仓库容量内
+func (tx *InventoryTx) validSlot(slot, location int32) bool {
+	if location != ItemLocationBag && location != ItemLocationWarehouse {
+		return false
+	}
+	return slot >= 0 && slot < tx.capacity
+}
+
+// freeSlots 按顺序列出某位置的空格子
+func (tx *InventoryTx) freeSlots(location, capacity int32) []int32 {
+	used := make(map[int32]bool)
+	for _, item := range tx.items {
+		if item.Location == location {
+			used[item.Slot] = true
+		}
+	}
+	var free []int32
+	for slot := int32(0); slot < capacity; slot++ {
+		if !used[slot] {
+			free = append(free, slot)
+		}
+	}
+	return free
+}
+
+// pending 提交时需要删除、更新与新建的物品
+func (tx *InventoryTx) pending() (deleted, updated, created []*persistence.DbItem) {
+	for _, item := range tx.items {
+		switch {
+		case tx.created[item]:
+			created = append(created, item)
+		case tx.dirty[item]:
+			updated = append(updated, item)
+		}
+	}
+	return tx.deleted, updated, created
+}
+
+// itemMaxStack 物品堆叠上限（来自 items.json，未配置视为不可堆叠）
+func itemMaxStack(itemID int32) (int32, error) {
+	itemDefine := datamanager.GetInstance().GetItem(itemID)
+	if itemDefine == nil {
+		return 0, inventory.ErrItemNotFound
+	}
+	return max(itemDefine.MaxStack, 1), nil
+}
@@ -0,0 +1,227 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"testing"
+
+	"greatestworks/internal/domain/inventory"
+	"greatestworks/internal/infrastructure/datamanager"
+	"greatestworks/internal/infrastructure/persistence"
+)
+
+// 测试物品：10001 生命药水（堆叠 99）、20001 铁剑（不可堆叠）
+const (
+	testPotionID = int32(10001)
+	testSwordID  = int32(20001)
+)
+
+func TestMain(m *testing.M) {
+	if err := datamanager.GetInstance().LoadAll("../../../configs/data"); err != nil {
+		fmt.Fprintf(os.Stderr, "load data: %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(m.Run())
+}
+
+func bagItem(uid int64, itemID, count, slot int32) *persistence.DbItem {
+	return &persistence.DbItem{ItemUID: uid, CharacterID: 1, ItemID: itemID, Count: count, Slot: slot, Location: ItemLocationBag}
+}
+
+// bagLayout 按格子列出背包内容：格子 -> 物品ID x 数量
+func bagLayout(tx *InventoryTx) []string {
+	var layout []string
+	for _, item := range tx.Items() {
+		if item.Location == ItemLocationBag {
+			layout = append(layout, fmt.Sprintf("%d:%dx%d", item.Slot, item.ItemID, item.Count))
+		}
+	}
+	sort.Strings(layout)
+	return layout
+}
+
+func snapshotItems(items []*persistence.DbItem) []persistence.DbItem {
+	out := make([]persistence.DbItem, len(items))
+	for i, item := range items {
+		out[i] = *item
+	}
+	return out
+}
+
+func TestInventoryTxAdd(t *testing.T) {
+	tests := []struct {
+		name     string
+		capacity int32
+		items    []*persistence.DbItem
+		itemID   int32
+		count    int32
+		bound    bool
+		want     []string
+		created  int
+		updated  int
+	}{
+		{
+			name:     "merges into existing stack first",
+			capacity: 4,
+			items:    []*persistence.DbItem{bagItem(1, testPotionID, 90, 0)},
+			itemID:   testPotionID,
+			count:    20,
+			want:     []string{"0:10001x99", "1:10001x11"},
+			created:  1,
+			updated:  1,
+		},
+		{
+			name:     "tops up several partial stacks",
+			capacity: 4,
+			items:    []*persistence.DbItem{bagItem(1, testPotionID, 98, 0), bagItem(2, testPotionID, 97, 2)},
+			itemID:   testPotionID,
+			count:    3,
+			want:     []string{"0:10001x99", "2:10001x99"},
+			updated:  2,
+		},
+		{
+			name:     "splits across empty slots by max stack",
+			capacity: 3,
+			itemID:   testPotionID,
+			count:    200,
+			want:     []string{"0:10001x99", "1:10001x99", "2:10001x2"},
+			created:  3,
+		},
+		{
+			name:     "non stackable items take one slot each",
+			capacity: 3,
+			items:    []*persistence.DbItem{bagItem(1, testPotionID, 5, 1)},
+			itemID:   testSwordID,
+			count:    2,
+			want:     []string{"0:20001x1", "1:10001x5", "2:20001x1"},
+			created:  2,
+		},
+		{
+			name:     "bound items do not merge with unbound stacks",
+			capacity: 2,
+			items:    []*persistence.DbItem{bagItem(1, testPotionID, 5, 0)},
+			itemID:   testPotionID,
+			count:    5,
+			bound:    true,
+			want:     []string{"0:10001x5", "1:10001x5"},
+			created:  1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := newInventoryTx(1, tt.capacity, tt.items)
+			add := tx.Add
+			if tt.bound {
+				add = tx.AddBound
+			}
+			if err := add(tt.itemID, tt.count); err != nil {
+				t.Fatalf("add: %v", err)
+			}
+			if got := bagLayout(tx); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("layout %v, want %v", got, tt.want)
+			}
+			deleted, updated, created := tx.pending()
+			if len(deleted) != 0 || len(updated) != tt.updated || len(created) != tt.created {
+				t.Fatalf("pending deleted=%d updated=%d created=%d, want 0/%d/%d", len(deleted), len(updated), len(created), tt.updated, tt.created)
+			}
+		})
+	}
+}
+
+func TestInventoryTxRemovePrefersSmallStacks(t *testing.T) {
+	tx := newInventoryTx(1, 4, []*persistence.DbItem{
+		bagItem(1, testPotionID, 50, 0),
+		bagItem(2, testPotionID, 3, 1),
+		bagItem(3, testPotionID, 10, 2),
+	})
+	if err := tx.Remove(testPotionID, 8); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if got, want := bagLayout(tx), []string{"0:10001x50", "2:10001x5"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("layout %v, want %v", got, want)
+	}
+	deleted, updated, _ := tx.pending()
+	if len(deleted) != 1 || deleted[0].ItemUID != 2 {
+		t.Fatalf("expected the 3-stack deleted, got %+v", deleted)
+	}
+	if len(updated) != 1 || updated[0].ItemUID != 3 {
+		t.Fatalf("expected the 10-stack updated, got %+v", updated)
+	}
+}
+
+func TestInventoryTxFailedOpLeavesInventoryUnchanged(t *testing.T) {
+	full := func() []*persistence.DbItem {
+		return []*persistence.DbItem{bagItem(1, testPotionID, 98, 0), bagItem(2, testSwordID, 1, 1)}
+	}
+	bound := bagItem(3, testPotionID, 5, 0)
+	bound.Bound = true
+
+	tests := []struct {
+		name  string
+		items []*persistence.DbItem
+		op    func(tx *InventoryTx) error
+		want  error
+	}{
+		{"add beyond capacity", full(), func(tx *InventoryTx) error { return tx.Add(testPotionID, 2) }, inventory.ErrInventoryFull},
+		{"add unknown item", full(), func(tx *InventoryTx) error { return tx.Add(99999, 1) }, inventory.ErrItemNotFound},
+		{"add zero", full(), func(tx *InventoryTx) error { return tx.Add(testPotionID, 0) }, inventory.ErrInvalidQuantity},
+		{"remove more than owned", full(), func(tx *InventoryTx) error { return tx.Remove(testPotionID, 99) }, inventory.ErrInsufficientItems},
+		{"take more than stack", full(), func(tx *InventoryTx) error { return tx.Take(1, 99) }, inventory.ErrInsufficientItems},
+		{"place on occupied slot", full(), func(tx *InventoryTx) error {
+			_, err := tx.Place(testPotionID, 1, 1, ItemLocationBag)
+			return err
+		}, inventory.ErrInvalidSlot},
+		{"extract bound item", []*persistence.DbItem{bound}, func(tx *InventoryTx) error {
+			_, err := tx.Extract(3, 1)
+			return err
+		}, inventory.ErrItemNotTradeable},
+		{"insert into full bag", full(), func(tx *InventoryTx) error {
+			return tx.Insert(&persistence.DbItem{ItemID: testSwordID, Count: 1})
+		}, inventory.ErrInventoryFull},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := newInventoryTx(1, 2, tt.items)
+			before := snapshotItems(tx.Items())
+			err := tt.op(tx)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("err %v, want %v", err, tt.want)
+			}
+			var opErr *inventory.OpError
+			if !errors.As(err, &opErr) {
+				t.Fatalf("expected *inventory.OpError, got %T", err)
+			}
+			if after := snapshotItems(tx.Items()); !reflect.DeepEqual(after, before) {
+				t.Fatalf("items changed after failed op:\n got %+v\nwant %+v", after, before)
+			}
+			deleted, updated, created := tx.pending()
+			if len(deleted)+len(updated)+len(created) != 0 {
+				t.Fatalf("failed op left pending writes: deleted=%d updated=%d created=%d", len(deleted), len(updated), len(created))
+			}
+		})
+	}
+}
+
+func TestInventoryTxGiveKeepsWholeStackUID(t *testing.T) {
+	from := newInventoryTx(1, 2, []*persistence.DbItem{bagItem(7, testSwordID, 1, 0)})
+	to := newInventoryTx(2, 2, []*persistence.DbItem{bagItem(8, testPotionID, 1, 0)})
+	if err := from.Give(7, 1, to); err != nil {
+		t.Fatalf("give: %v", err)
+	}
+	if len(bagLayout(from)) != 0 {
+		t.Fatalf("giver still holds %v", bagLayout(from))
+	}
+	moved := to.Find(7)
+	if moved == nil || moved.CharacterID != 2 || moved.Slot != 1 {
+		t.Fatalf("expected uid 7 moved to character 2 slot 1, got %+v", moved)
+	}
+	if deleted, updated, created := from.pending(); len(deleted)+len(updated)+len(created) != 0 {
+		t.Fatalf("giver should not write the moved item itself")
+	}
+	if _, updated, created := to.pending(); len(updated) != 1 || len(created) != 0 {
+		t.Fatalf("receiver should update the moved item in place, got updated=%d created=%d", len(updated), len(created))
+	}
+}
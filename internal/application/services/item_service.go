@@ -2,54 +2,147 @@ package services
 
 import (
 	"context"
-	"errors"
 	"fmt"
-	"time"
+	"sort"
 
-	"greatestworks/internal/infrastructure/datamanager"
+	"greatestworks/internal/domain/inventory"
 	"greatestworks/internal/infrastructure/persistence"
 )
 
-// ItemService 物品服务
+// 物品位置
+const (
+	ItemLocationBag       int32 = 0 // 背包
+	ItemLocationEquip     int32 = 1 // 装备栏
+	ItemLocationWarehouse int32 = 2 // 仓库
+)
+
+// DefaultBagCapacity 背包默认格子数
+const DefaultBagCapacity = 60
+
+// ItemStore 物品存储（由 persistence.ItemRepository 实现）
+type ItemStore interface {
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+	LockInventory(ctx context.Context, characterID int64) error
+	NextItemUIDs(ctx context.Context, n int32) (int64, error)
+	FindByCharacterID(ctx context.Context, characterID int64) ([]*persistence.DbItem, error)
+	FindByUID(ctx context.Context, itemUID int64) (*persistence.DbItem, error)
+	Create(ctx context.Context, item *persistence.DbItem) error
+	Update(ctx context.Context, item *persistence.DbItem) error
+	Delete(ctx context.Context, itemUID int64) error
+}
+
+// ItemService 物品服务：背包读写统一通过 Modify 在事务内批量提交
 type ItemService struct {
-	itemRepo *persistence.ItemRepository
+	itemRepo ItemStore
+	capacity int32
 }
 
 // NewItemService 创建物品服务
-func NewItemService(itemRepo *persistence.ItemRepository) *ItemService {
+func NewItemService(itemRepo ItemStore) *ItemService {
 	return &ItemService{
 		itemRepo: itemRepo,
+		capacity: DefaultBagCapacity,
 	}
 }
 
-// CreateItem 创建物品
-func (s *ItemService) CreateItem(ctx context.Context, characterID int64, itemID, count, slot, location int32) (int64, error) {
-	// 获取物品配置
-	itemDefine := datamanager.GetInstance().GetItem(itemID)
-	if itemDefine == nil {
-		return 0, errors.New("item not found")
+// SetBagCapacity 设置背包/仓库格子数
+func (s *ItemService) SetBagCapacity(capacity int32) {
+	if capacity > 0 {
+		s.capacity = capacity
 	}
+}
+
+// Modify 在同一事务内对角色物品执行一组操作：fn 返回错误时不做任何修改
+func (s *ItemService) Modify(ctx context.Context, characterID int64, fn func(tx *InventoryTx) error) error {
+	return s.Transact(ctx, []int64{characterID}, func(_ context.Context, txs map[int64]*InventoryTx) error {
+		return fn(txs[characterID])
+	})
+}
 
-	// 生成物品唯一ID
-	itemUID := time.Now().UnixNano()
-
-	// 创建物品
-	item := &persistence.DbItem{
-		ItemUID:     itemUID,
-		CharacterID: characterID,
-		ItemID:      itemID,
-		Count:       count,
-		Slot:        slot,
-		Location:    location,
-		Bound:       false,
-		Expire:      0,
+// Transact 在同一事务内修改多名角色的物品；fn 可使用 txCtx 在同一事务内写入其他数据（如金币、审计记录）
+func (s *ItemService) Transact(ctx context.Context, characterIDs []int64, fn func(txCtx context.Context, txs map[int64]*InventoryTx) error) error {
+	ids := append([]int64(nil), characterIDs...)
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return s.itemRepo.WithTransaction(ctx, func(txCtx context.Context) error {
+		txs := make(map[int64]*InventoryTx, len(ids))
+		for _, id := range ids {
+			if err := s.itemRepo.LockInventory(txCtx, id); err != nil {
+				return fmt.Errorf("failed to lock inventory: %w", err)
+			}
+			items, err := s.itemRepo.FindByCharacterID(txCtx, id)
+			if err != nil {
+				return err
+			}
+			txs[id] = newInventoryTx(id, s.capacity, items)
+		}
+		if err := fn(txCtx, txs); err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if err := s.commit(ctx, txCtx, txs[id]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// commit 写入工作单元的修改（唯一ID在事务外分配，避免计数器成为事务冲突点）
+func (s *ItemService) commit(ctx, txCtx context.Context, tx *InventoryTx) error {
+	deleted, updated, created := tx.pending()
+	if len(created) > 0 {
+		first, err := s.itemRepo.NextItemUIDs(ctx, int32(len(created)))
+		if err != nil {
+			return fmt.Errorf("failed to allocate item uid: %w", err)
+		}
+		for i, item := range created {
+			item.ItemUID = first + int64(i)
+		}
+	}
+	for _, item := range deleted {
+		if err := s.itemRepo.Delete(txCtx, item.ItemUID); err != nil {
+			return fmt.Errorf("failed to remove item: %w", err)
+		}
 	}
+	for _, item := range updated {
+		if err := s.itemRepo.Update(txCtx, item); err != nil {
+			return fmt.Errorf("failed to update item: %w", err)
+		}
+	}
+	for _, item := range created {
+		if err := s.itemRepo.Create(txCtx, item); err != nil {
+			return fmt.Errorf("failed to create item: %w", err)
+		}
+	}
+	return nil
+}
 
-	if err := s.itemRepo.Create(ctx, item); err != nil {
-		return 0, fmt.Errorf("failed to create item: %w", err)
+// CreateItem 在指定位置的空格子创建物品
+func (s *ItemService) CreateItem(ctx context.Context, characterID int64, itemID, count, slot, location int32) (int64, error) {
+	var created *persistence.DbItem
+	err := s.Modify(ctx, characterID, func(tx *InventoryTx) error {
+		var err error
+		created, err = tx.Place(itemID, count, slot, location)
+		return err
+	})
+	if err != nil {
+		return 0, err
 	}
+	return created.ItemUID, nil
+}
 
-	return itemUID, nil
+// AddToBag 放入背包：优先堆叠到已有的同类物品，剩余放入空格子；空间不足时不做任何修改并返回 inventory.ErrInventoryFull
+func (s *ItemService) AddToBag(ctx context.Context, characterID int64, itemID, count int32) error {
+	return s.Modify(ctx, characterID, func(tx *InventoryTx) error {
+		return tx.Add(itemID, count)
+	})
+}
+
+// RemoveFromBag 从背包扣除指定数量的物品（优先扣除数量少的堆叠）；数量不足时不做任何修改并返回 inventory.ErrInsufficientItems
+func (s *ItemService) RemoveFromBag(ctx context.Context, characterID int64, itemID, count int32) error {
+	return s.Modify(ctx, characterID, func(tx *InventoryTx) error {
+		return tx.Remove(itemID, count)
+	})
 }
 
 // GetItem 获取物品
@@ -62,43 +155,15 @@ func (s *ItemService) GetCharacterItems(ctx context.Context, characterID int64)
 	return s.itemRepo.FindByCharacterID(ctx, characterID)
 }
 
-// UseItem 使用物品
-func (s *ItemService) UseItem(ctx context.Context, itemUID int64) error {
-	item, err := s.itemRepo.FindByUID(ctx, itemUID)
-	if err != nil {
-		return err
-	}
-
-	// 获取物品配置
-	itemDefine := datamanager.GetInstance().GetItem(item.ItemID)
-	if itemDefine == nil {
-		return errors.New("item not found")
-	}
-
-	// TODO: 根据物品类型执行不同的使用逻辑
-
-	// 减少数量
-	item.Count--
-	if item.Count <= 0 {
-		// 删除物品
-		return s.itemRepo.Delete(ctx, itemUID)
-	}
-
-	// 更新物品
-	return s.itemRepo.Update(ctx, item)
-}
-
-// MoveItem 移动物品
+// MoveItem 移动物品（目标格子有同类物品时堆叠，否则交换）
 func (s *ItemService) MoveItem(ctx context.Context, itemUID int64, newSlot, newLocation int32) error {
 	item, err := s.itemRepo.FindByUID(ctx, itemUID)
 	if err != nil {
-		return err
+		return &inventory.OpError{Op: "move", ItemUID: itemUID, Err: inventory.ErrItemNotFound}
 	}
-
-	item.Slot = newSlot
-	item.Location = newLocation
-
-	return s.itemRepo.Update(ctx, item)
+	return s.Modify(ctx, item.CharacterID, func(tx *InventoryTx) error {
+		return tx.Move(itemUID, newSlot, newLocation)
+	})
 }
 
 // DeleteItem 删除物品
@@ -106,82 +171,31 @@ func (s *ItemService) DeleteItem(ctx context.Context, itemUID int64) error {
 	return s.itemRepo.Delete(ctx, itemUID)
 }
 
-// SplitItem 拆分物品
+// SplitItem 拆分物品（拆出的部分放入同一位置的第一个空格子）
 func (s *ItemService) SplitItem(ctx context.Context, itemUID int64, splitCount int32) (int64, error) {
 	item, err := s.itemRepo.FindByUID(ctx, itemUID)
 	if err != nil {
-		return 0, err
+		return 0, &inventory.OpError{Op: "split", ItemUID: itemUID, Err: inventory.ErrItemNotFound}
 	}
-
-	if item.Count <= splitCount {
-		return 0, errors.New("invalid split count")
-	}
-
-	// 减少原物品数量
-	item.Count -= splitCount
-	if err := s.itemRepo.Update(ctx, item); err != nil {
-		return 0, err
-	}
-
-	// 创建新物品
-	newItemUID := time.Now().UnixNano()
-	newItem := &persistence.DbItem{
-		ItemUID:     newItemUID,
-		CharacterID: item.CharacterID,
-		ItemID:      item.ItemID,
-		Count:       splitCount,
-		Slot:        -1, // 未放置槽位
-		Location:    item.Location,
-		Bound:       item.Bound,
-		Expire:      item.Expire,
-	}
-
-	if err := s.itemRepo.Create(ctx, newItem); err != nil {
+	var split *persistence.DbItem
+	err = s.Modify(ctx, item.CharacterID, func(tx *InventoryTx) error {
+		var err error
+		split, err = tx.Split(itemUID, splitCount, -1)
+		return err
+	})
+	if err != nil {
 		return 0, err
 	}
-
-	return newItemUID, nil
+	return split.ItemUID, nil
 }
 
-// MergeItem 合并物品
+// MergeItem 合并物品（超出堆叠上限的部分留在原物品）
 func (s *ItemService) MergeItem(ctx context.Context, fromUID, toUID int64) error {
-	fromItem, err := s.itemRepo.FindByUID(ctx, fromUID)
+	item, err := s.itemRepo.FindByUID(ctx, fromUID)
 	if err != nil {
-		return err
-	}
-
-	toItem, err := s.itemRepo.FindByUID(ctx, toUID)
-	if err != nil {
-		return err
-	}
-
-	// 检查是否可以合并
-	if fromItem.ItemID != toItem.ItemID {
-		return errors.New("cannot merge different items")
+		return &inventory.OpError{Op: "merge", ItemUID: fromUID, Err: inventory.ErrItemNotFound}
 	}
-
-	// 获取物品配置
-	itemDefine := datamanager.GetInstance().GetItem(fromItem.ItemID)
-	if itemDefine == nil {
-		return errors.New("item not found")
-	}
-
-	// 合并数量
-	totalCount := fromItem.Count + toItem.Count
-	if totalCount > itemDefine.MaxStack {
-		// 超过堆叠上限
-		toItem.Count = itemDefine.MaxStack
-		fromItem.Count = totalCount - itemDefine.MaxStack
-		if err := s.itemRepo.Update(ctx, fromItem); err != nil {
-			return err
-		}
-	} else {
-		// 全部合并
-		toItem.Count = totalCount
-		if err := s.itemRepo.Delete(ctx, fromUID); err != nil {
-			return err
-		}
-	}
-
-	return s.itemRepo.Update(ctx, toItem)
+	return s.Modify(ctx, item.CharacterID, func(tx *InventoryTx) error {
+		return tx.Merge(fromUID, toUID)
+	})
 }
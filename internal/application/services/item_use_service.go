@@ -0,0 +1,478 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"greatestworks/internal/domain/character"
+	"greatestworks/internal/domain/inventory"
+	"greatestworks/internal/domain/mapmanager"
+	"greatestworks/internal/infrastructure/datamanager"
+)
+
+// ItemEffectType 物品使用效果（items.json effect_type）
+type ItemEffectType int32
+
+const (
+	ItemEffectHealHP     ItemEffectType = 1 // 回复生命
+	ItemEffectHealMP     ItemEffectType = 2 // 回复魔法
+	ItemEffectBuff       ItemEffectType = 3 // 附加Buff
+	ItemEffectExp        ItemEffectType = 4 // 获得经验
+	ItemEffectTeleport   ItemEffectType = 5 // 传送到绑定位置或指定地图
+	ItemEffectLootBox    ItemEffectType = 6 // 开启宝箱
+	ItemEffectLearnSkill ItemEffectType = 7 // 学习技能
+	ItemEffectSummonPet  ItemEffectType = 8 // 召唤宠物
+)
+
+// 物品使用目标要求
+const (
+	ItemTargetSelf     int32 = 0 // 自身
+	ItemTargetFriendly int32 = 1 // 友方（未指定目标时为自身）
+	ItemTargetEnemy    int32 = 2 // 敌方
+)
+
+// 物品使用错误
+var (
+	ErrItemInCombat          = errors.New("item cannot be used in combat")
+	ErrItemUserDead          = errors.New("cannot use items while dead")
+	ErrItemInvalidTarget     = errors.New("invalid item target")
+	ErrItemTargetOutOfRange  = errors.New("item target out of range")
+	ErrItemNoEffect          = errors.New("item would have no effect")
+	ErrItemSkillKnown        = errors.New("skill already learned")
+	ErrItemEffectUnavailable = errors.New("item effect unavailable")
+)
+
+// itemUseRange 对目标使用物品的默认距离
+const itemUseRange float32 = 30
+
+// ItemUseStore 物品效果的角色数据读写（由 CharacterService 实现，均可在事务内调用）
+type ItemUseStore interface {
+	GrantReward(ctx context.Context, characterID int64, exp, gold int64, source, key string) (*RewardGrant, error)
+	LearnSkill(ctx context.Context, characterID int64, skillID int32) (bool, error)
+	BindPoint(ctx context.Context, characterID int64) (mapID int32, pos character.Vector3, ok bool, err error)
+}
+
+// PetSummoner 宠物召唤（宠物系统接入后注入）
+type PetSummoner interface {
+	SummonPet(ctx context.Context, characterID int64, petID int32) error
+}
+
+// ItemUse 一次物品使用的上下文
+type ItemUse struct {
+	CharacterID int64
+	Map         *mapmanager.Map
+	Actor       *character.Actor
+	Target      *character.Actor // 目标（自身物品为使用者）
+	Define      *datamanager.ItemDefine
+	Result      *ItemUseResult
+
+	grant *RewardGrant // 经验发放结果（提交后将升级应用到角色）
+}
+
+// ItemUseResult 物品使用结果
+type ItemUseResult struct {
+	ItemID   int32
+	Effect   ItemEffectType
+	TargetID int32
+	Amount   float32 // 实际回复量
+	BuffID   int32
+	Exp      int64
+	Level    int32
+	MapID    int32 // 传送目的地
+	Position character.Vector3
+	Items    []LootResult // 宝箱开出的物品
+	SkillID  int32
+	PetID    int32
+	Cooldown float32 // 冷却秒数
+}
+
+// ItemEffect 物品效果处理器（各阶段均可省略）：
+// Check 在扣除物品前校验；Apply 在扣除物品的同一事务内写入持久化数据（事务冲突时会重试，不得修改地图状态）；
+// Commit 在事务提交后作用于地图中的角色
+type ItemEffect struct {
+	Check  func(ctx context.Context, use *ItemUse) error
+	Apply  func(txCtx context.Context, use *ItemUse, tx *InventoryTx) error
+	Commit func(ctx context.Context, use *ItemUse) error
+}
+
+// ItemUseService 物品使用服务：校验等级、职业、战斗状态、目标与冷却后，在扣除物品的同一事务内执行按效果类型注册的处理器
+type ItemUseService struct {
+	mu         sync.Mutex
+	mapService *MapService
+	items      *ItemService
+	store      ItemUseStore
+	loot       *LootService
+	pets       PetSummoner
+	effects    map[ItemEffectType]ItemEffect
+	cooldowns  map[int32]map[int32]time.Time // 实体ID -> 冷却键 -> 冷却结束时间
+}
+
+// NewItemUseService 创建物品使用服务并注册内置效果
+func NewItemUseService(mapService *MapService, items *ItemService, store ItemUseStore, loot *LootService) *ItemUseService {
+	s := &ItemUseService{
+		mapService: mapService,
+		items:      items,
+		store:      store,
+		loot:       loot,
+		effects:    make(map[ItemEffectType]ItemEffect),
+		cooldowns:  make(map[int32]map[int32]time.Time),
+	}
+	s.RegisterEffect(ItemEffectHealHP, ItemEffect{Check: checkHealHP, Commit: commitHealHP})
+	s.RegisterEffect(ItemEffectHealMP, ItemEffect{Check: checkHealMP, Commit: commitHealMP})
+	s.RegisterEffect(ItemEffectBuff, ItemEffect{Check: checkBuff, Commit: commitBuff})
+	s.RegisterEffect(ItemEffectExp, ItemEffect{Apply: s.applyExp, Commit: commitExp})
+	s.RegisterEffect(ItemEffectTeleport, ItemEffect{Check: s.checkTeleport, Commit: s.commitTeleport})
+	s.RegisterEffect(ItemEffectLootBox, ItemEffect{Apply: s.applyLootBox})
+	s.RegisterEffect(ItemEffectLearnSkill, ItemEffect{Check: checkLearnSkill, Apply: s.applyLearnSkill, Commit: commitLearnSkill})
+	s.RegisterEffect(ItemEffectSummonPet, ItemEffect{Apply: s.applySummonPet})
+	return s
+}
+
+// RegisterEffect 注册（或替换）某类效果的处理器
+func (s *ItemUseService) RegisterEffect(effectType ItemEffectType, effect ItemEffect) {
+	s.mu.Lock()
+	s.effects[effectType] = effect
+	s.mu.Unlock()
+}
+
+// SetPetSummoner 注入宠物召唤
+func (s *ItemUseService) SetPetSummoner(pets PetSummoner) {
+	s.pets = pets
+}
+
+// UseItem 使用背包中的物品（targetID 为 0 表示对自身使用）：扣除一个物品并执行其效果，物品进入冷却
+func (s *ItemUseService) UseItem(ctx context.Context, entityID int32, itemUID int64, targetID int32) (*ItemUseResult, error) {
+	gameMap, actor, err := s.mapService.LocateActor(entityID)
+	if err != nil {
+		return nil, err
+	}
+	if actor.IsDeath() {
+		return nil, ErrItemUserDead
+	}
+	// 实体ID即角色ID（与登录绑定一致）
+	characterID := int64(entityID)
+	item, err := s.items.GetItem(ctx, itemUID)
+	if err != nil || item == nil || item.CharacterID != characterID || item.Location != ItemLocationBag {
+		return nil, &inventory.OpError{Op: "use", ItemUID: itemUID, Err: inventory.ErrItemNotFound}
+	}
+	def := datamanager.GetInstance().GetItem(item.ItemID)
+	if def == nil {
+		return nil, &inventory.OpError{Op: "use", ItemID: item.ItemID, ItemUID: itemUID, Err: inventory.ErrItemNotFound}
+	}
+	s.mu.Lock()
+	effect, ok := s.effects[ItemEffectType(def.EffectType)]
+	s.mu.Unlock()
+	if !ok {
+		return nil, &inventory.OpError{Op: "use", ItemID: item.ItemID, ItemUID: itemUID, Err: inventory.ErrItemNotUsable}
+	}
+	if actor.Level() < def.RequiredLevel {
+		return nil, &inventory.OpError{Op: "use", ItemID: item.ItemID, ItemUID: itemUID, Err: inventory.ErrInsufficientLevel}
+	}
+	if len(def.Classes) > 0 && !slices.Contains(def.Classes, actor.UnitID()) {
+		return nil, &inventory.OpError{Op: "use", ItemID: item.ItemID, ItemUID: itemUID, Err: inventory.ErrClassRestriction}
+	}
+	if def.NoCombat && actor.InCombat() {
+		return nil, ErrItemInCombat
+	}
+	target, err := s.resolveTarget(gameMap, actor, def, targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	use := &ItemUse{
+		CharacterID: characterID,
+		Map:         gameMap,
+		Actor:       actor,
+		Target:      target,
+		Define:      def,
+		Result:      &ItemUseResult{ItemID: def.ID, Effect: ItemEffectType(def.EffectType), TargetID: int32(target.ID()), Cooldown: def.Cooldown},
+	}
+	// 先占用冷却，避免并发请求重复使用；失败时恢复
+	release, err := s.reserveCooldown(entityID, def)
+	if err != nil {
+		return nil, err
+	}
+	if effect.Check != nil {
+		if err := effect.Check(ctx, use); err != nil {
+			release()
+			return nil, err
+		}
+	}
+	err = s.items.Transact(ctx, []int64{characterID}, func(txCtx context.Context, txs map[int64]*InventoryTx) error {
+		tx := txs[characterID]
+		if err := tx.Take(itemUID, 1); err != nil {
+			return err
+		}
+		if effect.Apply != nil {
+			return effect.Apply(txCtx, use, tx)
+		}
+		return nil
+	})
+	if err != nil {
+		release()
+		return nil, err
+	}
+	// 物品已扣除：此后的失败只报告，不再恢复冷却
+	if effect.Commit != nil {
+		if err := effect.Commit(ctx, use); err != nil {
+			return use.Result, err
+		}
+	}
+	return use.Result, nil
+}
+
+// resolveTarget 按物品的目标要求解析目标
+func (s *ItemUseService) resolveTarget(gameMap *mapmanager.Map, actor *character.Actor, def *datamanager.ItemDefine, targetID int32) (*character.Actor, error) {
+	if def.Target == ItemTargetSelf || (def.Target == ItemTargetFriendly && (targetID == 0 || targetID == int32(actor.ID()))) {
+		return actor, nil
+	}
+	if targetID == 0 {
+		return nil, ErrItemInvalidTarget
+	}
+	target := gameMap.GetActor(character.EntityID(targetID))
+	if target == nil || target.IsDeath() {
+		return nil, ErrItemInvalidTarget
+	}
+	switch def.Target {
+	case ItemTargetFriendly:
+		if !actor.IsFriendlyTo(target) {
+			return nil, ErrItemInvalidTarget
+		}
+	case ItemTargetEnemy:
+		if !actor.IsHostileTo(target) {
+			return nil, ErrItemInvalidTarget
+		}
+	default:
+		return nil, ErrItemInvalidTarget
+	}
+	useRange := def.UseRange
+	if useRange <= 0 {
+		useRange = itemUseRange
+	}
+	if actor.DistanceTo(target.Entity) > useRange || !gameMap.LineOfSight(actor.Position(), target.Position()) {
+		return nil, ErrItemTargetOutOfRange
+	}
+	return target, nil
+}
+
+// reserveCooldown 检查并占用冷却（同组物品共享冷却），返回恢复函数
+func (s *ItemUseService) reserveCooldown(entityID int32, def *datamanager.ItemDefine) (func(), error) {
+	if def.Cooldown <= 0 {
+		return func() {}, nil
+	}
+	key := -def.ID
+	if def.CooldownGroup != 0 {
+		key = def.CooldownGroup
+	}
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	timers := s.cooldowns[entityID]
+	if timers == nil {
+		timers = make(map[int32]time.Time)
+		s.cooldowns[entityID] = timers
+	}
+	for k, readyAt := range timers {
+		if !now.Before(readyAt) {
+			delete(timers, k)
+		}
+	}
+	if _, cooling := timers[key]; cooling {
+		return nil, &inventory.OpError{Op: "use", ItemID: def.ID, Err: inventory.ErrItemOnCooldown}
+	}
+	timers[key] = now.Add(time.Duration(def.Cooldown * float32(time.Second)))
+	return func() {
+		s.mu.Lock()
+		delete(timers, key)
+		s.mu.Unlock()
+	}, nil
+}
+
+// Forget 清理已全部冷却完毕的记录（冷却跨重新登录保留）
+func (s *ItemUseService) Forget(entityID int32) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, readyAt := range s.cooldowns[entityID] {
+		if now.Before(readyAt) {
+			return
+		}
+	}
+	delete(s.cooldowns, entityID)
+}
+
+// ========== 内置效果 ==========
+
+func checkHealHP(_ context.Context, use *ItemUse) error {
+	if use.Target.HP() >= use.Target.GetAttributeManager().Final().MaxHP {
+		return ErrItemNoEffect
+	}
+	return nil
+}
+
+func commitHealHP(_ context.Context, use *ItemUse) error {
+	before := use.Target.HP()
+	use.Target.ChangeHP(float32(use.Define.EffectValue))
+	use.Result.Amount = use.Target.HP() - before
+	return nil
+}
+
+func checkHealMP(_ context.Context, use *ItemUse) error {
+	if use.Target.MP() >= use.Target.GetAttributeManager().Final().MaxMP {
+		return ErrItemNoEffect
+	}
+	return nil
+}
+
+func commitHealMP(_ context.Context, use *ItemUse) error {
+	before := use.Target.MP()
+	use.Target.ChangeMP(float32(use.Define.EffectValue))
+	use.Result.Amount = use.Target.MP() - before
+	return nil
+}
+
+func checkBuff(_ context.Context, use *ItemUse) error {
+	def := datamanager.GetInstance().GetBuff(use.Define.EffectValue)
+	if def == nil {
+		return ErrItemEffectUnavailable
+	}
+	if def.Harmful && use.Target.GetBuffManager().IsImmune(character.DispelCategory(def.Category)) {
+		return character.ErrBuffImmune
+	}
+	return nil
+}
+
+func commitBuff(_ context.Context, use *ItemUse) error {
+	def := datamanager.GetInstance().GetBuff(use.Define.EffectValue)
+	buff := character.NewBuffFromConfig(buffConfigFromDefine(def), use.Target, use.Actor)
+	if _, err := use.Target.GetBuffManager().Apply(buff); err != nil {
+		return err
+	}
+	if def.Harmful {
+		use.Actor.EnterCombat()
+		use.Target.EnterCombat()
+	}
+	use.Result.BuffID = def.ID
+	return nil
+}
+
+func (s *ItemUseService) applyExp(txCtx context.Context, use *ItemUse, _ *InventoryTx) error {
+	if s.store == nil {
+		return ErrItemEffectUnavailable
+	}
+	grant, err := s.store.GrantReward(txCtx, use.CharacterID, int64(use.Define.EffectValue), 0, fmt.Sprintf("item:%d", use.Define.ID), "")
+	if err != nil {
+		return err
+	}
+	use.grant = grant
+	use.Result.Exp = int64(use.Define.EffectValue)
+	use.Result.Level = grant.NewLevel
+	return nil
+}
+
+func commitExp(_ context.Context, use *ItemUse) error {
+	if use.grant.NewLevel > use.Actor.Level() {
+		use.Actor.ApplyLevelUp(use.grant.NewLevel, use.grant.Growth)
+	}
+	return nil
+}
+
+// checkTeleport 确定目的地：指定地图的出生点，或绑定位置（未绑定时为当前地图的出生点）
+func (s *ItemUseService) checkTeleport(ctx context.Context, use *ItemUse) error {
+	mapID := use.Define.EffectValue
+	if mapID == 0 && s.store != nil {
+		bindMap, pos, ok, err := s.store.BindPoint(ctx, use.CharacterID)
+		if err != nil {
+			return err
+		}
+		if ok {
+			use.Result.MapID, use.Result.Position = bindMap, pos
+			return nil
+		}
+	}
+	if mapID == 0 {
+		mapID = use.Map.ID()
+	}
+	def := datamanager.GetInstance().GetMap(mapID)
+	if def == nil || def.Node != "" {
+		return ErrItemEffectUnavailable
+	}
+	for _, sp := range def.SpawnPoints {
+		if sp.Type == datamanager.SpawnPointTypeBirth {
+			use.Result.MapID, use.Result.Position = mapID, character.NewVector3(sp.X, sp.Y, sp.Z)
+			return nil
+		}
+	}
+	return ErrItemEffectUnavailable
+}
+
+func (s *ItemUseService) commitTeleport(ctx context.Context, use *ItemUse) error {
+	pos := use.Result.Position
+	if use.Result.MapID == use.Map.ID() {
+		return use.Map.UpdatePosition(use.Actor.ID(), pos)
+	}
+	if err := s.mapService.TransferActor(ctx, use.Actor, use.Map.ID(), use.Result.MapID, pos.X, pos.Y, pos.Z); err != nil {
+		return fmt.Errorf("failed to teleport: %w", err)
+	}
+	return nil
+}
+
+// applyLootBox 按宝箱掉落表开出物品放入背包（背包空间不足时不开启）
+func (s *ItemUseService) applyLootBox(_ context.Context, use *ItemUse, tx *InventoryTx) error {
+	if s.loot == nil || len(use.Define.Loot) == 0 {
+		return ErrItemEffectUnavailable
+	}
+	results := s.loot.RollTable(use.Define.Loot)
+	for _, r := range results {
+		if err := tx.Add(r.ItemID, r.Count); err != nil {
+			return err
+		}
+	}
+	use.Result.Items = results
+	return nil
+}
+
+func checkLearnSkill(_ context.Context, use *ItemUse) error {
+	if datamanager.GetInstance().GetSkill(use.Define.EffectValue) == nil {
+		return ErrItemEffectUnavailable
+	}
+	if use.Actor.GetSkillManager().GetSkill(use.Define.EffectValue) != nil {
+		return ErrItemSkillKnown
+	}
+	return nil
+}
+
+func (s *ItemUseService) applyLearnSkill(txCtx context.Context, use *ItemUse, _ *InventoryTx) error {
+	if s.store == nil {
+		return ErrItemEffectUnavailable
+	}
+	added, err := s.store.LearnSkill(txCtx, use.CharacterID, use.Define.EffectValue)
+	if err != nil {
+		return err
+	}
+	if !added {
+		return ErrItemSkillKnown
+	}
+	return nil
+}
+
+func commitLearnSkill(_ context.Context, use *ItemUse) error {
+	LearnSkill(use.Actor, use.Define.EffectValue)
+	use.Result.SkillID = use.Define.EffectValue
+	return nil
+}
+
+func (s *ItemUseService) applySummonPet(txCtx context.Context, use *ItemUse, _ *InventoryTx) error {
+	if s.pets == nil {
+		return ErrItemEffectUnavailable
+	}
+	if err := s.pets.SummonPet(txCtx, use.CharacterID, use.Define.EffectValue); err != nil {
+		return err
+	}
+	use.Result.PetID = use.Define.EffectValue
+	return nil
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"greatestworks/internal/domain/character"
+	"greatestworks/internal/infrastructure/datamanager"
+)
+
+// testWhistleID configs/data 中的狼哨（召唤宠物 1）
+const testWhistleID = int32(10009)
+
+type stubPetSummoner struct {
+	err    error
+	summon []int32
+}
+
+func (p *stubPetSummoner) SummonPet(_ context.Context, _ int64, petID int32) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.summon = append(p.summon, petID)
+	return nil
+}
+
+func newItemUseTestActor(t *testing.T) *character.Actor {
+	t.Helper()
+	a := character.NewActor(1, character.EntityTypePlayer, 1001, character.NewVector3(0, 0, 0), character.NewVector3(1, 0, 0), "p", 5)
+	if err := a.Start(context.Background()); err != nil {
+		t.Fatalf("actor start: %v", err)
+	}
+	return a
+}
+
+func TestHealItemEffects(t *testing.T) {
+	tests := []struct {
+		name    string
+		effect  ItemEffect
+		current func(a *character.Actor) float32
+		maximum func(a *character.Actor) float32
+		change  func(a *character.Actor, delta float32)
+	}{
+		{
+			name: "hp", effect: ItemEffect{Check: checkHealHP, Commit: commitHealHP},
+			current: (*character.Actor).HP,
+			maximum: func(a *character.Actor) float32 { return a.GetAttributeManager().Final().MaxHP },
+			change:  (*character.Actor).ChangeHP,
+		},
+		{
+			name: "mp", effect: ItemEffect{Check: checkHealMP, Commit: commitHealMP},
+			current: (*character.Actor).MP,
+			maximum: func(a *character.Actor) float32 { return a.GetAttributeManager().Final().MaxMP },
+			change:  (*character.Actor).ChangeMP,
+		},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newItemUseTestActor(t)
+			use := &ItemUse{Actor: a, Target: a, Define: &datamanager.ItemDefine{EffectValue: 1000}, Result: &ItemUseResult{}}
+			tt.change(a, tt.maximum(a))
+			if err := tt.effect.Check(ctx, use); !errors.Is(err, ErrItemNoEffect) {
+				t.Fatalf("full %s: err %v, want ErrItemNoEffect", tt.name, err)
+			}
+
+			tt.change(a, -30)
+			if err := tt.effect.Check(ctx, use); err != nil {
+				t.Fatalf("check: %v", err)
+			}
+			if err := tt.effect.Commit(ctx, use); err != nil {
+				t.Fatalf("commit: %v", err)
+			}
+			if use.Result.Amount != 30 {
+				t.Fatalf("healed %v, want 30 (capped at max)", use.Result.Amount)
+			}
+			if tt.current(a) != tt.maximum(a) {
+				t.Fatalf("%s %v, want max %v", tt.name, tt.current(a), tt.maximum(a))
+			}
+		})
+	}
+}
+
+func TestSummonPetItem(t *testing.T) {
+	ctx := context.Background()
+	newService := func(t *testing.T) (*ItemUseService, *memItemStore) {
+		ms := NewMapService()
+		if err := ms.EnterMapActor(ctx, newTestPlayer(t, 1, 10), testVillageMapID, 100, 0, 100); err != nil {
+			t.Fatalf("enter: %v", err)
+		}
+		store := newMemItemStore(nil, ownedItem(1, 1, testWhistleID, 1, 0))
+		return NewItemUseService(ms, NewItemService(store), nil, nil), store
+	}
+
+	t.Run("unwired", func(t *testing.T) {
+		s, store := newService(t)
+		if _, err := s.UseItem(ctx, 1, 1, 0); !errors.Is(err, ErrItemEffectUnavailable) {
+			t.Fatalf("err %v, want ErrItemEffectUnavailable", err)
+		}
+		if store.holdings(1)[testWhistleID] != 1 {
+			t.Fatalf("whistle consumed without a pet system")
+		}
+	})
+
+	t.Run("summoner fails", func(t *testing.T) {
+		s, store := newService(t)
+		pets := &stubPetSummoner{err: errors.New("pet slots full")}
+		s.SetPetSummoner(pets)
+		if _, err := s.UseItem(ctx, 1, 1, 0); !errors.Is(err, pets.err) {
+			t.Fatalf("err %v, want the summoner error", err)
+		}
+		if store.holdings(1)[testWhistleID] != 1 {
+			t.Fatalf("whistle consumed by a failed summon")
+		}
+	})
+
+	t.Run("wired", func(t *testing.T) {
+		s, store := newService(t)
+		pets := &stubPetSummoner{}
+		s.SetPetSummoner(pets)
+		result, err := s.UseItem(ctx, 1, 1, 0)
+		if err != nil {
+			t.Fatalf("use: %v", err)
+		}
+		if result.PetID != 1 || len(pets.summon) != 1 || pets.summon[0] != 1 {
+			t.Fatalf("result pet %d, summoned %v, want pet 1", result.PetID, pets.summon)
+		}
+		if store.holdings(1)[testWhistleID] != 0 {
+			t.Fatalf("whistle not consumed")
+		}
+	})
+}
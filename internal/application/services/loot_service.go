@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"greatestworks/internal/domain/character"
+	"greatestworks/internal/domain/mapmanager"
+	"greatestworks/internal/infrastructure/datamanager"
+)
+
+// 掉落参数
+const (
+	lootOwnerDuration = float32(30)  // 击杀者及其队伍独占拾取的秒数
+	lootLifetime      = float32(120) // 掉落物存在秒数
+	lootScatter       = float32(1.5) // 掉落散布半径
+	PickupRange       = float32(3)   // 拾取距离
+)
+
+// LootInventory 背包写入（由 ItemService 实现）
+type LootInventory interface {
+	AddToBag(ctx context.Context, characterID int64, itemID, count int32) error
+}
+
+// LootResult 单条掉落判定结果
+type LootResult struct {
+	ItemID int32
+	Count  int32
+}
+
+// LootService 掉落服务：怪物死亡按掉落表生成地面掉落物，校验归属与距离后拾取进背包
+type LootService struct {
+	mu         sync.Mutex
+	mapService *MapService
+	inventory  LootInventory
+	rng        *rand.Rand
+}
+
+// NewLootService 创建掉落服务
+func NewLootService(mapService *MapService, inventory LootInventory) *LootService {
+	return &LootService{
+		mapService: mapService,
+		inventory:  inventory,
+		rng:        rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Roll 按单位掉落表判定掉落
+func (s *LootService) Roll(unitID int32) []LootResult {
+	unit := datamanager.GetInstance().GetUnit(unitID)
+	if unit == nil {
+		return nil
+	}
+	return s.RollTable(unit.Loot)
+}
+
+// RollTable 按掉落表判定掉落（宝箱等）
+func (s *LootService) RollTable(table []datamanager.LootDefine) []LootResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var results []LootResult
+	for _, entry := range table {
+		if s.rng.Float32() >= entry.Chance {
+			continue
+		}
+		count := entry.MinCount
+		if entry.MaxCount > entry.MinCount {
+			count += s.rng.Int31n(entry.MaxCount - entry.MinCount + 1)
+		}
+		if count > 0 {
+			results = append(results, LootResult{ItemID: entry.ItemID, Count: count})
+		}
+	}
+	return results
+}
+
+// OnActorDeath 地图死亡回调：怪物按掉落表在死亡位置附近掉落，归属击杀者及其队伍
+func (s *LootService) OnActorDeath(ctx context.Context, gameMap *mapmanager.Map, actor *character.Actor) {
+	if actor.Type() != character.EntityTypeMonster {
+		return
+	}
+	results := s.Roll(actor.UnitID())
+	if len(results) == 0 {
+		return
+	}
+
+	var owners []character.EntityID
+	if src := actor.DamageSource(); src != nil && src.AttackerInfo.AttackerID != 0 {
+		killer := src.AttackerInfo.AttackerID
+		owners = append(owners, killer)
+		for _, member := range s.mapService.partyMembers(int32(killer)) {
+			owners = append(owners, character.EntityID(member))
+		}
+	}
+
+	center := actor.Position()
+	for i, r := range results {
+		pos := center
+		if len(results) > 1 {
+			angle := 2 * math.Pi * float64(i) / float64(len(results))
+			pos.X += lootScatter * float32(math.Cos(angle))
+			pos.Z += lootScatter * float32(math.Sin(angle))
+		}
+		_, _ = gameMap.DropItem(ctx, nextSpawnedEntityID(), r.ItemID, r.Count, pos, owners, lootOwnerDuration, lootLifetime)
+	}
+}
+
+// Pickup 拾取掉落物：校验距离与归属，写入背包成功后从地图移除
+func (s *LootService) Pickup(ctx context.Context, mapID, entityID int32, characterID int64, dropID int32) (*mapmanager.DroppedItem, error) {
+	gameMap, err := s.mapService.GetEntityMap(mapID, entityID)
+	if err != nil {
+		return nil, err
+	}
+	drop, err := gameMap.ClaimDrop(character.EntityID(entityID), character.EntityID(dropID), PickupRange)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.inventory.AddToBag(ctx, characterID, drop.ItemID, drop.Count); err != nil {
+		gameMap.ReleaseDrop(character.EntityID(dropID))
+		return nil, err
+	}
+	_ = gameMap.RemoveDrop(ctx, character.EntityID(dropID))
+	return drop, nil
+}
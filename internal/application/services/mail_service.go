@@ -2,30 +2,49 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"greatestworks/internal/infrastructure/persistence"
 )
 
+// 邮件错误
+var (
+	ErrMailNotFound      = errors.New("mail not found")
+	ErrMailNoAttachments = errors.New("mail has no attachments to claim")
+	ErrMailHasAttachment = errors.New("claim attachments before deleting mail")
+)
+
 // MailService 邮件服务
 type MailService struct {
 	mailRepo *persistence.MailRepository
+	items    *ItemService
+	wallet   WalletStore
 }
 
-// NewMailService 创建邮件服务
-func NewMailService(mailRepo *persistence.MailRepository) *MailService {
+// NewMailService 创建邮件服务（items/wallet 用于领取附件）
+func NewMailService(mailRepo *persistence.MailRepository, items *ItemService, wallet WalletStore) *MailService {
 	return &MailService{
 		mailRepo: mailRepo,
+		items:    items,
+		wallet:   wallet,
 	}
 }
 
 // SendMail 发送邮件
 func (s *MailService) SendMail(ctx context.Context, receiverID int64, senderName, title, content string, attachments []persistence.DbAttachment, expireDays int) (int64, error) {
-	// 生成邮件ID
-	mailID := time.Now().UnixNano()
+	return s.SendGoldMail(ctx, receiverID, senderName, title, content, attachments, 0, expireDays)
+}
+
+// SendGoldMail 发送附带金币的邮件（可在事务内调用）
+func (s *MailService) SendGoldMail(ctx context.Context, receiverID int64, senderName, title, content string, attachments []persistence.DbAttachment, gold int64, expireDays int) (int64, error) {
+	mailID, err := s.mailRepo.NextMailID(ctx)
+	if err != nil {
+		return 0, err
+	}
 
-	hasItems := len(attachments) > 0
+	hasItems := len(attachments) > 0 || gold > 0
 	expireAt := time.Now().AddDate(0, 0, expireDays)
 
 	mail := &persistence.DbMail{
@@ -37,6 +56,7 @@ func (s *MailService) SendMail(ctx context.Context, receiverID int64, senderName
 		IsRead:      false,
 		HasItems:    hasItems,
 		Attachments: attachments,
+		Gold:        gold,
 		ExpireAt:    expireAt,
 	}
 
@@ -57,23 +77,72 @@ func (s *MailService) GetMails(ctx context.Context, receiverID int64, limit int)
 }
 
 // ReadMail 读取邮件
-func (s *MailService) ReadMail(ctx context.Context, mailID int64) error {
-	return s.mailRepo.MarkAsRead(ctx, mailID)
+func (s *MailService) ReadMail(ctx context.Context, receiverID, mailID int64) error {
+	return s.mailRepo.MarkAsRead(ctx, receiverID, mailID)
 }
 
-// DeleteMail 删除邮件
-func (s *MailService) DeleteMail(ctx context.Context, mailID int64) error {
-	return s.mailRepo.Delete(ctx, mailID)
+// DeleteMail 删除邮件（附件未领取时拒绝删除）
+func (s *MailService) DeleteMail(ctx context.Context, receiverID, mailID int64) error {
+	deleted, err := s.mailRepo.Delete(ctx, receiverID, mailID)
+	if err != nil {
+		return fmt.Errorf("failed to delete mail: %w", err)
+	}
+	if !deleted {
+		mail, err := s.mailRepo.FindByID(ctx, receiverID, mailID)
+		if err == nil && mail != nil {
+			return ErrMailHasAttachment
+		}
+		return ErrMailNotFound
+	}
+	return nil
 }
 
-// ClaimAttachments 领取附件
-func (s *MailService) ClaimAttachments(ctx context.Context, mailID int64) ([]persistence.DbAttachment, error) {
-	// TODO: 实现附件领取逻辑
-	// 1. 检查背包空间
-	// 2. 添加物品到背包
-	// 3. 清空邮件附件或删除邮件
-
-	return nil, nil
+// ClaimAttachments 领取附件：物品放入背包、金币入账与清空附件在同一事务内完成，背包空间不足时不做任何修改
+func (s *MailService) ClaimAttachments(ctx context.Context, receiverID, mailID int64) (*persistence.DbMail, error) {
+	var claimed *persistence.DbMail
+	err := s.items.Transact(ctx, []int64{receiverID}, func(txCtx context.Context, txs map[int64]*InventoryTx) error {
+		mail, err := s.mailRepo.FindByID(txCtx, receiverID, mailID)
+		if err != nil {
+			return fmt.Errorf("failed to load mail: %w", err)
+		}
+		if mail == nil {
+			return ErrMailNotFound
+		}
+		if !mail.HasItems {
+			return ErrMailNoAttachments
+		}
+		tx := txs[receiverID]
+		for _, att := range mail.Attachments {
+			if err := tx.Add(att.ItemID, att.Count); err != nil {
+				return err
+			}
+		}
+		taken, err := s.mailRepo.TakeAttachments(txCtx, receiverID, mailID)
+		if err != nil {
+			return fmt.Errorf("failed to claim attachments: %w", err)
+		}
+		if !taken {
+			return ErrMailNoAttachments
+		}
+		if mail.Gold > 0 {
+			if _, err := s.wallet.Apply(txCtx, WalletChange{
+				CharacterID: receiverID,
+				Currency:    CurrencyGold,
+				Delta:       mail.Gold,
+				Reason:      WalletReasonMailClaim,
+				Source:      fmt.Sprintf("mail:%d", mailID),
+				Key:         fmt.Sprintf("mail:%d:%d", receiverID, mailID),
+			}); err != nil {
+				return err
+			}
+		}
+		claimed = mail
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return claimed, nil
 }
 
 // SendSystemMail 发送系统邮件
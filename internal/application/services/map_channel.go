@@ -0,0 +1,157 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"greatestworks/internal/domain/mapmanager"
+	"greatestworks/internal/infrastructure/datamanager"
+)
+
+// 分线默认参数
+const (
+	defaultChannelSoftCap = 150              // 单线软上限
+	defaultChannelHardCap = 200              // 单线硬上限
+	channelRetireAfter    = 60 * time.Second // 非主线空闲多久后回收
+	primaryChannel        = int32(1)         // 主线编号（常驻，不回收）
+)
+
+// 分线错误
+var (
+	ErrChannelNotFound = errors.New("channel not found")
+	ErrChannelFull     = errors.New("channel is full")
+)
+
+// PartyResolver 查询实体所在队伍的其他成员实体ID（不含自身），用于同队优先分配到同一线路
+type PartyResolver func(entityID int32) []int32
+
+// ChannelInfo 线路信息
+type ChannelInfo struct {
+	Channel int32 `json:"channel"`
+	Players int   `json:"players"`
+	SoftCap int32 `json:"soft_cap"`
+	HardCap int32 `json:"hard_cap"`
+}
+
+// mapChannel 单条线路（一个独立的地图实例）
+type mapChannel struct {
+	id         int32
+	gameMap    *mapmanager.Map
+	emptySince time.Time // 最近一次变为无玩家的时间，零值表示当前有玩家
+}
+
+// mapChannels 同一地图ID下的所有线路
+type mapChannels struct {
+	define   *datamanager.MapDefine
+	softCap  int32
+	hardCap  int32
+	channels map[int32]*mapChannel
+}
+
+// entityLocation 实体当前所在的地图与线路
+type entityLocation struct {
+	mapID   int32
+	channel int32
+}
+
+func newMapChannels(define *datamanager.MapDefine) *mapChannels {
+	softCap, hardCap := define.SoftCap, define.HardCap
+	if softCap <= 0 {
+		softCap = defaultChannelSoftCap
+	}
+	if hardCap <= 0 {
+		hardCap = defaultChannelHardCap
+	}
+	if hardCap < softCap {
+		hardCap = softCap
+	}
+	return &mapChannels{
+		define:   define,
+		softCap:  softCap,
+		hardCap:  hardCap,
+		channels: make(map[int32]*mapChannel),
+	}
+}
+
+// sortedIDs 按编号升序返回线路ID
+func (mc *mapChannels) sortedIDs() []int32 {
+	ids := make([]int32, 0, len(mc.channels))
+	for id := range mc.channels {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// mapSetup 新线路地图实例的初始化（注入广播、摆放NPC与刷怪等）
+type mapSetup func(gameMap *mapmanager.Map, define *datamanager.MapDefine)
+
+// open 创建新线路，编号取最小的未使用编号
+func (mc *mapChannels) open(setup mapSetup) *mapChannel {
+	id := primaryChannel
+	for {
+		if _, exists := mc.channels[id]; !exists {
+			break
+		}
+		id++
+	}
+	gameMap := mapmanager.NewMap(mc.define.ID, mc.define.Name, mc.define.Width, mc.define.Height)
+	if setup != nil {
+		setup(gameMap, mc.define)
+	}
+	ch := &mapChannel{id: id, gameMap: gameMap, emptySince: time.Now()}
+	mc.channels[id] = ch
+	return ch
+}
+
+// pick 为新进入的玩家选择线路：优先队友所在且未达硬上限的线路，其次编号最小且未达软上限的线路，否则新开线路
+func (mc *mapChannels) pick(partyChannels []int32, setup mapSetup) *mapChannel {
+	for _, id := range partyChannels {
+		if ch, ok := mc.channels[id]; ok && int32(ch.gameMap.PlayerCount()) < mc.hardCap {
+			return ch
+		}
+	}
+	for _, id := range mc.sortedIDs() {
+		ch := mc.channels[id]
+		if int32(ch.gameMap.PlayerCount()) < mc.softCap {
+			return ch
+		}
+	}
+	return mc.open(setup)
+}
+
+// retireIdle 回收长时间无玩家的非主线线路，返回被回收的线路
+func (mc *mapChannels) retireIdle(now time.Time) []*mapChannel {
+	var retired []*mapChannel
+	for _, id := range mc.sortedIDs() {
+		ch := mc.channels[id]
+		if ch.gameMap.PlayerCount() > 0 {
+			ch.emptySince = time.Time{}
+			continue
+		}
+		if ch.emptySince.IsZero() {
+			ch.emptySince = now
+			continue
+		}
+		if id != primaryChannel && now.Sub(ch.emptySince) >= channelRetireAfter {
+			delete(mc.channels, id)
+			retired = append(retired, ch)
+		}
+	}
+	return retired
+}
+
+// infos 线路信息列表
+func (mc *mapChannels) infos() []ChannelInfo {
+	list := make([]ChannelInfo, 0, len(mc.channels))
+	for _, id := range mc.sortedIDs() {
+		list = append(list, ChannelInfo{
+			Channel: id,
+			Players: mc.channels[id].gameMap.PlayerCount(),
+			SoftCap: mc.softCap,
+			HardCap: mc.hardCap,
+		})
+	}
+	return list
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"greatestworks/internal/domain/character"
+	"greatestworks/internal/infrastructure/datamanager"
+)
+
+// testArenaMapID 测试使用的地图（configs/data 中的 Boss Arena）
+const testArenaMapID = int32(3)
+
+func newTestPlayer(t *testing.T, id int32, level int32) *character.Actor {
+	t.Helper()
+	a := character.NewActor(character.EntityID(id), character.EntityTypePlayer, 1001, character.NewVector3(0, 0, 0), character.NewVector3(1, 0, 0), "p", level)
+	if err := a.Start(context.Background()); err != nil {
+		t.Fatalf("actor start: %v", err)
+	}
+	return a
+}
+
+// newCappedMapService 加载测试地图并把线路上限调小
+func newCappedMapService(t *testing.T, softCap, hardCap int32) *MapService {
+	t.Helper()
+	s := NewMapService()
+	if err := s.LoadMap(context.Background(), testArenaMapID); err != nil {
+		t.Fatalf("load map: %v", err)
+	}
+	s.maps[testArenaMapID].softCap, s.maps[testArenaMapID].hardCap = softCap, hardCap
+	return s
+}
+
+func enterPlayers(t *testing.T, s *MapService, ids ...int32) {
+	t.Helper()
+	for _, id := range ids {
+		if err := s.EnterMapActor(context.Background(), newTestPlayer(t, id, 1), testArenaMapID, 10, 0, 10); err != nil {
+			t.Fatalf("enter %d: %v", id, err)
+		}
+	}
+}
+
+func channelOf(t *testing.T, s *MapService, id int32) int32 {
+	t.Helper()
+	_, channel, ok := s.ChannelOf(id)
+	if !ok {
+		t.Fatalf("entity %d not located", id)
+	}
+	return channel
+}
+
+func TestNewMapChannelsCaps(t *testing.T) {
+	tests := []struct {
+		name       string
+		soft, hard int32
+		wantSoft   int32
+		wantHard   int32
+	}{
+		{"configured", 10, 20, 10, 20},
+		{"defaults", 0, 0, defaultChannelSoftCap, defaultChannelHardCap},
+		{"hard raised to soft", 30, 20, 30, 30},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mc := newMapChannels(&datamanager.MapDefine{ID: 9, SoftCap: tt.soft, HardCap: tt.hard})
+			if mc.softCap != tt.wantSoft || mc.hardCap != tt.wantHard {
+				t.Fatalf("caps %d/%d, want %d/%d", mc.softCap, mc.hardCap, tt.wantSoft, tt.wantHard)
+			}
+		})
+	}
+}
+
+func TestChannelSoftCapOpensNewChannel(t *testing.T) {
+	s := newCappedMapService(t, 2, 3)
+	enterPlayers(t, s, 1, 2, 3)
+
+	if got := []int32{channelOf(t, s, 1), channelOf(t, s, 2), channelOf(t, s, 3)}; got[0] != 1 || got[1] != 1 || got[2] != 2 {
+		t.Fatalf("channels %v, want [1 1 2]", got)
+	}
+	infos, err := s.ListChannels(context.Background(), testArenaMapID)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(infos) != 2 || infos[0].Players != 2 || infos[1].Players != 1 {
+		t.Fatalf("unexpected channel infos %+v", infos)
+	}
+}
+
+func TestChannelPartyPlacement(t *testing.T) {
+	s := newCappedMapService(t, 1, 2)
+	s.SetPartyResolver(func(entityID int32) []int32 {
+		if entityID == 2 || entityID == 3 {
+			return []int32{1}
+		}
+		return nil
+	})
+	enterPlayers(t, s, 1, 2)
+	if ch := channelOf(t, s, 2); ch != 1 {
+		t.Fatalf("party member placed in channel %d, want the leader's channel 1 (below hard cap)", ch)
+	}
+	enterPlayers(t, s, 3)
+	if ch := channelOf(t, s, 3); ch != 2 {
+		t.Fatalf("party member placed in channel %d, want 2 once channel 1 is at the hard cap", ch)
+	}
+}
+
+func TestSwitchChannel(t *testing.T) {
+	ctx := context.Background()
+	s := newCappedMapService(t, 1, 2)
+	enterPlayers(t, s, 1, 2, 3)
+	if ch := channelOf(t, s, 2); ch != 2 {
+		t.Fatalf("setup: entity 2 in channel %d", ch)
+	}
+
+	if err := s.SwitchChannel(ctx, testArenaMapID, 3, 9); !errors.Is(err, ErrChannelNotFound) {
+		t.Fatalf("unknown channel: err %v", err)
+	}
+	if err := s.SwitchChannel(ctx, testArenaMapID, 3, 1); err != nil {
+		t.Fatalf("switch: %v", err)
+	}
+	if ch := channelOf(t, s, 3); ch != 1 {
+		t.Fatalf("entity 3 in channel %d after switch, want 1", ch)
+	}
+	source, _ := s.GetChannelMap(testArenaMapID, 3)
+	target, _ := s.GetChannelMap(testArenaMapID, 1)
+	if source.GetActor(3) != nil || target.GetActor(3) == nil {
+		t.Fatalf("actor should have moved from channel 3 to channel 1")
+	}
+	if err := s.SwitchChannel(ctx, testArenaMapID, 2, 1); !errors.Is(err, ErrChannelFull) {
+		t.Fatalf("switch into full channel: err %v, want ErrChannelFull", err)
+	}
+	if ch := channelOf(t, s, 2); ch != 2 {
+		t.Fatalf("failed switch moved entity to channel %d", ch)
+	}
+}
+
+func TestRetireIdleChannels(t *testing.T) {
+	ctx := context.Background()
+	s := newCappedMapService(t, 1, 1)
+	enterPlayers(t, s, 1, 2)
+	mc := s.maps[testArenaMapID]
+	if len(mc.channels) != 2 {
+		t.Fatalf("setup: %d channels", len(mc.channels))
+	}
+	if err := s.LeaveMapByID(ctx, testArenaMapID, 1); err != nil {
+		t.Fatalf("leave: %v", err)
+	}
+	if err := s.LeaveMapByID(ctx, testArenaMapID, 2); err != nil {
+		t.Fatalf("leave: %v", err)
+	}
+
+	now := time.Now()
+	mc.channels[2].emptySince = time.Time{}
+	if retired := mc.retireIdle(now); len(retired) != 0 {
+		t.Fatalf("channel retired before its idle time started")
+	}
+	if retired := mc.retireIdle(now.Add(channelRetireAfter - time.Second)); len(retired) != 0 {
+		t.Fatalf("channel retired before %v idle", channelRetireAfter)
+	}
+	mc.channels[primaryChannel].emptySince = now.Add(-time.Hour)
+	retired := mc.retireIdle(now.Add(channelRetireAfter))
+	if len(retired) != 1 || retired[0].id != 2 {
+		t.Fatalf("retired %d channels, want only channel 2", len(retired))
+	}
+	if _, ok := mc.channels[primaryChannel]; !ok {
+		t.Fatalf("primary channel must never retire")
+	}
+
+	enterPlayers(t, s, 3, 4)
+	if ch := channelOf(t, s, 4); ch != 2 {
+		t.Fatalf("reopened channel %d, want lowest free id 2", ch)
+	}
+}
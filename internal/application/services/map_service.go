@@ -12,11 +12,23 @@ import (
 )
 
 // MapService 地图服务
+// 每个地图ID可拥有多条线路（独立的地图实例），按软/硬上限自动开线与回收
 type MapService struct {
-	mu   sync.RWMutex
-	maps map[int32]*mapmanager.Map
+	mu        sync.RWMutex
+	maps      map[int32]*mapChannels
+	locations map[int32]entityLocation // 实体ID -> 所在地图与线路
 	// 应用层广播适配器
 	broadcaster mapmanager.BroadcastFn
+	// 队伍查询（可选，用于同队同线）
+	partyResolver PartyResolver
+	// 刷怪条件使用的世界环境（时间、天气）
+	spawnEnv mapmanager.SpawnEnvironment
+	// 角色死亡回调（掉落等）
+	deathHandler mapmanager.DeathHandler
+	// 复活邀请回调
+	resurrectHandler mapmanager.ResurrectHandler
+	// 决斗结束回调（战绩记录）
+	duelEndHandler mapmanager.DuelEndHandler
 	// 异步刷怪/掉落等任务
 	spawnMgr *SpawnManager
 }
@@ -24,7 +36,8 @@ type MapService struct {
 // NewMapService 创建地图服务
 func NewMapService() *MapService {
 	return &MapService{
-		maps: make(map[int32]*mapmanager.Map),
+		maps:      make(map[int32]*mapChannels),
+		locations: make(map[int32]entityLocation),
 	}
 }
 
@@ -33,8 +46,10 @@ func (s *MapService) SetBroadcaster(fn mapmanager.BroadcastFn) {
 	s.mu.Lock()
 	s.broadcaster = fn
 	// 将已加载地图也设置广播器
-	for _, m := range s.maps {
-		m.SetBroadcaster(fn)
+	for _, mc := range s.maps {
+		for _, ch := range mc.channels {
+			ch.gameMap.SetBroadcaster(fn)
+		}
 	}
 	s.mu.Unlock()
 }
@@ -46,96 +61,335 @@ func (s *MapService) SetSpawnManager(sm *SpawnManager) {
 	s.mu.Unlock()
 }
 
-// LoadMap 加载地图
+// SetPartyResolver 注入队伍查询，用于将队友优先分配到同一线路
+func (s *MapService) SetPartyResolver(fn PartyResolver) {
+	s.mu.Lock()
+	s.partyResolver = fn
+	s.mu.Unlock()
+}
+
+// SetSpawnEnvironment 设置刷怪条件使用的世界环境（应用到已加载及后续创建的线路）
+func (s *MapService) SetSpawnEnvironment(env mapmanager.SpawnEnvironment) {
+	s.mu.Lock()
+	s.spawnEnv = env
+	for _, mc := range s.maps {
+		for _, ch := range mc.channels {
+			ch.gameMap.SetSpawnEnvironment(env)
+		}
+	}
+	s.mu.Unlock()
+}
+
+// SetDeathHandler 设置地图内角色死亡回调（应用到已加载及后续创建的线路）
+func (s *MapService) SetDeathHandler(fn mapmanager.DeathHandler) {
+	s.mu.Lock()
+	s.deathHandler = fn
+	for _, mc := range s.maps {
+		for _, ch := range mc.channels {
+			ch.gameMap.SetDeathHandler(fn)
+		}
+	}
+	s.mu.Unlock()
+}
+
+// SetResurrectHandler 设置复活邀请回调（应用到已加载及后续创建的线路）
+func (s *MapService) SetResurrectHandler(fn mapmanager.ResurrectHandler) {
+	s.mu.Lock()
+	s.resurrectHandler = fn
+	for _, mc := range s.maps {
+		for _, ch := range mc.channels {
+			ch.gameMap.SetResurrectHandler(fn)
+		}
+	}
+	s.mu.Unlock()
+}
+
+// SetDuelEndHandler 设置决斗结束回调（应用到已加载及后续创建的线路）
+func (s *MapService) SetDuelEndHandler(fn mapmanager.DuelEndHandler) {
+	s.mu.Lock()
+	s.duelEndHandler = fn
+	for _, mc := range s.maps {
+		for _, ch := range mc.channels {
+			ch.gameMap.SetDuelEndHandler(fn)
+		}
+	}
+	s.mu.Unlock()
+}
+
+// setupMapLocked 初始化新建的线路地图（调用方持有写锁）
+func (s *MapService) setupMapLocked(gameMap *mapmanager.Map, define *datamanager.MapDefine) {
+	if s.broadcaster != nil {
+		gameMap.SetBroadcaster(s.broadcaster)
+	}
+	if s.deathHandler != nil {
+		gameMap.SetDeathHandler(s.deathHandler)
+	}
+	if s.resurrectHandler != nil {
+		gameMap.SetResurrectHandler(s.resurrectHandler)
+	}
+	if s.duelEndHandler != nil {
+		gameMap.SetDuelEndHandler(s.duelEndHandler)
+	}
+	gameMap.SetEntityIDAllocator(nextSpawnedEntityID)
+	populateMap(gameMap, define, s.spawnEnv)
+}
+
+// LoadMap 加载地图（创建主线）
 func (s *MapService) LoadMap(ctx context.Context, mapID int32) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	_, err := s.loadMapLocked(mapID)
+	return err
+}
 
+// loadMapLocked 加载地图并返回其线路集合（调用方持有写锁）
+func (s *MapService) loadMapLocked(mapID int32) (*mapChannels, error) {
 	// 检查是否已加载
-	if _, exists := s.maps[mapID]; exists {
-		return nil
+	if mc, exists := s.maps[mapID]; exists {
+		return mc, nil
 	}
 
 	// 获取地图配置
 	mapDefine := datamanager.GetInstance().GetMap(mapID)
 	if mapDefine == nil {
-		return errors.New("map not found")
+		return nil, errors.New("map not found")
 	}
 
-	// 创建地图实例
-	gameMap := mapmanager.NewMap(mapID, mapDefine.Name, mapDefine.Width, mapDefine.Height)
-	if s.broadcaster != nil {
-		gameMap.SetBroadcaster(s.broadcaster)
-	}
-	s.maps[mapID] = gameMap
+	// 创建地图线路集合与主线实例
+	mc := newMapChannels(mapDefine)
+	mc.open(s.setupMapLocked)
+	s.maps[mapID] = mc
 
-	return nil
+	return mc, nil
 }
 
-// GetMap 获取地图
+// GetMap 获取地图（主线实例）
 func (s *MapService) GetMap(mapID int32) (*mapmanager.Map, error) {
+	return s.GetChannelMap(mapID, primaryChannel)
+}
+
+// GetChannelMap 获取地图指定线路的实例
+func (s *MapService) GetChannelMap(mapID, channel int32) (*mapmanager.Map, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	gameMap, exists := s.maps[mapID]
+	mc, exists := s.maps[mapID]
 	if !exists {
 		return nil, errors.New("map not loaded")
 	}
+	ch, exists := mc.channels[channel]
+	if !exists {
+		return nil, ErrChannelNotFound
+	}
 
-	return gameMap, nil
+	return ch.gameMap, nil
 }
 
-// EnterMap 进入地图
-func (s *MapService) EnterMap(ctx context.Context, entity *character.Entity, mapID int32, x, y, z float32) error {
-	gameMap, err := s.GetMap(mapID)
-	if err != nil {
-		// 尝试加载地图
-		if err := s.LoadMap(ctx, mapID); err != nil {
-			return err
+// GetEntityMap 获取实体所在线路的地图实例；实体不在该地图时返回主线
+func (s *MapService) GetEntityMap(mapID int32, entityID int32) (*mapmanager.Map, error) {
+	s.mu.RLock()
+	loc, ok := s.locations[entityID]
+	s.mu.RUnlock()
+	if ok && loc.mapID == mapID {
+		if m, err := s.GetChannelMap(mapID, loc.channel); err == nil {
+			return m, nil
 		}
-		gameMap, err = s.GetMap(mapID)
-		if err != nil {
-			return err
+	}
+	return s.GetMap(mapID)
+}
+
+// LocateActor 按实体当前所在线路查找地图实例及角色
+func (s *MapService) LocateActor(entityID int32) (*mapmanager.Map, *character.Actor, error) {
+	mapID, channel, ok := s.ChannelOf(entityID)
+	if !ok {
+		return nil, nil, errors.New("entity not in any map")
+	}
+	gameMap, err := s.GetChannelMap(mapID, channel)
+	if err != nil {
+		return nil, nil, err
+	}
+	actor := gameMap.GetActor(character.EntityID(entityID))
+	if actor == nil {
+		return nil, nil, errors.New("entity is not an actor")
+	}
+	return gameMap, actor, nil
+}
+
+// ChannelOf 获取实体当前所在的地图与线路
+func (s *MapService) ChannelOf(entityID int32) (mapID, channel int32, ok bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	loc, ok := s.locations[entityID]
+	return loc.mapID, loc.channel, ok
+}
+
+// ListChannels 获取地图的线路列表
+func (s *MapService) ListChannels(ctx context.Context, mapID int32) ([]ChannelInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	mc, exists := s.maps[mapID]
+	if !exists {
+		return nil, errors.New("map not loaded")
+	}
+	return mc.infos(), nil
+}
+
+// partyMembers 获取实体的队友（不含自身）
+func (s *MapService) partyMembers(entityID int32) []int32 {
+	s.mu.RLock()
+	resolver := s.partyResolver
+	s.mu.RUnlock()
+	if resolver == nil {
+		return nil
+	}
+	return resolver(entityID)
+}
+
+// partyChannelsLocked 获取队友在指定地图所处的线路（调用方持有锁）
+func (s *MapService) partyChannelsLocked(mapID, entityID int32) []int32 {
+	if s.partyResolver == nil {
+		return nil
+	}
+	var channels []int32
+	for _, memberID := range s.partyResolver(entityID) {
+		if loc, ok := s.locations[memberID]; ok && loc.mapID == mapID {
+			channels = append(channels, loc.channel)
 		}
 	}
+	return channels
+}
 
-	// 进入地图
+// enterLocked 选择线路并让实体进入（调用方持有写锁）
+func (s *MapService) enterLocked(ctx context.Context, mapID int32, entityID int32, enter func(*mapmanager.Map) error) error {
+	mc, err := s.loadMapLocked(mapID)
+	if err != nil {
+		return err
+	}
+	ch := mc.pick(s.partyChannelsLocked(mapID, entityID), s.setupMapLocked)
+	if err := enter(ch.gameMap); err != nil {
+		return err
+	}
+	ch.emptySince = time.Time{}
+	s.locations[entityID] = entityLocation{mapID: mapID, channel: ch.id}
+	return nil
+}
+
+// EnterMap 进入地图
+func (s *MapService) EnterMap(ctx context.Context, entity *character.Entity, mapID int32, x, y, z float32) error {
 	// 设置初始位置
 	entity.SetPosition(character.NewVector3(x, y, z))
-	_ = gameMap.Enter(ctx, entity)
+
+	s.mu.Lock()
+	err := s.enterLocked(ctx, mapID, int32(entity.ID()), func(m *mapmanager.Map) error {
+		return m.Enter(ctx, entity)
+	})
+	spawnMgr := s.spawnMgr
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
 
 	// 可选：在进入地图时投递一次异步任务（示例）
-	if s.spawnMgr != nil {
-		s.spawnMgr.Enqueue(func(ctx context.Context) {
+	if spawnMgr != nil {
+		spawnMgr.Enqueue(func(ctx context.Context) {
 			// 占位：后续可在此触发进入地图后的刷怪或欢迎事件
 		})
 	}
 	return nil
 }
 
-// LeaveMap 离开地图
-func (s *MapService) LeaveMap(ctx context.Context, entity *character.Entity, mapID int32) error {
-	gameMap, err := s.GetMap(mapID)
-	if err != nil {
+// EnterMapActor 角色进入地图（登记战斗属性，参与状态同步）
+func (s *MapService) EnterMapActor(ctx context.Context, actor *character.Actor, mapID int32, x, y, z float32) error {
+	actor.SetPosition(character.NewVector3(x, y, z))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.enterLocked(ctx, mapID, int32(actor.ID()), func(m *mapmanager.Map) error {
+		return m.EnterActor(ctx, actor)
+	})
+}
+
+// SwitchChannel 实体切换到同一地图的指定线路（保持当前位置），目标线路达到硬上限时失败
+func (s *MapService) SwitchChannel(ctx context.Context, mapID int32, entityID int32, channel int32) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	mc, exists := s.maps[mapID]
+	if !exists {
+		return errors.New("map not loaded")
+	}
+	loc, ok := s.locations[entityID]
+	if !ok || loc.mapID != mapID {
+		return errors.New("entity not in map")
+	}
+	if loc.channel == channel {
+		return nil
+	}
+	target, exists := mc.channels[channel]
+	if !exists {
+		return ErrChannelNotFound
+	}
+	if int32(target.gameMap.PlayerCount()) >= mc.hardCap {
+		return ErrChannelFull
+	}
+	source, exists := mc.channels[loc.channel]
+	if !exists {
+		return ErrChannelNotFound
+	}
+
+	id := character.EntityID(entityID)
+	entity := source.gameMap.GetEntity(id)
+	if entity == nil {
+		return errors.New("entity not in map")
+	}
+	actor := source.gameMap.GetActor(id)
+	enter := func(m *mapmanager.Map) error {
+		if actor != nil {
+			return m.EnterActor(ctx, actor)
+		}
+		return m.Enter(ctx, entity)
+	}
+
+	if err := source.gameMap.Leave(ctx, id); err != nil {
+		return err
+	}
+	if err := enter(target.gameMap); err != nil {
+		// 回滚：回到原线路
+		_ = enter(source.gameMap)
 		return err
 	}
+	target.emptySince = time.Time{}
+	s.locations[entityID] = entityLocation{mapID: mapID, channel: channel}
+	return nil
+}
 
-	_ = gameMap.Leave(ctx, entity.ID())
+// LeaveMap 离开地图
+func (s *MapService) LeaveMap(ctx context.Context, entity *character.Entity, mapID int32) error {
+	_ = s.LeaveMapByID(ctx, mapID, int32(entity.ID()))
 	return nil
 }
 
 // LeaveMapByID 使用地图ID和实体ID离开地图（便于接口/清理逻辑调用）
 func (s *MapService) LeaveMapByID(ctx context.Context, mapID int32, entityID int32) error {
-	gameMap, err := s.GetMap(mapID)
+	gameMap, err := s.GetEntityMap(mapID, entityID)
 	if err != nil {
 		return err
 	}
-	return gameMap.Leave(ctx, character.EntityID(entityID))
+	if err := gameMap.Leave(ctx, character.EntityID(entityID)); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	if loc, ok := s.locations[entityID]; ok && loc.mapID == mapID {
+		delete(s.locations, entityID)
+	}
+	s.mu.Unlock()
+	return nil
 }
 
 // UpdatePosition 更新位置
 func (s *MapService) UpdatePosition(ctx context.Context, entity *character.Entity, mapID int32, x, y, z float32) error {
-	gameMap, err := s.GetMap(mapID)
+	gameMap, err := s.GetEntityMap(mapID, int32(entity.ID()))
 	if err != nil {
 		return err
 	}
@@ -145,7 +399,7 @@ func (s *MapService) UpdatePosition(ctx context.Context, entity *character.Entit
 
 // UpdatePositionByID 使用地图ID和实体ID更新位置（便于接口层调用）
 func (s *MapService) UpdatePositionByID(ctx context.Context, mapID int32, entityID int32, x, y, z float32) error {
-	gameMap, err := s.GetMap(mapID)
+	gameMap, err := s.GetEntityMap(mapID, entityID)
 	if err != nil {
 		return err
 	}
@@ -179,24 +433,96 @@ func (s *MapService) TransferMap(ctx context.Context, entity *character.Entity,
 	return nil
 }
 
-// BroadcastToMap 向地图广播消息
-func (s *MapService) BroadcastToMap(ctx context.Context, mapID int32, message interface{}) error {
-	gameMap, err := s.GetMap(mapID)
-	if err != nil {
+// TransferActor 角色原子地从一张地图转移到另一张地图：离开与进入在同一把锁内完成，进入失败时回到原地图原位置
+func (s *MapService) TransferActor(ctx context.Context, actor *character.Actor, fromMapID, toMapID int32, x, y, z float32) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entityID := int32(actor.ID())
+	loc, ok := s.locations[entityID]
+	if !ok || loc.mapID != fromMapID {
+		return errors.New("entity not in map")
+	}
+	mc, exists := s.maps[fromMapID]
+	if !exists {
+		return errors.New("map not loaded")
+	}
+	source, exists := mc.channels[loc.channel]
+	if !exists {
+		return ErrChannelNotFound
+	}
+
+	oldPos := actor.Position()
+	if err := source.gameMap.Leave(ctx, actor.ID()); err != nil {
+		return err
+	}
+	delete(s.locations, entityID)
+
+	actor.SetPosition(character.NewVector3(x, y, z))
+	if err := s.enterLocked(ctx, toMapID, entityID, func(m *mapmanager.Map) error {
+		return m.EnterActor(ctx, actor)
+	}); err != nil {
+		// 回滚：回到原线路原位置
+		actor.SetPosition(oldPos)
+		if rerr := source.gameMap.EnterActor(ctx, actor); rerr == nil {
+			s.locations[entityID] = loc
+		}
 		return err
 	}
+	return nil
+}
 
-	// 获取地图内所有实体并广播
-	entities := gameMap.GetAllEntities()
-	recipients := make([]character.EntityID, 0, len(entities))
-	for _, e := range entities {
-		recipients = append(recipients, e.ID())
+// BroadcastToMap 向地图广播消息（覆盖所有线路）
+func (s *MapService) BroadcastToMap(ctx context.Context, mapID int32, message interface{}) error {
+	s.mu.RLock()
+	mc, exists := s.maps[mapID]
+	if !exists {
+		s.mu.RUnlock()
+		return errors.New("map not loaded")
+	}
+	maps := make([]*mapmanager.Map, 0, len(mc.channels))
+	for _, ch := range mc.channels {
+		maps = append(maps, ch.gameMap)
+	}
+	s.mu.RUnlock()
+
+	// 获取各线路内所有实体并广播
+	for _, gameMap := range maps {
+		entities := gameMap.GetAllEntities()
+		recipients := make([]character.EntityID, 0, len(entities))
+		for _, e := range entities {
+			recipients = append(recipients, e.ID())
+		}
+		gameMap.BroadcastTo(recipients, "map_broadcast", message)
 	}
-	gameMap.BroadcastTo(recipients, "map_broadcast", message)
 
 	return nil
 }
 
+// BroadcastToWorld 向所有地图、所有线路中的玩家广播（全服公告）
+func (s *MapService) BroadcastToWorld(topic string, message interface{}) {
+	s.mu.RLock()
+	var maps []*mapmanager.Map
+	for _, mc := range s.maps {
+		for _, ch := range mc.channels {
+			maps = append(maps, ch.gameMap)
+		}
+	}
+	s.mu.RUnlock()
+
+	for _, gameMap := range maps {
+		var recipients []character.EntityID
+		for _, e := range gameMap.GetAllEntities() {
+			if e.Type() == character.EntityTypePlayer {
+				recipients = append(recipients, e.ID())
+			}
+		}
+		if len(recipients) > 0 {
+			gameMap.BroadcastTo(recipients, topic, message)
+		}
+	}
+}
+
 // BroadcastToRange 向范围内广播消息
 func (s *MapService) BroadcastToRange(ctx context.Context, mapID int32, x, y, z, range_ float32, message interface{}) error {
 	gameMap, err := s.GetMap(mapID)
@@ -209,13 +535,41 @@ func (s *MapService) BroadcastToRange(ctx context.Context, mapID int32, x, y, z,
 	return nil
 }
 
-// Tick 地图更新（供 UpdateManager 调用）
+// AckSnapshot 客户端确认收到某帧快照
+func (s *MapService) AckSnapshot(ctx context.Context, mapID int32, entityID int32, tick uint32) error {
+	gameMap, err := s.GetEntityMap(mapID, entityID)
+	if err != nil {
+		return err
+	}
+	gameMap.AckSnapshot(character.EntityID(entityID), tick)
+	return nil
+}
+
+// RequestFullSnapshot 客户端请求全量重同步（丢包、重连）
+func (s *MapService) RequestFullSnapshot(ctx context.Context, mapID int32, entityID int32) error {
+	gameMap, err := s.GetEntityMap(mapID, entityID)
+	if err != nil {
+		return err
+	}
+	gameMap.RequestFullSnapshot(character.EntityID(entityID))
+	return nil
+}
+
+// Tick 地图更新（供 UpdateManager 调用）：推进所有线路并回收空闲线路
 func (s *MapService) Tick(ctx context.Context, delta time.Duration) {
-	// 目前无需要逐帧更新的逻辑；保留入口以便未来接入地图定时器/效果
-	// 示例：遍历地图执行周期性任务
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	_ = delta
-	_ = ctx
-	// for _, m := range s.maps { /* m.DoPeriodicStuff() */ }
+	now := time.Now()
+	s.mu.Lock()
+	maps := make([]*mapmanager.Map, 0, len(s.maps))
+	for _, mc := range s.maps {
+		mc.retireIdle(now)
+		for _, ch := range mc.channels {
+			maps = append(maps, ch.gameMap)
+		}
+	}
+	s.mu.Unlock()
+
+	dt := float32(delta.Seconds())
+	for _, m := range maps {
+		_ = m.Update(ctx, dt)
+	}
 }
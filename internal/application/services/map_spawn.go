@@ -0,0 +1,196 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"greatestworks/internal/domain/character"
+	"greatestworks/internal/domain/mapmanager"
+	"greatestworks/internal/infrastructure/datamanager"
+)
+
+// spawnedEntityIDBase 刷出实体（怪物/NPC）的实体ID起始值，与玩家实体ID区分
+const spawnedEntityIDBase = int32(1 << 30)
+
+var spawnedEntitySeq atomic.Int32
+
+// nextSpawnedEntityID 分配刷出实体的实体ID
+func nextSpawnedEntityID() character.EntityID {
+	return character.EntityID(spawnedEntityIDBase + spawnedEntitySeq.Add(1))
+}
+
+// WorldEnvironment 世界环境（游戏内时间与天气），供刷怪条件判断
+type WorldEnvironment struct {
+	mu        sync.RWMutex
+	startedAt time.Time
+	dayLength time.Duration // 一个游戏日对应的现实时长
+	weather   string
+}
+
+// NewWorldEnvironment 创建世界环境
+func NewWorldEnvironment(dayLength time.Duration) *WorldEnvironment {
+	if dayLength <= 0 {
+		dayLength = 24 * time.Hour
+	}
+	return &WorldEnvironment{startedAt: time.Now(), dayLength: dayLength, weather: "clear"}
+}
+
+// Hour 当前游戏内小时
+func (w *WorldEnvironment) Hour() int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	elapsed := time.Since(w.startedAt) % w.dayLength
+	return int(elapsed * 24 / w.dayLength)
+}
+
+// Weather 当前天气
+func (w *WorldEnvironment) Weather() string {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.weather
+}
+
+// SetWeather 设置天气
+func (w *WorldEnvironment) SetWeather(weather string) {
+	w.mu.Lock()
+	w.weather = weather
+	w.mu.Unlock()
+}
+
+// newUnitActor 按单位配置创建怪物角色（刷怪工厂）
+func newUnitActor(unitID int32, position character.Vector3) *character.Actor {
+	unit := datamanager.GetInstance().GetUnit(unitID)
+	if unit == nil {
+		return nil
+	}
+	monster := character.NewMonster(nextSpawnedEntityID(), unitID, position, character.NewVector3(0, 0, 1), unit.Name, unit.Level, nil)
+	monster.GetAttributeManager().SetBase(UnitAttributes(unit))
+	LearnUnitSkills(monster.Actor)
+	if err := monster.Start(context.Background()); err != nil {
+		return nil
+	}
+	return monster.Actor
+}
+
+// UnitAttributes 单位配置转换为基础属性
+func UnitAttributes(unit *datamanager.UnitDefine) character.Attributes {
+	return character.Attributes{
+		MaxHP:       float32(unit.MaxHP),
+		MaxMP:       float32(unit.MaxMP),
+		AD:          float32(unit.AD),
+		AP:          float32(unit.AP),
+		Def:         float32(unit.DEF),
+		MDef:        float32(unit.RES),
+		Cri:         0.05,
+		Crd:         1.5,
+		HitRate:     0.9,
+		DodgeRate:   0.05,
+		Speed:       unit.MoveSpeed,
+		AttackSpeed: 1,
+	}
+}
+
+// UnitLevelGrowth 单位（玩家职业）提升 levels 级的基础属性成长，未配置成长时为零
+func UnitLevelGrowth(unit *datamanager.UnitDefine, levels int32) character.LevelGrowth {
+	if unit == nil || unit.Growth == nil || levels <= 0 {
+		return character.LevelGrowth{}
+	}
+	g, n := unit.Growth, float32(levels)
+	return character.LevelGrowth{
+		MaxHP: float32(g.MaxHP) * n,
+		MaxMP: float32(g.MaxMP) * n,
+		AD:    float32(g.AD) * n,
+		AP:    float32(g.AP) * n,
+		Def:   float32(g.DEF) * n,
+		MDef:  float32(g.RES) * n,
+	}
+}
+
+// spawnRegions 将地图配置转换为刷怪区域；简化的 monsters 配置视为覆盖全图的区域
+func spawnRegions(define *datamanager.MapDefine) []*mapmanager.SpawnRegion {
+	regions := make([]*mapmanager.SpawnRegion, 0, len(define.SpawnRegions)+len(define.Monsters))
+	for _, d := range define.SpawnRegions {
+		r := &mapmanager.SpawnRegion{
+			ID:          d.ID,
+			UnitID:      d.UnitID,
+			MinX:        d.MinX,
+			MinZ:        d.MinZ,
+			MaxX:        d.MaxX,
+			MaxZ:        d.MaxZ,
+			Y:           d.Y,
+			MaxCount:    d.MaxCount,
+			RespawnTime: d.RespawnTime,
+			StartHour:   d.StartHour,
+			EndHour:     d.EndHour,
+			Weathers:    d.Weathers,
+			LoopWaves:   d.LoopWaves,
+		}
+		for _, w := range d.Waves {
+			r.Waves = append(r.Waves, mapmanager.SpawnWave{Delay: w.Delay, Count: w.Count})
+		}
+		regions = append(regions, r)
+	}
+	for _, d := range define.Monsters {
+		regions = append(regions, &mapmanager.SpawnRegion{
+			ID:          d.ID,
+			UnitID:      d.ID,
+			MaxX:        float32(define.Width),
+			MaxZ:        float32(define.Height),
+			MaxCount:    d.SpawnCount,
+			RespawnTime: d.RespawnTime,
+		})
+	}
+	return regions
+}
+
+// populateMap 按配置为新建的地图实例摆放NPC、设置障碍物并配置刷怪
+func populateMap(gameMap *mapmanager.Map, define *datamanager.MapDefine, env mapmanager.SpawnEnvironment) {
+	for _, d := range define.NPCs {
+		name := ""
+		if unit := datamanager.GetInstance().GetUnit(d.ID); unit != nil {
+			name = unit.Name
+		}
+		npc := character.NewNPC(nextSpawnedEntityID(), d.ID, d.ID, character.NewVector3(d.X, d.Y, d.Z), character.NewVector3(0, 0, 1), name, nil)
+		_ = gameMap.Enter(context.Background(), npc.Entity)
+	}
+	if len(define.Obstacles) > 0 {
+		obstacles := make([]mapmanager.Obstacle, 0, len(define.Obstacles))
+		for _, o := range define.Obstacles {
+			obstacles = append(obstacles, mapmanager.Obstacle{MinX: o.MinX, MinZ: o.MinZ, MaxX: o.MaxX, MaxZ: o.MaxZ})
+		}
+		gameMap.SetObstacles(obstacles)
+	}
+	gameMap.SetGraveyards(graveyards(define))
+	gameMap.SetPvPRules(mapmanager.PvPMode(define.PvPMode), pvpZones(define))
+	if env != nil {
+		gameMap.SetSpawnEnvironment(env)
+	}
+	gameMap.SetSpawnRegions(spawnRegions(define), newUnitActor)
+}
+
+// pvpZones 地图 PvP 规则区域
+func pvpZones(define *datamanager.MapDefine) []mapmanager.PvPZone {
+	zones := make([]mapmanager.PvPZone, 0, len(define.PvPZones))
+	for _, z := range define.PvPZones {
+		zones = append(zones, mapmanager.PvPZone{ID: z.ID, Mode: mapmanager.PvPMode(z.Mode), MinX: z.MinX, MinZ: z.MinZ, MaxX: z.MaxX, MaxZ: z.MaxZ})
+	}
+	return zones
+}
+
+// graveyards 地图复活点：未配置复活点时退化为全部刷新点
+func graveyards(define *datamanager.MapDefine) []character.Vector3 {
+	var points, all []character.Vector3
+	for _, sp := range define.SpawnPoints {
+		pos := character.NewVector3(sp.X, sp.Y, sp.Z)
+		all = append(all, pos)
+		if sp.Type == datamanager.SpawnPointTypeGraveyard {
+			points = append(points, pos)
+		}
+	}
+	if len(points) == 0 {
+		return all
+	}
+	return points
+}
@@ -0,0 +1,291 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"greatestworks/internal/domain/character"
+	"greatestworks/internal/infrastructure/datamanager"
+)
+
+// 传送参数
+const (
+	transferTicketTTL = 30 * time.Second // 客户端加载确认超时
+)
+
+// 传送错误
+var (
+	ErrPortalLevelTooLow    = errors.New("portal level requirement not met")
+	ErrPortalQuestRequired  = errors.New("portal quest requirement not met")
+	ErrTransferInProgress   = errors.New("transfer in progress")
+	ErrTransferNotFound     = errors.New("transfer ticket not found")
+	ErrRemoteTransferNotSet = errors.New("remote transfer not configured")
+)
+
+// QuestChecker 任务完成情况查询（由 QuestService 实现）
+type QuestChecker interface {
+	IsQuestFinished(ctx context.Context, characterID int64, questID int32) (bool, error)
+}
+
+// RemoteTransfer 跨场景节点转移角色（由 RPC 层实现）
+// 目标节点收到后应调用 PortalService.AcceptRemoteTransfer
+type RemoteTransfer interface {
+	TransferOut(ctx context.Context, ticket *TransferTicket, actor *character.Actor) error
+}
+
+// TransferTicket 传送票据：触发传送门后生成，客户端加载完成确认后执行转移
+type TransferTicket struct {
+	ID        string
+	EntityID  int32
+	PortalID  int32
+	FromMapID int32
+	ToMapID   int32
+	Node      string // 目标场景节点（空表示本节点）
+	X, Y, Z   float32
+	CreatedAt time.Time
+}
+
+// TransferNotice 下发给客户端的传送通知
+type TransferNotice struct {
+	Phase    string  `json:"phase"` // begin: 开始加载; denied: 条件不满足
+	TicketID string  `json:"ticket_id,omitempty"`
+	PortalID int32   `json:"portal_id"`
+	MapID    int32   `json:"map_id,omitempty"`
+	Node     string  `json:"node,omitempty"`
+	X        float32 `json:"x,omitempty"`
+	Y        float32 `json:"y,omitempty"`
+	Z        float32 `json:"z,omitempty"`
+	Reason   string  `json:"reason,omitempty"`
+}
+
+// PortalService 传送门服务：区域触发检测、条件校验与带加载握手的地图转移
+type PortalService struct {
+	mu           sync.Mutex
+	mapService   *MapService
+	questChecker QuestChecker
+	remote       RemoteTransfer
+	localNode    string
+
+	pending    map[int32]*TransferTicket // 实体ID -> 待确认的传送票据
+	inside     map[int32]int32           // 实体ID -> 当前所处的传送门ID（仅在进入区域时触发）
+	nextTicket uint64
+}
+
+// NewPortalService 创建传送门服务
+func NewPortalService(mapService *MapService) *PortalService {
+	return &PortalService{
+		mapService: mapService,
+		pending:    make(map[int32]*TransferTicket),
+		inside:     make(map[int32]int32),
+	}
+}
+
+// SetQuestChecker 注入任务查询，用于任务前置的传送门
+func (s *PortalService) SetQuestChecker(qc QuestChecker) {
+	s.mu.Lock()
+	s.questChecker = qc
+	s.mu.Unlock()
+}
+
+// SetRemoteTransfer 注入跨节点转移实现及本节点标识
+func (s *PortalService) SetRemoteTransfer(remote RemoteTransfer, localNode string) {
+	s.mu.Lock()
+	s.remote = remote
+	s.localNode = localNode
+	s.mu.Unlock()
+}
+
+// IsTransferring 实体是否正在等待传送确认（期间忽略移动）
+func (s *PortalService) IsTransferring(entityID int32) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pendingLocked(entityID) != nil
+}
+
+// pendingLocked 获取未过期的票据，过期票据直接丢弃（调用方持有锁）
+func (s *PortalService) pendingLocked(entityID int32) *TransferTicket {
+	ticket, ok := s.pending[entityID]
+	if !ok {
+		return nil
+	}
+	if time.Since(ticket.CreatedAt) > transferTicketTTL {
+		delete(s.pending, entityID)
+		return nil
+	}
+	return ticket
+}
+
+// CheckPortals 在实体移动后检测是否进入传送门区域；满足条件时生成票据并通知客户端开始加载
+func (s *PortalService) CheckPortals(ctx context.Context, mapID int32, entityID int32) (*TransferTicket, error) {
+	mapDefine := datamanager.GetInstance().GetMap(mapID)
+	if mapDefine == nil || len(mapDefine.Portals) == 0 {
+		return nil, nil
+	}
+	gameMap, err := s.mapService.GetEntityMap(mapID, entityID)
+	if err != nil {
+		return nil, err
+	}
+	actor := gameMap.GetActor(character.EntityID(entityID))
+	if actor == nil {
+		return nil, nil
+	}
+
+	pos := actor.Position2D()
+	var portal *datamanager.PortalDefine
+	for i := range mapDefine.Portals {
+		p := &mapDefine.Portals[i]
+		dx, dz := pos.X-p.X, pos.Y-p.Z
+		if dx*dx+dz*dz <= p.Radius*p.Radius {
+			portal = p
+			break
+		}
+	}
+
+	s.mu.Lock()
+	if portal == nil {
+		delete(s.inside, entityID)
+		s.mu.Unlock()
+		return nil, nil
+	}
+	if s.inside[entityID] == portal.ID || s.pendingLocked(entityID) != nil {
+		// 仍停留在同一传送门内或已在传送中，不重复触发
+		s.mu.Unlock()
+		return nil, nil
+	}
+	s.inside[entityID] = portal.ID
+	questChecker := s.questChecker
+	s.mu.Unlock()
+
+	notice := TransferNotice{PortalID: portal.ID}
+	if err := checkPortalRequirements(ctx, portal, actor, questChecker); err != nil {
+		notice.Phase = "denied"
+		notice.Reason = err.Error()
+		gameMap.BroadcastTo([]character.EntityID{actor.ID()}, "map_transfer", notice)
+		return nil, err
+	}
+
+	ticket := &TransferTicket{
+		EntityID:  entityID,
+		PortalID:  portal.ID,
+		FromMapID: mapID,
+		ToMapID:   portal.TargetMapID,
+		X:         portal.TargetX,
+		Y:         portal.TargetY,
+		Z:         portal.TargetZ,
+		CreatedAt: time.Now(),
+	}
+	if target := datamanager.GetInstance().GetMap(portal.TargetMapID); target != nil {
+		ticket.Node = target.Node
+	}
+
+	s.mu.Lock()
+	if s.localNode != "" && ticket.Node == s.localNode {
+		ticket.Node = ""
+	}
+	s.nextTicket++
+	ticket.ID = fmt.Sprintf("%d-%d", entityID, s.nextTicket)
+	s.pending[entityID] = ticket
+	s.mu.Unlock()
+
+	notice.Phase = "begin"
+	notice.TicketID = ticket.ID
+	notice.MapID = ticket.ToMapID
+	notice.Node = ticket.Node
+	notice.X, notice.Y, notice.Z = ticket.X, ticket.Y, ticket.Z
+	gameMap.BroadcastTo([]character.EntityID{actor.ID()}, "map_transfer", notice)
+	return ticket, nil
+}
+
+// checkPortalRequirements 校验等级与任务前置
+func checkPortalRequirements(ctx context.Context, portal *datamanager.PortalDefine, actor *character.Actor, questChecker QuestChecker) error {
+	if portal.RequiredLevel > 0 && actor.Level() < portal.RequiredLevel {
+		return ErrPortalLevelTooLow
+	}
+	if portal.RequiredQuestID > 0 {
+		if questChecker == nil {
+			return ErrPortalQuestRequired
+		}
+		done, err := questChecker.IsQuestFinished(ctx, int64(actor.ID()), portal.RequiredQuestID)
+		if err != nil {
+			return err
+		}
+		if !done {
+			return ErrPortalQuestRequired
+		}
+	}
+	return nil
+}
+
+// ConfirmTransfer 客户端加载完成后确认传送，执行原子转移；跨节点时交由 RemoteTransfer 处理
+func (s *PortalService) ConfirmTransfer(ctx context.Context, entityID int32, ticketID string) (*TransferTicket, error) {
+	s.mu.Lock()
+	ticket := s.pendingLocked(entityID)
+	if ticket == nil || ticket.ID != ticketID {
+		s.mu.Unlock()
+		return nil, ErrTransferNotFound
+	}
+	delete(s.pending, entityID)
+	remote := s.remote
+	s.mu.Unlock()
+
+	gameMap, err := s.mapService.GetEntityMap(ticket.FromMapID, entityID)
+	if err != nil {
+		return nil, err
+	}
+	actor := gameMap.GetActor(character.EntityID(entityID))
+	if actor == nil {
+		return nil, errors.New("entity not in map")
+	}
+
+	if ticket.Node == "" {
+		if err := s.mapService.TransferActor(ctx, actor, ticket.FromMapID, ticket.ToMapID, ticket.X, ticket.Y, ticket.Z); err != nil {
+			return nil, err
+		}
+		s.clearInside(entityID)
+		return ticket, nil
+	}
+
+	// 跨节点：先离开本节点地图，目标节点接收失败时回到原位置
+	if remote == nil {
+		return nil, ErrRemoteTransferNotSet
+	}
+	pos := actor.Position()
+	if err := s.mapService.LeaveMapByID(ctx, ticket.FromMapID, entityID); err != nil {
+		return nil, err
+	}
+	if err := remote.TransferOut(ctx, ticket, actor); err != nil {
+		_ = s.mapService.EnterMapActor(ctx, actor, ticket.FromMapID, pos.X, pos.Y, pos.Z)
+		return nil, err
+	}
+	s.clearInside(entityID)
+	return ticket, nil
+}
+
+// CancelTransfer 取消待确认的传送（客户端取消或加载失败）
+func (s *PortalService) CancelTransfer(entityID int32) {
+	s.mu.Lock()
+	delete(s.pending, entityID)
+	s.mu.Unlock()
+}
+
+// AcceptRemoteTransfer 目标节点接收跨节点转移过来的角色
+func (s *PortalService) AcceptRemoteTransfer(ctx context.Context, ticket *TransferTicket, actor *character.Actor) error {
+	return s.mapService.EnterMapActor(ctx, actor, ticket.ToMapID, ticket.X, ticket.Y, ticket.Z)
+}
+
+// Forget 实体下线时清理传送状态
+func (s *PortalService) Forget(entityID int32) {
+	s.mu.Lock()
+	delete(s.pending, entityID)
+	delete(s.inside, entityID)
+	s.mu.Unlock()
+}
+
+func (s *PortalService) clearInside(entityID int32) {
+	s.mu.Lock()
+	delete(s.inside, entityID)
+	s.mu.Unlock()
+}
@@ -0,0 +1,243 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"greatestworks/internal/domain/character"
+)
+
+// configs/data 中的传送门：新手村 101（950,500，需 5 级）-> 黑暗森林；黑暗森林 202（1950,1950，需 20 级与任务 1001）-> Boss 竞技场
+const (
+	testVillageMapID = int32(1)
+	testForestMapID  = int32(2)
+)
+
+type stubQuestChecker struct{ done bool }
+
+func (q stubQuestChecker) IsQuestFinished(context.Context, int64, int32) (bool, error) {
+	return q.done, nil
+}
+
+type stubRemoteTransfer struct {
+	err     error
+	tickets []*TransferTicket
+}
+
+func (r *stubRemoteTransfer) TransferOut(_ context.Context, ticket *TransferTicket, _ *character.Actor) error {
+	r.tickets = append(r.tickets, ticket)
+	return r.err
+}
+
+// transferNotices 记录下发给客户端的传送通知
+type transferNotices struct {
+	mu   sync.Mutex
+	list []TransferNotice
+}
+
+func (n *transferNotices) broadcast(_ []character.EntityID, topic string, payload interface{}) {
+	if notice, ok := payload.(TransferNotice); ok && topic == "map_transfer" {
+		n.mu.Lock()
+		n.list = append(n.list, notice)
+		n.mu.Unlock()
+	}
+}
+
+func (n *transferNotices) last() TransferNotice {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if len(n.list) == 0 {
+		return TransferNotice{}
+	}
+	return n.list[len(n.list)-1]
+}
+
+// newPortalTest 角色在 mapID 的 (x,z) 处进入地图
+func newPortalTest(t *testing.T, mapID int32, x, z float32, level int32) (*PortalService, *MapService, *transferNotices) {
+	t.Helper()
+	notices := &transferNotices{}
+	ms := NewMapService()
+	ms.SetBroadcaster(notices.broadcast)
+	if err := ms.EnterMapActor(context.Background(), newTestPlayer(t, 1, level), mapID, x, 0, z); err != nil {
+		t.Fatalf("enter: %v", err)
+	}
+	return NewPortalService(ms), ms, notices
+}
+
+func TestPortalLevelGate(t *testing.T) {
+	ctx := context.Background()
+	ps, _, notices := newPortalTest(t, testVillageMapID, 950, 500, 1)
+
+	ticket, err := ps.CheckPortals(ctx, testVillageMapID, 1)
+	if !errors.Is(err, ErrPortalLevelTooLow) || ticket != nil {
+		t.Fatalf("ticket %v err %v, want ErrPortalLevelTooLow", ticket, err)
+	}
+	if n := notices.last(); n.Phase != "denied" || n.PortalID != 101 {
+		t.Fatalf("expected denied notice for portal 101, got %+v", n)
+	}
+	if ps.IsTransferring(1) {
+		t.Fatalf("denied portal must not leave a pending ticket")
+	}
+	// 停留在区域内不重复触发
+	if _, err := ps.CheckPortals(ctx, testVillageMapID, 1); err != nil {
+		t.Fatalf("staying inside the portal retriggered: %v", err)
+	}
+}
+
+func TestPortalQuestGate(t *testing.T) {
+	tests := []struct {
+		name    string
+		checker QuestChecker
+		want    error
+	}{
+		{"no quest checker", nil, ErrPortalQuestRequired},
+		{"quest unfinished", stubQuestChecker{done: false}, ErrPortalQuestRequired},
+		{"quest finished", stubQuestChecker{done: true}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ps, _, _ := newPortalTest(t, testForestMapID, 1950, 1950, 20)
+			if tt.checker != nil {
+				ps.SetQuestChecker(tt.checker)
+			}
+			ticket, err := ps.CheckPortals(context.Background(), testForestMapID, 1)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("err %v, want %v", err, tt.want)
+			}
+			if (ticket != nil) != (tt.want == nil) {
+				t.Fatalf("ticket %v with err %v", ticket, err)
+			}
+		})
+	}
+}
+
+func TestPortalLoadingHandshake(t *testing.T) {
+	ctx := context.Background()
+	ps, ms, notices := newPortalTest(t, testVillageMapID, 950, 500, 5)
+
+	ticket, err := ps.CheckPortals(ctx, testVillageMapID, 1)
+	if err != nil || ticket == nil {
+		t.Fatalf("check: ticket %v err %v", ticket, err)
+	}
+	if n := notices.last(); n.Phase != "begin" || n.TicketID != ticket.ID || n.MapID != testForestMapID {
+		t.Fatalf("unexpected begin notice %+v", n)
+	}
+	if !ps.IsTransferring(1) {
+		t.Fatalf("entity should be transferring until the client confirms")
+	}
+	// 确认前仍在原地图
+	if mapID, _, _ := ms.ChannelOf(1); mapID != testVillageMapID {
+		t.Fatalf("moved to map %d before confirmation", mapID)
+	}
+
+	if _, err := ps.ConfirmTransfer(ctx, 1, "wrong"); !errors.Is(err, ErrTransferNotFound) {
+		t.Fatalf("wrong ticket: err %v", err)
+	}
+	if _, err := ps.ConfirmTransfer(ctx, 1, ticket.ID); err != nil {
+		t.Fatalf("confirm: %v", err)
+	}
+	gameMap, actor, err := ms.LocateActor(1)
+	if err != nil || gameMap.ID() != testForestMapID {
+		t.Fatalf("entity not in the target map: %v", err)
+	}
+	if pos := actor.Position(); pos.X != ticket.X || pos.Z != ticket.Z {
+		t.Fatalf("position %+v, want portal target (%v,%v)", pos, ticket.X, ticket.Z)
+	}
+	village, _ := ms.GetMap(testVillageMapID)
+	if village.GetActor(1) != nil {
+		t.Fatalf("actor still in the source map")
+	}
+	if ps.IsTransferring(1) {
+		t.Fatalf("ticket should be consumed")
+	}
+	if _, err := ps.ConfirmTransfer(ctx, 1, ticket.ID); !errors.Is(err, ErrTransferNotFound) {
+		t.Fatalf("confirming twice: err %v", err)
+	}
+}
+
+func TestPortalTicketExpiresAndCancels(t *testing.T) {
+	ctx := context.Background()
+	ps, _, _ := newPortalTest(t, testVillageMapID, 950, 500, 5)
+	ticket, err := ps.CheckPortals(ctx, testVillageMapID, 1)
+	if err != nil {
+		t.Fatalf("check: %v", err)
+	}
+	ps.mu.Lock()
+	ticket.CreatedAt = time.Now().Add(-transferTicketTTL - time.Second)
+	ps.mu.Unlock()
+	if ps.IsTransferring(1) {
+		t.Fatalf("expired ticket still pending")
+	}
+	if _, err := ps.ConfirmTransfer(ctx, 1, ticket.ID); !errors.Is(err, ErrTransferNotFound) {
+		t.Fatalf("confirm expired: err %v", err)
+	}
+
+	// 离开区域后再次进入重新触发，取消后票据失效
+	ps.clearInside(1)
+	ticket, err = ps.CheckPortals(ctx, testVillageMapID, 1)
+	if err != nil || ticket == nil {
+		t.Fatalf("retrigger: ticket %v err %v", ticket, err)
+	}
+	ps.CancelTransfer(1)
+	if _, err := ps.ConfirmTransfer(ctx, 1, ticket.ID); !errors.Is(err, ErrTransferNotFound) {
+		t.Fatalf("confirm cancelled: err %v", err)
+	}
+}
+
+func TestPortalRemoteTransfer(t *testing.T) {
+	ctx := context.Background()
+	pending := func(ps *PortalService) *TransferTicket {
+		ticket := &TransferTicket{ID: "1-1", EntityID: 1, PortalID: 101, FromMapID: testVillageMapID, ToMapID: testForestMapID, Node: "scene-2", X: 100, Z: 100, CreatedAt: time.Now()}
+		ps.mu.Lock()
+		ps.pending[1] = ticket
+		ps.mu.Unlock()
+		return ticket
+	}
+
+	t.Run("not configured", func(t *testing.T) {
+		ps, ms, _ := newPortalTest(t, testVillageMapID, 950, 500, 5)
+		ticket := pending(ps)
+		if _, err := ps.ConfirmTransfer(ctx, 1, ticket.ID); !errors.Is(err, ErrRemoteTransferNotSet) {
+			t.Fatalf("err %v, want ErrRemoteTransferNotSet", err)
+		}
+		if mapID, _, ok := ms.ChannelOf(1); !ok || mapID != testVillageMapID {
+			t.Fatalf("entity should stay in the source map")
+		}
+	})
+
+	t.Run("hands the actor to the remote node", func(t *testing.T) {
+		ps, ms, _ := newPortalTest(t, testVillageMapID, 950, 500, 5)
+		remote := &stubRemoteTransfer{}
+		ps.SetRemoteTransfer(remote, "scene-1")
+		ticket := pending(ps)
+		if _, err := ps.ConfirmTransfer(ctx, 1, ticket.ID); err != nil {
+			t.Fatalf("confirm: %v", err)
+		}
+		if len(remote.tickets) != 1 || remote.tickets[0] != ticket {
+			t.Fatalf("remote transfer not called with the ticket")
+		}
+		village, _ := ms.GetMap(testVillageMapID)
+		if village.GetActor(1) != nil {
+			t.Fatalf("actor should have left the local map")
+		}
+	})
+
+	t.Run("remote failure returns the actor", func(t *testing.T) {
+		ps, ms, _ := newPortalTest(t, testVillageMapID, 950, 500, 5)
+		ps.SetRemoteTransfer(&stubRemoteTransfer{err: errors.New("node down")}, "scene-1")
+		ticket := pending(ps)
+		if _, err := ps.ConfirmTransfer(ctx, 1, ticket.ID); err == nil {
+			t.Fatalf("expected remote error")
+		}
+		gameMap, actor, err := ms.LocateActor(1)
+		if err != nil || gameMap.ID() != testVillageMapID {
+			t.Fatalf("actor not restored to the source map: %v", err)
+		}
+		if pos := actor.Position(); pos.X != 950 || pos.Z != 500 {
+			t.Fatalf("actor restored at %+v, want its old position", pos)
+		}
+	})
+}
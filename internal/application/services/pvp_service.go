@@ -0,0 +1,178 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"greatestworks/internal/domain/character"
+	"greatestworks/internal/domain/mapmanager"
+	"greatestworks/internal/infrastructure/persistence"
+)
+
+// PvP 错误
+var (
+	ErrPvPFlagCooldown = errors.New("pvp flag toggled too recently")
+	ErrPvPNotAllowed   = errors.New("pvp not allowed against this target")
+	ErrDuelNotSameMap  = errors.New("duel target not in the same map")
+)
+
+// PvPConfig PvP 规则
+type PvPConfig struct {
+	FlagCooldown time.Duration // 两次切换 PvP 标记的最小间隔
+}
+
+// PvPRecordStore PvP 战绩记录（由 PvPRecordRepository 实现）
+type PvPRecordStore interface {
+	Save(ctx context.Context, doc *persistence.PvPRecordDocument) error
+}
+
+// PvPFlagResult PvP 标记切换结果
+type PvPFlagResult struct {
+	Enabled    bool
+	CooldownIn time.Duration // 距下次可切换的时间
+}
+
+// PvPService PvP 服务：切换 PvP 标记、决斗邀请/响应/认输，记录玩家击杀与决斗结果
+type PvPService struct {
+	mu         sync.Mutex
+	config     PvPConfig
+	mapService *MapService
+	store      PvPRecordStore
+	toggledAt  map[int32]time.Time // 实体ID -> 最近一次切换标记的时间
+}
+
+// NewPvPService 创建 PvP 服务
+func NewPvPService(config PvPConfig, mapService *MapService, store PvPRecordStore) *PvPService {
+	return &PvPService{
+		config:     config,
+		mapService: mapService,
+		store:      store,
+		toggledAt:  make(map[int32]time.Time),
+	}
+}
+
+// SetPvPFlag 开启/关闭 PvP 标记（状态未变化时不计入冷却）
+func (s *PvPService) SetPvPFlag(ctx context.Context, entityID int32, enabled bool) (*PvPFlagResult, error) {
+	_, actor, err := s.mapService.LocateActor(entityID)
+	if err != nil {
+		return nil, err
+	}
+	if actor.PvPFlag() == enabled {
+		return &PvPFlagResult{Enabled: enabled, CooldownIn: s.cooldownIn(entityID, time.Now())}, nil
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	if remain := s.cooldownLocked(entityID, now); remain > 0 {
+		s.mu.Unlock()
+		return &PvPFlagResult{Enabled: actor.PvPFlag(), CooldownIn: remain}, ErrPvPFlagCooldown
+	}
+	s.toggledAt[entityID] = now
+	// 清理已过冷却的记录（下线不清理，防止重新登录绕过冷却）
+	for id, at := range s.toggledAt {
+		if now.Sub(at) >= s.config.FlagCooldown {
+			delete(s.toggledAt, id)
+		}
+	}
+	s.mu.Unlock()
+
+	actor.SetPvPFlag(enabled)
+	return &PvPFlagResult{Enabled: enabled, CooldownIn: s.config.FlagCooldown}, nil
+}
+
+// cooldownIn 距下次可切换标记的时间
+func (s *PvPService) cooldownIn(entityID int32, now time.Time) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cooldownLocked(entityID, now)
+}
+
+// cooldownLocked 距下次可切换标记的时间（调用方持有锁）
+func (s *PvPService) cooldownLocked(entityID int32, now time.Time) time.Duration {
+	last, ok := s.toggledAt[entityID]
+	if !ok {
+		return 0
+	}
+	return max(s.config.FlagCooldown-now.Sub(last), 0)
+}
+
+// RequestDuel 向同一地图线路内附近的玩家发起决斗
+func (s *PvPService) RequestDuel(ctx context.Context, entityID, targetID int32) (*mapmanager.DuelNotice, error) {
+	gameMap, _, err := s.mapService.LocateActor(entityID)
+	if err != nil {
+		return nil, err
+	}
+	if gameMap.GetActor(character.EntityID(targetID)) == nil {
+		return nil, ErrDuelNotSameMap
+	}
+	return gameMap.RequestDuel(character.EntityID(entityID), character.EntityID(targetID))
+}
+
+// RespondDuel 接受或拒绝决斗邀请
+func (s *PvPService) RespondDuel(ctx context.Context, entityID, duelID int32, accept bool) (*mapmanager.DuelNotice, error) {
+	gameMap, _, err := s.mapService.LocateActor(entityID)
+	if err != nil {
+		return nil, err
+	}
+	return gameMap.RespondDuel(character.EntityID(entityID), duelID, accept)
+}
+
+// ForfeitDuel 认输或取消决斗
+func (s *PvPService) ForfeitDuel(ctx context.Context, entityID int32) (*mapmanager.DuelNotice, error) {
+	gameMap, _, err := s.mapService.LocateActor(entityID)
+	if err != nil {
+		return nil, err
+	}
+	return gameMap.ForfeitDuel(character.EntityID(entityID))
+}
+
+// OnActorDeath 地图死亡回调：玩家被其他玩家击杀时记录 PvP 击杀
+func (s *PvPService) OnActorDeath(ctx context.Context, gameMap *mapmanager.Map, actor *character.Actor) {
+	if actor.Type() != character.EntityTypePlayer {
+		return
+	}
+	src := actor.DamageSource()
+	if src == nil || src.AttackerInfo.AttackerID == 0 || src.AttackerInfo.AttackerID == actor.ID() {
+		return
+	}
+	killer := gameMap.GetActor(src.AttackerInfo.AttackerID)
+	if killer == nil || killer.Type() != character.EntityTypePlayer {
+		return
+	}
+	// 实体ID即角色ID（与登录绑定一致）
+	s.record(ctx, &persistence.PvPRecordDocument{
+		Kind:     persistence.PvPRecordKill,
+		WinnerID: int64(killer.ID()),
+		LoserID:  int64(actor.ID()),
+		MapID:    gameMap.ID(),
+		Mode:     int32(gameMap.PvPModeAt(actor.Position())),
+		SkillID:  src.AttackerInfo.SkillID,
+	})
+}
+
+// OnDuelEnd 决斗结束回调：记录决斗结果（平局记录双方）
+func (s *PvPService) OnDuelEnd(ctx context.Context, gameMap *mapmanager.Map, duel *mapmanager.Duel) {
+	doc := &persistence.PvPRecordDocument{
+		Kind:     persistence.PvPRecordDuel,
+		WinnerID: int64(duel.WinnerID),
+		LoserID:  int64(duel.LoserID),
+		MapID:    gameMap.ID(),
+		Mode:     int32(gameMap.PvPModeAt(duel.Center)),
+		Reason:   int32(duel.Reason),
+	}
+	if duel.WinnerID == 0 {
+		doc.WinnerID, doc.LoserID, doc.Draw = int64(duel.ChallengerID), int64(duel.TargetID), true
+	}
+	s.record(ctx, doc)
+}
+
+// record 保存战绩（未配置存储时忽略）
+func (s *PvPService) record(ctx context.Context, doc *persistence.PvPRecordDocument) {
+	if s.store == nil {
+		return
+	}
+	doc.CreatedAt = time.Now()
+	_ = s.store.Save(ctx, doc)
+}
@@ -63,7 +63,21 @@ func (s *QuestService) GetQuests(ctx context.Context, characterID int64) ([]*per
 	return s.questRepo.FindByCharacterID(ctx, characterID)
 }
 
-// UpdateObjective 更新任务目标
+// IsQuestFinished 任务是否已完成（已完成或已领奖）
+func (s *QuestService) IsQuestFinished(ctx context.Context, characterID int64, questID int32) (bool, error) {
+	quests, err := s.questRepo.FindByCharacterID(ctx, characterID)
+	if err != nil {
+		return false, err
+	}
+	for _, q := range quests {
+		if q.QuestID == questID && (q.Status == 1 || q.Status == 2) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// UpdateObjective 更新任务目标；questID 为 0 时更新所有进行中任务里匹配的目标（没有匹配的目标时不报错）
 func (s *QuestService) UpdateObjective(ctx context.Context, characterID int64, questID, objType, targetID, progress int32) error {
 	quests, err := s.questRepo.FindByCharacterID(ctx, characterID)
 	if err != nil {
@@ -71,49 +85,61 @@ func (s *QuestService) UpdateObjective(ctx context.Context, characterID int64, q
 	}
 
 	// 查找对应任务
-	var targetQuest *persistence.DbQuest
+	var targets []*persistence.DbQuest
 	for _, q := range quests {
-		if q.QuestID == questID && q.Status == 0 {
-			targetQuest = q
-			break
+		if (questID == 0 || q.QuestID == questID) && q.Status == 0 {
+			targets = append(targets, q)
+			if questID != 0 {
+				break
+			}
 		}
 	}
 
-	if targetQuest == nil {
+	if questID != 0 && len(targets) == 0 {
 		return errors.New("quest not found or already completed")
 	}
 
-	// 更新目标进度
-	updated := false
-	for i := range targetQuest.Objectives {
-		obj := &targetQuest.Objectives[i]
-		if obj.Type == objType && obj.TargetID == targetID {
-			obj.Current += progress
-			if obj.Current > obj.Required {
-				obj.Current = obj.Required
+	found := false
+	for _, targetQuest := range targets {
+		// 更新目标进度
+		updated := false
+		for i := range targetQuest.Objectives {
+			obj := &targetQuest.Objectives[i]
+			if obj.Type == objType && obj.TargetID == targetID {
+				obj.Current += progress
+				if obj.Current > obj.Required {
+					obj.Current = obj.Required
+				}
+				updated = true
+			}
+		}
+		if !updated {
+			continue
+		}
+		found = true
+
+		// 检查是否完成
+		allComplete := true
+		for _, obj := range targetQuest.Objectives {
+			if obj.Current < obj.Required {
+				allComplete = false
+				break
 			}
-			updated = true
 		}
-	}
 
-	if !updated {
-		return errors.New("objective not found")
-	}
+		if allComplete {
+			targetQuest.Status = 1 // 已完成
+		}
 
-	// 检查是否完成
-	allComplete := true
-	for _, obj := range targetQuest.Objectives {
-		if obj.Current < obj.Required {
-			allComplete = false
-			break
+		if err := s.questRepo.Update(ctx, targetQuest); err != nil {
+			return err
 		}
 	}
 
-	if allComplete {
-		targetQuest.Status = 1 // 已完成
+	if questID != 0 && !found {
+		return errors.New("objective not found")
 	}
-
-	return s.questRepo.Update(ctx, targetQuest)
+	return nil
 }
 
 // SubmitQuest 提交任务
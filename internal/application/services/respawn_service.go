@@ -0,0 +1,335 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"greatestworks/internal/domain/character"
+	"greatestworks/internal/domain/inventory"
+	"greatestworks/internal/domain/mapmanager"
+	"greatestworks/internal/infrastructure/persistence"
+)
+
+// RespawnOption 复活方式
+type RespawnOption int32
+
+const (
+	RespawnAtGraveyard RespawnOption = 1 // 最近复活点复活（需等待释放时间，扣除经验）
+	RespawnInPlace     RespawnOption = 2 // 消耗复活道具原地复活
+	RespawnAccept      RespawnOption = 3 // 接受其他玩家的复活技能（扣除少量经验）
+)
+
+// 复活错误
+var (
+	ErrNotDead              = errors.New("character is not dead")
+	ErrReleaseNotReady      = errors.New("release delay not elapsed")
+	ErrNoResurrectOffer     = errors.New("no pending resurrect offer")
+	ErrInvalidRespawnOption = errors.New("invalid respawn option")
+)
+
+// RespawnConfig 死亡与复活规则
+type RespawnConfig struct {
+	ReleaseDelay        time.Duration // 死亡后可回复活点复活的等待时间
+	AutoRelease         time.Duration // 超时自动回复活点复活（0 表示不自动）
+	ResurrectTimeout    time.Duration // 他人复活邀请有效期
+	ResurrectItemID     int32         // 原地复活消耗的道具（0 表示不可原地复活）
+	RespawnHealth       float32       // 复活点/道具复活后的生命与魔法比例
+	ExpPenalty          float64       // 复活点复活扣除当前等级升级经验的比例
+	ResurrectExpPenalty float64       // 接受他人复活扣除当前等级升级经验的比例
+}
+
+// RespawnStore 死亡状态持久化（由 CharacterService 实现）
+type RespawnStore interface {
+	RecordDeath(ctx context.Context, characterID int64, deadAt time.Time) error
+	RecordRespawn(ctx context.Context, characterID int64, expPenalty float64, hp, mp int32) (int64, error)
+}
+
+// RespawnInventory 复活道具扣除（由 ItemService 实现）
+type RespawnInventory interface {
+	Modify(ctx context.Context, characterID int64, fn func(tx *InventoryTx) error) error
+}
+
+// RespawnNotice 下发给死亡玩家的复活通知
+type RespawnNotice struct {
+	Phase         string        `json:"phase"` // dead: 死亡; offer: 收到复活邀请; respawned: 已复活
+	KillerID      int32         `json:"killer_id,omitempty"`
+	ReleaseIn     float32       `json:"release_in,omitempty"`      // 距可回复活点复活的秒数
+	AutoReleaseIn float32       `json:"auto_release_in,omitempty"` // 距自动复活的秒数
+	ItemID        int32         `json:"item_id,omitempty"`         // 原地复活所需道具
+	CasterID      int32         `json:"caster_id,omitempty"`
+	HPRatio       float32       `json:"hp_ratio,omitempty"`
+	ExpiresIn     float32       `json:"expires_in,omitempty"` // 复活邀请剩余秒数
+	Option        RespawnOption `json:"option,omitempty"`
+	ExpLost       int64         `json:"exp_lost,omitempty"`
+}
+
+// RespawnResult 复活结果
+type RespawnResult struct {
+	Option   RespawnOption
+	Position character.Vector3
+	HP       float32
+	ExpLost  int64
+}
+
+// resurrectOffer 他人发起的复活邀请
+type resurrectOffer struct {
+	casterID int32
+	hpRatio  float32
+	expireAt time.Time
+}
+
+// deathRecord 死亡中的玩家
+type deathRecord struct {
+	characterID int64
+	deadAt      time.Time
+	offer       *resurrectOffer
+}
+
+// RespawnService 复活服务：记录玩家死亡状态，提供复活点、道具原地与接受他人复活三种复活方式及超时自动复活
+type RespawnService struct {
+	mu         sync.Mutex
+	config     RespawnConfig
+	mapService *MapService
+	store      RespawnStore
+	inventory  RespawnInventory
+	dead       map[int32]*deathRecord // 实体ID -> 死亡记录
+}
+
+// NewRespawnService 创建复活服务
+func NewRespawnService(config RespawnConfig, mapService *MapService, store RespawnStore, inventory RespawnInventory) *RespawnService {
+	return &RespawnService{
+		config:     config,
+		mapService: mapService,
+		store:      store,
+		inventory:  inventory,
+		dead:       make(map[int32]*deathRecord),
+	}
+}
+
+// OnActorDeath 地图死亡回调：记录并持久化玩家死亡，通知其复活选项与计时
+func (s *RespawnService) OnActorDeath(ctx context.Context, gameMap *mapmanager.Map, actor *character.Actor) {
+	if actor.Type() != character.EntityTypePlayer {
+		return
+	}
+	entityID := int32(actor.ID())
+	s.mu.Lock()
+	record, restored := s.dead[entityID]
+	if !restored {
+		// 实体ID即角色ID（与登录绑定一致）
+		record = &deathRecord{characterID: int64(entityID), deadAt: time.Now()}
+		s.dead[entityID] = record
+	}
+	s.mu.Unlock()
+
+	if !restored && s.store != nil {
+		_ = s.store.RecordDeath(ctx, record.characterID, record.deadAt)
+	}
+	notice := s.deathNotice(record)
+	if src := actor.DamageSource(); src != nil {
+		notice.KillerID = int32(src.AttackerInfo.AttackerID)
+	}
+	gameMap.BroadcastTo([]character.EntityID{actor.ID()}, "player_respawn", notice)
+}
+
+// RestoreDeath 死亡中下线的玩家重新上线时恢复死亡记录（需在进入地图前调用，计时从原死亡时间继续）
+func (s *RespawnService) RestoreDeath(entityID int32, characterID int64, deadAt time.Time) {
+	s.mu.Lock()
+	s.dead[entityID] = &deathRecord{characterID: characterID, deadAt: deadAt}
+	s.mu.Unlock()
+}
+
+// OnResurrectOffer 地图复活邀请回调：记录邀请（覆盖旧邀请）并通知死亡玩家
+func (s *RespawnService) OnResurrectOffer(ctx context.Context, gameMap *mapmanager.Map, caster, target *character.Actor, hpRatio float32) {
+	entityID := int32(target.ID())
+	s.mu.Lock()
+	record, ok := s.dead[entityID]
+	if ok {
+		record.offer = &resurrectOffer{
+			casterID: int32(caster.ID()),
+			hpRatio:  hpRatio,
+			expireAt: time.Now().Add(s.config.ResurrectTimeout),
+		}
+	}
+	s.mu.Unlock()
+	if !ok {
+		return
+	}
+	gameMap.BroadcastTo([]character.EntityID{target.ID()}, "player_respawn", &RespawnNotice{
+		Phase:     "offer",
+		CasterID:  int32(caster.ID()),
+		HPRatio:   hpRatio,
+		ExpiresIn: float32(s.config.ResurrectTimeout.Seconds()),
+	})
+}
+
+// Respawn 按选择的方式复活死亡玩家
+func (s *RespawnService) Respawn(ctx context.Context, entityID int32, option RespawnOption) (*RespawnResult, error) {
+	gameMap, actor, err := s.mapService.LocateActor(entityID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	record, ok := s.dead[entityID]
+	if !ok || !actor.IsDeath() {
+		s.mu.Unlock()
+		return nil, ErrNotDead
+	}
+	hpRatio, penalty := s.config.RespawnHealth, 0.0
+	position := actor.Position()
+	switch option {
+	case RespawnAtGraveyard:
+		if time.Since(record.deadAt) < s.config.ReleaseDelay {
+			s.mu.Unlock()
+			return nil, ErrReleaseNotReady
+		}
+		if p, ok := gameMap.NearestGraveyard(position); ok {
+			position = p
+		}
+		penalty = s.config.ExpPenalty
+	case RespawnInPlace:
+		if s.config.ResurrectItemID == 0 || s.inventory == nil {
+			s.mu.Unlock()
+			return nil, ErrInvalidRespawnOption
+		}
+	case RespawnAccept:
+		if record.offer == nil || time.Now().After(record.offer.expireAt) {
+			record.offer = nil
+			s.mu.Unlock()
+			return nil, ErrNoResurrectOffer
+		}
+		hpRatio, penalty = record.offer.hpRatio, s.config.ResurrectExpPenalty
+	default:
+		s.mu.Unlock()
+		return nil, ErrInvalidRespawnOption
+	}
+	// 先移除记录，避免同一玩家的并发复活请求重复扣除
+	delete(s.dead, entityID)
+	s.mu.Unlock()
+
+	var bound bool
+	if option == RespawnInPlace {
+		// 先提交道具扣除再复活：事务失败（含提交失败）时不复活
+		err = s.inventory.Modify(ctx, record.characterID, func(tx *InventoryTx) error {
+			var err error
+			bound, err = takeResurrectItem(tx, s.config.ResurrectItemID)
+			return err
+		})
+		if err != nil {
+			s.restoreRecord(entityID, record)
+			return nil, err
+		}
+	}
+	if err := gameMap.Revive(ctx, actor.ID(), position, hpRatio, hpRatio); err != nil {
+		err = fmt.Errorf("failed to revive: %w", err)
+		if option == RespawnInPlace {
+			// 复活失败退还已扣除的道具（保持原绑定状态）
+			refundErr := s.inventory.Modify(ctx, record.characterID, func(tx *InventoryTx) error {
+				if bound {
+					return tx.AddBound(s.config.ResurrectItemID, 1)
+				}
+				return tx.Add(s.config.ResurrectItemID, 1)
+			})
+			if refundErr != nil {
+				err = errors.Join(err, fmt.Errorf("failed to refund resurrect item: %w", refundErr))
+			}
+		}
+		s.restoreRecord(entityID, record)
+		return nil, err
+	}
+
+	result := &RespawnResult{Option: option, Position: position, HP: actor.HP()}
+	if s.store != nil {
+		if lost, err := s.store.RecordRespawn(ctx, record.characterID, penalty, int32(actor.HP()), int32(actor.MP())); err == nil {
+			result.ExpLost = lost
+		}
+	}
+	gameMap.BroadcastTo([]character.EntityID{actor.ID()}, "player_respawn", &RespawnNotice{
+		Phase:   "respawned",
+		Option:  option,
+		ExpLost: result.ExpLost,
+	})
+	return result, nil
+}
+
+// Update 复活计时：过期的复活邀请作废，超过自动复活时间的玩家回复活点复活
+func (s *RespawnService) Update(ctx context.Context) {
+	now := time.Now()
+	var release []int32
+	s.mu.Lock()
+	for entityID, record := range s.dead {
+		if record.offer != nil && now.After(record.offer.expireAt) {
+			record.offer = nil
+		}
+		if s.config.AutoRelease > 0 && now.Sub(record.deadAt) >= s.config.AutoRelease {
+			release = append(release, entityID)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, entityID := range release {
+		// 已离开地图或已复活的玩家不再计时
+		if _, err := s.Respawn(ctx, entityID, RespawnAtGraveyard); err != nil {
+			s.Forget(entityID)
+		}
+	}
+}
+
+// Forget 玩家下线时清理内存中的死亡记录（死亡状态已持久化，重新上线后恢复）
+func (s *RespawnService) Forget(entityID int32) {
+	s.mu.Lock()
+	delete(s.dead, entityID)
+	s.mu.Unlock()
+}
+
+// restoreRecord 复活失败时恢复死亡记录
+func (s *RespawnService) restoreRecord(entityID int32, record *deathRecord) {
+	s.mu.Lock()
+	if _, ok := s.dead[entityID]; !ok {
+		s.dead[entityID] = record
+	}
+	s.mu.Unlock()
+}
+
+// deathNotice 构造死亡通知（计时从死亡时间起算）
+func (s *RespawnService) deathNotice(record *deathRecord) *RespawnNotice {
+	elapsed := time.Since(record.deadAt)
+	notice := &RespawnNotice{
+		Phase:     "dead",
+		ReleaseIn: float32(max(s.config.ReleaseDelay-elapsed, 0).Seconds()),
+		ItemID:    s.config.ResurrectItemID,
+	}
+	if s.config.AutoRelease > 0 {
+		notice.AutoReleaseIn = float32(max(s.config.AutoRelease-elapsed, 0).Seconds())
+	}
+	return notice
+}
+
+// takeResurrectItem 从背包扣除一个复活道具（优先数量少的堆叠），返回扣除的是否为绑定道具
+func takeResurrectItem(tx *InventoryTx, itemID int32) (bool, error) {
+	var stack *persistence.DbItem
+	for _, item := range tx.Items() {
+		if item.Location == ItemLocationBag && item.ItemID == itemID && (stack == nil || item.Count < stack.Count) {
+			stack = item
+		}
+	}
+	if stack == nil {
+		return false, &inventory.OpError{Op: "remove", ItemID: itemID, Err: inventory.ErrInsufficientItems}
+	}
+	bound := stack.Bound
+	return bound, tx.Take(stack.ItemUID, 1)
+}
+
+// ChainDeathHandlers 依次调用多个地图死亡回调
+func ChainDeathHandlers(handlers ...mapmanager.DeathHandler) mapmanager.DeathHandler {
+	return func(ctx context.Context, m *mapmanager.Map, actor *character.Actor) {
+		for _, h := range handlers {
+			if h != nil {
+				h(ctx, m, actor)
+			}
+		}
+	}
+}
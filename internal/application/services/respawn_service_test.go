@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"greatestworks/internal/domain/character"
+	"greatestworks/internal/domain/inventory"
+	"greatestworks/internal/infrastructure/persistence"
+)
+
+// stubRespawnInventory 内存背包：fn 成功且提交不失败时才保存修改
+type stubRespawnInventory struct {
+	items     []persistence.DbItem
+	commitErr error
+	calls     int
+	onCommit  func()
+}
+
+func (s *stubRespawnInventory) Modify(_ context.Context, characterID int64, fn func(tx *InventoryTx) error) error {
+	s.calls++
+	items := make([]*persistence.DbItem, len(s.items))
+	for i := range s.items {
+		item := s.items[i]
+		items[i] = &item
+	}
+	tx := newInventoryTx(characterID, 10, items)
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if s.commitErr != nil {
+		return s.commitErr
+	}
+	s.items = snapshotItems(tx.Items())
+	if s.onCommit != nil {
+		s.onCommit()
+	}
+	return nil
+}
+
+func (s *stubRespawnInventory) count(itemID int32) int32 {
+	var n int32
+	for _, item := range s.items {
+		if item.ItemID == itemID {
+			n += item.Count
+		}
+	}
+	return n
+}
+
+// newRespawnTest 玩家（实体1）在竞技场死亡，背包内有 2 个复活道具
+func newRespawnTest(t *testing.T) (*RespawnService, *stubRespawnInventory, *character.Actor) {
+	t.Helper()
+	ms := NewMapService()
+	actor := newTestPlayer(t, 1, 10)
+	if err := ms.EnterMapActor(context.Background(), actor, testArenaMapID, 10, 0, 10); err != nil {
+		t.Fatalf("enter: %v", err)
+	}
+	actor.ChangeHP(-actor.HP())
+	inv := &stubRespawnInventory{items: []persistence.DbItem{*bagItem(1, testPotionID, 2, 0)}}
+	s := NewRespawnService(RespawnConfig{ResurrectItemID: testPotionID, RespawnHealth: 0.5}, ms, nil, inv)
+	s.RestoreDeath(1, 1, time.Now())
+	return s, inv, actor
+}
+
+func TestRespawnInPlace(t *testing.T) {
+	s, inv, actor := newRespawnTest(t)
+	if _, err := s.Respawn(context.Background(), 1, RespawnInPlace); err != nil {
+		t.Fatalf("respawn: %v", err)
+	}
+	if actor.IsDeath() {
+		t.Fatalf("actor still dead")
+	}
+	if n := inv.count(testPotionID); n != 1 {
+		t.Fatalf("%d items left, want 1", n)
+	}
+}
+
+func TestRespawnInPlaceCommitFailure(t *testing.T) {
+	s, inv, actor := newRespawnTest(t)
+	inv.commitErr = errors.New("commit failed")
+	if _, err := s.Respawn(context.Background(), 1, RespawnInPlace); !errors.Is(err, inv.commitErr) {
+		t.Fatalf("err %v, want the commit error", err)
+	}
+	if !actor.IsDeath() {
+		t.Fatalf("actor revived although the item was not consumed")
+	}
+	if n := inv.count(testPotionID); n != 2 {
+		t.Fatalf("%d items left, want 2", n)
+	}
+	// 死亡记录保留，修复后可重试
+	inv.commitErr = nil
+	if _, err := s.Respawn(context.Background(), 1, RespawnInPlace); err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+}
+
+func TestRespawnInPlaceRefundsOnReviveFailure(t *testing.T) {
+	s, inv, actor := newRespawnTest(t)
+	// 道具扣除提交后、复活前被其他途径复活
+	inv.onCommit = func() {
+		inv.onCommit = nil
+		actor.ChangeHP(1)
+	}
+	if _, err := s.Respawn(context.Background(), 1, RespawnInPlace); err == nil {
+		t.Fatalf("expected revive error")
+	}
+	if n := inv.count(testPotionID); n != 2 || inv.calls != 2 {
+		t.Fatalf("%d items after %d modifications, want the item refunded", n, inv.calls)
+	}
+
+	inv.items = nil
+	actor.ChangeHP(-actor.HP())
+	var opErr *inventory.OpError
+	if _, err := s.Respawn(context.Background(), 1, RespawnInPlace); !errors.As(err, &opErr) || !errors.Is(err, inventory.ErrInsufficientItems) {
+		t.Fatalf("err %v, want ErrInsufficientItems", err)
+	}
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"greatestworks/internal/domain/character"
+	"greatestworks/internal/domain/mapmanager"
+	"greatestworks/internal/infrastructure/datamanager"
+	"greatestworks/internal/infrastructure/logging"
+)
+
+// rewardShareRange 击杀奖励的分配距离（距怪物超出该距离的参与者与队友不获得奖励）
+const rewardShareRange = float32(40)
+
+// RewardStore 经验与金币发放（由 CharacterService 实现）
+type RewardStore interface {
+	GrantReward(ctx context.Context, characterID int64, exp, gold int64, source, key string) (*RewardGrant, error)
+}
+
+// KillCredit 任务击杀计数（由 QuestService 实现）
+type KillCredit interface {
+	OnKill(ctx context.Context, characterID int64, targetID int32) error
+}
+
+// KillRewardNotice 下发给获得者的击杀奖励通知
+type KillRewardNotice struct {
+	MonsterID int32 `json:"monster_id"`
+	UnitID    int32 `json:"unit_id"`
+	Exp       int64 `json:"exp"`
+	Gold      int64 `json:"gold"`
+	Level     int32 `json:"level"`
+	TotalExp  int64 `json:"total_exp"` // 当前等级的经验
+}
+
+// RewardService 击杀奖励服务：怪物死亡后按伤害贡献在参与者及范围内队友之间分配经验与金币，计入任务击杀并处理升级
+type RewardService struct {
+	mu         sync.Mutex // 串行化发放，避免同一角色的并发奖励互相覆盖
+	mapService *MapService
+	store      RewardStore
+	quests     KillCredit
+	logger     logging.Logger
+}
+
+// NewRewardService 创建击杀奖励服务
+func NewRewardService(mapService *MapService, store RewardStore, quests KillCredit, logger logging.Logger) *RewardService {
+	return &RewardService{
+		mapService: mapService,
+		store:      store,
+		quests:     quests,
+		logger:     logger,
+	}
+}
+
+// OnActorDeath 地图死亡回调：怪物死亡时按单位配置构造怪物死亡事件并发放奖励
+func (s *RewardService) OnActorDeath(ctx context.Context, gameMap *mapmanager.Map, actor *character.Actor) {
+	if actor.Type() != character.EntityTypeMonster {
+		return
+	}
+	var killer character.EntityID
+	if src := actor.DamageSource(); src != nil {
+		killer = src.AttackerInfo.AttackerID
+	}
+	var exp, gold int32
+	if unit := datamanager.GetInstance().GetUnit(actor.UnitID()); unit != nil {
+		exp, gold = unit.ExpReward, unit.GoldReward
+	}
+	evt := character.NewMonsterDeathEvent(actor.ID(), killer, actor.Position(), nil, exp)
+	evt.DropGold = gold
+	evt.UnitID = actor.UnitID()
+	evt.Level = actor.Level()
+	evt.Contributors = actor.DamageContributors()
+	s.OnMonsterDeath(ctx, gameMap, evt)
+}
+
+// OnMonsterDeath 处理怪物死亡事件：分配经验与金币、计入任务击杀并同步升级
+func (s *RewardService) OnMonsterDeath(ctx context.Context, gameMap *mapmanager.Map, evt *character.MonsterDeathEvent) {
+	groups := s.rewardGroups(gameMap, evt)
+	shares := character.SplitKillReward(evt.Level, int64(evt.DropExp), int64(evt.DropGold), groups)
+	// 每次击杀一个流水键前缀，同一击杀的奖励只入账一次
+	killID := uuid.New().String()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, share := range shares {
+		actor := share.Actor
+		// 实体ID即角色ID（与登录绑定一致）
+		characterID := int64(actor.ID())
+		if s.quests != nil {
+			_ = s.quests.OnKill(ctx, characterID, evt.UnitID)
+		}
+		if s.store == nil || (share.Exp <= 0 && share.Gold <= 0) {
+			continue
+		}
+		grant, err := s.store.GrantReward(ctx, characterID, share.Exp, share.Gold, fmt.Sprintf("unit:%d", evt.UnitID), fmt.Sprintf("reward:unit:%d:%s:%d", evt.UnitID, killID, characterID))
+		if err != nil {
+			if s.logger != nil {
+				s.logger.Error("发放击杀奖励失败", err, logging.Fields{
+					"character_id": characterID,
+					"monster_id":   evt.MonsterID,
+					"unit_id":      evt.UnitID,
+					"map_id":       gameMap.ID(),
+					"exp":          share.Exp,
+					"gold":         share.Gold,
+				})
+			}
+			continue
+		}
+		if grant.NewLevel > actor.Level() {
+			actor.ApplyLevelUp(grant.NewLevel, grant.Growth)
+		}
+		gameMap.BroadcastTo([]character.EntityID{actor.ID()}, "kill_reward", &KillRewardNotice{
+			MonsterID: int32(evt.MonsterID),
+			UnitID:    evt.UnitID,
+			Exp:       share.Exp,
+			Gold:      share.Gold,
+			Level:     grant.NewLevel,
+			TotalExp:  grant.Exp,
+		})
+	}
+}
+
+// rewardGroups 将伤害贡献者按队伍合并为分配单位，成员为同一地图实例内存活且在分配距离内的参与者与队友
+func (s *RewardService) rewardGroups(gameMap *mapmanager.Map, evt *character.MonsterDeathEvent) []character.RewardGroup {
+	contributors := make([]character.EntityID, 0, len(evt.Contributors))
+	for id := range evt.Contributors {
+		contributors = append(contributors, id)
+	}
+	sort.Slice(contributors, func(i, j int) bool { return contributors[i] < contributors[j] })
+
+	var groups []character.RewardGroup
+	groupOf := make(map[character.EntityID]int)
+	eligible := func(id character.EntityID) *character.Actor {
+		actor := gameMap.GetActor(id)
+		if actor == nil || actor.Type() != character.EntityTypePlayer || actor.IsDeath() {
+			return nil
+		}
+		if actor.Position().ToVector2().Distance(evt.Position.ToVector2()) > rewardShareRange {
+			return nil
+		}
+		return actor
+	}
+	for _, id := range contributors {
+		if idx, ok := groupOf[id]; ok {
+			groups[idx].Damage += evt.Contributors[id]
+			continue
+		}
+		// 只统计玩家造成的伤害（已离开地图的玩家仍计入其队伍）
+		if actor := gameMap.GetActor(id); actor != nil && actor.Type() != character.EntityTypePlayer {
+			continue
+		}
+		idx := len(groups)
+		groups = append(groups, character.RewardGroup{Damage: evt.Contributors[id]})
+		for _, memberID := range append([]int32{int32(id)}, s.mapService.partyMembers(int32(id))...) {
+			member := character.EntityID(memberID)
+			if _, ok := groupOf[member]; ok {
+				continue
+			}
+			groupOf[member] = idx
+			if actor := eligible(member); actor != nil {
+				groups[idx].Members = append(groups[idx].Members, actor)
+			}
+		}
+	}
+	return groups
+}
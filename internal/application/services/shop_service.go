@@ -0,0 +1,412 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"greatestworks/internal/domain/character"
+	"greatestworks/internal/domain/inventory"
+	"greatestworks/internal/domain/npc"
+	"greatestworks/internal/infrastructure/datamanager"
+	"greatestworks/internal/infrastructure/persistence"
+)
+
+// NPC 商店错误
+var (
+	ErrShopNotFound        = errors.New("npc has no shop")
+	ErrShopOutOfRange      = errors.New("too far from shop")
+	ErrShopItemNotSellable = errors.New("item cannot be sold")
+	ErrBuybackNotFound     = errors.New("buyback entry not found")
+)
+
+const (
+	shopRange            float32 = 10 // 与商店NPC的最大交互距离
+	shopBuybackSize              = 12 // 每名玩家保留的回购条目数
+	shopReputationPerBuy         = 1  // 每次购买获得的声望
+	shopReputationMax            = 1000
+)
+
+// ReputationStore 声望持久化（由 ReputationRepository 实现，Add 需支持事务上下文）
+type ReputationStore interface {
+	Get(ctx context.Context, characterID int64, npcID int32) (int, error)
+	Add(ctx context.Context, characterID int64, npcID int32, delta int) error
+}
+
+// ShopItemView 商店商品（价格已按声望折算）
+type ShopItemView struct {
+	ItemID    int32 `json:"item_id"`
+	Price     int64 `json:"price"`
+	Stock     int32 `json:"stock"` // -1 表示不限量
+	MaxStock  int32 `json:"max_stock,omitempty"`
+	RestockAt int64 `json:"restock_at,omitempty"` // 下次补货的毫秒时间戳
+}
+
+// BuybackView 回购条目
+type BuybackView struct {
+	BuybackID int32 `json:"buyback_id"`
+	ItemID    int32 `json:"item_id"`
+	Count     int32 `json:"count"`
+	Enhance   int32 `json:"enhance,omitempty"` // 强化等级
+	Price     int64 `json:"price"`
+}
+
+// ShopView 商店快照
+type ShopView struct {
+	NPCID      int32          `json:"npc_id"` // NPC实体ID
+	Name       string         `json:"name"`
+	Reputation int            `json:"reputation"`
+	Standing   string         `json:"standing"` // 关系等级
+	Items      []ShopItemView `json:"items"`
+	Buyback    []BuybackView  `json:"buyback,omitempty"`
+}
+
+// buybackEntry 最近出售的物品（保留绑定状态与强化等级，回购时原样放回）
+type buybackEntry struct {
+	id      int32
+	itemID  int32
+	count   int32
+	bound   bool
+	enhance int32
+	price   int64
+}
+
+// ShopService NPC 商店：商品与库存来自 shops.json，按声望折扣；购买、出售与回购在同一事务内结算物品与金币
+type ShopService struct {
+	mu         sync.Mutex
+	mapService *MapService
+	items      *ItemService
+	wallet     WalletStore
+	reputation ReputationStore
+	shops      map[int32]*npc.NPCAggregate // NPC单位ID -> 商店
+	buyback    map[int32][]*buybackEntry   // 玩家实体ID -> 回购列表（新的在前）
+	nextID     int32
+}
+
+// NewShopService 创建 NPC 商店服务
+func NewShopService(mapService *MapService, items *ItemService, wallet WalletStore, reputation ReputationStore) *ShopService {
+	return &ShopService{
+		mapService: mapService,
+		items:      items,
+		wallet:     wallet,
+		reputation: reputation,
+		shops:      make(map[int32]*npc.NPCAggregate),
+		buyback:    make(map[int32][]*buybackEntry),
+	}
+}
+
+// Open 打开附近NPC的商店
+func (s *ShopService) Open(ctx context.Context, entityID, npcEntityID int32) (*ShopView, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	shop, unitID, err := s.locateLocked(ctx, entityID, npcEntityID)
+	if err != nil {
+		return nil, err
+	}
+	return s.viewLocked(shop, unitID, entityID, npcEntityID), nil
+}
+
+// Buy 购买商品：先预留库存，扣除金币、放入背包与增加声望在同一事务内完成，失败时退回库存
+func (s *ShopService) Buy(ctx context.Context, entityID, npcEntityID, itemID, count int32) (*ShopView, error) {
+	s.mu.Lock()
+	shop, unitID, err := s.locateLocked(ctx, entityID, npcEntityID)
+	if err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	playerID := playerKey(entityID)
+	req := &npc.TradeRequest{ItemID: strconv.Itoa(int(itemID)), Quantity: int(count), PlayerID: playerID}
+	result, err := shop.Trade(playerID, req)
+	if err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	shop.ClearEvents()
+	price := result.TotalPrice
+	gain := 0
+	if rel := shop.GetRelationship(playerID); rel != nil {
+		gain = min(shopReputationPerBuy, shopReputationMax-rel.GetValue())
+	}
+	s.mu.Unlock()
+
+	characterID := int64(entityID)
+	// 每次交易一个流水键，事务重试时只扣款一次
+	key := "shop:" + uuid.New().String()
+	err = s.items.Transact(ctx, []int64{characterID}, func(txCtx context.Context, txs map[int64]*InventoryTx) error {
+		if err := txs[characterID].Add(itemID, count); err != nil {
+			return err
+		}
+		if _, err := s.wallet.Apply(txCtx, WalletChange{
+			CharacterID: characterID,
+			Currency:    CurrencyGold,
+			Delta:       -int64(price),
+			Reason:      WalletReasonShopBuy,
+			Source:      fmt.Sprintf("npc:%d", unitID),
+			Key:         key,
+		}); err != nil {
+			return err
+		}
+		if gain > 0 {
+			return s.reputation.Add(txCtx, characterID, unitID, gain)
+		}
+		return nil
+	})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		if item := shop.GetShop().GetItem(req.ItemID); item != nil {
+			item.CancelPurchase(req.Quantity)
+		}
+		return nil, err
+	}
+	if gain > 0 {
+		_ = shop.UpdateRelationship(playerID, gain, "purchase")
+		shop.ClearEvents()
+	}
+	return s.viewLocked(shop, unitID, entityID, npcEntityID), nil
+}
+
+// Sell 出售背包中的物品（按物品定义的 sell_price），出售的物品可回购
+func (s *ShopService) Sell(ctx context.Context, entityID, npcEntityID int32, itemUID int64, count int32) (*ShopView, error) {
+	s.mu.Lock()
+	shop, unitID, err := s.locateLocked(ctx, entityID, npcEntityID)
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	characterID := int64(entityID)
+	key := "shop:" + uuid.New().String()
+	entry := &buybackEntry{count: count}
+	err = s.items.Transact(ctx, []int64{characterID}, func(txCtx context.Context, txs map[int64]*InventoryTx) error {
+		tx := txs[characterID]
+		item := tx.Find(itemUID)
+		if item == nil || item.Location != ItemLocationBag {
+			return &inventory.OpError{Op: "sell", ItemUID: itemUID, Err: inventory.ErrItemNotFound}
+		}
+		def := datamanager.GetInstance().GetItem(item.ItemID)
+		if def == nil || def.SellPrice <= 0 {
+			return ErrShopItemNotSellable
+		}
+		entry.itemID, entry.bound, entry.enhance = item.ItemID, item.Bound, item.Enhance
+		entry.price = int64(def.SellPrice) * int64(count)
+		if err := tx.Take(itemUID, count); err != nil {
+			return err
+		}
+		_, err := s.wallet.Apply(txCtx, WalletChange{
+			CharacterID: characterID,
+			Currency:    CurrencyGold,
+			Delta:       entry.price,
+			Reason:      WalletReasonShopSell,
+			Source:      fmt.Sprintf("npc:%d", unitID),
+			Key:         key,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	entry.id = s.nextID
+	s.addBuybackLocked(entityID, entry)
+	return s.viewLocked(shop, unitID, entityID, npcEntityID), nil
+}
+
+// Buyback 按出售价格买回最近出售的物品
+func (s *ShopService) Buyback(ctx context.Context, entityID, npcEntityID, buybackID int32) (*ShopView, error) {
+	s.mu.Lock()
+	shop, unitID, err := s.locateLocked(ctx, entityID, npcEntityID)
+	if err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	list := s.buyback[entityID]
+	idx := -1
+	for i, e := range list {
+		if e.id == buybackID {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		s.mu.Unlock()
+		return nil, ErrBuybackNotFound
+	}
+	// 先从回购列表取出，避免并发重复回购；事务失败时放回
+	entry := list[idx]
+	s.buyback[entityID] = append(list[:idx:idx], list[idx+1:]...)
+	s.mu.Unlock()
+
+	characterID := int64(entityID)
+	key := "shop:" + uuid.New().String()
+	err = s.items.Transact(ctx, []int64{characterID}, func(txCtx context.Context, txs map[int64]*InventoryTx) error {
+		item := &persistence.DbItem{ItemID: entry.itemID, Count: entry.count, Bound: entry.bound, Enhance: entry.enhance}
+		if err := txs[characterID].Insert(item); err != nil {
+			return err
+		}
+		_, err := s.wallet.Apply(txCtx, WalletChange{
+			CharacterID: characterID,
+			Currency:    CurrencyGold,
+			Delta:       -entry.price,
+			Reason:      WalletReasonShopBuyback,
+			Source:      fmt.Sprintf("npc:%d", unitID),
+			Key:         key,
+		})
+		return err
+	})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		s.addBuybackLocked(entityID, entry)
+		return nil, err
+	}
+	return s.viewLocked(shop, unitID, entityID, npcEntityID), nil
+}
+
+// addBuybackLocked 按出售先后（新的在前）放入回购列表，超出上限时丢弃最早的条目
+func (s *ShopService) addBuybackLocked(entityID int32, entry *buybackEntry) {
+	list := s.buyback[entityID]
+	idx := sort.Search(len(list), func(i int) bool { return list[i].id < entry.id })
+	list = append(list[:idx:idx], append([]*buybackEntry{entry}, list[idx:]...)...)
+	if len(list) > shopBuybackSize {
+		list = list[:shopBuybackSize]
+	}
+	s.buyback[entityID] = list
+}
+
+// Forget 玩家下线时清除回购列表
+func (s *ShopService) Forget(entityID int32) {
+	s.mu.Lock()
+	delete(s.buyback, entityID)
+	s.mu.Unlock()
+}
+
+// Update 按补货间隔补充限量商品
+func (s *ShopService) Update(ctx context.Context, dt time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, shop := range s.shops {
+		shop.GetShop().Update(dt)
+	}
+	return nil
+}
+
+// locateLocked 校验玩家在商店NPC附近，返回商店（首次访问时按配置创建）并加载玩家声望
+func (s *ShopService) locateLocked(ctx context.Context, entityID, npcEntityID int32) (*npc.NPCAggregate, int32, error) {
+	gameMap, actor, err := s.mapService.LocateActor(entityID)
+	if err != nil {
+		return nil, 0, err
+	}
+	entity := gameMap.GetEntity(character.EntityID(npcEntityID))
+	if entity == nil || entity.Type() != character.EntityTypeNPC {
+		return nil, 0, ErrShopNotFound
+	}
+	if actor.DistanceTo(entity) > shopRange {
+		return nil, 0, ErrShopOutOfRange
+	}
+	unitID := entity.UnitID()
+	shop := s.shops[unitID]
+	if shop == nil {
+		if shop = newShopAggregate(unitID); shop == nil {
+			return nil, 0, ErrShopNotFound
+		}
+		s.shops[unitID] = shop
+	}
+
+	playerID := playerKey(entityID)
+	if shop.GetRelationship(playerID) == nil {
+		value, err := s.reputation.Get(ctx, int64(entityID), unitID)
+		if err != nil {
+			return nil, 0, err
+		}
+		_ = shop.UpdateRelationship(playerID, value, "load")
+		shop.ClearEvents()
+	}
+	return shop, unitID, nil
+}
+
+// viewLocked 商店快照
+func (s *ShopService) viewLocked(shop *npc.NPCAggregate, unitID, entityID, npcEntityID int32) *ShopView {
+	view := &ShopView{NPCID: npcEntityID, Name: shop.GetShop().GetName(), Standing: npc.RelationshipLevelNeutral.String()}
+	rate := 1.0
+	if rel := shop.GetRelationship(playerKey(entityID)); rel != nil {
+		view.Reputation, view.Standing = rel.GetValue(), rel.GetLevel().String()
+		rate = rel.GetLevel().ShopPriceRate()
+	}
+	if def := datamanager.GetInstance().GetShop(unitID); def != nil {
+		for _, d := range def.Items {
+			item := shop.GetShop().GetItem(strconv.Itoa(int(d.ItemID)))
+			if item == nil {
+				continue
+			}
+			v := ShopItemView{ItemID: d.ItemID, Price: int64(float64(item.GetPrice()) * rate), Stock: -1}
+			if !item.IsUnlimited() {
+				v.Stock, v.MaxStock = int32(item.GetStock()), int32(item.GetMaxStock())
+				if at := item.NextRestock(); !at.IsZero() {
+					v.RestockAt = at.UnixMilli()
+				}
+			}
+			view.Items = append(view.Items, v)
+		}
+	}
+	for _, e := range s.buyback[entityID] {
+		view.Buyback = append(view.Buyback, BuybackView{BuybackID: e.id, ItemID: e.itemID, Count: e.count, Enhance: e.enhance, Price: e.price})
+	}
+	return view
+}
+
+// newShopAggregate 按 shops.json 创建商店NPC（未配置商店时返回 nil）
+func newShopAggregate(unitID int32) *npc.NPCAggregate {
+	dm := datamanager.GetInstance()
+	def := dm.GetShop(unitID)
+	if def == nil {
+		return nil
+	}
+	id := strconv.Itoa(int(unitID))
+	name := def.Name
+	if unit := dm.GetUnit(unitID); unit != nil {
+		name = unit.Name
+	}
+	shop := npc.NewShop(id, def.Name, "")
+	shop.SetSchedule(npc.NewAlwaysOpenShopSchedule())
+	for _, d := range def.Items {
+		itemDef := dm.GetItem(d.ItemID)
+		if itemDef == nil {
+			continue
+		}
+		price := d.Price
+		if price == 0 {
+			price = itemDef.Price
+		}
+		var item *npc.ShopItem
+		if d.Stock == 0 {
+			item = npc.NewUnlimitedShopItem(strconv.Itoa(int(d.ItemID)), itemDef.Name, itemDef.Description, int(price))
+		} else {
+			item = npc.NewShopItem(strconv.Itoa(int(d.ItemID)), itemDef.Name, itemDef.Description, int(price), int(d.Stock))
+			item.SetRestock(int(d.RestockAmount), time.Duration(d.RestockInterval*float32(time.Second)))
+		}
+		shop.AddItem(item)
+	}
+	aggregate := npc.NewNPCAggregate(id, name, "", npc.NPCTypeMerchant)
+	if err := aggregate.SetShop(shop); err != nil {
+		return nil
+	}
+	aggregate.ClearEvents()
+	return aggregate
+}
+
+// playerKey NPC 领域中的玩家ID（实体ID即角色ID）
+func playerKey(entityID int32) string {
+	return strconv.Itoa(int(entityID))
+}
@@ -0,0 +1,189 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"greatestworks/internal/domain/character"
+	"greatestworks/internal/domain/npc"
+	"greatestworks/internal/infrastructure/persistence"
+)
+
+// configs/data 中新手村的杂货商 3001（200,200）：生命药水 50 金币不限量，复活卷轴 500 金币限量 5
+const (
+	testShopUnitID   = int32(3001)
+	testScrollItemID = int32(10003)
+)
+
+type stubReputation struct {
+	values map[int32]int
+	addErr error
+}
+
+func (r *stubReputation) Get(_ context.Context, _ int64, npcID int32) (int, error) {
+	return r.values[npcID], nil
+}
+
+func (r *stubReputation) Add(_ context.Context, _ int64, npcID int32, delta int) error {
+	if r.addErr != nil {
+		return r.addErr
+	}
+	r.values[npcID] += delta
+	return nil
+}
+
+// newShopTest 玩家（实体1，1000 金币）站在杂货商旁，返回商店服务与商人的实体ID
+func newShopTest(t *testing.T, reputation int, items ...*persistence.DbItem) (*ShopService, *memItemStore, *memWallet, *stubReputation, int32) {
+	t.Helper()
+	ms := NewMapService()
+	if err := ms.EnterMapActor(context.Background(), newTestPlayer(t, 1, 10), testVillageMapID, 202, 0, 200); err != nil {
+		t.Fatalf("enter: %v", err)
+	}
+	gameMap, _ := ms.GetMap(testVillageMapID)
+	var merchant int32
+	for _, e := range gameMap.GetAllEntities() {
+		if e.Type() == character.EntityTypeNPC && e.UnitID() == testShopUnitID {
+			merchant = int32(e.ID())
+		}
+	}
+	if merchant == 0 {
+		t.Fatalf("merchant %d not spawned", testShopUnitID)
+	}
+	wallet := newMemWallet(map[int64]int64{1: 1000})
+	store := newMemItemStore(wallet, items...)
+	rep := &stubReputation{values: map[int32]int{testShopUnitID: reputation}}
+	return NewShopService(ms, NewItemService(store), wallet, rep), store, wallet, rep, merchant
+}
+
+func shopItem(view *ShopView, itemID int32) ShopItemView {
+	for _, item := range view.Items {
+		if item.ItemID == itemID {
+			return item
+		}
+	}
+	return ShopItemView{}
+}
+
+func TestShopBuyReservesStock(t *testing.T) {
+	ctx := context.Background()
+	s, store, wallet, rep, merchant := newShopTest(t, 0)
+
+	view, err := s.Buy(ctx, 1, merchant, testScrollItemID, 1)
+	if err != nil {
+		t.Fatalf("buy: %v", err)
+	}
+	if stock := shopItem(view, testScrollItemID).Stock; stock != 4 {
+		t.Fatalf("stock %d, want 4", stock)
+	}
+	if wallet.gold(1) != 500 || store.holdings(1)[testScrollItemID] != 1 || rep.values[testShopUnitID] != shopReputationPerBuy {
+		t.Fatalf("gold %d, items %v, reputation %d after buying", wallet.gold(1), store.holdings(1), rep.values[testShopUnitID])
+	}
+	if _, err := s.Buy(ctx, 1, merchant, testScrollItemID, 5); !errors.Is(err, npc.ErrShopItemOutOfStock) {
+		t.Fatalf("buying past the stock: err %v", err)
+	}
+
+	// 结算失败时退回预留的库存
+	tests := []struct {
+		name  string
+		setup func()
+	}{
+		{"not enough gold", func() {}},
+		{"reputation write fails", func() {
+			wallet.Apply(ctx, WalletChange{CharacterID: 1, Currency: CurrencyGold, Delta: 1000, Reason: WalletReasonReward})
+			rep.addErr = errors.New("reputation unavailable")
+		}},
+	}
+	for _, tt := range tests {
+		tt.setup()
+		gold, items := wallet.gold(1), store.snapshot()
+		if _, err := s.Buy(ctx, 1, merchant, testScrollItemID, 2); err == nil {
+			t.Fatalf("%s: expected an error", tt.name)
+		}
+		view, _ := s.Open(ctx, 1, merchant)
+		if stock := shopItem(view, testScrollItemID).Stock; stock != 4 {
+			t.Fatalf("%s: stock %d after a failed purchase, want 4", tt.name, stock)
+		}
+		if wallet.gold(1) != gold || !reflect.DeepEqual(store.snapshot(), items) {
+			t.Fatalf("%s: failed purchase changed gold or items", tt.name)
+		}
+	}
+}
+
+func TestShopReputationPricing(t *testing.T) {
+	tests := []struct {
+		reputation int
+		price      int64 // 单价
+		paid       int64 // 购买 2 个（按总价折算）
+		standing   npc.RelationshipLevel
+	}{
+		{0, 50, 100, npc.RelationshipLevelNeutral},
+		{60, 47, 95, npc.RelationshipLevelLiked},
+		{500, 40, 80, npc.RelationshipLevelRevered},
+		{-100, 55, 110, npc.RelationshipLevelDisliked},
+	}
+	for _, tt := range tests {
+		ctx := context.Background()
+		s, _, wallet, _, merchant := newShopTest(t, tt.reputation)
+		view, err := s.Open(ctx, 1, merchant)
+		if err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		if view.Standing != tt.standing.String() || shopItem(view, testPotionID).Price != tt.price {
+			t.Fatalf("reputation %d: standing %s price %d, want %s %d", tt.reputation, view.Standing, shopItem(view, testPotionID).Price, tt.standing, tt.price)
+		}
+		if _, err := s.Buy(ctx, 1, merchant, testPotionID, 2); err != nil {
+			t.Fatalf("reputation %d: buy: %v", tt.reputation, err)
+		}
+		if paid := 1000 - wallet.gold(1); paid != tt.paid {
+			t.Fatalf("reputation %d: paid %d, want %d", tt.reputation, paid, tt.paid)
+		}
+	}
+
+	s, _, _, _, merchant := newShopTest(t, -600)
+	if _, err := s.Buy(context.Background(), 1, merchant, testPotionID, 1); !errors.Is(err, npc.ErrInsufficientReputation) {
+		t.Fatalf("hostile buyer: err %v", err)
+	}
+}
+
+func TestShopSellBuybackRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	sword := ownedItem(1, 1, testSwordID, 1, 0)
+	sword.Bound, sword.Enhance = true, 7
+	s, store, wallet, _, merchant := newShopTest(t, 0, sword)
+
+	view, err := s.Sell(ctx, 1, merchant, 1, 1)
+	if err != nil {
+		t.Fatalf("sell: %v", err)
+	}
+	if wallet.gold(1) != 1100 || len(store.holdings(1)) != 0 {
+		t.Fatalf("gold %d, items %v after selling", wallet.gold(1), store.holdings(1))
+	}
+	if len(view.Buyback) != 1 || view.Buyback[0].Enhance != 7 || view.Buyback[0].Price != 100 {
+		t.Fatalf("buyback list %+v", view.Buyback)
+	}
+	entry := view.Buyback[0].BuybackID
+
+	// 金币不足时回购失败，条目保留
+	wallet.Apply(ctx, WalletChange{CharacterID: 1, Currency: CurrencyGold, Delta: -1050, Reason: WalletReasonShopBuy})
+	if _, err := s.Buyback(ctx, 1, merchant, entry); !errors.Is(err, ErrInsufficientGold) {
+		t.Fatalf("buyback without gold: err %v", err)
+	}
+	wallet.Apply(ctx, WalletChange{CharacterID: 1, Currency: CurrencyGold, Delta: 1050, Reason: WalletReasonReward})
+
+	view, err = s.Buyback(ctx, 1, merchant, entry)
+	if err != nil {
+		t.Fatalf("buyback: %v", err)
+	}
+	if len(view.Buyback) != 0 || wallet.gold(1) != 1000 {
+		t.Fatalf("buyback list %+v, gold %d", view.Buyback, wallet.gold(1))
+	}
+	items, _ := store.FindByCharacterID(ctx, 1)
+	if len(items) != 1 || items[0].ItemID != testSwordID || items[0].Enhance != 7 || !items[0].Bound {
+		t.Fatalf("bought back %+v, want the bound +7 sword", items)
+	}
+	if _, err := s.Buyback(ctx, 1, merchant, entry); !errors.Is(err, ErrBuybackNotFound) {
+		t.Fatalf("second buyback: err %v", err)
+	}
+}
@@ -0,0 +1,505 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"greatestworks/internal/domain/character"
+	"greatestworks/internal/domain/mapmanager"
+	"greatestworks/internal/infrastructure/persistence"
+)
+
+// 交易错误
+var (
+	ErrAlreadyTrading     = errors.New("already in a trade")
+	ErrTradeNotFound      = errors.New("trade not found")
+	ErrTradeTargetInvalid = errors.New("invalid trade target")
+	ErrTradeOutOfRange    = errors.New("trade target out of range")
+	ErrTradeNotPending    = errors.New("trade invitation not pending")
+	ErrTradeNotOpen       = errors.New("trade not open")
+	ErrTradeNotLocked     = errors.New("both sides must lock before confirming")
+	ErrTradeItemInvalid   = errors.New("item cannot be traded")
+	ErrTradeTooManyItems  = errors.New("too many items in trade")
+	ErrTradeExecuting     = errors.New("trade is being executed")
+)
+
+const (
+	tradeRange         float32 = 10               // 交易双方的最大距离
+	tradeInviteTimeout         = 30 * time.Second // 邀请有效期
+	tradeMaxItems              = 12               // 每方最多放入的物品数
+)
+
+// TradeState 交易状态
+type TradeState int32
+
+const (
+	TradeStatePending   TradeState = 0 // 等待对方接受
+	TradeStateOpen      TradeState = 1 // 交易中
+	TradeStateCompleted TradeState = 2 // 已完成
+	TradeStateCancelled TradeState = 3 // 已取消
+)
+
+// 交易结束原因
+const (
+	TradeEndCompleted  = "completed"
+	TradeEndCancelled  = "cancelled"
+	TradeEndDeclined   = "declined"
+	TradeEndExpired    = "expired"
+	TradeEndOutOfRange = "out_of_range"
+	TradeEndDisconnect = "disconnect"
+)
+
+// TradeAuditStore 交易审计记录（由 TradeRecordRepository 实现）
+type TradeAuditStore interface {
+	Save(ctx context.Context, doc *persistence.TradeRecordDocument) error
+}
+
+// TradeItemView 交易栏中的物品
+type TradeItemView struct {
+	ItemUID int64 `json:"item_uid"`
+	ItemID  int32 `json:"item_id"`
+	Count   int32 `json:"count"`
+	Enhance int32 `json:"enhance,omitempty"` // 强化等级
+}
+
+// TradeOfferView 交易一方的出价
+type TradeOfferView struct {
+	EntityID  int32           `json:"entity_id"`
+	Items     []TradeItemView `json:"items,omitempty"`
+	Gold      int64           `json:"gold,omitempty"`
+	Locked    bool            `json:"locked,omitempty"`
+	Confirmed bool            `json:"confirmed,omitempty"`
+}
+
+// TradeView 交易状态快照（topic: trade_invite / trade_update / trade_end）
+type TradeView struct {
+	TradeID int32             `json:"trade_id"`
+	State   TradeState        `json:"state"`
+	Offers  [2]TradeOfferView `json:"offers"` // 0 为发起方
+	Reason  string            `json:"reason,omitempty"`
+}
+
+// tradeSession 进行中的交易
+type tradeSession struct {
+	id        int32
+	uid       string // 全局唯一的交易标识（id 重启后复用），用作钱包流水幂等键
+	state     TradeState
+	gameMap   *mapmanager.Map
+	offers    [2]*TradeOfferView
+	expiresAt time.Time // 邀请过期时间
+	executing bool      // 正在执行交换（出价冻结，不可修改或取消）
+	endReason string    // 执行期间下线等原因记录的取消原因，交换失败时据此结束交易
+}
+
+// TradeService 玩家交易服务：邀请、放入物品与金币、锁定、双方确认后在同一事务内交换并记录审计
+type TradeService struct {
+	mu          sync.Mutex
+	mapService  *MapService
+	items       *ItemService
+	wallet      WalletStore
+	audit       TradeAuditStore
+	broadcast   mapmanager.BroadcastFn
+	nextID      int32
+	trades      map[int32]*tradeSession
+	playerTrade map[int32]int32 // 玩家实体ID -> 交易ID
+}
+
+// NewTradeService 创建交易服务
+func NewTradeService(mapService *MapService, items *ItemService, wallet WalletStore, audit TradeAuditStore) *TradeService {
+	return &TradeService{
+		mapService:  mapService,
+		items:       items,
+		wallet:      wallet,
+		audit:       audit,
+		trades:      make(map[int32]*tradeSession),
+		playerTrade: make(map[int32]int32),
+	}
+}
+
+// SetBroadcaster 设置推送函数（由接口层注入）
+func (s *TradeService) SetBroadcaster(fn mapmanager.BroadcastFn) {
+	s.mu.Lock()
+	s.broadcast = fn
+	s.mu.Unlock()
+}
+
+// Invite 邀请附近的玩家交易
+func (s *TradeService) Invite(ctx context.Context, entityID, targetID int32) (*TradeView, error) {
+	if entityID == targetID {
+		return nil, ErrTradeTargetInvalid
+	}
+	gameMap, err := s.inRange(entityID, targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, busy := s.playerTrade[entityID]; busy {
+		return nil, ErrAlreadyTrading
+	}
+	if _, busy := s.playerTrade[targetID]; busy {
+		return nil, ErrAlreadyTrading
+	}
+	s.nextID++
+	t := &tradeSession{
+		id:        s.nextID,
+		uid:       uuid.New().String(),
+		state:     TradeStatePending,
+		gameMap:   gameMap,
+		offers:    [2]*TradeOfferView{{EntityID: entityID}, {EntityID: targetID}},
+		expiresAt: time.Now().Add(tradeInviteTimeout),
+	}
+	s.trades[t.id] = t
+	s.playerTrade[entityID], s.playerTrade[targetID] = t.id, t.id
+	view := t.view()
+	s.push([]int32{targetID}, "trade_invite", view)
+	return view, nil
+}
+
+// Respond 接受或拒绝交易邀请
+func (s *TradeService) Respond(ctx context.Context, entityID, tradeID int32, accept bool) (*TradeView, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.trades[tradeID]
+	if !ok || t.offers[1].EntityID != entityID {
+		return nil, ErrTradeNotFound
+	}
+	if t.state != TradeStatePending {
+		return nil, ErrTradeNotPending
+	}
+	if !accept {
+		return s.endLocked(t, TradeStateCancelled, TradeEndDeclined), nil
+	}
+	if _, err := s.inRange(t.offers[0].EntityID, entityID); err != nil {
+		s.endLocked(t, TradeStateCancelled, TradeEndOutOfRange)
+		return nil, err
+	}
+	t.state = TradeStateOpen
+	return s.updatedLocked(t), nil
+}
+
+// SetItem 放入、调整或取回（count 为 0）交易物品，会重置双方的锁定与确认
+func (s *TradeService) SetItem(ctx context.Context, entityID int32, itemUID int64, count int32) (*TradeView, error) {
+	if count < 0 {
+		return nil, ErrTradeItemInvalid
+	}
+	var item *persistence.DbItem
+	if count > 0 {
+		var err error
+		item, err = s.items.GetItem(ctx, itemUID)
+		if err != nil || item.CharacterID != int64(entityID) || item.Location != ItemLocationBag || item.Bound || count > item.Count {
+			return nil, ErrTradeItemInvalid
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, offer, err := s.openTradeLocked(entityID)
+	if err != nil {
+		return nil, err
+	}
+	idx := -1
+	for i, it := range offer.Items {
+		if it.ItemUID == itemUID {
+			idx = i
+		}
+	}
+	switch {
+	case count == 0 && idx >= 0:
+		offer.Items = append(offer.Items[:idx], offer.Items[idx+1:]...)
+	case count > 0 && idx >= 0:
+		offer.Items[idx].Count = count
+	case count > 0:
+		if len(offer.Items) >= tradeMaxItems {
+			return nil, ErrTradeTooManyItems
+		}
+		offer.Items = append(offer.Items, TradeItemView{ItemUID: itemUID, ItemID: item.ItemID, Count: count, Enhance: item.Enhance})
+	}
+	t.resetLocked()
+	return s.updatedLocked(t), nil
+}
+
+// SetGold 设置放入交易的金币，会重置双方的锁定与确认
+func (s *TradeService) SetGold(ctx context.Context, entityID int32, gold int64) (*TradeView, error) {
+	if gold < 0 {
+		return nil, ErrInsufficientGold
+	}
+	if gold > 0 {
+		have, err := s.wallet.Balance(ctx, int64(entityID), CurrencyGold)
+		if err != nil {
+			return nil, err
+		}
+		if have < gold {
+			return nil, ErrInsufficientGold
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, offer, err := s.openTradeLocked(entityID)
+	if err != nil {
+		return nil, err
+	}
+	offer.Gold = gold
+	t.resetLocked()
+	return s.updatedLocked(t), nil
+}
+
+// Lock 锁定/解锁自己的出价；解锁会清除双方的确认
+func (s *TradeService) Lock(ctx context.Context, entityID int32, locked bool) (*TradeView, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, offer, err := s.openTradeLocked(entityID)
+	if err != nil {
+		return nil, err
+	}
+	offer.Locked = locked
+	if !locked {
+		t.offers[0].Confirmed, t.offers[1].Confirmed = false, false
+	}
+	return s.updatedLocked(t), nil
+}
+
+// Confirm 确认交易：双方均已锁定才可确认，双方都确认后执行交换
+func (s *TradeService) Confirm(ctx context.Context, entityID int32) (*TradeView, error) {
+	s.mu.Lock()
+	t, offer, err := s.openTradeLocked(entityID)
+	if err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	if !t.offers[0].Locked || !t.offers[1].Locked {
+		s.mu.Unlock()
+		return nil, ErrTradeNotLocked
+	}
+	offer.Confirmed = true
+	if !t.offers[0].Confirmed || !t.offers[1].Confirmed {
+		view := s.updatedLocked(t)
+		s.mu.Unlock()
+		return view, nil
+	}
+	// 执行期间出价冻结，释放服务锁后再进行数据库事务，不阻塞其他交易
+	t.executing = true
+	s.mu.Unlock()
+
+	err = s.execute(ctx, t)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t.executing = false
+	if err != nil {
+		if t.endReason != "" {
+			s.endLocked(t, TradeStateCancelled, t.endReason)
+			return nil, err
+		}
+		// 交换失败（物品或金币已变化、背包已满）时回到未锁定状态，由双方重新确认
+		t.resetLocked()
+		t.offers[0].Locked, t.offers[1].Locked = false, false
+		s.updatedLocked(t)
+		return nil, err
+	}
+	return s.endLocked(t, TradeStateCompleted, TradeEndCompleted), nil
+}
+
+// Cancel 主动取消交易或邀请
+func (s *TradeService) Cancel(ctx context.Context, entityID int32) (*TradeView, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.trades[s.playerTrade[entityID]]
+	if !ok {
+		return nil, ErrTradeNotFound
+	}
+	if t.executing {
+		return nil, ErrTradeExecuting
+	}
+	return s.endLocked(t, TradeStateCancelled, TradeEndCancelled), nil
+}
+
+// Forget 玩家下线时取消其交易
+func (s *TradeService) Forget(entityID int32) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t, ok := s.trades[s.playerTrade[entityID]]; ok {
+		if t.executing {
+			t.endReason = TradeEndDisconnect
+			return
+		}
+		s.endLocked(t, TradeStateCancelled, TradeEndDisconnect)
+	}
+}
+
+// Update 取消过期的邀请与双方离开交易距离（或离开地图）的交易
+func (s *TradeService) Update(ctx context.Context, _ time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := make([]int32, 0, len(s.trades))
+	for id := range s.trades {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	now := time.Now()
+	for _, id := range ids {
+		t := s.trades[id]
+		if t.executing {
+			continue
+		}
+		if t.state == TradeStatePending && now.After(t.expiresAt) {
+			s.endLocked(t, TradeStateCancelled, TradeEndExpired)
+			continue
+		}
+		if gameMap, err := s.inRange(t.offers[0].EntityID, t.offers[1].EntityID); err != nil || gameMap != t.gameMap {
+			s.endLocked(t, TradeStateCancelled, TradeEndOutOfRange)
+		}
+	}
+	return nil
+}
+
+// execute 在同一事务内交换双方物品与金币并写入审计记录：先取出双方物品再放入，避免背包满时互相阻塞。
+// 调用时不持有服务锁，出价由 executing 标记冻结
+func (s *TradeService) execute(ctx context.Context, t *tradeSession) error {
+	record := &persistence.TradeRecordDocument{TradeID: t.id, MapID: t.gameMap.ID()}
+	ids := []int64{int64(t.offers[0].EntityID), int64(t.offers[1].EntityID)}
+	return s.items.Transact(ctx, ids, func(txCtx context.Context, txs map[int64]*InventoryTx) error {
+		var moving [2][]*persistence.DbItem
+		for i, offer := range t.offers {
+			party := persistence.TradePartyRecord{CharacterID: ids[i], Gold: offer.Gold}
+			for _, it := range offer.Items {
+				item, err := txs[ids[i]].Extract(it.ItemUID, it.Count)
+				if err != nil {
+					return err
+				}
+				moving[i] = append(moving[i], item)
+				party.Items = append(party.Items, persistence.TradeItemRecord{ItemUID: it.ItemUID, ItemID: it.ItemID, Count: it.Count})
+			}
+			record.Parties[i] = party
+		}
+		for i, items := range moving {
+			for _, item := range items {
+				if err := txs[ids[1-i]].Insert(item); err != nil {
+					return err
+				}
+			}
+		}
+		for i, offer := range t.offers {
+			if offer.Gold == 0 {
+				continue
+			}
+			if _, err := s.wallet.Apply(txCtx, WalletChange{
+				CharacterID: ids[i],
+				Currency:    CurrencyGold,
+				Delta:       -offer.Gold,
+				Reason:      WalletReasonTrade,
+				Source:      fmt.Sprintf("character:%d", ids[1-i]),
+				Key:         fmt.Sprintf("trade:%s:%d:pay", t.uid, ids[i]),
+			}); err != nil {
+				return err
+			}
+			if _, err := s.wallet.Apply(txCtx, WalletChange{
+				CharacterID: ids[1-i],
+				Currency:    CurrencyGold,
+				Delta:       offer.Gold,
+				Reason:      WalletReasonTrade,
+				Source:      fmt.Sprintf("character:%d", ids[i]),
+				Key:         fmt.Sprintf("trade:%s:%d:receive", t.uid, ids[1-i]),
+			}); err != nil {
+				return err
+			}
+		}
+		if s.audit == nil {
+			return nil
+		}
+		return s.audit.Save(txCtx, record)
+	})
+}
+
+// openTradeLocked 玩家所在的进行中交易及其出价
+func (s *TradeService) openTradeLocked(entityID int32) (*tradeSession, *TradeOfferView, error) {
+	t, ok := s.trades[s.playerTrade[entityID]]
+	if !ok {
+		return nil, nil, ErrTradeNotFound
+	}
+	if t.state != TradeStateOpen {
+		return nil, nil, ErrTradeNotOpen
+	}
+	if t.executing {
+		return nil, nil, ErrTradeExecuting
+	}
+	if t.offers[0].EntityID == entityID {
+		return t, t.offers[0], nil
+	}
+	return t, t.offers[1], nil
+}
+
+// endLocked 结束交易并通知双方
+func (s *TradeService) endLocked(t *tradeSession, state TradeState, reason string) *TradeView {
+	t.state = state
+	delete(s.trades, t.id)
+	for _, offer := range t.offers {
+		if s.playerTrade[offer.EntityID] == t.id {
+			delete(s.playerTrade, offer.EntityID)
+		}
+	}
+	view := t.view()
+	view.Reason = reason
+	s.push([]int32{t.offers[0].EntityID, t.offers[1].EntityID}, "trade_end", view)
+	return view
+}
+
+// updatedLocked 通知双方交易变化
+func (s *TradeService) updatedLocked(t *tradeSession) *TradeView {
+	view := t.view()
+	s.push([]int32{t.offers[0].EntityID, t.offers[1].EntityID}, "trade_update", view)
+	return view
+}
+
+// inRange 双方在同一地图线路内且距离不超过交易距离
+func (s *TradeService) inRange(a, b int32) (*mapmanager.Map, error) {
+	gameMap, actor, err := s.mapService.LocateActor(a)
+	if err != nil {
+		return nil, ErrTradeTargetInvalid
+	}
+	other := gameMap.GetActor(character.EntityID(b))
+	if other == nil || other.Type() != character.EntityTypePlayer {
+		return nil, ErrTradeTargetInvalid
+	}
+	if actor.DistanceTo(other.Entity) > tradeRange {
+		return nil, ErrTradeOutOfRange
+	}
+	return gameMap, nil
+}
+
+// push 推送交易通知
+func (s *TradeService) push(recipients []int32, topic string, payload interface{}) {
+	if s.broadcast == nil {
+		return
+	}
+	ids := make([]character.EntityID, 0, len(recipients))
+	for _, id := range recipients {
+		ids = append(ids, character.EntityID(id))
+	}
+	s.broadcast(ids, topic, payload)
+}
+
+// resetLocked 出价变化：清除双方的锁定与确认
+func (t *tradeSession) resetLocked() {
+	for _, offer := range t.offers {
+		offer.Locked, offer.Confirmed = false, false
+	}
+}
+
+// view 交易快照
+func (t *tradeSession) view() *TradeView {
+	view := &TradeView{TradeID: t.id, State: t.state}
+	for i, offer := range t.offers {
+		view.Offers[i] = *offer
+		view.Offers[i].Items = append([]TradeItemView(nil), offer.Items...)
+	}
+	return view
+}
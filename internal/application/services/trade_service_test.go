@@ -0,0 +1,505 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"greatestworks/internal/domain/character"
+	"greatestworks/internal/infrastructure/persistence"
+)
+
+// memTxKey 标记 ctx 已处于内存事务中（嵌套事务加入外层事务）
+type memTxKey struct{}
+
+// memItemStore 内存物品存储：事务 fn 返回错误（或注入的提交错误）时物品与关联钱包整体回滚
+type memItemStore struct {
+	txMu      sync.Mutex
+	mu        sync.Mutex
+	items     map[int64]persistence.DbItem
+	nextUID   int64
+	wallet    *memWallet
+	commitErr error
+}
+
+func newMemItemStore(wallet *memWallet, items ...*persistence.DbItem) *memItemStore {
+	s := &memItemStore{items: make(map[int64]persistence.DbItem), nextUID: 1000, wallet: wallet}
+	for _, item := range items {
+		s.items[item.ItemUID] = *item
+	}
+	return s
+}
+
+func (s *memItemStore) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(memTxKey{}) != nil {
+		return fn(ctx)
+	}
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+	items := s.snapshot()
+	var balances map[walletAccount]int64
+	var entries map[string]WalletChange
+	if s.wallet != nil {
+		balances, entries = s.wallet.snapshot()
+	}
+	err := fn(context.WithValue(ctx, memTxKey{}, true))
+	if err == nil {
+		err = s.commitErr
+	}
+	if err != nil {
+		s.mu.Lock()
+		s.items = items
+		s.mu.Unlock()
+		if s.wallet != nil {
+			s.wallet.restore(balances, entries)
+		}
+	}
+	return err
+}
+
+func (s *memItemStore) LockInventory(context.Context, int64) error { return nil }
+
+func (s *memItemStore) NextItemUIDs(_ context.Context, n int32) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	first := s.nextUID
+	s.nextUID += int64(n)
+	return first, nil
+}
+
+func (s *memItemStore) FindByCharacterID(_ context.Context, characterID int64) ([]*persistence.DbItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var items []*persistence.DbItem
+	for _, item := range s.items {
+		if item.CharacterID == characterID {
+			item := item
+			items = append(items, &item)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ItemUID < items[j].ItemUID })
+	return items, nil
+}
+
+func (s *memItemStore) FindByUID(_ context.Context, itemUID int64) (*persistence.DbItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.items[itemUID]
+	if !ok {
+		return nil, fmt.Errorf("item %d not found", itemUID)
+	}
+	return &item, nil
+}
+
+func (s *memItemStore) Create(_ context.Context, item *persistence.DbItem) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items[item.ItemUID] = *item
+	return nil
+}
+
+func (s *memItemStore) Update(_ context.Context, item *persistence.DbItem) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items[item.ItemUID] = *item
+	return nil
+}
+
+func (s *memItemStore) Delete(_ context.Context, itemUID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.items, itemUID)
+	return nil
+}
+
+func (s *memItemStore) snapshot() map[int64]persistence.DbItem {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	items := make(map[int64]persistence.DbItem, len(s.items))
+	for uid, item := range s.items {
+		items[uid] = item
+	}
+	return items
+}
+
+// holdings 角色持有的物品：物品ID -> 数量
+func (s *memItemStore) holdings(characterID int64) map[int32]int32 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	held := make(map[int32]int32)
+	for _, item := range s.items {
+		if item.CharacterID == characterID {
+			held[item.ItemID] += item.Count
+		}
+	}
+	return held
+}
+
+type walletAccount struct {
+	characterID int64
+	currency    Currency
+}
+
+// memWallet 内存钱包：与 WalletService 相同的幂等键语义
+type memWallet struct {
+	mu       sync.Mutex
+	balances map[walletAccount]int64
+	entries  map[string]WalletChange
+}
+
+func newMemWallet(gold map[int64]int64) *memWallet {
+	w := &memWallet{balances: make(map[walletAccount]int64), entries: make(map[string]WalletChange)}
+	for id, amount := range gold {
+		w.balances[walletAccount{id, CurrencyGold}] = amount
+	}
+	return w
+}
+
+func (w *memWallet) Balance(_ context.Context, characterID int64, currency Currency) (int64, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.balances[walletAccount{characterID, currency}], nil
+}
+
+func (w *memWallet) Apply(_ context.Context, change WalletChange) (int64, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	account := walletAccount{change.CharacterID, change.Currency}
+	if prev, ok := w.entries[change.Key]; ok && change.Key != "" {
+		if prev.CharacterID != change.CharacterID || prev.Currency != change.Currency || prev.Delta != change.Delta {
+			return 0, ErrWalletEntryConflict
+		}
+		return w.balances[account], nil
+	}
+	if w.balances[account]+change.Delta < 0 {
+		return 0, insufficient(change.Currency)
+	}
+	w.balances[account] += change.Delta
+	if change.Key != "" {
+		w.entries[change.Key] = change
+	}
+	return w.balances[account], nil
+}
+
+func (w *memWallet) gold(characterID int64) int64 {
+	balance, _ := w.Balance(context.Background(), characterID, CurrencyGold)
+	return balance
+}
+
+func (w *memWallet) snapshot() (map[walletAccount]int64, map[string]WalletChange) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	balances := make(map[walletAccount]int64, len(w.balances))
+	for k, v := range w.balances {
+		balances[k] = v
+	}
+	entries := make(map[string]WalletChange, len(w.entries))
+	for k, v := range w.entries {
+		entries[k] = v
+	}
+	return balances, entries
+}
+
+func (w *memWallet) restore(balances map[walletAccount]int64, entries map[string]WalletChange) {
+	w.mu.Lock()
+	w.balances, w.entries = balances, entries
+	w.mu.Unlock()
+}
+
+// stubTradeAudit 审计记录：entered 非空时在写入前通知并等待 release
+type stubTradeAudit struct {
+	err     error
+	entered chan struct{}
+	release chan struct{}
+	records []*persistence.TradeRecordDocument
+}
+
+func (a *stubTradeAudit) Save(_ context.Context, doc *persistence.TradeRecordDocument) error {
+	if a.entered != nil {
+		a.entered <- struct{}{}
+		<-a.release
+	}
+	if a.err != nil {
+		return a.err
+	}
+	a.records = append(a.records, doc)
+	return nil
+}
+
+// tradeEnds 记录交易结束通知
+type tradeEnds struct {
+	mu   sync.Mutex
+	list []*TradeView
+}
+
+func (e *tradeEnds) broadcast(_ []character.EntityID, topic string, payload interface{}) {
+	if view, ok := payload.(*TradeView); ok && topic == "trade_end" {
+		e.mu.Lock()
+		e.list = append(e.list, view)
+		e.mu.Unlock()
+	}
+}
+
+func ownedItem(uid, characterID int64, itemID, count, slot int32) *persistence.DbItem {
+	item := bagItem(uid, itemID, count, slot)
+	item.CharacterID = characterID
+	return item
+}
+
+// newTradeTest 玩家1（铁剑、1000 金币）与玩家2（5 瓶药水、500 金币）相邻
+func newTradeTest(t *testing.T) (*TradeService, *memItemStore, *memWallet, *stubTradeAudit, *tradeEnds) {
+	t.Helper()
+	ms := NewMapService()
+	for id, x := range map[int32]float32{1: 10, 2: 12} {
+		if err := ms.EnterMapActor(context.Background(), newTestPlayer(t, id, 10), testArenaMapID, x, 0, 10); err != nil {
+			t.Fatalf("enter %d: %v", id, err)
+		}
+	}
+	wallet := newMemWallet(map[int64]int64{1: 1000, 2: 500})
+	store := newMemItemStore(wallet, ownedItem(1, 1, testSwordID, 1, 0), ownedItem(2, 2, testPotionID, 5, 0))
+	audit := &stubTradeAudit{}
+	ends := &tradeEnds{}
+	ts := NewTradeService(ms, NewItemService(store), wallet, audit)
+	ts.SetBroadcaster(ends.broadcast)
+	return ts, store, wallet, audit, ends
+}
+
+// lockedTrade 开启交易：玩家1 出铁剑与 100 金币，玩家2 出 5 瓶药水与 30 金币，双方锁定
+func lockedTrade(t *testing.T, ts *TradeService) int32 {
+	t.Helper()
+	ctx := context.Background()
+	view, err := ts.Invite(ctx, 1, 2)
+	if err != nil {
+		t.Fatalf("invite: %v", err)
+	}
+	if _, err := ts.Respond(ctx, 2, view.TradeID, true); err != nil {
+		t.Fatalf("respond: %v", err)
+	}
+	steps := []func() (*TradeView, error){
+		func() (*TradeView, error) { return ts.SetItem(ctx, 1, 1, 1) },
+		func() (*TradeView, error) { return ts.SetGold(ctx, 1, 100) },
+		func() (*TradeView, error) { return ts.SetItem(ctx, 2, 2, 5) },
+		func() (*TradeView, error) { return ts.SetGold(ctx, 2, 30) },
+		func() (*TradeView, error) { return ts.Lock(ctx, 1, true) },
+		func() (*TradeView, error) { return ts.Lock(ctx, 2, true) },
+	}
+	for i, step := range steps {
+		if _, err := step(); err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+	}
+	return view.TradeID
+}
+
+func TestTradeSwap(t *testing.T) {
+	ctx := context.Background()
+	ts, store, wallet, audit, _ := newTradeTest(t)
+	lockedTrade(t, ts)
+	if _, err := ts.Confirm(ctx, 1); err != nil {
+		t.Fatalf("confirm 1: %v", err)
+	}
+	view, err := ts.Confirm(ctx, 2)
+	if err != nil || view.State != TradeStateCompleted {
+		t.Fatalf("confirm 2: view %+v err %v", view, err)
+	}
+	if got := store.holdings(1); !reflect.DeepEqual(got, map[int32]int32{testPotionID: 5}) {
+		t.Fatalf("player 1 holds %v", got)
+	}
+	if got := store.holdings(2); !reflect.DeepEqual(got, map[int32]int32{testSwordID: 1}) {
+		t.Fatalf("player 2 holds %v", got)
+	}
+	if g1, g2 := wallet.gold(1), wallet.gold(2); g1 != 930 || g2 != 570 {
+		t.Fatalf("gold %d/%d, want 930/570", g1, g2)
+	}
+	if len(audit.records) != 1 {
+		t.Fatalf("%d audit records", len(audit.records))
+	}
+}
+
+func TestTradeLockAndConfirmRules(t *testing.T) {
+	ctx := context.Background()
+	ts, _, _, _, _ := newTradeTest(t)
+	lockedTrade(t, ts)
+
+	if _, err := ts.Lock(ctx, 2, false); err != nil {
+		t.Fatalf("unlock: %v", err)
+	}
+	if _, err := ts.Confirm(ctx, 1); !errors.Is(err, ErrTradeNotLocked) {
+		t.Fatalf("confirm with one side unlocked: err %v", err)
+	}
+	if _, err := ts.Lock(ctx, 2, true); err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+	if _, err := ts.Confirm(ctx, 1); err != nil {
+		t.Fatalf("confirm: %v", err)
+	}
+	// 修改出价清除双方的锁定与确认
+	view, err := ts.SetGold(ctx, 2, 40)
+	if err != nil {
+		t.Fatalf("set gold: %v", err)
+	}
+	for _, offer := range view.Offers {
+		if offer.Locked || offer.Confirmed {
+			t.Fatalf("offer %+v still locked after a change", offer)
+		}
+	}
+	// 解锁清除确认
+	ts.Lock(ctx, 1, true)
+	ts.Lock(ctx, 2, true)
+	ts.Confirm(ctx, 1)
+	if view, _ = ts.Lock(ctx, 2, false); view.Offers[0].Confirmed {
+		t.Fatalf("unlocking kept the other side's confirmation")
+	}
+}
+
+func TestTradeFrozenWhileExecuting(t *testing.T) {
+	ctx := context.Background()
+	ts, store, wallet, audit, ends := newTradeTest(t)
+	lockedTrade(t, ts)
+	if _, err := ts.Confirm(ctx, 1); err != nil {
+		t.Fatalf("confirm 1: %v", err)
+	}
+	audit.entered, audit.release = make(chan struct{}), make(chan struct{})
+	audit.err = errors.New("audit unavailable")
+	done := make(chan error, 1)
+	go func() {
+		_, err := ts.Confirm(ctx, 2)
+		done <- err
+	}()
+	select {
+	case <-audit.entered:
+	case <-time.After(time.Second):
+		t.Fatalf("swap did not start")
+	}
+
+	// 交换执行中：出价冻结，不可取消；计时不结束交易；下线只记录原因
+	if _, err := ts.Cancel(ctx, 1); !errors.Is(err, ErrTradeExecuting) {
+		t.Fatalf("cancel: err %v, want ErrTradeExecuting", err)
+	}
+	if _, err := ts.SetGold(ctx, 1, 0); !errors.Is(err, ErrTradeExecuting) {
+		t.Fatalf("set gold: err %v, want ErrTradeExecuting", err)
+	}
+	if _, err := ts.Lock(ctx, 2, false); !errors.Is(err, ErrTradeExecuting) {
+		t.Fatalf("unlock: err %v, want ErrTradeExecuting", err)
+	}
+	if err := ts.Update(ctx, 0); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	ts.Forget(2)
+	if len(ends.list) != 0 {
+		t.Fatalf("trade ended while executing: %+v", ends.list[0])
+	}
+
+	close(audit.release)
+	if err := <-done; !errors.Is(err, audit.err) {
+		t.Fatalf("confirm 2: err %v, want the audit error", err)
+	}
+	if len(ends.list) != 1 || ends.list[0].Reason != TradeEndDisconnect {
+		t.Fatalf("failed swap after disconnect should end the trade, got %+v", ends.list)
+	}
+	if _, err := ts.Cancel(ctx, 1); !errors.Is(err, ErrTradeNotFound) {
+		t.Fatalf("trade still open: err %v", err)
+	}
+	if store.holdings(1)[testSwordID] != 1 || wallet.gold(1) != 1000 {
+		t.Fatalf("failed swap changed player 1")
+	}
+}
+
+func TestTradeFailedSwapRollsBack(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(store *memItemStore, wallet *memWallet, audit *stubTradeAudit)
+		want  error
+	}{
+		{"audit fails after the swap", func(_ *memItemStore, _ *memWallet, audit *stubTradeAudit) {
+			audit.err = errors.New("audit unavailable")
+		}, nil},
+		{"commit fails", func(store *memItemStore, _ *memWallet, _ *stubTradeAudit) {
+			store.commitErr = errors.New("commit failed")
+		}, nil},
+		{"gold spent after locking", func(_ *memItemStore, wallet *memWallet, _ *stubTradeAudit) {
+			wallet.Apply(context.Background(), WalletChange{CharacterID: 1, Currency: CurrencyGold, Delta: -950, Reason: WalletReasonShopBuy})
+		}, ErrInsufficientGold},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			ts, store, wallet, audit, _ := newTradeTest(t)
+			lockedTrade(t, ts)
+			tt.setup(store, wallet, audit)
+			items := store.snapshot()
+			g1, g2 := wallet.gold(1), wallet.gold(2)
+
+			ts.Confirm(ctx, 1)
+			_, err := ts.Confirm(ctx, 2)
+			if err == nil || (tt.want != nil && !errors.Is(err, tt.want)) {
+				t.Fatalf("err %v, want %v", err, tt.want)
+			}
+			if !reflect.DeepEqual(store.snapshot(), items) {
+				t.Fatalf("inventories changed by a failed swap")
+			}
+			if wallet.gold(1) != g1 || wallet.gold(2) != g2 {
+				t.Fatalf("gold %d/%d, want %d/%d", wallet.gold(1), wallet.gold(2), g1, g2)
+			}
+			// 交易保持开启，回到未锁定状态等待双方重新确认
+			view, err := ts.SetGold(ctx, 2, 30)
+			if err != nil {
+				t.Fatalf("trade not reopened: %v", err)
+			}
+			for _, offer := range view.Offers {
+				if offer.Locked || offer.Confirmed {
+					t.Fatalf("offer %+v still locked after a failed swap", offer)
+				}
+			}
+		})
+	}
+}
+
+func TestTradeLedgerKeysIdempotent(t *testing.T) {
+	ctx := context.Background()
+	ts, _, wallet, _, _ := newTradeTest(t)
+	goldTrade := func() *tradeSession {
+		view, _ := ts.Invite(ctx, 1, 2)
+		ts.Respond(ctx, 2, view.TradeID, true)
+		ts.SetGold(ctx, 1, 100)
+		ts.SetGold(ctx, 2, 30)
+		ts.mu.Lock()
+		defer ts.mu.Unlock()
+		return ts.trades[view.TradeID]
+	}
+
+	first := goldTrade()
+	for i := 0; i < 2; i++ {
+		if err := ts.execute(ctx, first); err != nil {
+			t.Fatalf("execute %d: %v", i, err)
+		}
+	}
+	if g1, g2 := wallet.gold(1), wallet.gold(2); g1 != 930 || g2 != 570 {
+		t.Fatalf("gold %d/%d after a retried swap, want 930/570", g1, g2)
+	}
+	if len(wallet.entries) != 4 {
+		t.Fatalf("%d ledger entries, want pay and receive for both sides", len(wallet.entries))
+	}
+	for key := range wallet.entries {
+		if !strings.Contains(key, first.uid) {
+			t.Fatalf("ledger key %q not scoped to the trade", key)
+		}
+	}
+
+	// 相同出价的另一笔交易使用新的键
+	ts.mu.Lock()
+	ts.endLocked(first, TradeStateCompleted, TradeEndCompleted)
+	ts.mu.Unlock()
+	if err := ts.execute(ctx, goldTrade()); err != nil {
+		t.Fatalf("second trade: %v", err)
+	}
+	if g1, g2 := wallet.gold(1), wallet.gold(2); g1 != 860 || g2 != 640 {
+		t.Fatalf("gold %d/%d after the second trade, want 860/640", g1, g2)
+	}
+}
@@ -0,0 +1,224 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"greatestworks/internal/infrastructure/persistence"
+)
+
+// Currency 钱包货币
+type Currency string
+
+// 货币种类
+const (
+	CurrencyGold       Currency = "gold"        // 金币
+	CurrencyBoundGold  Currency = "bound_gold"  // 绑定金币（不可交易）
+	CurrencyHonor      Currency = "honor"       // 荣誉点
+	CurrencyEventToken Currency = "event_token" // 活动代币
+)
+
+// Currencies 全部货币
+var Currencies = []Currency{CurrencyGold, CurrencyBoundGold, CurrencyHonor, CurrencyEventToken}
+
+// Valid 是否为已知货币
+func (c Currency) Valid() bool {
+	for _, known := range Currencies {
+		if c == known {
+			return true
+		}
+	}
+	return false
+}
+
+// 流水原因
+const (
+	WalletReasonOpening       = "opening_balance" // 开户时转入角色原有金币
+	WalletReasonReward        = "reward"
+	WalletReasonShopBuy       = "shop_buy"
+	WalletReasonShopSell      = "shop_sell"
+	WalletReasonShopBuyback   = "shop_buyback"
+	WalletReasonAuctionFee    = "auction_fee"
+	WalletReasonAuctionBid    = "auction_bid"
+	WalletReasonAuctionBuyout = "auction_buyout"
+	WalletReasonMailClaim     = "mail_claim"
+	WalletReasonTrade         = "trade"
+	WalletReasonEnhance       = "enhance"
+)
+
+// 钱包错误
+var (
+	ErrInsufficientGold    = errors.New("insufficient gold")
+	ErrInsufficientBalance = errors.New("insufficient balance")
+	ErrInvalidCurrency     = errors.New("invalid currency")
+	ErrWalletEntryConflict = errors.New("ledger entry key already used by a different change")
+)
+
+// walletHistoryLimit 单次查询流水的最大条数
+const walletHistoryLimit = 200
+
+// WalletChange 一次余额变动
+type WalletChange struct {
+	CharacterID int64
+	Currency    Currency
+	Delta       int64
+	Reason      string
+	Source      string // 来源，如 npc:1001、auction:42、character:10086
+	Key         string // 幂等键：相同键只入账一次，重复提交返回首次入账后的余额；为空时每次都是新的变动
+}
+
+// WalletStore 钱包读写（由 WalletService 实现，Apply 可在事务内调用）
+type WalletStore interface {
+	Balance(ctx context.Context, characterID int64, currency Currency) (int64, error)
+	Apply(ctx context.Context, change WalletChange) (int64, error)
+}
+
+// WalletService 角色钱包：多货币余额只经流水变动，余额不为负，每笔变动带原因与来源可追溯；
+// 角色首次使用钱包时原有金币以开户流水转入
+type WalletService struct {
+	mu            sync.Mutex
+	repo          *persistence.WalletRepository
+	characterRepo *persistence.CharacterRepository
+	opened        map[int64]bool
+}
+
+// NewWalletService 创建钱包服务
+func NewWalletService(repo *persistence.WalletRepository, characterRepo *persistence.CharacterRepository) *WalletService {
+	return &WalletService{
+		repo:          repo,
+		characterRepo: characterRepo,
+		opened:        make(map[int64]bool),
+	}
+}
+
+// Balance 查询单一货币余额
+func (s *WalletService) Balance(ctx context.Context, characterID int64, currency Currency) (int64, error) {
+	balances, err := s.Balances(ctx, characterID)
+	if err != nil {
+		return 0, err
+	}
+	if !currency.Valid() {
+		return 0, ErrInvalidCurrency
+	}
+	return balances[currency], nil
+}
+
+// Balances 查询全部货币余额（只读）：钱包尚未开户时金币按角色原有金币返回，不写入开户流水
+func (s *WalletService) Balances(ctx context.Context, characterID int64) (map[Currency]int64, error) {
+	stored, exists, err := s.repo.Balances(ctx, characterID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		dbChar, err := s.characterRepo.FindByID(ctx, characterID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load character: %w", err)
+		}
+		stored = map[string]int64{string(CurrencyGold): max(dbChar.Gold, 0)}
+	}
+	balances := make(map[Currency]int64, len(Currencies))
+	for _, c := range Currencies {
+		balances[c] = stored[string(c)]
+	}
+	return balances, nil
+}
+
+// Apply 按变动增减余额并记录流水，返回变动后的余额；扣除超过余额时不做修改。
+// 在事务上下文中调用时随事务提交，否则自行开启事务
+func (s *WalletService) Apply(ctx context.Context, change WalletChange) (int64, error) {
+	if !change.Currency.Valid() {
+		return 0, ErrInvalidCurrency
+	}
+	if change.Reason == "" {
+		return 0, fmt.Errorf("wallet change without reason")
+	}
+	var balance int64
+	apply := func(txCtx context.Context) error {
+		if err := s.open(txCtx, change.CharacterID); err != nil {
+			return err
+		}
+		entry := &persistence.LedgerEntryDocument{
+			EntryKey:    change.Key,
+			CharacterID: change.CharacterID,
+			Currency:    string(change.Currency),
+			Delta:       change.Delta,
+			Reason:      change.Reason,
+			Source:      change.Source,
+		}
+		applied, ok, err := s.repo.Apply(txCtx, entry)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return insufficient(change.Currency)
+		}
+		if applied.CharacterID != entry.CharacterID || applied.Currency != entry.Currency || applied.Delta != entry.Delta {
+			return ErrWalletEntryConflict
+		}
+		balance = applied.Balance
+		return nil
+	}
+	if err := s.repo.WithTransaction(ctx, apply); err != nil {
+		return 0, err
+	}
+	return balance, nil
+}
+
+// History 按时间倒序查询流水（客服排查争议使用）
+func (s *WalletService) History(ctx context.Context, filter persistence.LedgerFilter) ([]*persistence.LedgerEntryDocument, error) {
+	if filter.Currency != "" && !Currency(filter.Currency).Valid() {
+		return nil, ErrInvalidCurrency
+	}
+	if filter.Limit <= 0 || filter.Limit > walletHistoryLimit {
+		filter.Limit = walletHistoryLimit
+	}
+	return s.repo.FindEntries(ctx, filter)
+}
+
+// open 首次使用钱包时以开户流水转入角色原有金币（流水键固定，重复开户不会重复转入）
+func (s *WalletService) open(ctx context.Context, characterID int64) error {
+	s.mu.Lock()
+	opened := s.opened[characterID]
+	s.mu.Unlock()
+	if opened {
+		return nil
+	}
+
+	_, exists, err := s.repo.Balances(ctx, characterID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		dbChar, err := s.characterRepo.FindByID(ctx, characterID)
+		if err != nil {
+			return fmt.Errorf("failed to load character: %w", err)
+		}
+		if _, _, err := s.repo.Apply(ctx, &persistence.LedgerEntryDocument{
+			EntryKey:    fmt.Sprintf("opening:%d", characterID),
+			CharacterID: characterID,
+			Currency:    string(CurrencyGold),
+			Delta:       max(dbChar.Gold, 0),
+			Reason:      WalletReasonOpening,
+			Source:      fmt.Sprintf("character:%d", characterID),
+		}); err != nil {
+			return err
+		}
+	}
+	// 事务内开户可能随事务回滚，提交前不记为已开户
+	if !s.repo.InTransaction(ctx) {
+		s.mu.Lock()
+		s.opened[characterID] = true
+		s.mu.Unlock()
+	}
+	return nil
+}
+
+// insufficient 余额不足错误（金币沿用 ErrInsufficientGold）
+func insufficient(currency Currency) error {
+	if currency == CurrencyGold {
+		return ErrInsufficientGold
+	}
+	return ErrInsufficientBalance
+}
@@ -10,12 +10,15 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 
 	"greatestworks/internal/application/handlers"
+	appsvc "greatestworks/internal/application/services"
 	"greatestworks/internal/config"
 	"greatestworks/internal/database"
 	"greatestworks/internal/events"
+	"greatestworks/internal/infrastructure/auth"
 	"greatestworks/internal/infrastructure/logging"
 	"greatestworks/internal/infrastructure/messaging"
 	"greatestworks/internal/infrastructure/monitoring"
+	"greatestworks/internal/infrastructure/persistence"
 	httpiface "greatestworks/internal/interfaces/http"
 	"greatestworks/internal/interfaces/rpc"
 )
@@ -37,6 +40,9 @@ type GameBootstrap struct {
 	commandBus *handlers.CommandBus
 	queryBus   *handlers.QueryBus
 
+	// app services
+	walletService *appsvc.WalletService
+
 	ctx    context.Context
 	cancel context.CancelFunc
 }
@@ -180,10 +186,19 @@ func (s *GameBootstrap) initializeInfrastructure(cfg *config.Config) error {
 }
 
 func (s *GameBootstrap) initializeApplicationLayer(cfg *config.Config) error {
-	_ = cfg
 	s.logger.Info("初始化应用服务层")
 	s.commandBus = handlers.NewCommandBus()
 	s.queryBus = handlers.NewQueryBus()
+
+	db := s.mongoClient.Database(cfg.Database.MongoDB.Database)
+	walletRepo := persistence.NewWalletRepository(db)
+	if cfg.Database.MongoDB.AllowNonTransactional {
+		if cfg.App.Environment != "development" {
+			return fmt.Errorf("mongodb.allow_non_transactional is only allowed in development (environment: %s)", cfg.App.Environment)
+		}
+		walletRepo.AllowNonTransactional(s.logger)
+	}
+	s.walletService = appsvc.NewWalletService(walletRepo, persistence.NewCharacterRepository(db))
 	s.logger.Info("应用服务层初始化完成")
 	return nil
 }
@@ -192,6 +207,12 @@ func (s *GameBootstrap) initializeHTTPServer(cfg *config.Config) error {
 	s.logger.Info("初始化HTTP服务器")
 	httpConfig := &httpiface.ServerConfig{Host: cfg.Server.HTTP.Host, Port: cfg.Server.HTTP.Port, ReadTimeout: cfg.Server.HTTP.ReadTimeout, WriteTimeout: cfg.Server.HTTP.WriteTimeout, IdleTimeout: cfg.Server.HTTP.IdleTimeout}
 	s.httpServer = httpiface.NewServer(httpConfig, s.logger)
+	jwtService := auth.NewJWTService(&auth.JWTConfig{
+		Secret:   cfg.Security.JWT.Secret,
+		Issuer:   cfg.Security.JWT.Issuer,
+		Audience: cfg.Security.JWT.Audience,
+	}, s.logger)
+	httpiface.RegisterWalletRoutes(s.httpServer, httpiface.NewWalletHTTPHandlers(s.walletService, s.logger), jwtService)
 	if cfg.Monitoring.Profiling.Enabled && cfg.Monitoring.Profiling.Host == cfg.Server.HTTP.Host && cfg.Monitoring.Profiling.Port == cfg.Server.HTTP.Port {
 		s.httpServer.EnableProfiling()
 	}
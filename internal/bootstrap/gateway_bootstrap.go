@@ -41,8 +41,24 @@ type GatewayBootstrap struct {
 	mapService       *appServices.MapService
 	fightService     *appServices.FightService
 	characterService *appServices.CharacterService
+	walletService    *appServices.WalletService
+	portalService    *appServices.PortalService
+	lootService      *appServices.LootService
+	equipmentService *appServices.EquipmentService
+	respawnService   *appServices.RespawnService
+	rewardService    *appServices.RewardService
+	battleService    *appServices.BattleService
+	pvpService       *appServices.PvPService
+	tradeService     *appServices.TradeService
+	mailService      *appServices.MailService
+	auctionService   *appServices.AuctionService
+	shopService      *appServices.ShopService
+	itemUseService   *appServices.ItemUseService
+	craftService     *appServices.CraftService
+	enhanceService   *appServices.EnhanceService
 	updateMgr        *appServices.UpdateManager
 	spawnMgr         *appServices.SpawnManager
+	deathWorker      *appServices.DeathWorker
 
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -90,12 +106,36 @@ func (s *GatewayBootstrap) Start() error {
 				return nil
 			}))
 		}
+		if s.respawnService != nil {
+			s.updateMgr.Register("respawn.tick", appServices.UpdateFunc(func(ctx context.Context, d time.Duration) error {
+				s.respawnService.Update(ctx)
+				return nil
+			}))
+		}
+		if s.battleService != nil {
+			s.updateMgr.Register("battle.tick", appServices.UpdateFunc(s.battleService.Update))
+		}
+		if s.tradeService != nil {
+			s.updateMgr.Register("trade.tick", appServices.UpdateFunc(s.tradeService.Update))
+		}
+		if s.auctionService != nil {
+			s.updateMgr.Register("auction.tick", appServices.UpdateFunc(s.auctionService.Update))
+		}
+		if s.shopService != nil {
+			s.updateMgr.Register("shop.tick", appServices.UpdateFunc(s.shopService.Update))
+		}
+		if s.craftService != nil {
+			s.updateMgr.Register("craft.tick", appServices.UpdateFunc(s.craftService.Update))
+		}
 		s.updateMgr.Start(s.ctx)
 	}
 	if s.spawnMgr != nil {
 		// default 2 workers; can be made configurable later
 		s.spawnMgr.Start(s.ctx, 2)
 	}
+	if s.deathWorker != nil {
+		s.deathWorker.Start(2)
+	}
 
 	go func() {
 		if err := s.tcpServer.Start(); err != nil {
@@ -129,6 +169,9 @@ func (s *GatewayBootstrap) Stop() error {
 	if s.spawnMgr != nil {
 		s.spawnMgr.Stop()
 	}
+	if s.deathWorker != nil {
+		s.deathWorker.Stop()
+	}
 	if s.tcpServer != nil {
 		if err := s.tcpServer.Stop(); err != nil {
 			s.logger.Error("Failed to stop TCP server", err)
@@ -201,13 +244,77 @@ func (s *GatewayBootstrap) initializeApplicationLayer(cfg *config.Config) error
 	characterRepo := persistence.NewCharacterRepository(db)
 	itemRepo := persistence.NewItemRepository(db)
 	questRepo := persistence.NewQuestRepository(db)
+	battleRepo := persistence.NewBattleRepository(db)
+	pvpRepo := persistence.NewPvPRecordRepository(db)
+	tradeRepo := persistence.NewTradeRecordRepository(db)
+	mailRepo := persistence.NewMailRepository(db)
+	auctionRepo := persistence.NewAuctionRepository(db)
+	reputationRepo := persistence.NewReputationRepository(db)
+	craftingRepo := persistence.NewCraftingRepository(db)
+	walletRepo := persistence.NewWalletRepository(db)
+	if err := walletRepo.EnsureIndexes(s.ctx); err != nil {
+		return err
+	}
+	if cfg.Database.MongoDB.AllowNonTransactional {
+		if cfg.App.Environment != "development" {
+			return fmt.Errorf("mongodb.allow_non_transactional is only allowed in development (environment: %s)", cfg.App.Environment)
+		}
+		s.logger.Warn("已启用 MongoDB 无事务降级：背包与钱包写入不保证原子性，仅限开发环境")
+		itemRepo.AllowNonTransactional(s.logger)
+		walletRepo.AllowNonTransactional(s.logger)
+	}
 
 	// Instantiate application services
 	s.mapService = appServices.NewMapService()
+	s.mapService.SetSpawnEnvironment(appServices.NewWorldEnvironment(2 * time.Hour))
 	s.fightService = appServices.NewFightService(nil)
-	s.characterService = appServices.NewCharacterService(characterRepo, itemRepo, questRepo)
-	s.updateMgr = appServices.NewUpdateManager(s.logger, 50*time.Millisecond)
+	s.fightService.SetMapService(s.mapService)
+	s.walletService = appServices.NewWalletService(walletRepo, characterRepo)
+	s.characterService = appServices.NewCharacterService(characterRepo, itemRepo, questRepo, s.walletService)
+	s.portalService = appServices.NewPortalService(s.mapService)
+	questService := appServices.NewQuestService(questRepo)
+	s.portalService.SetQuestChecker(questService)
+	itemService := appServices.NewItemService(itemRepo)
+	itemService.SetBagCapacity(int32(cfg.Game.Player.MaxInventorySlots))
+	s.lootService = appServices.NewLootService(s.mapService, itemService)
+	s.equipmentService = appServices.NewEquipmentService(itemRepo, s.mapService)
+	s.tradeService = appServices.NewTradeService(s.mapService, itemService, s.walletService, tradeRepo)
+	s.mailService = appServices.NewMailService(mailRepo, itemService, s.walletService)
+	s.auctionService = appServices.NewAuctionService(appServices.AuctionConfig{
+		Durations:       cfg.Game.Auction.Durations,
+		ListingFeeRate:  cfg.Game.Auction.ListingFeeRate,
+		MinListingFee:   cfg.Game.Auction.MinListingFee,
+		SalesTaxRate:    cfg.Game.Auction.SalesTaxRate,
+		MinBidIncrement: cfg.Game.Auction.MinBidIncrement,
+		MaxListings:     cfg.Game.Auction.MaxListings,
+		MailExpireDays:  cfg.Game.Auction.MailExpireDays,
+	}, auctionRepo, itemService, s.walletService, s.mailService)
+	s.shopService = appServices.NewShopService(s.mapService, itemService, s.walletService, reputationRepo)
+	s.itemUseService = appServices.NewItemUseService(s.mapService, itemService, s.characterService, s.lootService)
+	s.craftService = appServices.NewCraftService(s.mapService, itemService, craftingRepo)
+	s.enhanceService = appServices.NewEnhanceService(s.mapService, itemService, s.walletService)
+	s.respawnService = appServices.NewRespawnService(appServices.RespawnConfig{
+		ReleaseDelay:        cfg.Game.Death.ReleaseDelay,
+		AutoRelease:         cfg.Game.Death.AutoRelease,
+		ResurrectTimeout:    cfg.Game.Death.ResurrectTimeout,
+		ResurrectItemID:     cfg.Game.Death.ResurrectItemID,
+		RespawnHealth:       float32(cfg.Game.Death.RespawnHealth),
+		ExpPenalty:          cfg.Game.Death.ExpPenalty,
+		ResurrectExpPenalty: cfg.Game.Death.ResurrectExpPenalty,
+	}, s.mapService, s.characterService, itemService)
+	s.rewardService = appServices.NewRewardService(s.mapService, s.characterService, questService, s.logger)
+	s.pvpService = appServices.NewPvPService(appServices.PvPConfig{FlagCooldown: cfg.Game.PvP.FlagCooldown}, s.mapService, pvpRepo)
 	s.spawnMgr = appServices.NewSpawnManager(s.logger, 1024)
+	// 死亡回调包含数据库读写，由专用队列处理，不阻塞地图 tick
+	s.deathWorker = appServices.NewDeathWorker(s.logger, appServices.ChainDeathHandlers(s.lootService.OnActorDeath, s.rewardService.OnActorDeath, s.pvpService.OnActorDeath, s.respawnService.OnActorDeath), 1024)
+	s.mapService.SetDeathHandler(s.deathWorker.OnActorDeath)
+	s.mapService.SetDuelEndHandler(s.pvpService.OnDuelEnd)
+	s.mapService.SetResurrectHandler(s.respawnService.OnResurrectOffer)
+	s.battleService = appServices.NewBattleService(battleRepo, s.mapService, s.characterService, appServices.BattleConfig{
+		TurnTimeout: cfg.Game.Battle.TurnTimeout,
+		MaxRounds:   cfg.Game.Battle.MaxRounds,
+	})
+	s.updateMgr = appServices.NewUpdateManager(s.logger, 50*time.Millisecond)
 	// Wiring: map service uses spawn manager for async tasks
 	s.mapService.SetSpawnManager(s.spawnMgr)
 	s.logger.Info("应用服务层初始化完成")
@@ -222,49 +329,97 @@ func (s *GatewayBootstrap) initializeTCPServer(cfg *config.Config) error {
 	s.tcpServer.SetMapService(s.mapService)
 	s.tcpServer.SetFightService(s.fightService)
 	s.tcpServer.SetCharacterService(s.characterService)
+	s.tcpServer.SetPortalService(s.portalService)
+	s.tcpServer.SetLootService(s.lootService)
+	s.tcpServer.SetEquipmentService(s.equipmentService)
+	s.tcpServer.SetRespawnService(s.respawnService)
+	s.tcpServer.SetBattleService(s.battleService)
+	s.tcpServer.SetPvPService(s.pvpService)
+	s.tcpServer.SetTradeService(s.tradeService)
+	s.tcpServer.SetAuctionService(s.auctionService)
+	s.tcpServer.SetMailService(s.mailService)
+	s.tcpServer.SetShopService(s.shopService)
+	s.tcpServer.SetItemUseService(s.itemUseService)
+	s.tcpServer.SetCraftService(s.craftService)
+	s.tcpServer.SetEnhanceService(s.enhanceService)
 
-	// Inject broadcaster from TCP server into MapService
-	if s.mapService != nil {
-		connMgr := s.tcpServer.GetConnectionManager()
-		s.mapService.SetBroadcaster(func(recipients []character.EntityID, topic string, payload interface{}) {
-			// Choose a message type based on topic
-			var msgType uint32
-			switch topic {
-			case "entity_move":
-				msgType = uint32(tcpProtocol.MsgPlayerMove)
-			case "entity_appear", "entity_disappear":
-				msgType = uint32(tcpProtocol.MsgPlayerStatusSync)
-			case "skill_cast":
-				msgType = uint32(tcpProtocol.MsgBattleSkill)
-			default:
-				msgType = uint32(tcpProtocol.MsgPlayerStatus)
-			}
+	// Inject broadcaster from TCP server into MapService and BattleService
+	connMgr := s.tcpServer.GetConnectionManager()
+	broadcast := func(recipients []character.EntityID, topic string, payload interface{}) {
+		// Choose a message type based on topic
+		var msgType uint32
+		switch topic {
+		case "entity_move":
+			msgType = uint32(tcpProtocol.MsgPlayerMove)
+		case "entity_appear", "entity_disappear", "entity_snapshot":
+			msgType = uint32(tcpProtocol.MsgPlayerStatusSync)
+		case "skill_cast", "skill_cast_cancel", "skill_cast_complete", "cc_diminish":
+			msgType = uint32(tcpProtocol.MsgBattleSkill)
+		case "skill_hit", "entity_death":
+			msgType = uint32(tcpProtocol.MsgBattleDamage)
+		case "equipment_change":
+			msgType = uint32(tcpProtocol.MsgItemEquip)
+		case "map_transfer":
+			msgType = uint32(tcpProtocol.MsgMapTransfer)
+		case "player_respawn", "entity_revive":
+			msgType = uint32(tcpProtocol.MsgPlayerRespawn)
+		case "kill_reward":
+			msgType = uint32(tcpProtocol.MsgPlayerExpGain)
+		case "level_up":
+			msgType = uint32(tcpProtocol.MsgPlayerLevelUp)
+		case "battle_state":
+			msgType = uint32(tcpProtocol.MsgBattleStatus)
+		case "battle_action":
+			msgType = uint32(tcpProtocol.MsgBattleAction)
+		case "battle_result":
+			msgType = uint32(tcpProtocol.MsgBattleResult)
+		case "pvp_flag":
+			msgType = uint32(tcpProtocol.MsgPvPFlag)
+		case "duel_request", "duel_start", "duel_end":
+			msgType = uint32(tcpProtocol.MsgDuel)
+		case "trade_invite", "trade_update", "trade_end":
+			msgType = uint32(tcpProtocol.MsgItemTrade)
+		case "craft_progress", "craft_end":
+			msgType = uint32(tcpProtocol.MsgItemCraft)
+		case "world_announce":
+			msgType = uint32(tcpProtocol.MsgChatMessage)
+		default:
+			msgType = uint32(tcpProtocol.MsgPlayerStatus)
+		}
 
-			msg := &tcpProtocol.Message{
-				Header: tcpProtocol.MessageHeader{
-					Magic:       tcpProtocol.MessageMagic,
-					MessageID:   0,
-					MessageType: msgType,
-					Flags:       tcpProtocol.FlagBroadcast | tcpProtocol.FlagAsync,
-					PlayerID:    0,
-					Timestamp:   time.Now().Unix(),
-					Sequence:    0,
-				},
-				Payload: map[string]interface{}{
-					"topic":   topic,
-					"payload": payload,
-				},
-			}
-			if data, err := json.Marshal(msg); err == nil {
-				for _, id := range recipients {
-					if session, ok := connMgr.GetSessionByPlayer(int32(id)); ok {
-						_ = session.Send(data)
-					}
+		msg := &tcpProtocol.Message{
+			Header: tcpProtocol.MessageHeader{
+				Magic:       tcpProtocol.MessageMagic,
+				MessageID:   0,
+				MessageType: msgType,
+				Flags:       tcpProtocol.FlagBroadcast | tcpProtocol.FlagAsync,
+				PlayerID:    0,
+				Timestamp:   time.Now().Unix(),
+				Sequence:    0,
+			},
+			Payload: map[string]interface{}{
+				"topic":   topic,
+				"payload": payload,
+			},
+		}
+		if data, err := json.Marshal(msg); err == nil {
+			for _, id := range recipients {
+				if session, ok := connMgr.GetSessionByPlayer(int32(id)); ok {
+					_ = session.Send(data)
 				}
-			} else {
-				s.logger.Error("广播消息序列化失败", err, logging.Fields{"topic": topic})
 			}
-		})
+		} else {
+			s.logger.Error("广播消息序列化失败", err, logging.Fields{"topic": topic})
+		}
+	}
+	if s.mapService != nil {
+		s.mapService.SetBroadcaster(broadcast)
+	}
+	if s.battleService != nil {
+		s.battleService.SetBroadcaster(broadcast)
+	}
+	if s.tradeService != nil {
+		s.tradeService.SetBroadcaster(broadcast)
 	}
 	s.logger.Info("TCP服务器初始化完成")
 	return nil
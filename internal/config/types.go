@@ -141,6 +141,8 @@ type MongoDBConfig struct {
 	SocketTimeout  time.Duration `yaml:"socket_timeout"`
 	ReplicaSet     string        `yaml:"replica_set"`
 	RetryWrites    bool          `yaml:"retry_writes"`
+	// AllowNonTransactional 仅开发环境：单机 MongoDB 不支持事务时降级为无事务执行（记录告警）
+	AllowNonTransactional bool `yaml:"allow_non_transactional"`
 }
 
 // RedisConfig defines redis connection pool.
@@ -383,6 +385,9 @@ type GameConfig struct {
 	Player     PlayerConfig     `yaml:"player"`
 	Battle     BattleConfig     `yaml:"battle"`
 	Experience ExperienceConfig `yaml:"experience"`
+	Death      DeathConfig      `yaml:"death"`
+	PvP        PvPConfig        `yaml:"pvp"`
+	Auction    AuctionConfig    `yaml:"auction"`
 	Chat       ChatConfig       `yaml:"chat"`
 	Ranking    RankingConfig    `yaml:"ranking"`
 	Weather    WeatherConfig    `yaml:"weather"`
@@ -407,6 +412,7 @@ type BattleConfig struct {
 	CriticalDamageBase float64       `yaml:"critical_damage_base"`
 	MaxParticipants    int           `yaml:"max_participants"`
 	TurnTimeout        time.Duration `yaml:"turn_timeout"`
+	MaxRounds          int           `yaml:"max_rounds"` // 回合制战斗回合上限
 }
 
 // ExperienceConfig domain defaults.
@@ -416,6 +422,33 @@ type ExperienceConfig struct {
 	MaxExpBonus     float64 `yaml:"max_exp_bonus"`
 }
 
+// PvPConfig player-versus-player rules.
+type PvPConfig struct {
+	FlagCooldown time.Duration `yaml:"flag_cooldown"` // 两次切换 PvP 标记的最小间隔
+}
+
+// AuctionConfig auction house rules.
+type AuctionConfig struct {
+	Durations       []time.Duration `yaml:"durations"`         // 可选的上架时长
+	ListingFeeRate  float64         `yaml:"listing_fee_rate"`  // 上架手续费比例（按起拍价与一口价较高者）
+	MinListingFee   int64           `yaml:"min_listing_fee"`   // 最低上架手续费
+	SalesTaxRate    float64         `yaml:"sales_tax_rate"`    // 成交税率
+	MinBidIncrement float64         `yaml:"min_bid_increment"` // 最小加价比例
+	MaxListings     int64           `yaml:"max_listings"`      // 每名角色同时上架数上限
+	MailExpireDays  int             `yaml:"mail_expire_days"`  // 拍卖邮件保留天数
+}
+
+// DeathConfig player death and respawn rules.
+type DeathConfig struct {
+	ReleaseDelay        time.Duration `yaml:"release_delay"`         // 死亡后可回复活点复活的等待时间
+	AutoRelease         time.Duration `yaml:"auto_release"`          // 超时自动回复活点复活
+	ResurrectTimeout    time.Duration `yaml:"resurrect_timeout"`     // 他人复活邀请有效期
+	ResurrectItemID     int32         `yaml:"resurrect_item_id"`     // 原地复活消耗的道具
+	RespawnHealth       float64       `yaml:"respawn_health"`        // 复活点/道具复活后的生命比例
+	ExpPenalty          float64       `yaml:"exp_penalty"`           // 复活点复活扣除当前等级升级经验的比例
+	ResurrectExpPenalty float64       `yaml:"resurrect_exp_penalty"` // 接受他人复活扣除当前等级升级经验的比例
+}
+
 // ChatConfig domain defaults.
 type ChatConfig struct {
 	MaxMessageLength int      `yaml:"max_message_length"`
@@ -949,12 +982,48 @@ func (c *Config) ApplyDefaults() {
 	if c.Game.Battle.TurnTimeout == 0 {
 		c.Game.Battle.TurnTimeout = 30 * time.Second
 	}
+	if c.Game.Battle.MaxRounds == 0 {
+		c.Game.Battle.MaxRounds = 30
+	}
 	if c.Game.Experience.BaseExpPerLevel == 0 {
 		c.Game.Experience.BaseExpPerLevel = 100
 	}
 	if c.Game.Experience.ExpMultiplier == 0 {
 		c.Game.Experience.ExpMultiplier = 1.2
 	}
+	if c.Game.Death.ReleaseDelay == 0 {
+		c.Game.Death.ReleaseDelay = 5 * time.Second
+	}
+	if c.Game.Death.AutoRelease == 0 {
+		c.Game.Death.AutoRelease = 5 * time.Minute
+	}
+	if c.Game.Death.ResurrectTimeout == 0 {
+		c.Game.Death.ResurrectTimeout = time.Minute
+	}
+	if c.Game.Death.RespawnHealth == 0 {
+		c.Game.Death.RespawnHealth = 0.5
+	}
+	if c.Game.PvP.FlagCooldown == 0 {
+		c.Game.PvP.FlagCooldown = time.Minute
+	}
+	if len(c.Game.Auction.Durations) == 0 {
+		c.Game.Auction.Durations = []time.Duration{12 * time.Hour, 24 * time.Hour, 48 * time.Hour}
+	}
+	if c.Game.Auction.ListingFeeRate == 0 {
+		c.Game.Auction.ListingFeeRate = 0.05
+	}
+	if c.Game.Auction.SalesTaxRate == 0 {
+		c.Game.Auction.SalesTaxRate = 0.05
+	}
+	if c.Game.Auction.MinBidIncrement == 0 {
+		c.Game.Auction.MinBidIncrement = 0.05
+	}
+	if c.Game.Auction.MaxListings == 0 {
+		c.Game.Auction.MaxListings = 20
+	}
+	if c.Game.Auction.MailExpireDays == 0 {
+		c.Game.Auction.MailExpireDays = 30
+	}
 	if c.Game.Chat.MaxMessageLength == 0 {
 		c.Game.Chat.MaxMessageLength = 500
 	}
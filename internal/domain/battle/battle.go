@@ -7,6 +7,14 @@ import (
 	"time"
 )
 
+// 回合制规则默认值
+const (
+	DefaultTurnTimeout = 15 * time.Second // 每个行动回合的时限
+	DefaultMaxRounds   = 30               // 回合上限，超过后按剩余生命判定胜负
+	defaultAttack      = 20               // 未配置攻击力时的基础攻击力
+	autoHealRatio      = 0.3              // 自动行动时生命低于该比例优先治疗
+)
+
 // BattleID 战斗ID值对象
 type BattleID struct {
 	value string
@@ -17,6 +25,11 @@ func NewBattleID() BattleID {
 	return BattleID{value: uuid.New().String()}
 }
 
+// BattleIDFromString 从字符串创建战斗ID
+func BattleIDFromString(value string) BattleID {
+	return BattleID{value: value}
+}
+
 // String 返回字符串表示
 func (id BattleID) String() string {
 	return id.value
@@ -55,6 +68,12 @@ type Battle struct {
 	createdAt    time.Time
 	updatedAt    time.Time
 	version      int64
+
+	// 回合制：参与者按加入顺序轮流行动，每次行动有时限
+	turnIndex    int           // 当前行动者在参与者列表中的位置
+	turnDeadline time.Time     // 当前行动者的行动截止时间
+	turnTimeout  time.Duration // 每次行动的时限
+	maxRounds    int           // 回合上限（0 表示不限）
 }
 
 // BattleParticipant 战斗参与者
@@ -67,6 +86,20 @@ type BattleParticipant struct {
 	DamageDealt int             `json:"damage_dealt"`
 	DamageTaken int             `json:"damage_taken"`
 	JoinedAt    time.Time       `json:"joined_at"`
+	MaxHP       int             `json:"max_hp"`
+	Attack      int             `json:"attack"`
+	IsAI        bool            `json:"is_ai,omitempty"`     // 由系统自动行动
+	Defending   bool            `json:"defending,omitempty"` // 防御中，受到的伤害减半直到下次行动
+}
+
+// Combatant 加入战斗的参战者属性
+type Combatant struct {
+	PlayerID player.PlayerID
+	Team     int
+	HP       int
+	MP       int
+	Attack   int  // 0 表示使用基础攻击力
+	IsAI     bool // AI 参战者轮到时由系统自动行动
 }
 
 // BattleRound 战斗回合
@@ -87,6 +120,7 @@ type BattleAction struct {
 	Damage     int              `json:"damage"`
 	Healing    int              `json:"healing"`
 	Critical   bool             `json:"critical"`
+	Auto       bool             `json:"auto,omitempty"` // AI 或超时由系统代为行动
 	Timestamp  time.Time        `json:"timestamp"`
 }
 
@@ -113,6 +147,45 @@ func NewBattle(battleType BattleType) *Battle {
 		createdAt:    now,
 		updatedAt:    now,
 		version:      1,
+		turnTimeout:  DefaultTurnTimeout,
+		maxRounds:    DefaultMaxRounds,
+	}
+}
+
+// ReconstructBattle 从持久化数据重建战斗聚合根
+func ReconstructBattle(
+	id string,
+	battleType BattleType,
+	status BattleStatus,
+	participants []*BattleParticipant,
+	rounds []*BattleRound,
+	winner *player.PlayerID,
+	startTime time.Time,
+	endTime *time.Time,
+	turnIndex int,
+	turnDeadline time.Time,
+	turnTimeout time.Duration,
+	maxRounds int,
+	createdAt time.Time,
+	updatedAt time.Time,
+	version int64,
+) *Battle {
+	return &Battle{
+		id:           BattleIDFromString(id),
+		battleType:   battleType,
+		status:       status,
+		participants: participants,
+		rounds:       rounds,
+		winner:       winner,
+		startTime:    startTime,
+		endTime:      endTime,
+		turnIndex:    turnIndex,
+		turnDeadline: turnDeadline,
+		turnTimeout:  turnTimeout,
+		maxRounds:    maxRounds,
+		createdAt:    createdAt,
+		updatedAt:    updatedAt,
+		version:      version,
 	}
 }
 
@@ -138,26 +211,34 @@ func (b *Battle) Participants() []*BattleParticipant {
 
 // AddParticipant 添加参与者
 func (b *Battle) AddParticipant(playerID player.PlayerID, team int, hp, mp int) error {
+	return b.AddCombatant(Combatant{PlayerID: playerID, Team: team, HP: hp, MP: mp})
+}
+
+// AddCombatant 按参战者属性添加参与者
+func (b *Battle) AddCombatant(c Combatant) error {
 	if b.status != BattleStatusWaiting {
 		return ErrBattleAlreadyStarted
 	}
 
 	// 检查玩家是否已经参与
 	for _, p := range b.participants {
-		if p.PlayerID == playerID {
+		if p.PlayerID == c.PlayerID {
 			return ErrPlayerAlreadyInBattle
 		}
 	}
 
 	participant := &BattleParticipant{
-		PlayerID:    playerID,
-		Team:        team,
-		CurrentHP:   hp,
-		CurrentMP:   mp,
+		PlayerID:    c.PlayerID,
+		Team:        c.Team,
+		CurrentHP:   c.HP,
+		CurrentMP:   c.MP,
 		IsAlive:     true,
 		DamageDealt: 0,
 		DamageTaken: 0,
 		JoinedAt:    time.Now(),
+		MaxHP:       c.HP,
+		Attack:      c.Attack,
+		IsAI:        c.IsAI,
 	}
 
 	b.participants = append(b.participants, participant)
@@ -179,27 +260,219 @@ func (b *Battle) Start() error {
 
 	b.status = BattleStatusInProgress
 	b.startTime = time.Now()
+	b.beginRound(b.startTime)
 	b.updatedAt = time.Now()
 	b.version++
 
 	return nil
 }
 
-// ExecuteAction 执行战斗行动
-func (b *Battle) ExecuteAction(actorID player.PlayerID, targetID *player.PlayerID, actionType ActionType, skillID *string) (*BattleAction, error) {
+// SetTurnRules 设置行动时限与回合上限（开始前设置）
+func (b *Battle) SetTurnRules(turnTimeout time.Duration, maxRounds int) {
+	if turnTimeout > 0 {
+		b.turnTimeout = turnTimeout
+	}
+	b.maxRounds = maxRounds
+}
+
+// CurrentActor 当前行动者（战斗未进行时为 nil）
+func (b *Battle) CurrentActor() *BattleParticipant {
+	if b.status != BattleStatusInProgress || b.turnIndex < 0 || b.turnIndex >= len(b.participants) {
+		return nil
+	}
+	return b.participants[b.turnIndex]
+}
+
+// TurnDeadline 当前行动者的行动截止时间
+func (b *Battle) TurnDeadline() time.Time {
+	return b.turnDeadline
+}
+
+// TurnIndex 当前行动者在参与者列表中的位置
+func (b *Battle) TurnIndex() int {
+	return b.turnIndex
+}
+
+// TurnTimeout 每次行动的时限
+func (b *Battle) TurnTimeout() time.Duration {
+	return b.turnTimeout
+}
+
+// MaxRounds 回合上限
+func (b *Battle) MaxRounds() int {
+	return b.maxRounds
+}
+
+// CurrentRound 当前回合数
+func (b *Battle) CurrentRound() int {
+	return len(b.rounds)
+}
+
+// checkTurn 校验行动者是否可以行动（战斗进行中、存活且轮到其行动）
+func (b *Battle) checkTurn(actorID player.PlayerID) (*BattleParticipant, error) {
 	if b.status != BattleStatusInProgress {
 		return nil, ErrBattleNotInProgress
 	}
-
-	// 查找行动者
 	actor := b.findParticipant(actorID)
 	if actor == nil {
 		return nil, ErrPlayerNotInBattle
 	}
-
 	if !actor.IsAlive {
 		return nil, ErrPlayerDead
 	}
+	if current := b.CurrentActor(); current == nil || current.PlayerID != actorID {
+		return nil, ErrNotYourTurn
+	}
+	return actor, nil
+}
+
+// finishAction 行动结算后检查战斗是否结束，未结束则轮到下一名行动者
+func (b *Battle) finishAction(now time.Time) {
+	b.checkBattleEnd()
+	if b.status == BattleStatusInProgress {
+		b.advanceTurn(now)
+	}
+	b.updatedAt = now
+	b.version++
+}
+
+// advanceTurn 轮到本回合下一名存活参与者；本回合所有存活参与者行动完毕后开始新回合，达到回合上限时按剩余生命结束战斗
+func (b *Battle) advanceTurn(now time.Time) {
+	for i := b.turnIndex + 1; i < len(b.participants); i++ {
+		if b.participants[i].IsAlive {
+			b.startTurn(i, now)
+			return
+		}
+	}
+	if n := len(b.rounds); n > 0 && b.rounds[n-1].EndTime == nil {
+		b.rounds[n-1].EndTime = &now
+	}
+	if b.maxRounds > 0 && len(b.rounds) >= b.maxRounds {
+		b.endBattle(b.leadingTeam())
+		return
+	}
+	b.beginRound(now)
+}
+
+// beginRound 开始新回合，由第一名存活参与者行动
+func (b *Battle) beginRound(now time.Time) {
+	b.rounds = append(b.rounds, &BattleRound{
+		RoundNumber: len(b.rounds) + 1,
+		Actions:     make([]*BattleAction, 0),
+		StartTime:   now,
+	})
+	for i, p := range b.participants {
+		if p.IsAlive {
+			b.startTurn(i, now)
+			return
+		}
+	}
+}
+
+// startTurn 轮到指定参与者行动（其防御状态在此时结束）
+func (b *Battle) startTurn(index int, now time.Time) {
+	b.turnIndex = index
+	b.participants[index].Defending = false
+	b.turnDeadline = now.Add(b.turnTimeout)
+}
+
+// leadingTeam 剩余生命比例最高的队伍（并列时取先加入者所在队伍）
+func (b *Battle) leadingTeam() int {
+	hp := make(map[int]int)
+	maxHP := make(map[int]int)
+	var order []int
+	for _, p := range b.participants {
+		if _, ok := maxHP[p.Team]; !ok {
+			order = append(order, p.Team)
+		}
+		maxHP[p.Team] += max(p.MaxHP, 1)
+		if p.IsAlive {
+			hp[p.Team] += p.CurrentHP
+		}
+	}
+	best, bestRatio := -1, -1.0
+	for _, team := range order {
+		if ratio := float64(hp[team]) / float64(maxHP[team]); ratio > bestRatio {
+			best, bestRatio = team, ratio
+		}
+	}
+	return best
+}
+
+// AutoAction 系统代替当前行动者行动（AI 参与者或行动超时）：生命低于三成时治疗，否则攻击生命最低的敌方
+func (b *Battle) AutoAction() (*BattleAction, error) {
+	actor := b.CurrentActor()
+	if actor == nil {
+		return nil, ErrBattleNotInProgress
+	}
+	var action *BattleAction
+	var err error
+	if actor.MaxHP > 0 && float64(actor.CurrentHP) < float64(actor.MaxHP)*autoHealRatio {
+		action, err = b.ExecuteAction(actor.PlayerID, nil, ActionTypeHeal, nil)
+	} else if target := b.weakestEnemy(actor); target != nil {
+		action, err = b.ExecuteAction(actor.PlayerID, &target.PlayerID, ActionTypeAttack, nil)
+	} else {
+		action, err = b.ExecuteAction(actor.PlayerID, nil, ActionTypeDefend, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	action.Auto = true
+	return action, nil
+}
+
+// ExpireTurn 当前行动者超时未行动时由系统代为行动（未超时返回 nil）
+func (b *Battle) ExpireTurn(now time.Time) (*BattleAction, error) {
+	if b.status != BattleStatusInProgress || now.Before(b.turnDeadline) {
+		return nil, nil
+	}
+	return b.AutoAction()
+}
+
+// Forfeit 参与者认输（离开战斗）：视为阵亡，轮到其行动时由下一名参与者行动
+func (b *Battle) Forfeit(playerID player.PlayerID) error {
+	if b.status != BattleStatusInProgress {
+		return ErrBattleNotInProgress
+	}
+	p := b.findParticipant(playerID)
+	if p == nil {
+		return ErrPlayerNotInBattle
+	}
+	if !p.IsAlive {
+		return ErrPlayerDead
+	}
+	p.IsAlive = false
+	now := time.Now()
+	b.checkBattleEnd()
+	if b.status == BattleStatusInProgress && b.participants[b.turnIndex] == p {
+		b.advanceTurn(now)
+	}
+	b.updatedAt = now
+	b.version++
+	return nil
+}
+
+// weakestEnemy 生命最低的存活敌方
+func (b *Battle) weakestEnemy(actor *BattleParticipant) *BattleParticipant {
+	var target *BattleParticipant
+	for _, p := range b.participants {
+		if p.Team == actor.Team || !p.IsAlive {
+			continue
+		}
+		if target == nil || p.CurrentHP < target.CurrentHP {
+			target = p
+		}
+	}
+	return target
+}
+
+// ExecuteAction 执行战斗行动
+func (b *Battle) ExecuteAction(actorID player.PlayerID, targetID *player.PlayerID, actionType ActionType, skillID *string) (*BattleAction, error) {
+	// 校验战斗状态、行动者存活及行动顺序
+	actor, err := b.checkTurn(actorID)
+	if err != nil {
+		return nil, err
+	}
 
 	// 创建行动
 	action := &BattleAction{
@@ -224,11 +497,8 @@ func (b *Battle) ExecuteAction(actorID player.PlayerID, targetID *player.PlayerI
 	// 添加到当前回合
 	b.addActionToCurrentRound(action)
 
-	// 检查战斗是否结束
-	b.checkBattleEnd()
-
-	b.updatedAt = time.Now()
-	b.version++
+	// 检查战斗是否结束并轮到下一名行动者
+	b.finishAction(time.Now())
 
 	return action, nil
 }
@@ -245,8 +515,10 @@ func (b *Battle) executeAttack(action *BattleAction, actor *BattleParticipant) {
 	}
 
 	// 计算伤害（简化版本）
-	baseDamage := 20 // 基础攻击力
-	damage := baseDamage
+	damage := defaultAttack // 基础攻击力
+	if actor.Attack > 0 {
+		damage = actor.Attack
+	}
 
 	// 暴击判断
 	if b.rollCritical() {
@@ -254,7 +526,14 @@ func (b *Battle) executeAttack(action *BattleAction, actor *BattleParticipant) {
 		action.Critical = true
 	}
 
-	action.Damage = damage
+	action.Damage = dealDamage(actor, target, damage)
+}
+
+// dealDamage 结算伤害（防御中的目标伤害减半），返回实际伤害
+func dealDamage(actor, target *BattleParticipant, damage int) int {
+	if target.Defending {
+		damage /= 2
+	}
 	target.CurrentHP -= damage
 	target.DamageTaken += damage
 	actor.DamageDealt += damage
@@ -263,16 +542,21 @@ func (b *Battle) executeAttack(action *BattleAction, actor *BattleParticipant) {
 		target.CurrentHP = 0
 		target.IsAlive = false
 	}
+	return damage
 }
 
 // executeDefend 执行防御
 func (b *Battle) executeDefend(action *BattleAction, actor *BattleParticipant) {
-	// 防御状态，下次受到伤害减半（简化实现）
+	// 防御状态，受到的伤害减半直到下次行动
+	actor.Defending = true
 }
 
 // executeHeal 执行治疗
 func (b *Battle) executeHeal(action *BattleAction, actor *BattleParticipant) {
 	healAmount := 30 // 基础治疗量
+	if actor.MaxHP > 0 {
+		healAmount = min(healAmount, actor.MaxHP-actor.CurrentHP)
+	}
 	actor.CurrentHP += healAmount
 	action.Healing = healAmount
 }
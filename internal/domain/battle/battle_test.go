@@ -0,0 +1,115 @@
+package battle
+
+import (
+	"testing"
+	"time"
+
+	"greatestworks/internal/domain/player"
+)
+
+func newTurnTestBattle(t *testing.T, combatants ...Combatant) *Battle {
+	t.Helper()
+	b := NewBattle(BattleTypePvE)
+	b.SetTurnRules(time.Second, 3)
+	for _, c := range combatants {
+		if err := b.AddCombatant(c); err != nil {
+			t.Fatalf("add combatant: %v", err)
+		}
+	}
+	if err := b.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	return b
+}
+
+func TestTurnOrderAndRounds(t *testing.T) {
+	a, c := player.PlayerIDFromString("1"), player.PlayerIDFromString("2")
+	b := newTurnTestBattle(t,
+		Combatant{PlayerID: a, Team: 1, HP: 1000, Attack: 10},
+		Combatant{PlayerID: c, Team: 2, HP: 1000, Attack: 10},
+	)
+	if b.CurrentActor().PlayerID != a || b.CurrentRound() != 1 {
+		t.Fatalf("expected first participant to act in round 1")
+	}
+	if _, err := b.ExecuteAction(c, &a, ActionTypeAttack, nil); err != ErrNotYourTurn {
+		t.Fatalf("expected out of turn action rejected, got %v", err)
+	}
+	if _, err := b.ExecuteAction(a, nil, ActionTypeDefend, nil); err != nil {
+		t.Fatalf("defend: %v", err)
+	}
+	action, err := b.ExecuteAction(c, &a, ActionTypeAttack, nil)
+	if err != nil {
+		t.Fatalf("attack: %v", err)
+	}
+	// 防御中伤害减半（暴击时为 10）
+	if action.Damage != 5 && action.Damage != 10 {
+		t.Fatalf("expected defended damage halved, got %d", action.Damage)
+	}
+	if b.CurrentActor().PlayerID != a || b.CurrentRound() != 2 || b.findParticipant(a).Defending {
+		t.Fatalf("expected round 2 to start with first participant and defend cleared")
+	}
+}
+
+func TestExpiredTurnAutoActsAndMaxRoundsDecide(t *testing.T) {
+	a, c := player.PlayerIDFromString("1"), player.PlayerIDFromString("2")
+	b := newTurnTestBattle(t,
+		Combatant{PlayerID: a, Team: 1, HP: 1000, Attack: 10},
+		Combatant{PlayerID: c, Team: 2, HP: 1000, Attack: 10},
+	)
+	if action, err := b.ExpireTurn(time.Now()); action != nil || err != nil {
+		t.Fatalf("turn must not expire before deadline")
+	}
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		now = now.Add(2 * time.Second)
+		action, err := b.ExpireTurn(now)
+		if err != nil || action == nil || !action.Auto || action.ActionType != ActionTypeAttack {
+			t.Fatalf("expected auto attack on timeout, got %+v %v", action, err)
+		}
+	}
+	if b.IsFinished() {
+		t.Fatalf("battle finished before round limit")
+	}
+	// 第 3 回合最后一次行动后达到回合上限，剩余生命多的一方获胜（相同时先加入的队伍获胜）
+	if _, err := b.ExpireTurn(now.Add(2 * time.Second)); err != nil {
+		t.Fatalf("expire: %v", err)
+	}
+	want := a
+	if b.findParticipant(c).CurrentHP > b.findParticipant(a).CurrentHP {
+		want = c
+	}
+	if !b.IsFinished() || b.Winner() == nil || *b.Winner() != want {
+		t.Fatalf("expected battle decided by remaining hp at round limit")
+	}
+}
+
+func TestAIAutoActionAndForfeit(t *testing.T) {
+	a, ai, ally := player.PlayerIDFromString("1"), player.PlayerIDFromString("ai-1"), player.PlayerIDFromString("3")
+	b := newTurnTestBattle(t,
+		Combatant{PlayerID: a, Team: 1, HP: 100, Attack: 10},
+		Combatant{PlayerID: ai, Team: 2, HP: 100, Attack: 10, IsAI: true},
+		Combatant{PlayerID: ally, Team: 1, HP: 100, Attack: 10},
+	)
+	if _, err := b.ExecuteAction(a, &ai, ActionTypeAttack, nil); err != nil {
+		t.Fatalf("attack: %v", err)
+	}
+	// AI 生命过低时治疗，否则攻击生命最低的敌方
+	b.findParticipant(ally).CurrentHP = 50
+	action, err := b.AutoAction()
+	if err != nil || action.TargetID == nil || *action.TargetID != ally {
+		t.Fatalf("expected ai to attack weakest enemy, got %+v %v", action, err)
+	}
+
+	if err := b.Forfeit(ally); err != nil {
+		t.Fatalf("forfeit: %v", err)
+	}
+	if b.IsFinished() || b.CurrentActor().PlayerID != a {
+		t.Fatalf("expected forfeited participant skipped")
+	}
+	if err := b.Forfeit(a); err != nil {
+		t.Fatalf("forfeit: %v", err)
+	}
+	if !b.IsFinished() || b.Winner() == nil || *b.Winner() != ai {
+		t.Fatalf("expected ai team to win after all opponents forfeit")
+	}
+}
@@ -18,4 +18,5 @@ var (
 	ErrActionOnCooldown         = errors.NewDomainError("ACTION_ON_COOLDOWN", "行动冷却中")
 	ErrBattleAlreadyFinished    = errors.NewDomainError("BATTLE_ALREADY_FINISHED", "战斗已结束")
 	ErrBattleNotFinished        = errors.NewDomainError("BATTLE_NOT_FINISHED", "战斗未结束")
+	ErrNotYourTurn              = errors.NewDomainError("NOT_YOUR_TURN", "未轮到该玩家行动")
 )
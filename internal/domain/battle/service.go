@@ -12,6 +12,8 @@ import (
 type Service struct {
 	battleRepository Repository
 	skillRegistry    *SkillRegistry
+	turnTimeout      time.Duration
+	maxRounds        int
 }
 
 // NewService 创建战斗领域服务
@@ -22,12 +24,21 @@ func NewService(battleRepository Repository) *Service {
 	return &Service{
 		battleRepository: battleRepository,
 		skillRegistry:    skillRegistry,
+		turnTimeout:      DefaultTurnTimeout,
+		maxRounds:        DefaultMaxRounds,
 	}
 }
 
+// SetTurnRules 设置新建战斗的行动时限与回合上限
+func (s *Service) SetTurnRules(turnTimeout time.Duration, maxRounds int) {
+	s.turnTimeout = turnTimeout
+	s.maxRounds = maxRounds
+}
+
 // CreateBattle 创建战斗
 func (s *Service) CreateBattle(ctx context.Context, battleType BattleType) (*Battle, error) {
 	battle := NewBattle(battleType)
+	battle.SetTurnRules(s.turnTimeout, s.maxRounds)
 
 	if err := s.battleRepository.Save(ctx, battle); err != nil {
 		return nil, fmt.Errorf("save battle: %w", err)
@@ -54,6 +65,24 @@ func (s *Service) JoinBattle(ctx context.Context, battleID BattleID, playerID pl
 	return nil
 }
 
+// AddCombatant 按参战者属性加入战斗（AI 参战者同样通过此方法加入）
+func (s *Service) AddCombatant(ctx context.Context, battleID BattleID, c Combatant) error {
+	battle, err := s.battleRepository.FindByID(ctx, battleID)
+	if err != nil {
+		return fmt.Errorf("find battle: %w", err)
+	}
+
+	if err := battle.AddCombatant(c); err != nil {
+		return err
+	}
+
+	if err := s.battleRepository.Update(ctx, battle); err != nil {
+		return fmt.Errorf("update battle: %w", err)
+	}
+
+	return nil
+}
+
 // StartBattle 开始战斗
 func (s *Service) StartBattle(ctx context.Context, battleID BattleID) error {
 	battle, err := s.battleRepository.FindByID(ctx, battleID)
@@ -91,6 +120,78 @@ func (s *Service) ExecuteAttack(ctx context.Context, battleID BattleID, actorID
 	return action, nil
 }
 
+// ExecuteAction 执行攻击、防御或治疗行动
+func (s *Service) ExecuteAction(ctx context.Context, battleID BattleID, actorID player.PlayerID, targetID *player.PlayerID, actionType ActionType) (*BattleAction, error) {
+	if actionType == ActionTypeSkill {
+		return nil, ErrInvalidAction
+	}
+	battle, err := s.battleRepository.FindByID(ctx, battleID)
+	if err != nil {
+		return nil, fmt.Errorf("find battle: %w", err)
+	}
+
+	action, err := battle.ExecuteAction(actorID, targetID, actionType, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.battleRepository.Update(ctx, battle); err != nil {
+		return nil, fmt.Errorf("update battle: %w", err)
+	}
+
+	return action, nil
+}
+
+// AdvanceBattle 推进战斗：AI 参与者轮到时自动行动，行动超时的参与者由系统代为行动，直到轮到未超时的玩家或战斗结束
+func (s *Service) AdvanceBattle(ctx context.Context, battleID BattleID, now time.Time) (*Battle, []*BattleAction, error) {
+	battle, err := s.battleRepository.FindByID(ctx, battleID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("find battle: %w", err)
+	}
+
+	var actions []*BattleAction
+	// 每名参与者每回合至多行动一次，以回合上限约束循环次数
+	limit := len(battle.participants) * (max(battle.maxRounds, 1) + 1)
+	for i := 0; i < limit && battle.Status() == BattleStatusInProgress; i++ {
+		current := battle.CurrentActor()
+		if current == nil || (!current.IsAI && now.Before(battle.TurnDeadline())) {
+			break
+		}
+		action, err := battle.AutoAction()
+		if err != nil {
+			return nil, nil, err
+		}
+		actions = append(actions, action)
+	}
+	if len(actions) == 0 {
+		return battle, nil, nil
+	}
+
+	if err := s.battleRepository.Update(ctx, battle); err != nil {
+		return nil, nil, fmt.Errorf("update battle: %w", err)
+	}
+
+	return battle, actions, nil
+}
+
+// Forfeit 参与者认输离开战斗
+func (s *Service) Forfeit(ctx context.Context, battleID BattleID, playerID player.PlayerID) (*Battle, error) {
+	battle, err := s.battleRepository.FindByID(ctx, battleID)
+	if err != nil {
+		return nil, fmt.Errorf("find battle: %w", err)
+	}
+
+	if err := battle.Forfeit(playerID); err != nil {
+		return nil, err
+	}
+
+	if err := s.battleRepository.Update(ctx, battle); err != nil {
+		return nil, fmt.Errorf("update battle: %w", err)
+	}
+
+	return battle, nil
+}
+
 // ExecuteSkill 执行技能
 func (s *Service) ExecuteSkill(ctx context.Context, battleID BattleID, actorID player.PlayerID, targetID *player.PlayerID, skillID string) (*BattleAction, error) {
 	battle, err := s.battleRepository.FindByID(ctx, battleID)
@@ -104,10 +205,10 @@ func (s *Service) ExecuteSkill(ctx context.Context, battleID BattleID, actorID p
 		return nil, fmt.Errorf("get skill: %w", err)
 	}
 
-	// 检查行动者是否有足够的魔法值
-	actor := battle.findParticipant(actorID)
-	if actor == nil {
-		return nil, ErrPlayerNotInBattle
+	// 校验行动顺序并检查行动者是否有足够的魔法值
+	actor, err := battle.checkTurn(actorID)
+	if err != nil {
+		return nil, err
 	}
 
 	if actor.CurrentMP < skill.ManaCost() {
@@ -157,8 +258,8 @@ func (s *Service) executeSkillAction(battle *Battle, actorID player.PlayerID, ta
 	// 添加到当前回合
 	battle.addActionToCurrentRound(action)
 
-	// 检查战斗是否结束
-	battle.checkBattleEnd()
+	// 检查战斗是否结束并轮到下一名行动者
+	battle.finishAction(time.Now())
 
 	return action, nil
 }
@@ -188,15 +289,7 @@ func (s *Service) executeSkillAttack(battle *Battle, action *BattleAction, skill
 		action.Critical = true
 	}
 
-	action.Damage = damage
-	target.CurrentHP -= damage
-	target.DamageTaken += damage
-	actor.DamageDealt += damage
-
-	if target.CurrentHP <= 0 {
-		target.CurrentHP = 0
-		target.IsAlive = false
-	}
+	action.Damage = dealDamage(actor, target, damage)
 
 	// 应用技能效果
 	for _, effect := range skill.Effects() {
@@ -220,11 +313,12 @@ func (s *Service) executeSkillHeal(battle *Battle, action *BattleAction, skill *
 	}
 
 	healAmount := skill.Healing()
+	// 不能超过最大生命值（最大生命值为加入战斗时的生命值）
+	if target.MaxHP > 0 {
+		healAmount = min(healAmount, target.MaxHP-target.CurrentHP)
+	}
 	target.CurrentHP += healAmount
 	action.Healing = healAmount
-
-	// 不能超过最大生命值（这里简化处理，假设最大生命值为初始生命值）
-	// 实际项目中应该从玩家数据中获取最大生命值
 }
 
 // executeSkillDefense 执行防御技能
@@ -3,6 +3,7 @@ package character
 import (
 	"context"
 	"fmt"
+	"math"
 	"sync"
 )
 
@@ -23,19 +24,32 @@ type Actor struct {
 	speed float32 // 移动速度
 
 	// 状态
-	flagState FlagState // 状态标志位
+	flagState FlagState      // 状态标志位
+	animState AnimationState // 动画状态
 
 	// 伤害来源信息
 	damageSourceInfo *DamageInfo
+	// 各攻击者造成的累计伤害（击杀奖励按贡献分配）
+	damageTaken map[EntityID]int64
+	// 剩余战斗状态秒数（造成或受到伤害时刷新）
+	combatTimer float32
 
 	// 子系统（聚合其他值对象或服务）
 	attributeManager *AttributeManager // 属性管理器
 	skillManager     *SkillManager     // 技能管理器
 	buffManager      *BuffManager      // Buff管理器
+	equipmentManager *EquipmentManager // 装备管理器
 	spell            *Spell            // 施法器
 
 	// 领域事件发布器（可选注入）
 	publisher EventPublisher
+	// 范围目标解析器与投射物发射器（由所在地图注入）
+	resolver TargetResolver
+	launcher MissileLauncher
+	// PvP：规则判定（由所在地图注入）、阵营与 PvP 标记
+	judge   PvPJudge
+	faction int32
+	pvpFlag bool
 }
 
 // DamageInfo 伤害信息
@@ -77,6 +91,9 @@ const (
 	AttackerTypeEnvironment AttackerType = 3 // 环境伤害
 )
 
+// CombatTimeout 最后一次造成或受到伤害后保持战斗状态的秒数
+const CombatTimeout float32 = 5
+
 // NewActor 创建新Actor（工厂方法）
 func NewActor(
 	entityID EntityID,
@@ -100,6 +117,7 @@ func NewActor(
 	actor.attributeManager = NewAttributeManager(actor)
 	actor.skillManager = NewSkillManager(actor)
 	actor.buffManager = NewBuffManager(actor)
+	actor.equipmentManager = NewEquipmentManager(actor)
 	actor.spell = NewSpell(actor)
 
 	return actor
@@ -128,6 +146,41 @@ func (a *Actor) SetLevel(level int32) {
 	a.level = level
 }
 
+// LevelGrowth 升级带来的基础属性成长
+type LevelGrowth struct {
+	MaxHP float32
+	MaxMP float32
+	AD    float32
+	AP    float32
+	Def   float32
+	MDef  float32
+}
+
+// ApplyLevelUp 升级到新等级：叠加基础属性成长，回满生命与魔法并发布升级事件
+func (a *Actor) ApplyLevelUp(newLevel int32, growth LevelGrowth) {
+	a.mu.Lock()
+	oldLevel := a.level
+	a.level = newLevel
+	publisher := a.publisher
+	a.mu.Unlock()
+
+	a.attributeManager.ModifyBase(func(attrs *Attributes) {
+		attrs.MaxHP += growth.MaxHP
+		attrs.MaxMP += growth.MaxMP
+		attrs.AD += growth.AD
+		attrs.AP += growth.AP
+		attrs.Def += growth.Def
+		attrs.MDef += growth.MDef
+	})
+	fin := a.attributeManager.Final()
+	a.ChangeHP(fin.MaxHP)
+	a.ChangeMP(fin.MaxMP)
+
+	if publisher != nil && newLevel != oldLevel {
+		publisher.Publish(NewPlayerLevelUpEvent(a.ID(), oldLevel, newLevel))
+	}
+}
+
 // ========== 战斗属性 ==========
 
 // HP 获取当前生命值
@@ -201,9 +254,25 @@ func (a *Actor) Revive(ctx context.Context) error {
 	maxMP := a.attributeManager.Final().MaxMP
 	a.ChangeHP(maxHP)
 	a.ChangeMP(maxMP)
+	a.mu.Lock()
+	a.damageTaken = nil
+	a.combatTimer = 0
+	a.mu.Unlock()
 	return nil
 }
 
+// ReviveWith 按比例恢复生命与魔法复活（生命至少为 1），并清除伤害来源
+func (a *Actor) ReviveWith(hpRatio, mpRatio float32) {
+	fin := a.attributeManager.Final()
+	a.mu.Lock()
+	a.hp = max(fin.MaxHP*hpRatio, 1)
+	a.mp = fin.MaxMP * mpRatio
+	a.damageSourceInfo = nil
+	a.damageTaken = nil
+	a.combatTimer = 0
+	a.mu.Unlock()
+}
+
 // ========== 状态标志 ==========
 
 // FlagState 获取状态标志
@@ -269,27 +338,57 @@ func (a *Actor) SetFlagStateExact(state FlagState) {
 	// TODO: 同步状态变化到客户端
 }
 
+// ========== 动画状态 ==========
+
+// AnimationState 获取动画状态（死亡优先）
+func (a *Actor) AnimationState() AnimationState {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.hp <= 0 && a.attributeManager.Final().MaxHP > 0 {
+		return AnimationStateDeath
+	}
+	return a.animState
+}
+
+// SetAnimationState 设置动画状态
+func (a *Actor) SetAnimationState(state AnimationState) {
+	a.mu.Lock()
+	a.animState = state
+	a.mu.Unlock()
+}
+
 // ========== 伤害处理 ==========
 
 // OnHurt 受到伤害
 func (a *Actor) OnHurt(ctx context.Context, info *DamageInfo) error {
+	// 决斗伤害不致死：至少保留 1 点生命，由地图判定决斗失败
+	attackerID := info.AttackerInfo.AttackerID
+	if judge := a.GetPvPJudge(); judge != nil && attackerID != 0 && info.DamageType != DamageTypeHeal && judge.Dueling(attackerID, a.ID()) {
+		if hp := a.HP(); float32(info.Amount) >= hp {
+			info.Amount = max(int32(math.Ceil(float64(hp)))-1, 0)
+		}
+	}
+
 	a.mu.Lock()
 	a.damageSourceInfo = info
+	if attacker := info.AttackerInfo.AttackerID; attacker != 0 && info.Amount > 0 && info.DamageType != DamageTypeHeal {
+		if a.damageTaken == nil {
+			a.damageTaken = make(map[EntityID]int64)
+		}
+		a.damageTaken[attacker] += int64(info.Amount)
+		a.combatTimer = CombatTimeout
+	}
+	publisher := a.publisher
 	a.mu.Unlock()
 
-	// TODO: 广播受伤消息到地图内的玩家
-	// mapRef := a.GetMap()
-	// if gameMap, ok := mapRef.(*Map); ok {
-	//     gameMap.BroadcastEntityHurt(info)
-	// }
-
 	// 扣除生命值
 	a.ChangeHP(-float32(info.Amount))
 
-	// 发布造成伤害事件（由被伤害方触发发布，聚合ID为攻击者）
-	if a.publisher != nil {
+	// 发布造成伤害事件（由被伤害方触发发布，聚合ID为攻击者；所在地图据此向观察者广播命中）
+	if publisher != nil {
 		evt := NewDamageDealtEvent(info.AttackerInfo.AttackerID, a.ID(), info.Amount, info.DamageType, info.IsCrit)
-		a.publisher.Publish(evt)
+		evt.SkillID = info.AttackerInfo.SkillID
+		publisher.Publish(evt)
 	}
 
 	// TODO: 记录日志
@@ -306,6 +405,31 @@ func (a *Actor) DamageSource() *DamageInfo {
 	return a.damageSourceInfo
 }
 
+// DamageContributors 各攻击者造成的累计伤害（复活后清空）
+func (a *Actor) DamageContributors() map[EntityID]int64 {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	contributors := make(map[EntityID]int64, len(a.damageTaken))
+	for id, dmg := range a.damageTaken {
+		contributors[id] = dmg
+	}
+	return contributors
+}
+
+// EnterCombat 进入（或刷新）战斗状态
+func (a *Actor) EnterCombat() {
+	a.mu.Lock()
+	a.combatTimer = CombatTimeout
+	a.mu.Unlock()
+}
+
+// InCombat 是否处于战斗状态
+func (a *Actor) InCombat() bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.combatTimer > 0
+}
+
 // ========== 子系统访问 ==========
 
 // AttributeManager 获取属性管理器
@@ -323,6 +447,11 @@ func (a *Actor) GetBuffManager() *BuffManager {
 	return a.buffManager
 }
 
+// GetEquipmentManager 获取装备管理器
+func (a *Actor) GetEquipmentManager() *EquipmentManager {
+	return a.equipmentManager
+}
+
 // Spell 获取施法器
 func (a *Actor) GetSpell() *Spell {
 	return a.spell
@@ -336,10 +465,18 @@ type EventPublisher interface {
 }
 
 // SetEventPublisher 注入事件发布器
-func (a *Actor) SetEventPublisher(p EventPublisher) { a.publisher = p }
+func (a *Actor) SetEventPublisher(p EventPublisher) {
+	a.mu.Lock()
+	a.publisher = p
+	a.mu.Unlock()
+}
 
 // GetEventPublisher 获取事件发布器
-func (a *Actor) GetEventPublisher() EventPublisher { return a.publisher }
+func (a *Actor) GetEventPublisher() EventPublisher {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.publisher
+}
 
 // ========== 生命周期 ==========
 
@@ -386,18 +523,25 @@ func (a *Actor) Update(ctx context.Context, deltaTime float32) error {
 		return err
 	}
 
+	if err := a.spell.Update(ctx, deltaTime); err != nil {
+		return err
+	}
+
 	if err := a.buffManager.Update(ctx, deltaTime); err != nil {
 		return err
 	}
 
-	// 按回复速度恢复生命与魔法，并刷新移动速度
+	// 按回复速度恢复生命与魔法（死亡后不回复），并刷新移动速度
 	fin := a.attributeManager.Final()
-	if fin.HPRegen != 0 || fin.MPRegen != 0 {
+	if (fin.HPRegen != 0 || fin.MPRegen != 0) && !a.IsDeath() {
 		a.ChangeHP(fin.HPRegen * deltaTime)
 		a.ChangeMP(fin.MPRegen * deltaTime)
 	}
 	a.mu.Lock()
 	a.speed = fin.Speed
+	if a.combatTimer > 0 {
+		a.combatTimer -= deltaTime
+	}
 	a.mu.Unlock()
 
 	return nil
@@ -0,0 +1,119 @@
+package character
+
+import (
+	"context"
+	"testing"
+)
+
+func newBuffTestActor(t *testing.T, id EntityID) *Actor {
+	t.Helper()
+	a := NewActor(id, EntityTypePlayer, 1, NewVector3(0, 0, 0), NewVector3(1, 0, 0), "buff", 1)
+	if err := a.Start(context.Background()); err != nil {
+		t.Fatalf("actor start failed: %v", err)
+	}
+	return a
+}
+
+func TestBuffStackRules(t *testing.T) {
+	target := newBuffTestActor(t, 1)
+	casterA := newBuffTestActor(t, 2)
+	casterB := newBuffTestActor(t, 3)
+	bm := target.GetBuffManager()
+
+	poison := BuffConfig{ID: 301, Duration: 6, Stack: BuffStackCount, MaxStack: 3, Harmful: true}
+	for i := 0; i < 5; i++ {
+		_, _ = bm.Apply(NewBuffFromConfig(poison, target, casterA))
+	}
+	if b := bm.GetBuffByID(301); b == nil || b.Stacks() != 3 || len(bm.BuffIDs()) != 1 {
+		t.Fatalf("expected one poison capped at 3 stacks, got %v", bm.BuffIDs())
+	}
+
+	burn := BuffConfig{ID: 302, Duration: 5, Stack: BuffStackPerCaster, Harmful: true}
+	_, _ = bm.Apply(NewBuffFromConfig(burn, target, casterA))
+	_, _ = bm.Apply(NewBuffFromConfig(burn, target, casterB))
+	_, _ = bm.Apply(NewBuffFromConfig(burn, target, casterA))
+	if n := len(bm.BuffIDs()); n != 3 {
+		t.Fatalf("expected one burn per caster, got %v", bm.BuffIDs())
+	}
+
+	// 刷新规则：重复施加重置持续时间
+	slow := BuffConfig{ID: 101, Duration: 4}
+	_, _ = bm.Apply(NewBuffFromConfig(slow, target, casterA))
+	_ = bm.Update(context.Background(), 3)
+	_, _ = bm.Apply(NewBuffFromConfig(slow, target, casterA))
+	if b := bm.GetBuffByID(101); b == nil || b.Remaining() != 4 {
+		t.Fatalf("expected refreshed duration")
+	}
+}
+
+func TestBuffStacksScaleModifier(t *testing.T) {
+	target := newBuffTestActor(t, 1)
+	baseAD := target.GetAttributeManager().Final().AD
+	cfg := BuffConfig{ID: 1, Duration: 10, Stack: BuffStackCount, MaxStack: 5, Modifier: AttributeModifier{ADAdd: 2}}
+	_, _ = target.GetBuffManager().Apply(NewBuffFromConfig(cfg, target, target))
+	_, _ = target.GetBuffManager().Apply(NewBuffFromConfig(cfg, target, target))
+	if ad := target.GetAttributeManager().Final().AD; ad != baseAD+4 {
+		t.Fatalf("expected AD %v with two stacks, got %v", baseAD+4, ad)
+	}
+}
+
+func TestBuffPeriodicDamageAndHeal(t *testing.T) {
+	target := newBuffTestActor(t, 1)
+	caster := newBuffTestActor(t, 2)
+	bm := target.GetBuffManager()
+	maxHP := target.HP()
+
+	dot := BuffConfig{ID: 301, Duration: 3, Stack: BuffStackCount, MaxStack: 5, TickInterval: 1, TickDamage: 5, DamageType: DamageTypeReal, Harmful: true}
+	_, _ = bm.Apply(NewBuffFromConfig(dot, target, caster))
+	_, _ = bm.Apply(NewBuffFromConfig(dot, target, caster))
+	_ = bm.Update(context.Background(), 0.5)
+	if target.HP() != maxHP {
+		t.Fatalf("dot ticked early")
+	}
+	_ = bm.Update(context.Background(), 0.5)
+	if target.HP() != maxHP-10 {
+		t.Fatalf("expected one tick of 2 stacks x 5, got hp %v", target.HP())
+	}
+	src := target.DamageSource()
+	if src == nil || src.AttackerInfo.AttackerID != caster.ID() || src.AttackerInfo.BuffID != 301 {
+		t.Fatalf("expected damage attributed to caster buff, got %+v", src)
+	}
+	_ = bm.Update(context.Background(), 2)
+	if target.HP() != maxHP-30 || bm.GetBuffByID(301) != nil {
+		t.Fatalf("expected three ticks then expiry, got hp %v", target.HP())
+	}
+
+	hot := BuffConfig{ID: 202, Duration: 2, TickInterval: 1, TickHeal: 8}
+	_, _ = bm.Apply(NewBuffFromConfig(hot, target, target))
+	_ = bm.Update(context.Background(), 2)
+	if target.HP() != maxHP-14 {
+		t.Fatalf("expected two heal ticks, got hp %v", target.HP())
+	}
+}
+
+func TestBuffDispelAndImmunity(t *testing.T) {
+	target := newBuffTestActor(t, 1)
+	bm := target.GetBuffManager()
+
+	_, _ = bm.Apply(NewBuffFromConfig(BuffConfig{ID: 101, Duration: 10, Harmful: true, Category: DispelCategoryMagic, Dispellable: true}, target, nil))
+	_, _ = bm.Apply(NewBuffFromConfig(BuffConfig{ID: 303, Duration: 10, Harmful: true, Category: DispelCategoryCurse, Dispellable: true}, target, nil))
+	_, _ = bm.Apply(NewBuffFromConfig(BuffConfig{ID: 201, Duration: 10, Category: DispelCategoryMagic, Dispellable: true}, target, nil))
+	stun := BuffConfig{ID: 401, Duration: 10, Flags: FlagStateStun, Harmful: true, Category: DispelCategoryControl}
+	_, _ = bm.Apply(NewBuffFromConfig(stun, target, nil))
+
+	if removed := bm.Dispel(DispelCategoryMagic, true, 0); len(removed) != 1 || removed[0] != 101 {
+		t.Fatalf("expected only harmful magic dispelled, got %v", removed)
+	}
+	if removed := bm.Dispel(DispelCategoryNone, true, 0); len(removed) != 1 || removed[0] != 303 {
+		t.Fatalf("expected undispellable stun kept, got %v", removed)
+	}
+
+	// 免疫控制：施加时清除已有控制，并拒绝新的控制
+	_, _ = bm.Apply(NewBuffFromConfig(BuffConfig{ID: 402, Duration: 5, Immune: []DispelCategory{DispelCategoryControl}}, target, nil))
+	if bm.GetBuffByID(401) != nil || target.GetFlagState().HasFlag(FlagStateStun) {
+		t.Fatalf("expected stun purged by immunity")
+	}
+	if _, err := bm.Apply(NewBuffFromConfig(stun, target, nil)); err != ErrBuffImmune {
+		t.Fatalf("expected immune error, got %v", err)
+	}
+}
@@ -0,0 +1,185 @@
+package character
+
+import (
+	"context"
+	"testing"
+)
+
+func newCastTestActor(t *testing.T, id EntityID) (*Actor, *fakePublisher) {
+	t.Helper()
+	a := NewActor(id, EntityTypePlayer, 1, NewVector3(0, 0, 0), NewVector3(1, 0, 0), "caster", 1)
+	if err := a.Start(context.Background()); err != nil {
+		t.Fatalf("actor start: %v", err)
+	}
+	events := &fakePublisher{}
+	a.SetEventPublisher(events)
+	return a, events
+}
+
+func lastCancelReason(t *testing.T, events *fakePublisher) CastCancelReason {
+	t.Helper()
+	events.mu.Lock()
+	defer events.mu.Unlock()
+	for i := len(events.events) - 1; i >= 0; i-- {
+		if evt, ok := events.events[i].(*SkillCastCancelledEvent); ok {
+			return evt.Reason
+		}
+	}
+	t.Fatalf("no cancel event published")
+	return CastCancelNone
+}
+
+func TestCastCancelledByMoveAndControl(t *testing.T) {
+	ctx := context.Background()
+	caster, events := newCastTestActor(t, 1)
+	sk := NewSkill(10, caster)
+	sk.SetTimings(1, 0.1, 5)
+	caster.GetSkillManager().AddSkill(sk)
+
+	if !caster.GetSpell().Cast(10, nil) || !caster.GetSpell().IsCasting() {
+		t.Fatalf("expected cast to start")
+	}
+	_ = caster.Update(ctx, 0.2)
+	caster.GetSpell().Interrupt(CastCancelMove, 0)
+	_ = caster.Update(ctx, 0.1)
+	if sk.State() != SkillStateReady || lastCancelReason(t, events) != CastCancelMove {
+		t.Fatalf("expected move to cancel cast back to ready, state=%v", sk.State())
+	}
+
+	// 眩晕 Buff 打断吟唱
+	if !caster.GetSpell().Cast(10, nil) {
+		t.Fatalf("expected recast after cancel")
+	}
+	stun := NewBuff(401, caster, caster, 1)
+	stun.SetFlagAdd(FlagStateStun)
+	caster.GetBuffManager().AddBuff(stun)
+	_ = caster.Update(ctx, 0.1)
+	if sk.State() != SkillStateReady || lastCancelReason(t, events) != CastCancelControl {
+		t.Fatalf("expected stun to cancel cast, state=%v", sk.State())
+	}
+	if events.CountByName("SkillCastCompleted") != 0 {
+		t.Fatalf("cancelled casts must not complete")
+	}
+}
+
+func TestStaleInterruptDoesNotCancelNextCast(t *testing.T) {
+	ctx := context.Background()
+	caster, events := newCastTestActor(t, 1)
+	sk := NewSkill(10, caster)
+	sk.SetTimings(0.5, 0.1, 1)
+	caster.GetSkillManager().AddSkill(sk)
+
+	// 未施法时的移动不影响之后的施法
+	caster.GetSpell().Interrupt(CastCancelMove, 0)
+	if !caster.GetSpell().Cast(10, nil) {
+		t.Fatalf("cast rejected")
+	}
+	for i := 0; i < 6; i++ {
+		_ = caster.Update(ctx, 0.1)
+	}
+	if events.CountByName("SkillCastCancelled") != 0 || events.CountByName("SkillCastCompleted") != 1 {
+		t.Fatalf("expected cast to complete, cancelled=%d", events.CountByName("SkillCastCancelled"))
+	}
+}
+
+func TestGlobalCooldownBetweenSkills(t *testing.T) {
+	ctx := context.Background()
+	caster, _ := newCastTestActor(t, 1)
+	caster.GetSpell().SetGlobalCooldown(1)
+	for _, id := range []int32{10, 11, 12} {
+		sk := NewSkill(id, caster)
+		sk.SetTimings(0, 0.1, 0.5)
+		caster.GetSkillManager().AddSkill(sk)
+	}
+	caster.GetSkillManager().GetSkill(12).SetOffGCD(true)
+
+	if !caster.GetSpell().Cast(10, nil) {
+		t.Fatalf("first cast rejected")
+	}
+	if caster.GetSpell().Cast(11, nil) {
+		t.Fatalf("expected second skill blocked by global cooldown")
+	}
+	if !caster.GetSpell().Cast(12, nil) {
+		t.Fatalf("expected off-gcd skill allowed")
+	}
+	for i := 0; i < 10; i++ {
+		_ = caster.Update(ctx, 0.1)
+	}
+	if caster.GetSpell().GlobalCooldown() != 0 || !caster.GetSpell().Cast(11, nil) {
+		t.Fatalf("expected cast allowed after global cooldown")
+	}
+}
+
+func TestChannelTicksAndInterruptLockout(t *testing.T) {
+	ctx := context.Background()
+	caster, events := newCastTestActor(t, 1)
+	target, hits := newCastTestActor(t, 2)
+
+	channel := NewSkill(20, caster)
+	channel.SetTimings(0, 0, 6)
+	channel.SetChannel(3, 1)
+	channel.SetDamage(10, 0, 0, DamageTypeMagical)
+	caster.GetSkillManager().AddSkill(channel)
+	if !caster.GetSpell().Cast(20, target) {
+		t.Fatalf("channel rejected")
+	}
+	for i := 0; i < 40; i++ {
+		_ = caster.Update(ctx, 0.05)
+	}
+	if got := hits.CountByName("DamageDealt"); got != 2 {
+		t.Fatalf("expected 2 channel ticks after 2s, got %d", got)
+	}
+
+	// 打断技能命中后，引导中的技能进入冷却（取冷却与封锁时长的较大者）
+	kick := NewSkill(30, target)
+	kick.SetTimings(0, 0.1, 10)
+	kick.SetInterrupt(8)
+	target.GetSkillManager().AddSkill(kick)
+	if !target.GetSpell().Cast(30, caster) {
+		t.Fatalf("kick rejected")
+	}
+	_ = target.Update(ctx, 0.05)
+	_ = caster.Update(ctx, 0.05)
+	if channel.State() != SkillStateCooling || lastCancelReason(t, events) != CastCancelInterrupt {
+		t.Fatalf("expected channel interrupted, state=%v", channel.State())
+	}
+	for i := 0; i < 140; i++ {
+		_ = caster.Update(ctx, 0.05)
+	}
+	if channel.State() != SkillStateCooling {
+		t.Fatalf("expected lockout to outlast cooldown")
+	}
+	if events.CountByName("SkillCastCompleted") != 0 {
+		t.Fatalf("interrupted channel must not complete")
+	}
+}
+
+func TestOverlappingCastsKeepTheirOwnTargets(t *testing.T) {
+	ctx := context.Background()
+	caster := NewActor(20, EntityTypePlayer, 1, NewVector3(0, 0, 0), NewVector3(1, 0, 0), "caster", 1)
+	first := NewActor(21, EntityTypeMonster, 1, NewVector3(1, 0, 0), NewVector3(-1, 0, 0), "first", 1)
+	second := NewActor(22, EntityTypeMonster, 1, NewVector3(1, 0, 1), NewVector3(-1, 0, 0), "second", 1)
+	for _, a := range []*Actor{caster, first, second} {
+		if err := a.Start(ctx); err != nil {
+			t.Fatalf("start: %v", err)
+		}
+	}
+
+	slow := NewSkill(1, caster)
+	slow.SetTimings(0.5, 0.01, 1)
+	slow.SetDamage(20, 0, 0, DamageTypeReal)
+	fast := NewSkill(2, caster)
+	fast.SetTimings(0, 0.01, 1)
+	fast.SetDamage(20, 0, 0, DamageTypeReal)
+	caster.GetSkillManager().AddSkill(slow)
+	caster.GetSkillManager().AddSkill(fast)
+
+	if !caster.GetSpell().Cast(1, first) || !caster.GetSpell().Cast(2, second) {
+		t.Fatalf("casts rejected")
+	}
+	_ = caster.Update(ctx, 0.6)
+	maxHP := first.GetAttributeManager().Final().MaxHP
+	if first.HP() >= maxHP || second.HP() >= maxHP || caster.HP() < maxHP {
+		t.Fatalf("expected each skill to hit its own target: first=%v second=%v caster=%v", first.HP(), second.HP(), caster.HP())
+	}
+}
@@ -0,0 +1,128 @@
+package character
+
+import "errors"
+
+// ErrCCImmune 目标处于控制递减免疫期，或被配置为免疫控制（首领）
+var ErrCCImmune = errors.New("target is immune to crowd control")
+
+// DiminishingConfig 控制递减配置
+type DiminishingConfig struct {
+	ResetWindow float32   // 最近一次控制结束后经过该时间未再受控则重置（秒）
+	Multipliers []float32 // 窗口内第 N 次受控的持续时间系数，超出后免疫
+}
+
+// DefaultDiminishing 默认递减：100%、50%、25%，之后免疫；控制结束 18 秒后重置
+func DefaultDiminishing() DiminishingConfig {
+	return DiminishingConfig{ResetWindow: 18, Multipliers: []float32{1, 0.5, 0.25}}
+}
+
+// diminishingCategories 参与递减的控制类别（按状态标志独立计数）
+var diminishingCategories = []FlagState{FlagStateStun, FlagStateRoot, FlagStateSilence}
+
+// drState 单个控制类别的递减状态
+type drState struct {
+	count   int     // 窗口内已生效次数
+	resetAt float32 // 重置时刻（BuffManager 时钟）
+}
+
+// SetDiminishing 设置控制递减配置
+func (bm *BuffManager) SetDiminishing(cfg DiminishingConfig) {
+	bm.mu.Lock()
+	bm.drConfig = cfg
+	bm.mu.Unlock()
+}
+
+// SetCCImmune 设置是否免疫控制（首领单位）
+func (bm *BuffManager) SetCCImmune(immune bool) {
+	bm.mu.Lock()
+	bm.ccImmune = immune
+	bm.mu.Unlock()
+}
+
+// IsCCImmune 是否免疫控制
+func (bm *BuffManager) IsCCImmune() bool {
+	bm.mu.RLock()
+	defer bm.mu.RUnlock()
+	return bm.ccImmune
+}
+
+// DiminishingCount 某控制类别在当前窗口内已生效的次数（窗口已过期时为 0）
+func (bm *BuffManager) DiminishingCount(category FlagState) int {
+	bm.mu.RLock()
+	defer bm.mu.RUnlock()
+	if st, ok := bm.dr[category]; ok && bm.clock < st.resetAt {
+		return st.count
+	}
+	return 0
+}
+
+// diminishLocked 按递减规则缩短控制类 Buff 的持续时间并累计次数，返回需通知客户端的递减事件；
+// 免疫时返回 ErrCCImmune 且不累计（调用方持有锁）
+func (bm *BuffManager) diminishLocked(buff *Buff) ([]DomainEvent, error) {
+	var categories []FlagState
+	for _, c := range diminishingCategories {
+		if buff.addFlags.HasFlag(c) {
+			categories = append(categories, c)
+		}
+	}
+	if len(categories) == 0 {
+		return nil, nil
+	}
+	targetID := EntityID(0)
+	if bm.owner != nil {
+		targetID = bm.owner.ID()
+	}
+
+	multiplier := float32(1)
+	immune := bm.ccImmune
+	for _, c := range categories {
+		st := bm.dr[c]
+		if st == nil || bm.clock >= st.resetAt {
+			st = &drState{}
+			bm.dr[c] = st
+		}
+		if st.count >= len(bm.drConfig.Multipliers) {
+			immune = true
+			break
+		}
+		if m := bm.drConfig.Multipliers[st.count]; m < multiplier {
+			multiplier = m
+		}
+	}
+
+	events := make([]DomainEvent, 0, len(categories))
+	if immune {
+		for _, c := range categories {
+			st := bm.dr[c]
+			var resetIn float32
+			count := 0
+			if st != nil {
+				count = st.count
+				resetIn = max(st.resetAt-bm.clock, 0)
+			}
+			events = append(events, NewCCDiminishedEvent(targetID, c, int32(count), 0, resetIn))
+		}
+		return events, ErrCCImmune
+	}
+
+	buff.duration *= multiplier
+	for _, c := range categories {
+		st := bm.dr[c]
+		st.count++
+		st.resetAt = max(st.resetAt, bm.clock+buff.duration+bm.drConfig.ResetWindow)
+		events = append(events, NewCCDiminishedEvent(targetID, c, int32(st.count), multiplier, st.resetAt-bm.clock))
+	}
+	return events, nil
+}
+
+// publishAll 通过所属角色的事件发布器发布事件（调用方不得持有 bm.mu）
+func (bm *BuffManager) publishAll(events []DomainEvent) {
+	if bm.owner == nil || len(events) == 0 {
+		return
+	}
+	if publisher := bm.owner.GetEventPublisher(); publisher != nil {
+		for _, e := range events {
+			publisher.Publish(e)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package character
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestStunDiminishingReturns(t *testing.T) {
+	target := newBuffTestActor(t, 1)
+	caster := newBuffTestActor(t, 2)
+	pub := &fakePublisher{}
+	target.SetEventPublisher(pub)
+	bm := target.GetBuffManager()
+	stun := BuffConfig{ID: 401, Duration: 4, Flags: FlagStateStun, Harmful: true}
+
+	// 100% / 50% / 25%，之后免疫
+	for i, want := range []float32{4, 2, 1} {
+		b, err := bm.Apply(NewBuffFromConfig(stun, target, caster))
+		if err != nil || b.Remaining() != want {
+			t.Fatalf("application %d: expected %v seconds, got %v (err %v)", i+1, want, b, err)
+		}
+		if !target.GetFlagState().HasFlag(FlagStateStun) {
+			t.Fatalf("application %d should stun", i+1)
+		}
+		_ = bm.Update(context.Background(), want)
+	}
+	if _, err := bm.Apply(NewBuffFromConfig(stun, target, caster)); !errors.Is(err, ErrCCImmune) {
+		t.Fatalf("expected immunity after three stuns, got %v", err)
+	}
+	if target.GetFlagState().HasFlag(FlagStateStun) {
+		t.Fatalf("immune target should not be stunned")
+	}
+	if n := pub.CountByName("CCDiminished"); n != 4 {
+		t.Fatalf("expected 4 diminishing notifications, got %d", n)
+	}
+
+	// 其他类别独立计数
+	root := BuffConfig{ID: 402, Duration: 3, Flags: FlagStateRoot, Harmful: true}
+	if b, err := bm.Apply(NewBuffFromConfig(root, target, caster)); err != nil || b.Remaining() != 3 {
+		t.Fatalf("root should not share stun diminishing")
+	}
+
+	// 控制结束后经过重置窗口恢复全额
+	_ = bm.Update(context.Background(), DefaultDiminishing().ResetWindow)
+	if bm.DiminishingCount(FlagStateStun) != 0 {
+		t.Fatalf("stun diminishing should reset after the window")
+	}
+	if b, err := bm.Apply(NewBuffFromConfig(stun, target, caster)); err != nil || b.Remaining() != 4 {
+		t.Fatalf("expected full duration after reset, got %v (err %v)", b, err)
+	}
+}
+
+func TestCCImmuneUnitRejectsControl(t *testing.T) {
+	boss := newBuffTestActor(t, 1)
+	caster := newBuffTestActor(t, 2)
+	bm := boss.GetBuffManager()
+	bm.SetCCImmune(true)
+
+	silence := BuffConfig{ID: 403, Duration: 3, Flags: FlagStateSilence, Harmful: true}
+	if _, err := bm.Apply(NewBuffFromConfig(silence, boss, caster)); !errors.Is(err, ErrCCImmune) {
+		t.Fatalf("expected boss to be immune to silence, got %v", err)
+	}
+	// 非控制类减益不受影响
+	slow := BuffConfig{ID: 101, Duration: 4, Flags: FlagStateSlow, Harmful: true}
+	if _, err := bm.Apply(NewBuffFromConfig(slow, boss, caster)); err != nil {
+		t.Fatalf("slow should still apply to boss: %v", err)
+	}
+}
@@ -0,0 +1,174 @@
+package character
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+// 装备错误
+var (
+	ErrInvalidEquipSlot   = errors.New("invalid equip slot")
+	ErrEquipLevelTooLow   = errors.New("insufficient level to equip")
+	ErrEquipClassMismatch = errors.New("class cannot equip this item")
+	ErrEquipSlotEmpty     = errors.New("equip slot is empty")
+)
+
+// EquipSlot 装备槽位
+type EquipSlot int32
+
+const (
+	EquipSlotNone   EquipSlot = 0
+	EquipSlotWeapon EquipSlot = 1 // 武器
+	EquipSlotArmor  EquipSlot = 2 // 衣服
+	EquipSlotHelmet EquipSlot = 3 // 头盔
+	EquipSlotGloves EquipSlot = 4 // 手套
+	EquipSlotBoots  EquipSlot = 5 // 鞋子
+	EquipSlotRing   EquipSlot = 6 // 戒指
+
+	EquipSlotCount = 6
+)
+
+// Valid 槽位是否有效
+func (s EquipSlot) Valid() bool {
+	return s > EquipSlotNone && s <= EquipSlotCount
+}
+
+// Equipment 已穿戴的装备
+type Equipment struct {
+	ItemUID       int64 // 物品唯一ID
+	ItemID        int32 // 物品配置ID（客户端据此显示外观）
+	Slot          EquipSlot
+	RequiredLevel int32   // 需求等级
+	Classes       []int32 // 可穿戴的职业（unitID），为空表示不限
+	Modifier      AttributeModifier
+}
+
+// EquipmentManager 装备管理器：按槽位管理已穿戴装备，变更后重算属性并发布外观变化事件
+type EquipmentManager struct {
+	owner *Actor
+	mu    sync.RWMutex
+	slots map[EquipSlot]*Equipment
+}
+
+// NewEquipmentManager 创建装备管理器
+func NewEquipmentManager(owner *Actor) *EquipmentManager {
+	return &EquipmentManager{
+		owner: owner,
+		slots: make(map[EquipSlot]*Equipment),
+	}
+}
+
+// CanEquip 校验槽位、等级与职业
+func (em *EquipmentManager) CanEquip(eq *Equipment) error {
+	if eq == nil || !eq.Slot.Valid() {
+		return ErrInvalidEquipSlot
+	}
+	if em.owner.Level() < eq.RequiredLevel {
+		return ErrEquipLevelTooLow
+	}
+	if len(eq.Classes) == 0 {
+		return nil
+	}
+	for _, class := range eq.Classes {
+		if class == em.owner.UnitID() {
+			return nil
+		}
+	}
+	return ErrEquipClassMismatch
+}
+
+// Equip 穿戴装备，返回被替换下的装备（可能为 nil）
+func (em *EquipmentManager) Equip(eq *Equipment) (*Equipment, error) {
+	if err := em.CanEquip(eq); err != nil {
+		return nil, err
+	}
+	em.mu.Lock()
+	replaced := em.slots[eq.Slot]
+	em.slots[eq.Slot] = eq
+	em.mu.Unlock()
+
+	em.changed(eq.Slot, eq.ItemID)
+	return replaced, nil
+}
+
+// Unequip 卸下指定槽位的装备
+func (em *EquipmentManager) Unequip(slot EquipSlot) (*Equipment, error) {
+	em.mu.Lock()
+	eq, ok := em.slots[slot]
+	if !ok {
+		em.mu.Unlock()
+		return nil, ErrEquipSlotEmpty
+	}
+	delete(em.slots, slot)
+	em.mu.Unlock()
+
+	em.changed(slot, 0)
+	return eq, nil
+}
+
+// Restore 加载角色时恢复已穿戴装备（不校验、不发布事件，只重算属性）
+func (em *EquipmentManager) Restore(equipments []*Equipment) {
+	em.mu.Lock()
+	for _, eq := range equipments {
+		if eq != nil && eq.Slot.Valid() {
+			em.slots[eq.Slot] = eq
+		}
+	}
+	em.mu.Unlock()
+	em.owner.attributeManager.Recalculate()
+}
+
+// Get 获取槽位上的装备
+func (em *EquipmentManager) Get(slot EquipSlot) *Equipment {
+	em.mu.RLock()
+	defer em.mu.RUnlock()
+	return em.slots[slot]
+}
+
+// All 所有已穿戴装备（按槽位排序）
+func (em *EquipmentManager) All() []*Equipment {
+	em.mu.RLock()
+	list := make([]*Equipment, 0, len(em.slots))
+	for _, eq := range em.slots {
+		list = append(list, eq)
+	}
+	em.mu.RUnlock()
+	sort.Slice(list, func(i, j int) bool { return list[i].Slot < list[j].Slot })
+	return list
+}
+
+// Appearance 外观：槽位 -> 物品配置ID
+func (em *EquipmentManager) Appearance() map[EquipSlot]int32 {
+	em.mu.RLock()
+	defer em.mu.RUnlock()
+	if len(em.slots) == 0 {
+		return nil
+	}
+	look := make(map[EquipSlot]int32, len(em.slots))
+	for slot, eq := range em.slots {
+		look[slot] = eq.ItemID
+	}
+	return look
+}
+
+// CollectModifiers 收集所有装备的属性修饰器
+func (em *EquipmentManager) CollectModifiers() []AttributeModifier {
+	em.mu.RLock()
+	defer em.mu.RUnlock()
+	mods := make([]AttributeModifier, 0, len(em.slots))
+	for _, eq := range em.slots {
+		mods = append(mods, eq.Modifier)
+	}
+	return mods
+}
+
+// changed 装备变化：重算属性（当前生命/魔法收敛到新上限）并发布外观变化事件（所在地图据此向观察者广播）
+func (em *EquipmentManager) changed(slot EquipSlot, itemID int32) {
+	em.owner.attributeManager.Recalculate()
+	em.owner.ChangeHP(0)
+	em.owner.ChangeMP(0)
+	if publisher := em.owner.GetEventPublisher(); publisher != nil {
+		publisher.Publish(NewEquipmentChangedEvent(em.owner.ID(), slot, itemID))
+	}
+}
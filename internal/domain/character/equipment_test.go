@@ -0,0 +1,85 @@
+package character
+
+import (
+	"context"
+	"testing"
+)
+
+func TestEquipValidatesLevelAndClass(t *testing.T) {
+	actor := NewActor(1, EntityTypePlayer, 1001, NewVector3(0, 0, 0), NewVector3(1, 0, 0), "warrior", 5)
+	if err := actor.Start(context.Background()); err != nil {
+		t.Fatalf("actor start failed: %v", err)
+	}
+	em := actor.GetEquipmentManager()
+
+	if _, err := em.Equip(&Equipment{ItemID: 1, Slot: EquipSlotNone}); err != ErrInvalidEquipSlot {
+		t.Fatalf("expected invalid slot, got %v", err)
+	}
+	if _, err := em.Equip(&Equipment{ItemID: 1, Slot: EquipSlotWeapon, RequiredLevel: 6}); err != ErrEquipLevelTooLow {
+		t.Fatalf("expected level too low, got %v", err)
+	}
+	if _, err := em.Equip(&Equipment{ItemID: 1, Slot: EquipSlotWeapon, Classes: []int32{1002}}); err != ErrEquipClassMismatch {
+		t.Fatalf("expected class mismatch, got %v", err)
+	}
+	if _, err := em.Equip(&Equipment{ItemID: 1, Slot: EquipSlotWeapon, RequiredLevel: 5, Classes: []int32{1001}}); err != nil {
+		t.Fatalf("expected equip ok, got %v", err)
+	}
+}
+
+func TestEquipmentFeedsFinalAttributes(t *testing.T) {
+	actor := NewActor(1, EntityTypePlayer, 1001, NewVector3(0, 0, 0), NewVector3(1, 0, 0), "warrior", 5)
+	if err := actor.Start(context.Background()); err != nil {
+		t.Fatalf("actor start failed: %v", err)
+	}
+	events := &fakePublisher{}
+	actor.SetEventPublisher(events)
+	am := actor.GetAttributeManager()
+	em := actor.GetEquipmentManager()
+	baseAD, baseMaxHP := am.Final().AD, am.Final().MaxHP
+
+	sword := &Equipment{ItemUID: 100, ItemID: 20001, Slot: EquipSlotWeapon, Modifier: AttributeModifier{ADAdd: 50}}
+	armor := &Equipment{ItemUID: 101, ItemID: 20003, Slot: EquipSlotArmor, Modifier: AttributeModifier{MaxHPAdd: 80}}
+	if _, err := em.Equip(sword); err != nil {
+		t.Fatalf("equip sword: %v", err)
+	}
+	if _, err := em.Equip(armor); err != nil {
+		t.Fatalf("equip armor: %v", err)
+	}
+	actor.ChangeHP(1000)
+	if am.Final().AD != baseAD+50 || am.Final().MaxHP != baseMaxHP+80 || actor.HP() != baseMaxHP+80 {
+		t.Fatalf("equipment bonuses not applied: ad=%v maxhp=%v hp=%v", am.Final().AD, am.Final().MaxHP, actor.HP())
+	}
+
+	// 同槽位替换返回旧装备
+	axe := &Equipment{ItemUID: 102, ItemID: 20005, Slot: EquipSlotWeapon, Modifier: AttributeModifier{ADAdd: 20}}
+	replaced, err := em.Equip(axe)
+	if err != nil || replaced != sword {
+		t.Fatalf("expected sword replaced, got %v %v", replaced, err)
+	}
+	if am.Final().AD != baseAD+20 {
+		t.Fatalf("expected replaced weapon bonus, got ad=%v", am.Final().AD)
+	}
+
+	// 卸下衣服后生命收敛到新上限
+	if _, err := em.Unequip(EquipSlotArmor); err != nil {
+		t.Fatalf("unequip armor: %v", err)
+	}
+	if am.Final().MaxHP != baseMaxHP || actor.HP() != baseMaxHP {
+		t.Fatalf("expected hp clamped to %v, got maxhp=%v hp=%v", baseMaxHP, am.Final().MaxHP, actor.HP())
+	}
+	if _, err := em.Unequip(EquipSlotArmor); err != ErrEquipSlotEmpty {
+		t.Fatalf("expected empty slot, got %v", err)
+	}
+
+	look := em.Appearance()
+	if len(look) != 1 || look[EquipSlotWeapon] != 20005 {
+		t.Fatalf("unexpected appearance %v", look)
+	}
+	if n := events.CountByName("EquipmentChanged"); n != 4 {
+		t.Fatalf("expected 4 equipment events, got %d", n)
+	}
+	last := events.events[3].(*EquipmentChangedEvent)
+	if last.Slot != EquipSlotArmor || last.ItemID != 0 {
+		t.Fatalf("unexpected unequip event %+v", last)
+	}
+}
@@ -94,15 +94,15 @@ func NewPlayerCreatedEvent(characterID, userID int64, name string, level int32)
 // PlayerLevelUpEvent 玩家升级事件
 type PlayerLevelUpEvent struct {
 	BaseDomainEvent
-	CharacterID int64
-	OldLevel    int32
-	NewLevel    int32
+	EntityID EntityID
+	OldLevel int32
+	NewLevel int32
 }
 
-func NewPlayerLevelUpEvent(characterID int64, oldLevel, newLevel int32) *PlayerLevelUpEvent {
+func NewPlayerLevelUpEvent(entityID EntityID, oldLevel, newLevel int32) *PlayerLevelUpEvent {
 	return &PlayerLevelUpEvent{
-		BaseDomainEvent: NewBaseDomainEvent("PlayerLevelUp", characterID),
-		CharacterID:     characterID,
+		BaseDomainEvent: NewBaseDomainEvent("PlayerLevelUp", entityID),
+		EntityID:        entityID,
 		OldLevel:        oldLevel,
 		NewLevel:        newLevel,
 	}
@@ -135,6 +135,7 @@ type DamageDealtEvent struct {
 	Amount     int32
 	DamageType DamageType
 	IsCrit     bool
+	SkillID    int32 // 来源技能（0 表示非技能伤害）
 }
 
 func NewDamageDealtEvent(attackerID, targetID EntityID, amount int32, damageType DamageType, isCrit bool) *DamageDealtEvent {
@@ -151,9 +152,11 @@ func NewDamageDealtEvent(attackerID, targetID EntityID, amount int32, damageType
 // SkillCastEvent 技能释放事件
 type SkillCastEvent struct {
 	BaseDomainEvent
-	CasterID EntityID
-	SkillID  int32
-	TargetID EntityID
+	CasterID    EntityID
+	SkillID     int32
+	TargetID    EntityID
+	CastTime    float32 // 吟唱时间（0 表示瞬发）
+	ChannelTime float32 // 引导时间（0 表示非引导技能）
 }
 
 func NewSkillCastEvent(casterID EntityID, skillID int32, targetID EntityID) *SkillCastEvent {
@@ -165,6 +168,38 @@ func NewSkillCastEvent(casterID EntityID, skillID int32, targetID EntityID) *Ski
 	}
 }
 
+// SkillCastCancelledEvent 吟唱/引导被打断事件
+type SkillCastCancelledEvent struct {
+	BaseDomainEvent
+	CasterID EntityID
+	SkillID  int32
+	Reason   CastCancelReason
+}
+
+func NewSkillCastCancelledEvent(casterID EntityID, skillID int32, reason CastCancelReason) *SkillCastCancelledEvent {
+	return &SkillCastCancelledEvent{
+		BaseDomainEvent: NewBaseDomainEvent("SkillCastCancelled", casterID),
+		CasterID:        casterID,
+		SkillID:         skillID,
+		Reason:          reason,
+	}
+}
+
+// SkillCastCompletedEvent 吟唱/引导完成事件
+type SkillCastCompletedEvent struct {
+	BaseDomainEvent
+	CasterID EntityID
+	SkillID  int32
+}
+
+func NewSkillCastCompletedEvent(casterID EntityID, skillID int32) *SkillCastCompletedEvent {
+	return &SkillCastCompletedEvent{
+		BaseDomainEvent: NewBaseDomainEvent("SkillCastCompleted", casterID),
+		CasterID:        casterID,
+		SkillID:         skillID,
+	}
+}
+
 // BuffAddedEvent Buff添加事件
 type BuffAddedEvent struct {
 	BaseDomainEvent
@@ -199,6 +234,78 @@ func NewBuffRemovedEvent(targetID EntityID, buffID int32) *BuffRemovedEvent {
 	}
 }
 
+// CCDiminishedEvent 控制递减状态变化事件（Multiplier 为 0 表示免疫）
+type CCDiminishedEvent struct {
+	BaseDomainEvent
+	TargetID   EntityID
+	Category   FlagState // 控制类别：眩晕/定身/沉默
+	Count      int32     // 窗口内已生效次数
+	Multiplier float32   // 本次持续时间系数
+	ResetIn    float32   // 距递减重置的剩余时间（秒）
+}
+
+func NewCCDiminishedEvent(targetID EntityID, category FlagState, count int32, multiplier, resetIn float32) *CCDiminishedEvent {
+	return &CCDiminishedEvent{
+		BaseDomainEvent: NewBaseDomainEvent("CCDiminished", targetID),
+		TargetID:        targetID,
+		Category:        category,
+		Count:           count,
+		Multiplier:      multiplier,
+		ResetIn:         resetIn,
+	}
+}
+
+// EquipmentChangedEvent 装备外观变化事件
+type EquipmentChangedEvent struct {
+	BaseDomainEvent
+	EntityID EntityID
+	Slot     EquipSlot
+	ItemID   int32 // 0 表示卸下
+}
+
+func NewEquipmentChangedEvent(entityID EntityID, slot EquipSlot, itemID int32) *EquipmentChangedEvent {
+	return &EquipmentChangedEvent{
+		BaseDomainEvent: NewBaseDomainEvent("EquipmentChanged", entityID),
+		EntityID:        entityID,
+		Slot:            slot,
+		ItemID:          itemID,
+	}
+}
+
+// PvPFlagChangedEvent PvP 标记变化事件
+type PvPFlagChangedEvent struct {
+	BaseDomainEvent
+	EntityID EntityID
+	Enabled  bool
+}
+
+func NewPvPFlagChangedEvent(entityID EntityID, enabled bool) *PvPFlagChangedEvent {
+	return &PvPFlagChangedEvent{
+		BaseDomainEvent: NewBaseDomainEvent("PvPFlagChanged", entityID),
+		EntityID:        entityID,
+		Enabled:         enabled,
+	}
+}
+
+// ResurrectOfferedEvent 复活邀请事件（复活技能命中死亡友方，由目标确认后复活）
+type ResurrectOfferedEvent struct {
+	BaseDomainEvent
+	CasterID EntityID
+	TargetID EntityID
+	SkillID  int32
+	HPRatio  float32
+}
+
+func NewResurrectOfferedEvent(casterID, targetID EntityID, skillID int32, hpRatio float32) *ResurrectOfferedEvent {
+	return &ResurrectOfferedEvent{
+		BaseDomainEvent: NewBaseDomainEvent("ResurrectOffered", casterID),
+		CasterID:        casterID,
+		TargetID:        targetID,
+		SkillID:         skillID,
+		HPRatio:         hpRatio,
+	}
+}
+
 // ========== 怪物相关事件 ==========
 
 // MonsterDeathEvent 怪物死亡事件
@@ -209,6 +316,11 @@ type MonsterDeathEvent struct {
 	Position  Vector3
 	DropItems []int32 // 掉落物品ID列表
 	DropExp   int32   // 掉落经验
+	DropGold  int32   // 掉落金币
+	UnitID    int32   // 怪物单位ID（任务击杀目标）
+	Level     int32   // 怪物等级（经验按等级差衰减）
+	// 各攻击者造成的累计伤害
+	Contributors map[EntityID]int64
 }
 
 func NewMonsterDeathEvent(monsterID, killerID EntityID, position Vector3, dropItems []int32, dropExp int32) *MonsterDeathEvent {
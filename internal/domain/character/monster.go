@@ -226,9 +226,30 @@ type Missile struct {
 	speed    float32 // 飞行速度
 	lifetime float32 // 生命周期
 	elapsed  float32 // 已存在时间
+	maxRange float32 // 最大飞行距离，0 表示不限
+	traveled float32 // 已飞行距离
 
 	// 技能信息
 	skillID int32 // 关联的技能ID
+
+	// 技能投射物（由施法器发射，命中时结算技能效果）
+	caster    *Actor
+	skill     *Skill
+	homing    *Actor  // 追踪目标，为空时沿方向直线飞行
+	hitRadius float32 // 碰撞半径
+}
+
+// MissileConfig 技能投射物配置（Speed 为 0 表示技能即时生效）
+type MissileConfig struct {
+	Speed     float32 // 飞行速度
+	Range     float32 // 最大飞行距离
+	HitRadius float32 // 碰撞半径
+	Homing    bool    // 是否追踪目标
+}
+
+// MissileLauncher 投射物发射器（由所在地图实现：分配实体ID、加入地图并逐帧推进）
+type MissileLauncher interface {
+	LaunchMissile(caster *Actor, skill *Skill, target *Actor, point Vector3) (*Missile, error)
 }
 
 // NewMissile 创建投射物
@@ -254,34 +275,120 @@ func NewMissile(
 	}
 }
 
-// Update 更新投射物
-func (m *Missile) Update(ctx context.Context, deltaTime float32) error {
-	// 调用Entity的Update
-	if err := m.Entity.Update(ctx, deltaTime); err != nil {
-		return err
+// NewSkillMissile 按技能投射物配置从施法者位置发射投射物
+// 追踪投射物飞向目标；直线投射物飞向目标点，范围技能飞到目标点即引爆
+func NewSkillMissile(entityID EntityID, caster *Actor, skill *Skill, target *Actor, point Vector3) *Missile {
+	cfg := skill.missile // 由 Skill.Update 在持有技能锁时发射，直接读取配置
+	origin := caster.Position()
+	if target != nil {
+		point = target.Position()
+	}
+	dir := point.ToVector2().Sub(origin.ToVector2()).Normalized()
+	if dir.X == 0 && dir.Y == 0 {
+		dir = caster.Direction().ToVector2().Normalized()
 	}
 
-	// 更新飞行时间
+	maxRange := cfg.Range
+	if skill.area.IsArea() && (target == nil || !cfg.Homing) {
+		if d := origin.ToVector2().Distance(point.ToVector2()); d > 0 && (maxRange <= 0 || d < maxRange) {
+			maxRange = d
+		}
+	}
+	var lifetime float32
+	if cfg.Speed > 0 && maxRange > 0 {
+		lifetime = 2 * maxRange / cfg.Speed // 兜底，正常由飞行距离结束
+	}
+
+	m := NewMissile(entityID, 0, origin, dir.ToVector3(), caster.ID(), skill.ID(), cfg.Speed, lifetime)
+	m.target = point
+	m.maxRange = maxRange
+	m.caster = caster
+	m.skill = skill
+	m.hitRadius = cfg.HitRadius
+	if cfg.Homing && target != nil {
+		m.homing = target
+		m.targetID = target.ID()
+	}
+	return m
+}
+
+// CasterID 获取施法者ID
+func (m *Missile) CasterID() EntityID { return m.casterID }
+
+// Caster 获取施法者
+func (m *Missile) Caster() *Actor { return m.caster }
+
+// TargetID 获取追踪目标ID
+func (m *Missile) TargetID() EntityID { return m.targetID }
+
+// Homing 获取追踪目标
+func (m *Missile) Homing() *Actor { return m.homing }
+
+// SkillID 获取技能ID
+func (m *Missile) SkillID() int32 { return m.skillID }
+
+// Speed 获取飞行速度
+func (m *Missile) Speed() float32 { return m.speed }
+
+// HitRadius 获取碰撞半径
+func (m *Missile) HitRadius() float32 { return m.hitRadius }
+
+// Advance 推进飞行时间并计算下一位置（不修改实体位置，由地图同步 AOI 后设置）
+// 超出生命周期或最大飞行距离时投射物失效
+func (m *Missile) Advance(deltaTime float32) Vector3 {
+	pos := m.Position()
 	m.elapsed += deltaTime
+	if m.lifetime > 0 && m.elapsed >= m.lifetime {
+		m.Invalidate()
+		return pos
+	}
 
-	// 检查是否超时
-	if m.elapsed >= m.lifetime {
+	step := m.speed * deltaTime
+	dir := m.Direction().ToVector2()
+	if m.homing != nil {
+		// 追踪：朝目标当前位置转向，步长足以到达时停在目标处
+		offset := m.homing.Position().ToVector2().Sub(pos.ToVector2())
+		dist := offset.Distance(Vector2{})
+		if dist > 0 {
+			dir = offset.Mul(1 / dist)
+			m.SetDirection(dir.ToVector3())
+		}
+		if step > dist {
+			step = dist
+		}
+	}
+	if m.maxRange > 0 && m.traveled+step >= m.maxRange {
+		step = m.maxRange - m.traveled
 		m.Invalidate()
-		return nil
 	}
+	m.traveled += step
+	next := pos.ToVector2().Add(dir.Mul(step)).ToVector3()
+	next.Y = pos.Y
+	return next
+}
 
-	// TODO: 更新位置
-	// 沿方向移动
-	// newPos := m.Position().Add(m.Direction().Mul(m.speed * deltaTime))
-	// m.SetPosition(newPos)
+// Update 更新投射物（独立推进，不经地图时直接更新位置）
+func (m *Missile) Update(ctx context.Context, deltaTime float32) error {
+	// 调用Entity的Update
+	if err := m.Entity.Update(ctx, deltaTime); err != nil {
+		return err
+	}
+	m.SetPosition(m.Advance(deltaTime))
+	return nil
+}
 
-	// TODO: 检查碰撞
-	// if hit detected {
-	//     m.Invalidate()
-	//     trigger skill effect
-	// }
+// Impact 命中结算：单体技能作用于命中目标，范围技能以命中位置为圆心结算；投射物随即失效
+func (m *Missile) Impact(target *Actor) {
+	m.Invalidate()
+	if m.caster == nil || m.skill == nil || m.caster.spell == nil {
+		return
+	}
+	m.caster.spell.applyAt(m.skill, target, m.Position(), m.Direction())
+}
 
-	return nil
+// Detonates 飞行结束（到达目标点）时是否引爆（范围技能）
+func (m *Missile) Detonates() bool {
+	return m.skill != nil && m.skill.area.IsArea()
 }
 
 // String 字符串表示
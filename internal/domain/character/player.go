@@ -15,7 +15,6 @@ type Player struct {
 	// 角色数据
 	characterID int64 // 角色ID（数据库主键）
 	exp         int32 // 经验值
-	gold        int64 // 金币
 
 	// 背包系统（聚合）
 	inventory *Inventory
@@ -84,11 +83,6 @@ func (p *Player) Exp() int32 {
 	return p.exp
 }
 
-// Gold 获取金币
-func (p *Player) Gold() int64 {
-	return p.gold
-}
-
 // GetName 获取角色名称
 func (p *Player) GetName() string {
 	return p.Name()
@@ -111,11 +105,6 @@ func (p *Player) GetExp() int64 {
 	return int64(p.exp)
 }
 
-// GetGold 获取金币
-func (p *Player) GetGold() int64 {
-	return p.gold
-}
-
 // AddExp 添加经验值
 func (p *Player) AddExp(amount int64) {
 	p.exp += int32(amount)
@@ -126,24 +115,22 @@ func (p *Player) AddExp(amount int64) {
 	}
 }
 
-// AddGold 添加金币
-func (p *Player) AddGold(amount int64) {
-	p.gold += amount
-	if p.gold < 0 {
-		p.gold = 0
-	}
-}
-
 // CanLevelUp 是否可以升级
 func (p *Player) CanLevelUp() bool {
 	currentLevel := p.Level()
-	if currentLevel >= 100 { // 最大等级100
+	if currentLevel >= MaxLevel {
 		return false
 	}
 
-	// 简化的升级经验公式: exp >= level * 1000
-	requiredExp := int32(currentLevel * 1000)
-	return p.exp >= requiredExp
+	return int64(p.exp) >= LevelUpExp(currentLevel)
+}
+
+// MaxLevel 最大等级
+const MaxLevel int32 = 100
+
+// LevelUpExp 升到下一级所需经验（简化公式: level * 1000）
+func LevelUpExp(level int32) int64 {
+	return int64(level) * 1000
 }
 
 // LevelUp 升级
@@ -155,8 +142,7 @@ func (p *Player) LevelUp() {
 	p.SetLevel(newLevel)
 
 	// 扣除升级所需经验
-	requiredExp := int32(currentLevel * 1000)
-	p.exp -= requiredExp
+	p.exp -= int32(LevelUpExp(currentLevel))
 
 	// 恢复生命和魔法 - 使用ChangeHP/ChangeMP设置为最大值
 	attrs := p.Actor.GetAttributeManager().Final()
@@ -184,17 +170,6 @@ func (p *Player) ChangeExp(amount int32) {
 	// p.syncAttributeEntry(AttributeTypeExp, p.exp)
 }
 
-// ChangeGold 改变金币
-func (p *Player) ChangeGold(amount int64) {
-	p.gold += amount
-	if p.gold < 0 {
-		p.gold = 0
-	}
-
-	// TODO: 同步属性变化
-	// p.syncAttributeEntry(AttributeTypeGold, int32(p.gold))
-}
-
 // ========== 子系统访问 ==========
 
 // GetInventory 获取背包
@@ -0,0 +1,65 @@
+package character
+
+// PvPJudge PvP 规则判定（由所在地图按区域规则、PvP 标记、阵营与决斗实现）
+type PvPJudge interface {
+	// PvPAllowed 两名玩家之间能否互相攻击
+	PvPAllowed(a, b *Actor) bool
+	// Dueling 两者是否正在决斗（决斗伤害不致死）
+	Dueling(a, b EntityID) bool
+}
+
+// SetPvPJudge 注入 PvP 规则判定
+func (a *Actor) SetPvPJudge(j PvPJudge) {
+	a.mu.Lock()
+	a.judge = j
+	a.mu.Unlock()
+}
+
+// GetPvPJudge 获取 PvP 规则判定
+func (a *Actor) GetPvPJudge() PvPJudge {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.judge
+}
+
+// SetFaction 设置阵营（阵营战区域内不同阵营的玩家互相敌对，0 表示无阵营）
+func (a *Actor) SetFaction(faction int32) {
+	a.mu.Lock()
+	a.faction = faction
+	a.mu.Unlock()
+}
+
+// Faction 获取阵营
+func (a *Actor) Faction() int32 {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.faction
+}
+
+// SetPvPFlag 开启/关闭 PvP 标记，变化时发布事件
+func (a *Actor) SetPvPFlag(enabled bool) {
+	a.mu.Lock()
+	changed := a.pvpFlag != enabled
+	a.pvpFlag = enabled
+	publisher := a.publisher
+	a.mu.Unlock()
+	if changed && publisher != nil {
+		publisher.Publish(NewPvPFlagChangedEvent(a.ID(), enabled))
+	}
+}
+
+// PvPFlag 是否开启 PvP 标记
+func (a *Actor) PvPFlag() bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.pvpFlag
+}
+
+// pvpHostile 两名玩家之间是否按所在地图的 PvP 规则敌对
+func (a *Actor) pvpHostile(other *Actor) bool {
+	if a == other || a.Type() != EntityTypePlayer || other.Type() != EntityTypePlayer {
+		return false
+	}
+	judge := a.GetPvPJudge()
+	return judge != nil && judge.PvPAllowed(a, other)
+}
@@ -0,0 +1,63 @@
+package character
+
+import "sort"
+
+// 击杀经验的等级差衰减
+const (
+	rewardLevelGrace = int32(3) // 玩家高出怪物的等级在此范围内不衰减
+	rewardLevelDecay = 0.1      // 超出后每级衰减的比例
+	rewardMinFactor  = 0.1      // 最低保留比例
+)
+
+// RewardGroup 击杀奖励的分配单位：一名伤害贡献者或一支队伍
+type RewardGroup struct {
+	Damage  int64    // 该组造成的伤害
+	Members []*Actor // 范围内可获得奖励的成员
+}
+
+// RewardShare 单个成员获得的击杀奖励
+type RewardShare struct {
+	Actor *Actor
+	Exp   int64
+	Gold  int64
+}
+
+// LevelDiffFactor 按玩家与怪物等级差计算经验比例：玩家等级过高时逐级衰减
+func LevelDiffFactor(playerLevel, monsterLevel int32) float64 {
+	diff := playerLevel - monsterLevel - rewardLevelGrace
+	if diff <= 0 {
+		return 1
+	}
+	return max(1-float64(diff)*rewardLevelDecay, rewardMinFactor)
+}
+
+// SplitKillReward 按伤害比例将经验与金币分配到各组，组内成员平分；经验再按各成员的等级差衰减
+// 返回结果按实体ID排序，没有成员的组不产生奖励
+func SplitKillReward(monsterLevel int32, exp, gold int64, groups []RewardGroup) []RewardShare {
+	var total int64
+	for _, g := range groups {
+		total += g.Damage
+	}
+	if total <= 0 {
+		return nil
+	}
+
+	var shares []RewardShare
+	for _, g := range groups {
+		if len(g.Members) == 0 || g.Damage <= 0 {
+			continue
+		}
+		n := int64(len(g.Members))
+		groupExp := exp * g.Damage / total
+		groupGold := gold * g.Damage / total
+		for _, member := range g.Members {
+			shares = append(shares, RewardShare{
+				Actor: member,
+				Exp:   int64(float64(groupExp/n) * LevelDiffFactor(member.Level(), monsterLevel)),
+				Gold:  groupGold / n,
+			})
+		}
+	}
+	sort.Slice(shares, func(i, j int) bool { return shares[i].Actor.ID() < shares[j].Actor.ID() })
+	return shares
+}
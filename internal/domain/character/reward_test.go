@@ -0,0 +1,113 @@
+package character
+
+import (
+	"context"
+	"testing"
+)
+
+func newRewardTestActor(t *testing.T, id EntityID, level int32) *Actor {
+	t.Helper()
+	a := NewActor(id, EntityTypePlayer, 1001, NewVector3(0, 0, 0), NewVector3(1, 0, 0), "p", level)
+	if err := a.Start(context.Background()); err != nil {
+		t.Fatalf("actor start: %v", err)
+	}
+	return a
+}
+
+func TestDamageContributorsTracked(t *testing.T) {
+	monster := NewActor(100, EntityTypeMonster, 2001, NewVector3(0, 0, 0), NewVector3(1, 0, 0), "m", 5)
+	if err := monster.Start(context.Background()); err != nil {
+		t.Fatalf("monster start: %v", err)
+	}
+	ctx := context.Background()
+	_ = monster.OnHurt(ctx, &DamageInfo{AttackerInfo: AttackerInfo{AttackerID: 1}, Amount: 30})
+	_ = monster.OnHurt(ctx, &DamageInfo{AttackerInfo: AttackerInfo{AttackerID: 2}, Amount: 10})
+	_ = monster.OnHurt(ctx, &DamageInfo{AttackerInfo: AttackerInfo{AttackerID: 1}, Amount: 20})
+	_ = monster.OnHurt(ctx, &DamageInfo{Amount: 5})
+
+	got := monster.DamageContributors()
+	if len(got) != 2 || got[1] != 50 || got[2] != 10 {
+		t.Fatalf("unexpected contributors %v", got)
+	}
+	_ = monster.Revive(ctx)
+	if len(monster.DamageContributors()) != 0 {
+		t.Fatalf("expected contributors cleared on revive")
+	}
+}
+
+func TestCombatStateFromDamage(t *testing.T) {
+	ctx := context.Background()
+	a := newRewardTestActor(t, 1, 5)
+	_ = a.OnHurt(ctx, &DamageInfo{Amount: 5})
+	if a.InCombat() {
+		t.Fatalf("environment damage should not enter combat")
+	}
+	_ = a.OnHurt(ctx, &DamageInfo{AttackerInfo: AttackerInfo{AttackerID: 2}, Amount: 5})
+	if !a.InCombat() {
+		t.Fatalf("expected combat after being attacked")
+	}
+	_ = a.Update(ctx, CombatTimeout-1)
+	if !a.InCombat() {
+		t.Fatalf("combat ended too early")
+	}
+	_ = a.Update(ctx, 1)
+	if a.InCombat() {
+		t.Fatalf("expected combat to end after %v seconds", CombatTimeout)
+	}
+}
+
+func TestSplitKillRewardByDamageAndLevel(t *testing.T) {
+	solo := newRewardTestActor(t, 1, 5)
+	partyA := newRewardTestActor(t, 2, 5)
+	partyB := newRewardTestActor(t, 3, 10) // 高出怪物 5 级：衰减 20%
+
+	shares := SplitKillReward(5, 900, 90, []RewardGroup{
+		{Damage: 100, Members: []*Actor{solo}},
+		{Damage: 200, Members: []*Actor{partyA, partyB}},
+		{Damage: 300}, // 无成员在范围内，份额作废
+	})
+	if len(shares) != 3 {
+		t.Fatalf("expected 3 shares, got %+v", shares)
+	}
+	want := []struct {
+		id   EntityID
+		exp  int64
+		gold int64
+	}{{1, 150, 15}, {2, 150, 15}, {3, 120, 15}}
+	for i, w := range want {
+		if shares[i].Actor.ID() != w.id || shares[i].Exp != w.exp || shares[i].Gold != w.gold {
+			t.Fatalf("share %d: want %+v, got id=%d exp=%d gold=%d", i, w, shares[i].Actor.ID(), shares[i].Exp, shares[i].Gold)
+		}
+	}
+
+	if f := LevelDiffFactor(40, 5); f != rewardMinFactor {
+		t.Fatalf("expected min factor, got %v", f)
+	}
+	if f := LevelDiffFactor(1, 30); f != 1 {
+		t.Fatalf("expected no decay for low level player, got %v", f)
+	}
+}
+
+func TestApplyLevelUpGrowsBaseAndPublishes(t *testing.T) {
+	a := newRewardTestActor(t, 1, 1)
+	events := &fakePublisher{}
+	a.SetEventPublisher(events)
+	base := *a.GetAttributeManager().Base()
+	a.ChangeHP(-a.HP() / 2)
+
+	a.ApplyLevelUp(3, LevelGrowth{MaxHP: 100, AD: 10})
+	fin := a.GetAttributeManager().Final()
+	if a.Level() != 3 || fin.MaxHP != base.MaxHP+100 || fin.AD != base.AD+10 {
+		t.Fatalf("unexpected level=%d maxhp=%v ad=%v", a.Level(), fin.MaxHP, fin.AD)
+	}
+	if a.HP() != fin.MaxHP {
+		t.Fatalf("expected hp refilled to %v, got %v", fin.MaxHP, a.HP())
+	}
+	if events.CountByName("PlayerLevelUp") != 1 {
+		t.Fatalf("expected level up event")
+	}
+	evt := events.events[0].(*PlayerLevelUpEvent)
+	if evt.EntityID != 1 || evt.OldLevel != 1 || evt.NewLevel != 3 {
+		t.Fatalf("unexpected event %+v", evt)
+	}
+}
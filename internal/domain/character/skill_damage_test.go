@@ -35,3 +35,57 @@ func TestSkillDamageReducesTargetHP(t *testing.T) {
 		t.Fatalf("expected defender HP to reduce; before=%v after=%v", baseHP, defender.HP())
 	}
 }
+
+func TestApplyCrit(t *testing.T) {
+	attrs := &Attributes{Cri: 0.25, Crd: 2}
+	cases := []struct {
+		roll     float32
+		wantDmg  float32
+		wantCrit bool
+	}{
+		{roll: 0, wantDmg: 200, wantCrit: true},
+		{roll: 0.249, wantDmg: 200, wantCrit: true},
+		{roll: 0.25, wantDmg: 100, wantCrit: false},
+		{roll: 0.9, wantDmg: 100, wantCrit: false},
+	}
+	for _, c := range cases {
+		dmg, crit := applyCrit(100, attrs, c.roll)
+		if dmg != c.wantDmg || crit != c.wantCrit {
+			t.Fatalf("roll %v: got dmg=%v crit=%v, want dmg=%v crit=%v", c.roll, dmg, crit, c.wantDmg, c.wantCrit)
+		}
+	}
+}
+
+func TestSkillHitRollsCritFromAttacker(t *testing.T) {
+	ctx := context.Background()
+	attacker := NewActor(30, EntityTypePlayer, 1, NewVector3(0, 0, 0), NewVector3(1, 0, 0), "att", 1)
+	defender := NewActor(31, EntityTypeMonster, 1, NewVector3(1, 0, 0), NewVector3(-1, 0, 0), "def", 1)
+	for _, a := range []*Actor{attacker, defender} {
+		if err := a.Start(ctx); err != nil {
+			t.Fatalf("start: %v", err)
+		}
+	}
+	pub := &fakePublisher{}
+	defender.SetEventPublisher(pub)
+
+	sk := NewSkill(5002, attacker)
+	sk.SetTimings(0, 0.01, 0.01)
+	sk.SetDamage(10, 0, 0, DamageTypeReal)
+	attacker.GetSkillManager().AddSkill(sk)
+	attacker.GetSpell().SetRandom(func() float32 { return 0 })
+	want := int32(computeDamage(attacker, defender, sk) * attacker.GetAttributeManager().Final().Crd)
+
+	if !attacker.GetSpell().Cast(sk.ID(), defender) {
+		t.Fatalf("failed to cast skill")
+	}
+	_ = attacker.Update(ctx, 0.02)
+	var hits []*DamageDealtEvent
+	for _, e := range pub.events {
+		if d, ok := e.(*DamageDealtEvent); ok {
+			hits = append(hits, d)
+		}
+	}
+	if len(hits) != 1 || !hits[0].IsCrit || hits[0].Amount != want {
+		t.Fatalf("expected one critical hit of %d, got %+v", want, hits)
+	}
+}
@@ -2,6 +2,8 @@ package character
 
 import (
 	"context"
+	"errors"
+	"math/rand"
 	"sync"
 )
 
@@ -54,6 +56,32 @@ type AttributeModifier struct {
 	AttackSpeedAdd, AttackSpeedMul float32
 }
 
+// Scaled 按层数缩放修饰器（多层 Buff 的加成线性叠加）
+func (m AttributeModifier) Scaled(stacks int32) AttributeModifier {
+	if stacks <= 1 {
+		return m
+	}
+	n := float32(stacks)
+	return AttributeModifier{
+		MaxHPAdd: m.MaxHPAdd * n, MaxHPMul: m.MaxHPMul * n,
+		MaxMPAdd: m.MaxMPAdd * n, MaxMPMul: m.MaxMPMul * n,
+		HPRegenAdd: m.HPRegenAdd * n, MPRegenAdd: m.MPRegenAdd * n,
+
+		ADAdd: m.ADAdd * n, ADMul: m.ADMul * n,
+		APAdd: m.APAdd * n, APMul: m.APMul * n,
+		DefAdd: m.DefAdd * n, DefMul: m.DefMul * n,
+		MDefAdd: m.MDefAdd * n, MDefMul: m.MDefMul * n,
+
+		CriAdd: m.CriAdd * n, CrdAdd: m.CrdAdd * n,
+		HitRateAdd:     m.HitRateAdd * n,
+		DodgeRateAdd:   m.DodgeRateAdd * n,
+		SpeedAdd:       m.SpeedAdd * n,
+		SpeedMul:       m.SpeedMul * n,
+		AttackSpeedAdd: m.AttackSpeedAdd * n,
+		AttackSpeedMul: m.AttackSpeedMul * n,
+	}
+}
+
 // NewAttributeManager 创建属性管理器
 func NewAttributeManager(owner *Actor) *AttributeManager {
 	return &AttributeManager{
@@ -112,12 +140,15 @@ func (am *AttributeManager) Recalculate() {
 	// 基础拷贝
 	*am.final = *am.base
 
-	// TODO: 装备加成（占位）
-	// 例如：am.final.AD += equip.ADAdd; am.final.AD *= (1 + equip.ADMul)
-
-	// Buff 加成（叠加所有 Buff 的属性修饰器）
+	// 装备与 Buff 加成（叠加所有属性修饰器）
+	var mods []AttributeModifier
+	if am.owner != nil && am.owner.equipmentManager != nil {
+		mods = append(mods, am.owner.equipmentManager.CollectModifiers()...)
+	}
 	if am.owner != nil && am.owner.buffManager != nil {
-		mods := am.owner.buffManager.CollectModifiers()
+		mods = append(mods, am.owner.buffManager.CollectModifiers()...)
+	}
+	if len(mods) > 0 {
 		var m AttributeModifier // 汇总
 		for _, mod := range mods {
 			m.MaxHPAdd += mod.MaxHPAdd
@@ -248,6 +279,9 @@ type Skill struct {
 	state         SkillState
 	cooldownTimer float32 // 冷却计时器
 	castTimer     float32 // 施法计时器
+	effectPending bool    // 瞬发技能待应用效果（目标在 StartCast 之后才设置）
+	castTarget    *Actor  // 本次施法目标（每个技能独立，避免并行施法互相覆盖）
+	castPoint     Vector3 // 本次施法目标点
 
 	// 配置（占位，后续由 DataManager 驱动）
 	castTime     float32 // 吟唱时间
@@ -259,6 +293,22 @@ type Skill struct {
 	scaleAD    float32
 	scaleAP    float32
 	dmgType    DamageType
+
+	// 作用范围（默认单体）
+	area SkillArea
+	// 投射物（速度为 0 表示即时生效）
+	missile MissileConfig
+	// 命中时附加的 Buff
+	buffs []BuffConfig
+	// 复活死亡友方后恢复的生命比例（大于 0 表示复活技能）
+	resurrect float32
+	// 引导：激活窗口即引导时长，期间每隔 channelTick 秒结算一次效果（0 表示非引导技能）
+	channelTick  float32
+	channelTicks int32 // 本次引导已结算的次数
+	// 命中时打断目标的吟唱/引导，被打断的技能冷却该时长
+	interrupt float32
+	// 不触发也不受公共冷却限制
+	offGCD bool
 }
 
 // SkillState 技能状态
@@ -300,11 +350,115 @@ func (s *Skill) SetDamage(base, scaleAD, scaleAP float32, dmgType DamageType) {
 	s.mu.Unlock()
 }
 
+// SetArea 配置技能作用范围
+func (s *Skill) SetArea(area SkillArea) {
+	s.mu.Lock()
+	s.area = area
+	s.mu.Unlock()
+}
+
+// Area 获取技能作用范围
+func (s *Skill) Area() SkillArea {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.area
+}
+
+// SetMissile 配置技能投射物
+func (s *Skill) SetMissile(cfg MissileConfig) {
+	s.mu.Lock()
+	s.missile = cfg
+	s.mu.Unlock()
+}
+
+// MissileConfig 获取技能投射物配置
+func (s *Skill) MissileConfig() MissileConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.missile
+}
+
+// SetBuffEffects 配置技能命中时附加的 Buff
+func (s *Skill) SetBuffEffects(buffs []BuffConfig) {
+	s.mu.Lock()
+	s.buffs = buffs
+	s.mu.Unlock()
+}
+
+// SetResurrect 设置为复活技能（hpRatio 为复活后的生命比例）
+func (s *Skill) SetResurrect(hpRatio float32) {
+	s.mu.Lock()
+	s.resurrect = hpRatio
+	s.mu.Unlock()
+}
+
+// Resurrect 复活后的生命比例（0 表示非复活技能）
+func (s *Skill) Resurrect() float32 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.resurrect
+}
+
+// SetChannel 设置为引导技能：引导 duration 秒，每 tick 秒结算一次效果
+func (s *Skill) SetChannel(duration, tick float32) {
+	s.mu.Lock()
+	s.activeTime = duration
+	s.channelTick = tick
+	s.mu.Unlock()
+}
+
+// SetInterrupt 设置命中打断效果（lockout 为被打断技能的冷却时长）
+func (s *Skill) SetInterrupt(lockout float32) {
+	s.mu.Lock()
+	s.interrupt = lockout
+	s.mu.Unlock()
+}
+
+// SetOffGCD 设置是否脱离公共冷却
+func (s *Skill) SetOffGCD(off bool) {
+	s.mu.Lock()
+	s.offGCD = off
+	s.mu.Unlock()
+}
+
+// OffGCD 是否脱离公共冷却
+func (s *Skill) OffGCD() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.offGCD
+}
+
+// IsCasting 是否处于可被打断的吟唱或引导中
+func (s *Skill) IsCasting() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.castingLocked()
+}
+
+// castingLocked 是否处于吟唱或引导中（调用方持有锁）
+func (s *Skill) castingLocked() bool {
+	return s.state == SkillStateIntonate || (s.state == SkillStateActive && s.channelTick > 0)
+}
+
 // Update 更新技能
 func (s *Skill) Update(ctx context.Context, deltaTime float32) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	// 吟唱/引导中施法者死亡或收到打断请求时取消施法
+	if s.castingLocked() && s.owner != nil {
+		if s.owner.IsDeath() {
+			s.cancelLocked(CastCancelDeath, 0)
+			return nil
+		}
+		if s.owner.spell != nil {
+			if reason, lockout, ok := s.owner.spell.takeInterrupt(s); ok {
+				s.cancelLocked(reason, lockout)
+				return nil
+			}
+		}
+	}
+
 	switch s.state {
 	case SkillStateIntonate:
 		s.castTimer -= deltaTime
@@ -312,14 +466,32 @@ func (s *Skill) Update(ctx context.Context, deltaTime float32) error {
 			// 进入激活
 			s.state = SkillStateActive
 			s.castTimer = s.activeTime
+			if s.channelTick > 0 {
+				// 引导技能吟唱完成后开始引导，效果按间隔结算
+				s.channelTicks = 0
+				return nil
+			}
+			s.publish(NewSkillCastCompletedEvent(s.owner.ID(), s.id))
 			// 命中/效果应用（命中目标、生成投射物等）
 			if s.owner != nil && s.owner.spell != nil {
 				s.owner.spell.ApplySkillEffect(s)
 			}
 		}
 	case SkillStateActive:
+		if s.effectPending {
+			s.effectPending = false
+			if s.owner != nil && s.owner.spell != nil {
+				s.owner.spell.ApplySkillEffect(s)
+			}
+		}
 		s.castTimer -= deltaTime
+		if s.channelTick > 0 {
+			s.channelLocked()
+		}
 		if s.castTimer <= 0 {
+			if s.channelTick > 0 {
+				s.publish(NewSkillCastCompletedEvent(s.owner.ID(), s.id))
+			}
 			// 进入冷却
 			s.state = SkillStateCooling
 			s.cooldownTimer = s.cooldownTime
@@ -334,6 +506,46 @@ func (s *Skill) Update(ctx context.Context, deltaTime float32) error {
 	return nil
 }
 
+// channelLocked 按已引导时长补齐应结算的引导次数（调用方持有锁）
+func (s *Skill) channelLocked() {
+	if s.owner == nil || s.owner.spell == nil {
+		return
+	}
+	elapsed := s.activeTime - max(s.castTimer, 0)
+	due := int32((elapsed + channelTickEpsilon) / s.channelTick)
+	for ; s.channelTicks < due; s.channelTicks++ {
+		s.owner.spell.ApplySkillEffect(s)
+	}
+}
+
+// cancelLocked 取消吟唱/引导：被打断的吟唱回到就绪，引导已生效则进入冷却；lockout 大于 0 时至少冷却该时长（调用方持有锁）
+func (s *Skill) cancelLocked(reason CastCancelReason, lockout float32) {
+	cooldown := lockout
+	if s.state == SkillStateActive {
+		cooldown = max(s.cooldownTime, lockout)
+	}
+	s.castTarget = nil
+	s.effectPending = false
+	s.castTimer = 0
+	if cooldown > 0 {
+		s.state = SkillStateCooling
+		s.cooldownTimer = cooldown
+	} else {
+		s.state = SkillStateReady
+	}
+	s.publish(NewSkillCastCancelledEvent(s.owner.ID(), s.id, reason))
+}
+
+// publish 通过施法者的事件发布器发布施法事件
+func (s *Skill) publish(event DomainEvent) {
+	if s.owner == nil {
+		return
+	}
+	if publisher := s.owner.GetEventPublisher(); publisher != nil {
+		publisher.Publish(event)
+	}
+}
+
 // State 获取当前技能状态
 func (s *Skill) State() SkillState {
 	s.mu.RLock()
@@ -350,8 +562,23 @@ func (s *Skill) SetTimings(cast, active, cooldown float32) {
 	s.mu.Unlock()
 }
 
+// CastTimings 本次施法的吟唱与引导时长（非引导技能引导时长为 0）
+func (s *Skill) CastTimings() (cast, channel float32) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.channelTick > 0 {
+		channel = s.activeTime
+	}
+	return s.castTime, channel
+}
+
 // StartCast 尝试开始施法（由施法器或应用层触发）
 func (s *Skill) StartCast() bool {
+	return s.startCastAt(nil, Vector3{})
+}
+
+// startCastAt 开始施法并记录本次施法的目标与目标点
+func (s *Skill) startCastAt(target *Actor, point Vector3) bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -368,31 +595,47 @@ func (s *Skill) StartCast() bool {
 		return false
 	}
 
+	s.castTarget = target
+	s.castPoint = point
+	s.channelTicks = 0
 	if s.castTime > 0 {
 		s.state = SkillStateIntonate
 		s.castTimer = s.castTime
 	} else {
 		s.state = SkillStateActive
 		s.castTimer = s.activeTime
+		// 引导技能按间隔结算，不立即生效
+		s.effectPending = s.channelTick == 0
 	}
 	return true
 }
 
 // ========== BuffManager Buff管理器 ==========
 
+// ErrBuffImmune 目标免疫该类别的 Buff
+var ErrBuffImmune = errors.New("target is immune to buff")
+
 // BuffManager Buff管理器
 type BuffManager struct {
 	owner *Actor
 	mu    sync.RWMutex
 
 	buffs []*Buff // Buff列表
+
+	// 控制递减
+	clock    float32 // 累计时间（秒），用于递减窗口
+	dr       map[FlagState]*drState
+	drConfig DiminishingConfig
+	ccImmune bool
 }
 
 // NewBuffManager 创建Buff管理器
 func NewBuffManager(owner *Actor) *BuffManager {
 	return &BuffManager{
-		owner: owner,
-		buffs: make([]*Buff, 0),
+		owner:    owner,
+		buffs:    make([]*Buff, 0),
+		dr:       make(map[FlagState]*drState),
+		drConfig: DefaultDiminishing(),
 	}
 }
 
@@ -401,16 +644,23 @@ func (bm *BuffManager) Start(ctx context.Context) error {
 	return nil
 }
 
+// buffTick 待结算的周期效果
+type buffTick struct {
+	buff  *Buff
+	count int
+}
+
 // Update 每帧更新
 func (bm *BuffManager) Update(ctx context.Context, deltaTime float32) error {
 	bm.mu.Lock()
-	defer bm.mu.Unlock()
+	bm.clock += deltaTime
 
 	// 更新所有Buff
 	toRemove := make([]int, 0)
+	var ticks []buffTick
 	for i, buff := range bm.buffs {
-		if err := buff.Update(ctx, deltaTime); err != nil {
-			return err
+		if n := buff.advance(deltaTime); n > 0 {
+			ticks = append(ticks, buffTick{buff: buff, count: n})
 		}
 		if buff.IsExpired() {
 			toRemove = append(toRemove, i)
@@ -422,14 +672,15 @@ func (bm *BuffManager) Update(ctx context.Context, deltaTime float32) error {
 		idx := toRemove[i]
 		bm.buffs = append(bm.buffs[:idx], bm.buffs[idx+1:]...)
 	}
+	bm.mu.Unlock()
+
+	// 周期伤害/治疗在锁外结算（伤害会发布事件、可能导致死亡）
+	for _, t := range ticks {
+		t.buff.applyTicks(ctx, t.count)
+	}
 
 	if len(toRemove) > 0 {
-		// Buff 变化后触发属性重算
-		if bm.owner != nil && bm.owner.attributeManager != nil {
-			bm.owner.attributeManager.Recalculate()
-		}
-		// 刷新基于 Buff 的状态标志
-		bm.refreshActorFlags()
+		bm.onBuffsChanged()
 	}
 
 	return nil
@@ -438,33 +689,116 @@ func (bm *BuffManager) Update(ctx context.Context, deltaTime float32) error {
 // AddBuff 添加Buff
 func (bm *BuffManager) AddBuff(buff *Buff) {
 	bm.mu.Lock()
-	defer bm.mu.Unlock()
 	bm.buffs = append(bm.buffs, buff)
-	// Buff 变化后触发属性重算
-	if bm.owner != nil && bm.owner.attributeManager != nil {
-		bm.owner.attributeManager.Recalculate()
+	bm.mu.Unlock()
+	bm.onBuffsChanged()
+}
+
+// Apply 按叠加规则施加 Buff：目标免疫该类别减益时拒绝；眩晕/定身/沉默按控制递减缩短持续时间，递减免疫时拒绝；
+// 同ID已存在时刷新持续时间（叠层规则同时增加层数，每施法者独立规则仅匹配同一施法者），返回生效的 Buff 实例
+func (bm *BuffManager) Apply(buff *Buff) (*Buff, error) {
+	bm.mu.Lock()
+	if buff.harmful && bm.immuneLocked(buff.category) {
+		bm.mu.Unlock()
+		return nil, ErrBuffImmune
 	}
-	// 刷新状态标志
-	bm.refreshActorFlags()
+	events, err := bm.diminishLocked(buff)
+	if err != nil {
+		bm.mu.Unlock()
+		bm.publishAll(events)
+		return nil, err
+	}
+
+	for _, b := range bm.buffs {
+		if b.id != buff.id || (buff.stackRule == BuffStackPerCaster && b.caster != buff.caster) {
+			continue
+		}
+		b.elapsed = 0
+		if len(events) > 0 {
+			b.duration = buff.duration
+		}
+		b.caster = buff.caster
+		if b.stackRule == BuffStackCount && b.stacks < b.maxStacks {
+			b.stacks++
+		}
+		bm.mu.Unlock()
+		bm.onBuffsChanged()
+		bm.publishAll(events)
+		return b, nil
+	}
+
+	// 提供免疫的 Buff 生效时清除已有的对应类别减益
+	if len(buff.immune) > 0 {
+		kept := bm.buffs[:0]
+		for _, b := range bm.buffs {
+			if !(b.harmful && buff.grantsImmunity(b.category)) {
+				kept = append(kept, b)
+			}
+		}
+		bm.buffs = kept
+	}
+	bm.buffs = append(bm.buffs, buff)
+	bm.mu.Unlock()
+	bm.onBuffsChanged()
+	bm.publishAll(events)
+	return buff, nil
+}
+
+// IsImmune 是否免疫某类别的减益
+func (bm *BuffManager) IsImmune(category DispelCategory) bool {
+	bm.mu.RLock()
+	defer bm.mu.RUnlock()
+	return bm.immuneLocked(category)
+}
+
+// immuneLocked 是否免疫某类别（调用方持有锁）
+func (bm *BuffManager) immuneLocked(category DispelCategory) bool {
+	if category == DispelCategoryNone {
+		return false
+	}
+	for _, b := range bm.buffs {
+		if b.grantsImmunity(category) {
+			return true
+		}
+	}
+	return false
+}
+
+// Dispel 驱散可驱散的 Buff：harmful 选择减益或增益，category 为 DispelCategoryNone 时匹配任意类别，
+// 按施加顺序驱散最多 count 个（0 表示不限），返回被驱散的 Buff ID
+func (bm *BuffManager) Dispel(category DispelCategory, harmful bool, count int) []int32 {
+	bm.mu.Lock()
+	var removed []int32
+	kept := bm.buffs[:0]
+	for _, b := range bm.buffs {
+		match := b.dispellable && b.harmful == harmful &&
+			(category == DispelCategoryNone || b.category == category)
+		if match && (count <= 0 || len(removed) < count) {
+			removed = append(removed, b.id)
+			continue
+		}
+		kept = append(kept, b)
+	}
+	bm.buffs = kept
+	bm.mu.Unlock()
+
+	if len(removed) > 0 {
+		bm.onBuffsChanged()
+	}
+	return removed
 }
 
 // RemoveBuff 移除Buff
 func (bm *BuffManager) RemoveBuff(buff *Buff) {
 	bm.mu.Lock()
-	defer bm.mu.Unlock()
-
 	for i, b := range bm.buffs {
 		if b == buff {
 			bm.buffs = append(bm.buffs[:i], bm.buffs[i+1:]...)
 			break
 		}
 	}
-	// Buff 变化后触发属性重算
-	if bm.owner != nil && bm.owner.attributeManager != nil {
-		bm.owner.attributeManager.Recalculate()
-	}
-	// 刷新状态标志
-	bm.refreshActorFlags()
+	bm.mu.Unlock()
+	bm.onBuffsChanged()
 }
 
 // GetBuffByID 按ID获取Buff
@@ -482,30 +816,40 @@ func (bm *BuffManager) GetBuffByID(id int32) *Buff {
 // RemoveBuffByID 按ID移除Buff
 func (bm *BuffManager) RemoveBuffByID(id int32) {
 	bm.mu.Lock()
-	defer bm.mu.Unlock()
 	for i := 0; i < len(bm.buffs); i++ {
 		if bm.buffs[i] != nil && bm.buffs[i].id == id {
 			bm.buffs = append(bm.buffs[:i], bm.buffs[i+1:]...)
 			i--
 		}
 	}
-	if bm.owner != nil && bm.owner.attributeManager != nil {
-		bm.owner.attributeManager.Recalculate()
-	}
-	bm.refreshActorFlags()
+	bm.mu.Unlock()
+	bm.onBuffsChanged()
 }
 
-// CollectModifiers 汇总当前 Buff 的属性修饰器快照
+// CollectModifiers 汇总当前 Buff 的属性修饰器快照（按层数缩放）
 func (bm *BuffManager) CollectModifiers() []AttributeModifier {
 	bm.mu.RLock()
 	defer bm.mu.RUnlock()
 	mods := make([]AttributeModifier, 0, len(bm.buffs))
 	for _, b := range bm.buffs {
-		mods = append(mods, b.Modifier())
+		mods = append(mods, b.Modifier().Scaled(b.stacks))
 	}
 	return mods
 }
 
+// BuffIDs 获取当前 Buff ID 列表（按添加顺序）
+func (bm *BuffManager) BuffIDs() []int32 {
+	bm.mu.RLock()
+	defer bm.mu.RUnlock()
+	ids := make([]int32, 0, len(bm.buffs))
+	for _, b := range bm.buffs {
+		if b != nil {
+			ids = append(ids, b.id)
+		}
+	}
+	return ids
+}
+
 // 汇总 Buff 的状态标志位（位或）
 func (bm *BuffManager) collectFlags() FlagState {
 	var flags FlagState = FlagStateZero
@@ -515,17 +859,77 @@ func (bm *BuffManager) collectFlags() FlagState {
 	return flags
 }
 
+// onBuffsChanged Buff 变化后重算属性并刷新状态标志（调用方不得持有 bm.mu，
+// 属性重算会回调 CollectModifiers）
+func (bm *BuffManager) onBuffsChanged() {
+	if bm.owner == nil {
+		return
+	}
+	if bm.owner.attributeManager != nil {
+		bm.owner.attributeManager.Recalculate()
+	}
+	bm.refreshActorFlags()
+}
+
 // 刷新 Actor 的状态标志，基于当前 Buff 汇总
 func (bm *BuffManager) refreshActorFlags() {
 	if bm.owner == nil {
 		return
 	}
+	bm.mu.RLock()
 	flags := bm.collectFlags()
+	bm.mu.RUnlock()
 	bm.owner.SetFlagStateExact(flags)
+	// 眩晕/沉默打断吟唱与引导
+	if (flags.HasFlag(FlagStateStun) || flags.HasFlag(FlagStateSilence)) && bm.owner.spell != nil {
+		bm.owner.spell.Interrupt(CastCancelControl, 0)
+	}
 }
 
 // ========== Buff ==========
 
+// BuffStackRule Buff 重复施加时的叠加规则
+type BuffStackRule int32
+
+const (
+	BuffStackRefresh   BuffStackRule = 0 // 刷新持续时间
+	BuffStackCount     BuffStackRule = 1 // 叠加层数并刷新持续时间
+	BuffStackPerCaster BuffStackRule = 2 // 每个施法者独立一份
+)
+
+// DispelCategory 驱散/免疫类别
+type DispelCategory int32
+
+const (
+	DispelCategoryNone    DispelCategory = 0 // 无类别
+	DispelCategoryMagic   DispelCategory = 1 // 魔法
+	DispelCategoryCurse   DispelCategory = 2 // 诅咒
+	DispelCategoryPoison  DispelCategory = 3 // 毒
+	DispelCategoryControl DispelCategory = 4 // 控制
+)
+
+// BuffConfig Buff 配置（由应用层按配置表构造）
+type BuffConfig struct {
+	ID       int32
+	Duration float32
+	Modifier AttributeModifier
+	Flags    FlagState
+
+	Stack    BuffStackRule
+	MaxStack int32
+
+	// 周期效果（每层每跳）
+	TickInterval float32
+	TickDamage   float32
+	TickHeal     float32
+	DamageType   DamageType
+
+	Harmful     bool             // 是否为减益
+	Category    DispelCategory   // 驱散类别
+	Dispellable bool             // 是否可被驱散
+	Immune      []DispelCategory // 生效期间免疫的减益类别
+}
+
 // Buff Buff实例
 type Buff struct {
 	id       int32
@@ -538,25 +942,128 @@ type Buff struct {
 
 	// 状态效果：为简化，使用位或累加的 FlagState
 	addFlags FlagState
+
+	// 叠加
+	stackRule BuffStackRule
+	stacks    int32
+	maxStacks int32
+
+	// 周期效果
+	tickInterval float32
+	tickTimer    float32
+	tickDamage   float32
+	tickHeal     float32
+	dmgType      DamageType
+
+	// 驱散与免疫
+	harmful     bool
+	category    DispelCategory
+	dispellable bool
+	immune      []DispelCategory
 }
 
 // NewBuff 创建Buff
 func NewBuff(id int32, owner, caster *Actor, duration float32) *Buff {
 	return &Buff{
-		id:       id,
-		owner:    owner,
-		caster:   caster,
-		duration: duration,
-		elapsed:  0,
+		id:        id,
+		owner:     owner,
+		caster:    caster,
+		duration:  duration,
+		elapsed:   0,
+		stacks:    1,
+		maxStacks: 1,
+	}
+}
+
+// NewBuffFromConfig 按配置创建Buff
+func NewBuffFromConfig(cfg BuffConfig, owner, caster *Actor) *Buff {
+	b := NewBuff(cfg.ID, owner, caster, cfg.Duration)
+	b.modifier = cfg.Modifier
+	b.addFlags = cfg.Flags
+	b.stackRule = cfg.Stack
+	if cfg.MaxStack > 1 {
+		b.maxStacks = cfg.MaxStack
 	}
+	b.tickInterval = cfg.TickInterval
+	b.tickDamage = cfg.TickDamage
+	b.tickHeal = cfg.TickHeal
+	b.dmgType = cfg.DamageType
+	b.harmful = cfg.Harmful
+	b.category = cfg.Category
+	b.dispellable = cfg.Dispellable
+	b.immune = cfg.Immune
+	return b
 }
 
-// Update 更新Buff
+// ID 获取BuffID
+func (b *Buff) ID() int32 { return b.id }
+
+// Caster 获取施法者
+func (b *Buff) Caster() *Actor { return b.caster }
+
+// Stacks 获取当前层数
+func (b *Buff) Stacks() int32 { return b.stacks }
+
+// Remaining 获取剩余持续时间
+func (b *Buff) Remaining() float32 { return b.duration - b.elapsed }
+
+// Update 更新Buff（独立推进时直接结算周期效果）
 func (b *Buff) Update(ctx context.Context, deltaTime float32) error {
-	b.elapsed += deltaTime
+	b.applyTicks(ctx, b.advance(deltaTime))
 	return nil
 }
 
+// advance 推进时间，返回本帧到期的周期效果次数
+func (b *Buff) advance(deltaTime float32) int {
+	b.elapsed += deltaTime
+	if b.tickInterval <= 0 {
+		return 0
+	}
+	b.tickTimer += deltaTime
+	n := 0
+	for b.tickTimer >= b.tickInterval {
+		b.tickTimer -= b.tickInterval
+		n++
+	}
+	return n
+}
+
+// applyTicks 结算周期伤害/治疗（按层数缩放，伤害来源记为施法者的 Buff）
+func (b *Buff) applyTicks(ctx context.Context, n int) {
+	if b.owner == nil {
+		return
+	}
+	stacks := float32(b.stacks)
+	for i := 0; i < n && !b.owner.IsDeath(); i++ {
+		if b.tickDamage > 0 {
+			info := &DamageInfo{
+				TargetID:     b.owner.ID(),
+				AttackerInfo: AttackerInfo{AttackerType: AttackerTypeBuff, BuffID: b.id},
+				Amount:       int32(b.tickDamage * stacks),
+				DamageType:   b.dmgType,
+			}
+			if b.caster != nil {
+				info.AttackerInfo.AttackerID = b.caster.ID()
+				b.caster.EnterCombat()
+			}
+			_ = b.owner.OnHurt(ctx, info)
+		}
+		if b.tickHeal > 0 && !b.owner.IsDeath() {
+			b.owner.ChangeHP(b.tickHeal * stacks)
+		}
+	}
+}
+
+// grantsImmunity 是否提供对某类别的免疫
+func (b *Buff) grantsImmunity(category DispelCategory) bool {
+	for _, c := range b.immune {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
 // IsExpired 是否过期
 func (b *Buff) IsExpired() bool {
 	return b.elapsed >= b.duration
@@ -576,13 +1083,37 @@ func (b *Buff) FlagAdd() FlagState { return b.addFlags }
 
 // ========== Spell 施法器 ==========
 
+// channelTickEpsilon 引导结算的计时容差（抵消帧时间累加误差）
+const channelTickEpsilon = float32(1e-4)
+
+// CastCancelReason 吟唱/引导被取消的原因
+type CastCancelReason int32
+
+const (
+	CastCancelNone      CastCancelReason = 0
+	CastCancelMove      CastCancelReason = 1 // 移动
+	CastCancelControl   CastCancelReason = 2 // 眩晕/沉默
+	CastCancelInterrupt CastCancelReason = 3 // 被打断技能命中
+	CastCancelDeath     CastCancelReason = 4 // 施法者死亡
+)
+
 // Spell 施法器 - 管理当前正在施放的技能
 type Spell struct {
 	owner *Actor
 	mu    sync.RWMutex
 
 	currentSkill *Skill // 当前正在施放的技能
-	target       *Actor // 当前施法目标（简化：单体）
+	target       *Actor // 当前施法目标
+	casting      *Skill // 最近开始吟唱/引导的技能（瞬发技能可在其吟唱期间释放）
+
+	gcd      float32 // 公共冷却时长（按职业配置）
+	gcdTimer float32 // 公共冷却剩余时间
+
+	// 待处理的打断请求（由吟唱/引导中的技能在下一次更新时处理，避免在其他技能结算中直接修改技能状态）
+	interruptReason  CastCancelReason
+	interruptLockout float32
+
+	random func() float32 // 暴击判定的随机源（返回 [0,1)），为空时使用全局随机源
 }
 
 // NewSpell 创建施法器
@@ -592,6 +1123,24 @@ func NewSpell(owner *Actor) *Spell {
 	}
 }
 
+// SetRandom 设置暴击判定的随机源（固定种子的模拟使用）
+func (s *Spell) SetRandom(fn func() float32) {
+	s.mu.Lock()
+	s.random = fn
+	s.mu.Unlock()
+}
+
+// roll 取一个 [0,1) 的随机数
+func (s *Spell) roll() float32 {
+	s.mu.RLock()
+	fn := s.random
+	s.mu.RUnlock()
+	if fn == nil {
+		return rand.Float32()
+	}
+	return fn()
+}
+
 // CurrentSkill 获取当前技能
 func (s *Spell) CurrentSkill() *Skill {
 	s.mu.RLock()
@@ -606,8 +1155,73 @@ func (s *Spell) SetCurrentSkill(skill *Skill) {
 	s.currentSkill = skill
 }
 
-// Cast 根据技能ID对目标施放技能
+// SetGlobalCooldown 设置公共冷却时长
+func (s *Spell) SetGlobalCooldown(seconds float32) {
+	s.mu.Lock()
+	s.gcd = seconds
+	s.mu.Unlock()
+}
+
+// GlobalCooldown 公共冷却剩余时间
+func (s *Spell) GlobalCooldown() float32 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.gcdTimer
+}
+
+// Update 每帧更新公共冷却
+func (s *Spell) Update(ctx context.Context, deltaTime float32) error {
+	s.mu.Lock()
+	s.gcdTimer = max(s.gcdTimer-deltaTime, 0)
+	s.mu.Unlock()
+	return nil
+}
+
+// IsCasting 是否有技能处于吟唱或引导中
+func (s *Spell) IsCasting() bool {
+	s.mu.RLock()
+	sk := s.casting
+	s.mu.RUnlock()
+	return sk != nil && sk.IsCasting()
+}
+
+// Interrupt 请求打断吟唱/引导，在该技能下一次更新时生效；lockout 大于 0 时被打断的技能冷却该时长
+// 未在吟唱/引导时的请求被忽略（下一次吟唱/引导开始时清除）
+func (s *Spell) Interrupt(reason CastCancelReason, lockout float32) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.interruptReason == CastCancelNone || lockout >= s.interruptLockout {
+		s.interruptReason = reason
+		s.interruptLockout = lockout
+	}
+}
+
+// takeInterrupt 取出针对该技能的打断请求
+func (s *Spell) takeInterrupt(sk *Skill) (CastCancelReason, float32, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.interruptReason == CastCancelNone || s.casting != sk {
+		return CastCancelNone, 0, false
+	}
+	reason, lockout := s.interruptReason, s.interruptLockout
+	s.interruptReason, s.interruptLockout = CastCancelNone, 0
+	return reason, lockout, true
+}
+
+// Cast 根据技能ID对目标施放技能（目标点取目标位置，无目标时取自身位置）
 func (s *Spell) Cast(skillID int32, target *Actor) bool {
+	if s.owner == nil {
+		return false
+	}
+	point := s.owner.Position()
+	if target != nil {
+		point = target.Position()
+	}
+	return s.CastAt(skillID, target, point)
+}
+
+// CastAt 根据技能ID对目标与目标点施放技能
+func (s *Spell) CastAt(skillID int32, target *Actor, point Vector3) bool {
 	if s.owner == nil {
 		return false
 	}
@@ -619,37 +1233,131 @@ func (s *Spell) Cast(skillID int32, target *Actor) bool {
 	if sk == nil {
 		return false
 	}
-	if !sk.StartCast() {
+	// 吟唱/引导中不能开始另一个吟唱或引导技能；公共冷却中只能释放脱离公共冷却的技能
+	castTime, channelTime := sk.CastTimings()
+	timed := castTime > 0 || channelTime > 0
+	if timed && s.IsCasting() {
+		return false
+	}
+	offGCD := sk.OffGCD()
+	if !offGCD && s.GlobalCooldown() > 0 {
+		return false
+	}
+	if !sk.startCastAt(target, point) {
 		return false
 	}
 	s.mu.Lock()
 	s.currentSkill = sk
 	s.target = target
+	if timed {
+		s.casting = sk
+		s.interruptReason, s.interruptLockout = CastCancelNone, 0
+	}
+	if !offGCD {
+		s.gcdTimer = s.gcd
+	}
 	s.mu.Unlock()
+
+	if publisher := s.owner.GetEventPublisher(); publisher != nil {
+		var targetID EntityID
+		if target != nil {
+			targetID = target.ID()
+		}
+		evt := NewSkillCastEvent(s.owner.ID(), skillID, targetID)
+		evt.CastTime, evt.ChannelTime = castTime, channelTime
+		publisher.Publish(evt)
+	}
 	return true
 }
 
 // ApplySkillEffect 在技能进入 Active 时调用，应用技能效果到目标
+// 投射物技能发射投射物并在命中时结算；范围技能通过所在地图解析范围内目标，按解析顺序依次结算
 func (s *Spell) ApplySkillEffect(skill *Skill) {
-	s.mu.RLock()
-	target := s.target
-	s.mu.RUnlock()
-	if target == nil || target.IsDeath() || s.owner == nil {
+	if s.owner == nil {
 		return
 	}
-	// 计算伤害并应用
-	dmg := computeDamage(s.owner, target, skill)
-	if dmg <= 0 {
+
+	// 由 Skill.Update 在持有技能锁时调用，直接读取配置与本次施法目标
+	target, point := skill.castTarget, skill.castPoint
+	if skill.missile.Speed > 0 {
+		if launcher := s.owner.GetMissileLauncher(); launcher != nil {
+			_, _ = launcher.LaunchMissile(s.owner, skill, target, point)
+			return
+		}
+	}
+	origin, dir := s.areaFrame(skill.area, target, point)
+	s.applyAt(skill, target, origin, dir)
+}
+
+// applyAt 以给定原点与朝向结算技能：单体技能作用于目标，范围技能作用于范围内解析出的目标
+func (s *Spell) applyAt(skill *Skill, target *Actor, origin, dir Vector3) {
+	area := skill.area
+	if !area.IsArea() {
+		s.hit(skill, target)
+		return
+	}
+	resolver := s.owner.GetTargetResolver()
+	if resolver == nil {
 		return
 	}
-	info := &DamageInfo{
-		TargetID:     target.ID(),
-		AttackerInfo: AttackerInfo{AttackerID: s.owner.ID(), AttackerType: AttackerTypeSkill, SkillID: skill.ID()},
-		Amount:       int32(dmg),
-		DamageType:   skill.dmgType,
-		IsCrit:       false, IsMiss: false,
+	for _, t := range resolver.ResolveTargets(s.owner, area, origin, dir) {
+		s.hit(skill, t)
+	}
+}
+
+// areaFrame 计算范围技能的原点与朝向：圆心取目标点，扇形/矩形朝向目标点（与自身重合时取自身朝向）
+func (s *Spell) areaFrame(area SkillArea, target *Actor, point Vector3) (Vector3, Vector3) {
+	self := s.owner.Position()
+	if area.Shape == AreaShapeCircleAtPoint {
+		return point, s.owner.Direction()
+	}
+	if target != nil && target != s.owner {
+		point = target.Position()
+	}
+	dir := point.ToVector2().Sub(self.ToVector2())
+	if dir.X == 0 && dir.Y == 0 {
+		return self, s.owner.Direction()
+	}
+	return self, dir.ToVector3()
+}
+
+// hit 对单个目标结算技能伤害与附加 Buff；复活技能对死亡友方发起复活邀请
+func (s *Spell) hit(skill *Skill, target *Actor) {
+	if target == nil {
+		return
+	}
+	if target.IsDeath() {
+		if skill.resurrect > 0 && s.owner.IsFriendlyTo(target) {
+			if publisher := s.owner.GetEventPublisher(); publisher != nil {
+				publisher.Publish(NewResurrectOfferedEvent(s.owner.ID(), target.ID(), skill.ID(), skill.resurrect))
+			}
+		}
+		return
+	}
+	// 计算伤害并应用
+	if dmg := computeDamage(s.owner, target, skill); dmg > 0 {
+		dmg, crit := applyCrit(dmg, s.owner.GetAttributeManager().Final(), s.roll())
+		info := &DamageInfo{
+			TargetID:     target.ID(),
+			AttackerInfo: AttackerInfo{AttackerID: s.owner.ID(), AttackerType: AttackerTypeSkill, SkillID: skill.ID()},
+			Amount:       int32(dmg),
+			DamageType:   skill.dmgType,
+			IsCrit:       crit,
+		}
+		s.owner.EnterCombat()
+		_ = target.OnHurt(context.Background(), info)
+	}
+	// 打断目标的吟唱/引导
+	if skill.interrupt > 0 && target != s.owner && !target.IsDeath() {
+		target.GetSpell().Interrupt(CastCancelInterrupt, skill.interrupt)
+	}
+	// 附加 Buff（目标免疫时忽略）
+	for _, cfg := range skill.buffs {
+		if target.IsDeath() {
+			break
+		}
+		_, _ = target.GetBuffManager().Apply(NewBuffFromConfig(cfg, target, s.owner))
 	}
-	_ = target.OnHurt(context.Background(), info)
 }
 
 // computeDamage 伤害计算（简化且确定性）
@@ -682,6 +1390,17 @@ func computeDamage(attacker *Actor, defender *Actor, skill *Skill) float32 {
 	return dmg
 }
 
+// applyCrit 按攻击者暴击率判定暴击（roll 为 [0,1) 的随机数），暴击时伤害乘以暴击伤害倍率
+func applyCrit(dmg float32, attacker *Attributes, roll float32) (float32, bool) {
+	if attacker == nil || roll >= attacker.Cri {
+		return dmg, false
+	}
+	if attacker.Crd > 1 {
+		dmg *= attacker.Crd
+	}
+	return dmg, true
+}
+
 // SetTarget 设置当前施法目标
 func (s *Spell) SetTarget(target *Actor) {
 	s.mu.Lock()
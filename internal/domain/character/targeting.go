@@ -0,0 +1,149 @@
+package character
+
+import (
+	"math"
+)
+
+// AreaShape 技能作用范围形状
+type AreaShape int32
+
+const (
+	AreaShapeSingle        AreaShape = 0 // 单体（仅作用于施法目标）
+	AreaShapeCircle        AreaShape = 1 // 以施法者为圆心的圆
+	AreaShapeCircleAtPoint AreaShape = 2 // 以指定点为圆心的圆
+	AreaShapeCone          AreaShape = 3 // 施法者前方扇形
+	AreaShapeLine          AreaShape = 4 // 施法者前方矩形
+)
+
+// TargetFilter 范围技能目标筛选
+type TargetFilter int32
+
+const (
+	TargetFilterHostile  TargetFilter = 0 // 敌方
+	TargetFilterFriendly TargetFilter = 1 // 友方（含自身）
+	TargetFilterAll      TargetFilter = 2 // 敌方与友方
+)
+
+// SkillArea 技能作用范围（XZ 平面）
+type SkillArea struct {
+	Shape      AreaShape
+	Radius     float32 // 圆/扇形半径，矩形长度
+	Angle      float32 // 扇形张角（度）
+	Width      float32 // 矩形宽度
+	MaxTargets int32   // 最大目标数，0 表示不限
+	Filter     TargetFilter
+}
+
+// IsArea 是否为范围技能
+func (a SkillArea) IsArea() bool {
+	return a.Shape != AreaShapeSingle
+}
+
+// Reach 范围内任意点距原点的最大距离（用于 AOI 粗筛）
+func (a SkillArea) Reach() float32 {
+	if a.Shape == AreaShapeLine {
+		half := a.Width / 2
+		return float32(math.Sqrt(float64(a.Radius*a.Radius + half*half)))
+	}
+	return a.Radius
+}
+
+// Contains 判断点是否在范围内；origin 为圆心或施法者位置，dir 为朝向（扇形/矩形使用）
+func (a SkillArea) Contains(origin, dir, p Vector2) bool {
+	offset := p.Sub(origin)
+	dist := offset.Distance(Vector2{})
+	switch a.Shape {
+	case AreaShapeCircle, AreaShapeCircleAtPoint:
+		return dist <= a.Radius
+	case AreaShapeCone:
+		if dist > a.Radius {
+			return false
+		}
+		if dist == 0 {
+			return true
+		}
+		forward := dir.Normalized()
+		cos := (offset.X*forward.X + offset.Y*forward.Y) / dist
+		half := float64(a.Angle) / 2 * math.Pi / 180
+		return float64(cos) >= math.Cos(half)-1e-6
+	case AreaShapeLine:
+		forward := dir.Normalized()
+		along := offset.X*forward.X + offset.Y*forward.Y
+		across := offset.X*forward.Y - offset.Y*forward.X
+		return along >= 0 && along <= a.Radius && float32(math.Abs(float64(across))) <= a.Width/2
+	}
+	return false
+}
+
+// TargetResolver 范围目标解析（由地图基于 AOI 实现，按距离、ID 排序并截断到目标上限）
+type TargetResolver interface {
+	ResolveTargets(caster *Actor, area SkillArea, origin, dir Vector3) []*Actor
+}
+
+// SetTargetResolver 注入范围目标解析器
+func (a *Actor) SetTargetResolver(r TargetResolver) {
+	a.mu.Lock()
+	a.resolver = r
+	a.mu.Unlock()
+}
+
+// GetTargetResolver 获取范围目标解析器
+func (a *Actor) GetTargetResolver() TargetResolver {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.resolver
+}
+
+// SetMissileLauncher 注入投射物发射器
+func (a *Actor) SetMissileLauncher(l MissileLauncher) {
+	a.mu.Lock()
+	a.launcher = l
+	a.mu.Unlock()
+}
+
+// GetMissileLauncher 获取投射物发射器
+func (a *Actor) GetMissileLauncher() MissileLauncher {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.launcher
+}
+
+// side 阵营：玩家及其宠物/召唤物为一方，怪物为另一方，其余为中立
+func side(t EntityType) int {
+	switch t {
+	case EntityTypePlayer, EntityTypePet, EntityTypeSummon:
+		return 1
+	case EntityTypeMonster:
+		return 2
+	}
+	return 0
+}
+
+// IsHostileTo 是否与对方敌对（玩家之间按所在地图的 PvP 规则）
+func (a *Actor) IsHostileTo(other *Actor) bool {
+	sa, so := side(a.Type()), side(other.Type())
+	if sa != 0 && so != 0 && sa != so {
+		return true
+	}
+	return a.pvpHostile(other)
+}
+
+// IsFriendlyTo 是否与对方友好（自身视为友方，PvP 敌对的玩家不视为友方）
+func (a *Actor) IsFriendlyTo(other *Actor) bool {
+	if a == other {
+		return true
+	}
+	sa := side(a.Type())
+	return sa != 0 && sa == side(other.Type()) && !a.pvpHostile(other)
+}
+
+// CanAffect 按筛选条件判断范围技能能否作用于对方
+func (a *Actor) CanAffect(other *Actor, filter TargetFilter) bool {
+	switch filter {
+	case TargetFilterFriendly:
+		return a.IsFriendlyTo(other)
+	case TargetFilterAll:
+		return a.IsFriendlyTo(other) || a.IsHostileTo(other)
+	}
+	return a.IsHostileTo(other)
+}
@@ -0,0 +1,49 @@
+package character
+
+import "testing"
+
+func TestSkillAreaContains(t *testing.T) {
+	origin := NewVector2(0, 0)
+	forward := NewVector2(1, 0)
+
+	circle := SkillArea{Shape: AreaShapeCircle, Radius: 5}
+	if !circle.Contains(origin, forward, NewVector2(3, 4)) || circle.Contains(origin, forward, NewVector2(4, 4)) {
+		t.Fatalf("circle containment wrong")
+	}
+
+	cone := SkillArea{Shape: AreaShapeCone, Radius: 5, Angle: 90}
+	if !cone.Contains(origin, forward, NewVector2(3, 2)) {
+		t.Fatalf("point inside cone rejected")
+	}
+	if cone.Contains(origin, forward, NewVector2(2, 3)) {
+		t.Fatalf("point outside cone angle accepted")
+	}
+	if cone.Contains(origin, forward, NewVector2(-3, 0)) {
+		t.Fatalf("point behind caster accepted")
+	}
+
+	line := SkillArea{Shape: AreaShapeLine, Radius: 10, Width: 2}
+	if !line.Contains(origin, forward, NewVector2(9, 0.9)) {
+		t.Fatalf("point inside line rejected")
+	}
+	if line.Contains(origin, forward, NewVector2(9, 1.1)) || line.Contains(origin, forward, NewVector2(10.5, 0)) {
+		t.Fatalf("point outside line accepted")
+	}
+}
+
+func TestTargetFilters(t *testing.T) {
+	player := NewActor(1, EntityTypePlayer, 1, NewVector3(0, 0, 0), NewVector3(1, 0, 0), "p", 1)
+	ally := NewActor(2, EntityTypePlayer, 1, NewVector3(0, 0, 0), NewVector3(1, 0, 0), "a", 1)
+	monster := NewActor(3, EntityTypeMonster, 2001, NewVector3(0, 0, 0), NewVector3(1, 0, 0), "m", 1)
+	npc := NewActor(4, EntityTypeNPC, 3001, NewVector3(0, 0, 0), NewVector3(1, 0, 0), "n", 1)
+
+	if !player.CanAffect(monster, TargetFilterHostile) || player.CanAffect(ally, TargetFilterHostile) {
+		t.Fatalf("hostile filter wrong")
+	}
+	if !player.CanAffect(ally, TargetFilterFriendly) || !player.CanAffect(player, TargetFilterFriendly) || player.CanAffect(monster, TargetFilterFriendly) {
+		t.Fatalf("friendly filter wrong")
+	}
+	if player.CanAffect(npc, TargetFilterAll) {
+		t.Fatalf("neutral npc should not be affected")
+	}
+}
@@ -1,6 +1,9 @@
 package inventory
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	// 背包相关错误
@@ -34,3 +37,20 @@ var (
 	ErrItemNotTradeable = errors.New("item is not tradeable")
 	ErrTradeRestricted  = errors.New("trade is restricted")
 )
+
+// OpError 背包操作错误：记录失败的操作与物品，可用 errors.Is 匹配具体原因
+type OpError struct {
+	Op      string // add/remove/move/split/merge
+	ItemID  int32
+	ItemUID int64
+	Err     error
+}
+
+func (e *OpError) Error() string {
+	if e.ItemUID != 0 {
+		return fmt.Sprintf("inventory %s item %d (uid %d): %v", e.Op, e.ItemID, e.ItemUID, e.Err)
+	}
+	return fmt.Sprintf("inventory %s item %d: %v", e.Op, e.ItemID, e.Err)
+}
+
+func (e *OpError) Unwrap() error { return e.Err }
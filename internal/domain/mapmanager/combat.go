@@ -0,0 +1,273 @@
+package mapmanager
+
+import (
+	"sort"
+
+	character "greatestworks/internal/domain/character"
+)
+
+// Obstacle 阻挡视线的障碍物（XZ 平面矩形）
+type Obstacle struct {
+	MinX, MinZ float32
+	MaxX, MaxZ float32
+}
+
+// SkillCastNotice 施法开始通知
+type SkillCastNotice struct {
+	CasterID    character.EntityID `json:"caster_id"`
+	SkillID     int32              `json:"skill_id"`
+	TargetID    character.EntityID `json:"target_id,omitempty"`
+	CastTime    float32            `json:"cast_time,omitempty"`    // 吟唱时间，大于 0 时随后下发完成或取消通知
+	ChannelTime float32            `json:"channel_time,omitempty"` // 引导时间
+	Tick        uint32             `json:"tick"`
+}
+
+// SkillCastCancelNotice 吟唱/引导取消通知
+type SkillCastCancelNotice struct {
+	CasterID character.EntityID         `json:"caster_id"`
+	SkillID  int32                      `json:"skill_id"`
+	Reason   character.CastCancelReason `json:"reason"`
+	Tick     uint32                     `json:"tick"`
+}
+
+// SkillCastCompleteNotice 吟唱/引导完成通知
+type SkillCastCompleteNotice struct {
+	CasterID character.EntityID `json:"caster_id"`
+	SkillID  int32              `json:"skill_id"`
+	Tick     uint32             `json:"tick"`
+}
+
+// SkillHitNotice 命中通知
+type SkillHitNotice struct {
+	AttackerID character.EntityID   `json:"attacker_id"`
+	TargetID   character.EntityID   `json:"target_id"`
+	SkillID    int32                `json:"skill_id,omitempty"`
+	Damage     int32                `json:"damage"`
+	DamageType character.DamageType `json:"damage_type"`
+	IsCrit     bool                 `json:"is_crit,omitempty"`
+	HP         float32              `json:"hp"`
+	Dead       bool                 `json:"dead,omitempty"`
+	Tick       uint32               `json:"tick"`
+}
+
+// EntityDeathNotice 死亡通知
+type EntityDeathNotice struct {
+	EntityID character.EntityID `json:"entity_id"`
+	KillerID character.EntityID `json:"killer_id,omitempty"`
+	SkillID  int32              `json:"skill_id,omitempty"`
+	Tick     uint32             `json:"tick"`
+}
+
+// EquipmentChangeNotice 装备外观变化通知
+type EquipmentChangeNotice struct {
+	EntityID character.EntityID  `json:"entity_id"`
+	Slot     character.EquipSlot `json:"slot"`
+	ItemID   int32               `json:"item_id"` // 0 表示卸下
+	Tick     uint32              `json:"tick"`
+}
+
+// LevelUpNotice 升级通知
+type LevelUpNotice struct {
+	EntityID character.EntityID `json:"entity_id"`
+	Level    int32              `json:"level"`
+	MaxHP    float32            `json:"max_hp"`
+	Tick     uint32             `json:"tick"`
+}
+
+// CCDiminishNotice 控制递减状态通知（Multiplier 为 0 表示免疫）
+type CCDiminishNotice struct {
+	EntityID   character.EntityID  `json:"entity_id"`
+	Category   character.FlagState `json:"category"`
+	Count      int32               `json:"count"`
+	Multiplier float32             `json:"multiplier"`
+	ResetIn    float32             `json:"reset_in"`
+	Tick       uint32              `json:"tick"`
+}
+
+// SetObstacles 设置阻挡视线的障碍物
+func (m *Map) SetObstacles(obstacles []Obstacle) {
+	m.mu.Lock()
+	m.obstacles = obstacles
+	m.mu.Unlock()
+}
+
+// LineOfSight 两点之间（XZ 平面）是否无障碍物遮挡
+func (m *Map) LineOfSight(from, to character.Vector3) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.lineOfSightLocked(from, to)
+}
+
+// lineOfSightLocked 视线检测（调用方持有锁）
+func (m *Map) lineOfSightLocked(from, to character.Vector3) bool {
+	for _, o := range m.obstacles {
+		if segmentHitsRect(from.X, from.Z, to.X, to.Z, o) {
+			return false
+		}
+	}
+	return true
+}
+
+// segmentHitsRect 线段与矩形是否相交（Liang-Barsky 裁剪）
+func segmentHitsRect(x0, z0, x1, z1 float32, o Obstacle) bool {
+	dx, dz := x1-x0, z1-z0
+	t0, t1 := float32(0), float32(1)
+	clip := func(p, q float32) bool {
+		if p == 0 {
+			return q >= 0
+		}
+		r := q / p
+		if p < 0 {
+			if r > t1 {
+				return false
+			}
+			if r > t0 {
+				t0 = r
+			}
+		} else {
+			if r < t0 {
+				return false
+			}
+			if r < t1 {
+				t1 = r
+			}
+		}
+		return true
+	}
+	return clip(-dx, x0-o.MinX) && clip(dx, o.MaxX-x0) &&
+		clip(-dz, z0-o.MinZ) && clip(dz, o.MaxZ-z0)
+}
+
+// ResolveTargets 实现 character.TargetResolver：通过 AOI 网格粗筛后按形状、阵营、存活与视线精确筛选，
+// 按距原点由近到远（同距按实体ID）排序并截断到目标上限，保证结算顺序确定
+func (m *Map) ResolveTargets(caster *character.Actor, area character.SkillArea, origin, dir character.Vector3) []*character.Actor {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	type candidate struct {
+		actor *character.Actor
+		dist  float32
+	}
+	o2, d2 := origin.ToVector2(), dir.ToVector2()
+	var candidates []candidate
+	for _, id := range m.aoiGrid.GetNearby(o2.X, o2.Y, area.Reach()) {
+		actor, ok := m.actors[id]
+		if !ok || actor.IsDeath() || !caster.CanAffect(actor, area.Filter) {
+			continue
+		}
+		pos := actor.Position()
+		if !area.Contains(o2, d2, pos.ToVector2()) || !m.lineOfSightLocked(origin, pos) {
+			continue
+		}
+		candidates = append(candidates, candidate{actor: actor, dist: o2.Distance(pos.ToVector2())})
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].dist != candidates[j].dist {
+			return candidates[i].dist < candidates[j].dist
+		}
+		return candidates[i].actor.ID() < candidates[j].actor.ID()
+	})
+	if area.MaxTargets > 0 && len(candidates) > int(area.MaxTargets) {
+		candidates = candidates[:area.MaxTargets]
+	}
+	targets := make([]*character.Actor, len(candidates))
+	for i, c := range candidates {
+		targets[i] = c.actor
+	}
+	return targets
+}
+
+// Publish 实现 character.EventPublisher：将地图内角色的战斗事件广播给 AOI 观察者
+func (m *Map) Publish(event character.DomainEvent) {
+	if evt, ok := event.(*character.ResurrectOfferedEvent); ok {
+		m.offerResurrect(evt)
+		return
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.broadcaster == nil {
+		return
+	}
+
+	switch evt := event.(type) {
+	case *character.SkillCastEvent:
+		notice := &SkillCastNotice{
+			CasterID:    evt.CasterID,
+			SkillID:     evt.SkillID,
+			TargetID:    evt.TargetID,
+			CastTime:    evt.CastTime,
+			ChannelTime: evt.ChannelTime,
+			Tick:        m.tick,
+		}
+		m.broadcaster(m.viewersLocked(evt.CasterID), "skill_cast", notice)
+	case *character.SkillCastCancelledEvent:
+		notice := &SkillCastCancelNotice{CasterID: evt.CasterID, SkillID: evt.SkillID, Reason: evt.Reason, Tick: m.tick}
+		m.broadcaster(m.viewersLocked(evt.CasterID), "skill_cast_cancel", notice)
+	case *character.SkillCastCompletedEvent:
+		notice := &SkillCastCompleteNotice{CasterID: evt.CasterID, SkillID: evt.SkillID, Tick: m.tick}
+		m.broadcaster(m.viewersLocked(evt.CasterID), "skill_cast_complete", notice)
+	case *character.DamageDealtEvent:
+		notice := &SkillHitNotice{
+			AttackerID: evt.AttackerID,
+			TargetID:   evt.TargetID,
+			SkillID:    evt.SkillID,
+			Damage:     evt.Amount,
+			DamageType: evt.DamageType,
+			IsCrit:     evt.IsCrit,
+			Tick:       m.tick,
+		}
+		if target, ok := m.actors[evt.TargetID]; ok {
+			notice.HP = target.HP()
+			notice.Dead = target.IsDeath()
+		}
+		m.broadcaster(m.viewersLocked(evt.TargetID), "skill_hit", notice)
+	case *character.EquipmentChangedEvent:
+		notice := &EquipmentChangeNotice{EntityID: evt.EntityID, Slot: evt.Slot, ItemID: evt.ItemID, Tick: m.tick}
+		m.broadcaster(m.viewersLocked(evt.EntityID), "equipment_change", notice)
+	case *character.PlayerLevelUpEvent:
+		notice := &LevelUpNotice{EntityID: evt.EntityID, Level: evt.NewLevel, Tick: m.tick}
+		if actor, ok := m.actors[evt.EntityID]; ok {
+			notice.MaxHP = actor.GetAttributeManager().Final().MaxHP
+		}
+		m.broadcaster(m.viewersLocked(evt.EntityID), "level_up", notice)
+	case *character.CCDiminishedEvent:
+		notice := &CCDiminishNotice{
+			EntityID:   evt.TargetID,
+			Category:   evt.Category,
+			Count:      evt.Count,
+			Multiplier: evt.Multiplier,
+			ResetIn:    evt.ResetIn,
+			Tick:       m.tick,
+		}
+		m.broadcaster(m.viewersLocked(evt.TargetID), "cc_diminish", notice)
+	case *character.PvPFlagChangedEvent:
+		notice := &PvPFlagNotice{EntityID: evt.EntityID, Enabled: evt.Enabled, Tick: m.tick}
+		m.broadcaster(m.viewersLocked(evt.EntityID), "pvp_flag", notice)
+	}
+}
+
+// broadcastDeath 向 AOI 观察者广播角色死亡（调用方持有锁）
+func (m *Map) broadcastDeath(actor *character.Actor) {
+	if m.broadcaster == nil {
+		return
+	}
+	notice := &EntityDeathNotice{EntityID: actor.ID(), Tick: m.tick}
+	if src := actor.DamageSource(); src != nil {
+		notice.KillerID = src.AttackerInfo.AttackerID
+		notice.SkillID = src.AttackerInfo.SkillID
+	}
+	m.broadcaster(m.viewersLocked(actor.ID()), "entity_death", notice)
+}
+
+// viewersLocked 能看到某实体的所有对象（含自身，调用方持有锁）
+func (m *Map) viewersLocked(entityID character.EntityID) []character.EntityID {
+	set := m.visibleSets[entityID]
+	viewers := make([]character.EntityID, 0, len(set)+1)
+	viewers = append(viewers, entityID)
+	for id := range set {
+		viewers = append(viewers, id)
+	}
+	return viewers
+}
@@ -0,0 +1,261 @@
+package mapmanager
+
+import (
+	"context"
+	"testing"
+
+	character "greatestworks/internal/domain/character"
+)
+
+type capturedBroadcast struct {
+	recipients []character.EntityID
+	topic      string
+	payload    interface{}
+}
+
+func newCombatTestMap(t *testing.T) (*Map, *[]capturedBroadcast, *character.Actor, *character.Actor) {
+	t.Helper()
+	m := NewMap(1, "test", 1000, 1000)
+	var got []capturedBroadcast
+	m.SetBroadcaster(func(recipients []character.EntityID, topic string, payload interface{}) {
+		got = append(got, capturedBroadcast{recipients: recipients, topic: topic, payload: payload})
+	})
+	caster := character.NewActor(1, character.EntityTypePlayer, 1, character.NewVector3(10, 0, 10), character.NewVector3(1, 0, 0), "caster", 1)
+	target := character.NewActor(2, character.EntityTypeMonster, 2001, character.NewVector3(12, 0, 10), character.NewVector3(1, 0, 0), "target", 1)
+	for _, a := range []*character.Actor{caster, target} {
+		if err := a.Start(context.Background()); err != nil {
+			t.Fatalf("actor start: %v", err)
+		}
+		if err := m.EnterActor(context.Background(), a); err != nil {
+			t.Fatalf("enter: %v", err)
+		}
+	}
+	return m, &got, caster, target
+}
+
+func topicCount(got []capturedBroadcast, topic string) int {
+	n := 0
+	for _, b := range got {
+		if b.topic == topic {
+			n++
+		}
+	}
+	return n
+}
+
+func TestSkillResolvedByMapUpdate(t *testing.T) {
+	ctx := context.Background()
+	m, got, caster, target := newCombatTestMap(t)
+
+	sk := character.NewSkill(1, caster)
+	sk.SetDamage(100000, 0, 0, character.DamageTypePhysical)
+	sk.SetTimings(0.3, 0.1, 1)
+	caster.GetSkillManager().AddSkill(sk)
+
+	if !caster.GetSpell().Cast(1, target) {
+		t.Fatalf("cast rejected")
+	}
+	if topicCount(*got, "skill_cast") != 1 {
+		t.Fatalf("expected skill_cast broadcast")
+	}
+
+	// 吟唱未结束不结算
+	_ = m.Update(ctx, 0.1)
+	if topicCount(*got, "skill_hit") != 0 {
+		t.Fatalf("hit before cast time elapsed")
+	}
+
+	_ = m.Update(ctx, 0.3)
+	if topicCount(*got, "skill_hit") != 1 {
+		t.Fatalf("expected skill_hit after cast time")
+	}
+	_ = m.Update(ctx, 0.1)
+	if topicCount(*got, "entity_death") != 1 {
+		t.Fatalf("expected entity_death broadcast")
+	}
+	for _, b := range *got {
+		if b.topic != "skill_hit" {
+			continue
+		}
+		hit := b.payload.(*SkillHitNotice)
+		if hit.AttackerID != 1 || hit.TargetID != 2 || hit.SkillID != 1 || !hit.Dead {
+			t.Fatalf("unexpected hit notice %+v", hit)
+		}
+		if len(b.recipients) != 2 {
+			t.Fatalf("expected hit sent to target and caster, got %v", b.recipients)
+		}
+	}
+	if sk.State() != character.SkillStateCooling {
+		t.Fatalf("expected skill cooling, got %v", sk.State())
+	}
+}
+
+func TestLineOfSight(t *testing.T) {
+	m := NewMap(1, "test", 1000, 1000)
+	m.SetObstacles([]Obstacle{{MinX: 10, MinZ: 0, MaxX: 12, MaxZ: 20}})
+	if m.LineOfSight(character.NewVector3(0, 0, 5), character.NewVector3(20, 0, 5)) {
+		t.Fatalf("expected obstacle to block sight")
+	}
+	if !m.LineOfSight(character.NewVector3(0, 0, 25), character.NewVector3(20, 0, 25)) {
+		t.Fatalf("expected clear sight above obstacle")
+	}
+	if !m.LineOfSight(character.NewVector3(0, 0, 5), character.NewVector3(9, 0, 5)) {
+		t.Fatalf("segment ending before obstacle should be clear")
+	}
+}
+
+func TestAreaSkillHitsTargetsInOrder(t *testing.T) {
+	ctx := context.Background()
+	m, got, caster, near := newCombatTestMap(t)
+
+	// 更远的两个怪物与一个超出范围的怪物，另有一个友方玩家
+	var monsters []*character.Actor
+	for i, x := range []float32{14, 14, 30} {
+		a := character.NewActor(character.EntityID(10+i), character.EntityTypeMonster, 2001, character.NewVector3(x, 0, 10), character.NewVector3(1, 0, 0), "m", 1)
+		_ = a.Start(ctx)
+		_ = m.EnterActor(ctx, a)
+		monsters = append(monsters, a)
+	}
+	ally := character.NewActor(20, character.EntityTypePlayer, 1, character.NewVector3(11, 0, 10), character.NewVector3(1, 0, 0), "ally", 1)
+	_ = ally.Start(ctx)
+	_ = m.EnterActor(ctx, ally)
+
+	area := character.SkillArea{Shape: character.AreaShapeCircle, Radius: 5, MaxTargets: 2}
+	targets := m.ResolveTargets(caster, area, caster.Position(), caster.Direction())
+	if len(targets) != 2 || targets[0] != near || targets[1] != monsters[0] {
+		t.Fatalf("expected nearest hostile targets in id order, got %v", targets)
+	}
+
+	sk := character.NewSkill(3, caster)
+	sk.SetDamage(10, 0, 0, character.DamageTypePhysical)
+	sk.SetTimings(0, 0.1, 1)
+	area.MaxTargets = 0
+	sk.SetArea(area)
+	caster.GetSkillManager().AddSkill(sk)
+	if !caster.GetSpell().Cast(3, nil) {
+		t.Fatalf("cast rejected")
+	}
+	_ = m.Update(ctx, 0.1)
+
+	var hits []character.EntityID
+	for _, b := range *got {
+		if b.topic == "skill_hit" {
+			hits = append(hits, b.payload.(*SkillHitNotice).TargetID)
+		}
+	}
+	want := []character.EntityID{2, 10, 11}
+	if len(hits) != len(want) {
+		t.Fatalf("expected hits %v, got %v", want, hits)
+	}
+	for i := range want {
+		if hits[i] != want[i] {
+			t.Fatalf("expected hits %v, got %v", want, hits)
+		}
+	}
+	if ally.HP() != ally.GetAttributeManager().Final().MaxHP || monsters[2].HP() != monsters[2].GetAttributeManager().Final().MaxHP {
+		t.Fatalf("ally or out-of-range monster damaged")
+	}
+}
+
+func TestAreaTargetsBlockedBySight(t *testing.T) {
+	m, _, caster, _ := newCombatTestMap(t)
+	m.SetObstacles([]Obstacle{{MinX: 11, MinZ: 0, MaxX: 11.5, MaxZ: 20}})
+	area := character.SkillArea{Shape: character.AreaShapeCircle, Radius: 5}
+	if targets := m.ResolveTargets(caster, area, caster.Position(), caster.Direction()); len(targets) != 0 {
+		t.Fatalf("expected target behind obstacle excluded, got %v", targets)
+	}
+}
+
+func TestEquipmentChangeBroadcastToObservers(t *testing.T) {
+	m, got, caster, target := newCombatTestMap(t)
+	if _, err := caster.GetEquipmentManager().Equip(&character.Equipment{ItemID: 20001, Slot: character.EquipSlotWeapon}); err != nil {
+		t.Fatalf("equip: %v", err)
+	}
+	if topicCount(*got, "equipment_change") != 1 {
+		t.Fatalf("expected equipment_change broadcast")
+	}
+	for _, b := range *got {
+		if b.topic != "equipment_change" {
+			continue
+		}
+		notice := b.payload.(*EquipmentChangeNotice)
+		if notice.EntityID != caster.ID() || notice.Slot != character.EquipSlotWeapon || notice.ItemID != 20001 {
+			t.Fatalf("unexpected notice %+v", notice)
+		}
+		if len(b.recipients) != 2 {
+			t.Fatalf("expected change sent to caster and observer, got %v", b.recipients)
+		}
+	}
+
+	// 新进入视野的观察者通过实体出现消息获得外观
+	appears := m.buildAppearPayload([]character.EntityID{caster.ID(), target.ID()})
+	if appears[0].Equipment[character.EquipSlotWeapon] != 20001 || appears[1].Equipment != nil {
+		t.Fatalf("unexpected appearance %+v", appears)
+	}
+}
+
+func TestLevelUpBroadcastToObservers(t *testing.T) {
+	_, got, caster, _ := newCombatTestMap(t)
+	caster.ApplyLevelUp(2, character.LevelGrowth{MaxHP: 50})
+	if topicCount(*got, "level_up") != 1 {
+		t.Fatalf("expected level_up broadcast")
+	}
+	for _, b := range *got {
+		if b.topic != "level_up" {
+			continue
+		}
+		notice := b.payload.(*LevelUpNotice)
+		if notice.EntityID != caster.ID() || notice.Level != 2 || notice.MaxHP != caster.GetAttributeManager().Final().MaxHP {
+			t.Fatalf("unexpected notice %+v", notice)
+		}
+		if len(b.recipients) != 2 {
+			t.Fatalf("expected level up sent to caster and observer, got %v", b.recipients)
+		}
+	}
+}
+
+func TestMoveCancelsCastAndBroadcasts(t *testing.T) {
+	ctx := context.Background()
+	m, got, caster, target := newCombatTestMap(t)
+
+	sk := character.NewSkill(1, caster)
+	sk.SetDamage(100, 0, 0, character.DamageTypePhysical)
+	sk.SetTimings(0.5, 0.1, 1)
+	caster.GetSkillManager().AddSkill(sk)
+
+	if !caster.GetSpell().Cast(1, target) {
+		t.Fatalf("cast rejected")
+	}
+	cast := (*got)[len(*got)-1].payload.(*SkillCastNotice)
+	if cast.CastTime != 0.5 {
+		t.Fatalf("expected cast time in notice, got %+v", cast)
+	}
+	// 原地同步位置不打断
+	if err := m.UpdatePosition(caster.ID(), caster.Position()); err != nil {
+		t.Fatalf("move: %v", err)
+	}
+	_ = m.Update(ctx, 0.1)
+	if topicCount(*got, "skill_cast_cancel") != 0 {
+		t.Fatalf("unexpected cancel without movement")
+	}
+	if err := m.UpdatePosition(caster.ID(), character.NewVector3(11, 0, 10)); err != nil {
+		t.Fatalf("move: %v", err)
+	}
+	_ = m.Update(ctx, 0.1)
+	if topicCount(*got, "skill_cast_cancel") != 1 {
+		t.Fatalf("expected skill_cast_cancel broadcast")
+	}
+	_ = m.Update(ctx, 0.5)
+	if topicCount(*got, "skill_hit") != 0 || topicCount(*got, "skill_cast_complete") != 0 {
+		t.Fatalf("cancelled cast must not resolve")
+	}
+
+	// 重新施法且不移动时下发完成通知后结算
+	if !caster.GetSpell().Cast(1, target) {
+		t.Fatalf("recast rejected")
+	}
+	_ = m.Update(ctx, 0.6)
+	if topicCount(*got, "skill_cast_complete") != 1 || topicCount(*got, "skill_hit") != 1 {
+		t.Fatalf("expected completed cast to hit")
+	}
+}
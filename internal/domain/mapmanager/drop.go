@@ -0,0 +1,197 @@
+package mapmanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	character "greatestworks/internal/domain/character"
+)
+
+// 掉落物错误
+var (
+	ErrDropNotFound   = errors.New("drop not found")
+	ErrDropNotOwner   = errors.New("drop belongs to others")
+	ErrDropOutOfRange = errors.New("drop out of pickup range")
+	ErrDropClaimed    = errors.New("drop is being picked up")
+)
+
+// DeathHandler 地图内角色死亡回调（每次死亡只触发一次，由应用层注入用于掉落、奖励等）
+type DeathHandler func(ctx context.Context, m *Map, actor *character.Actor)
+
+// DroppedItem 地面掉落物
+type DroppedItem struct {
+	Entity *character.Entity
+	ItemID int32
+	Count  int32
+
+	owners      map[character.EntityID]struct{} // 归属者（击杀者或其队伍），为空表示无归属
+	ownerRemain float32                         // 归属剩余秒数，到期后任何人可拾取
+	lifeRemain  float32                         // 剩余存在秒数，到期后消失
+	claimedBy   character.EntityID              // 正在拾取者（背包写入期间占用）
+}
+
+// DropInfo 掉落物下发信息（随实体出现消息一起下发）
+type DropInfo struct {
+	ItemID      int32                `json:"item_id"`
+	Count       int32                `json:"count"`
+	Owners      []character.EntityID `json:"owners,omitempty"`
+	OwnerRemain float32              `json:"owner_remain,omitempty"`
+}
+
+// info 构造下发信息（调用方持有锁）
+func (d *DroppedItem) info() *DropInfo {
+	info := &DropInfo{ItemID: d.ItemID, Count: d.Count}
+	if d.ownerRemain > 0 {
+		info.OwnerRemain = d.ownerRemain
+		for id := range d.owners {
+			info.Owners = append(info.Owners, id)
+		}
+	}
+	return info
+}
+
+// SetDeathHandler 设置角色死亡回调
+func (m *Map) SetDeathHandler(fn DeathHandler) {
+	m.mu.Lock()
+	m.deathHandler = fn
+	m.mu.Unlock()
+}
+
+// DropItem 在地图上放置掉落物；ownerDuration 秒内仅 owners 可拾取，lifetime 秒后消失
+func (m *Map) DropItem(ctx context.Context, entityID character.EntityID, itemID, count int32, position character.Vector3,
+	owners []character.EntityID, ownerDuration, lifetime float32) (*DroppedItem, error) {
+	drop := &DroppedItem{
+		Entity:      character.NewEntity(entityID, character.EntityTypeDroppedItem, itemID, position, character.NewVector3(0, 0, 1)),
+		ItemID:      itemID,
+		Count:       count,
+		owners:      make(map[character.EntityID]struct{}, len(owners)),
+		ownerRemain: ownerDuration,
+		lifeRemain:  lifetime,
+	}
+	for _, id := range owners {
+		drop.owners[id] = struct{}{}
+	}
+
+	// 先登记掉落信息，使实体出现消息携带物品与归属
+	m.mu.Lock()
+	if _, exists := m.entities[entityID]; exists {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("entity already in map: %d", entityID)
+	}
+	m.drops[entityID] = drop
+	m.mu.Unlock()
+	if err := m.Enter(ctx, drop.Entity); err != nil {
+		m.mu.Lock()
+		delete(m.drops, entityID)
+		m.mu.Unlock()
+		return nil, err
+	}
+	return drop, nil
+}
+
+// GetDrop 获取掉落物
+func (m *Map) GetDrop(dropID character.EntityID) *DroppedItem {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.drops[dropID]
+}
+
+// ClaimDrop 校验拾取距离与归属并占用掉落物；写入背包成功后调用 RemoveDrop，失败调用 ReleaseDrop
+func (m *Map) ClaimDrop(pickerID, dropID character.EntityID, maxRange float32) (*DroppedItem, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	drop, ok := m.drops[dropID]
+	if !ok {
+		return nil, ErrDropNotFound
+	}
+	if drop.claimedBy != 0 {
+		return nil, ErrDropClaimed
+	}
+	picker, ok := m.entities[pickerID]
+	if !ok {
+		return nil, fmt.Errorf("entity not in map: %d", pickerID)
+	}
+	if actor, ok := m.actors[pickerID]; ok && actor.IsDeath() {
+		return nil, ErrActorDead
+	}
+	if picker.DistanceTo(drop.Entity) > maxRange {
+		return nil, ErrDropOutOfRange
+	}
+	if drop.ownerRemain > 0 && len(drop.owners) > 0 {
+		if _, owner := drop.owners[pickerID]; !owner {
+			return nil, ErrDropNotOwner
+		}
+	}
+	drop.claimedBy = pickerID
+	return drop, nil
+}
+
+// ReleaseDrop 释放占用（拾取失败时调用）
+func (m *Map) ReleaseDrop(dropID character.EntityID) {
+	m.mu.Lock()
+	if drop, ok := m.drops[dropID]; ok {
+		drop.claimedBy = 0
+	}
+	m.mu.Unlock()
+}
+
+// RemoveDrop 移除掉落物（拾取完成或过期）
+func (m *Map) RemoveDrop(ctx context.Context, dropID character.EntityID) error {
+	m.mu.Lock()
+	_, ok := m.drops[dropID]
+	delete(m.drops, dropID)
+	m.mu.Unlock()
+	if !ok {
+		return ErrDropNotFound
+	}
+	return m.Leave(ctx, dropID)
+}
+
+// updateDeaths 检测新死亡的角色，广播死亡并触发死亡回调（不持有地图锁调用回调）
+func (m *Map) updateDeaths(ctx context.Context) {
+	m.mu.Lock()
+	handler := m.deathHandler
+	var died []*character.Actor
+	for id, actor := range m.actors {
+		if !actor.IsDeath() {
+			delete(m.deceased, id)
+			continue
+		}
+		if _, notified := m.deceased[id]; notified {
+			continue
+		}
+		m.deceased[id] = struct{}{}
+		m.broadcastDeath(actor)
+		died = append(died, actor)
+	}
+	m.mu.Unlock()
+
+	if handler == nil {
+		return
+	}
+	for _, actor := range died {
+		handler(ctx, m, actor)
+	}
+}
+
+// updateDrops 推进掉落物归属与存在计时，移除过期掉落物
+func (m *Map) updateDrops(ctx context.Context, deltaTime float32) {
+	m.mu.Lock()
+	var expired []character.EntityID
+	for id, drop := range m.drops {
+		if drop.ownerRemain > 0 {
+			drop.ownerRemain -= deltaTime
+		}
+		drop.lifeRemain -= deltaTime
+		if drop.lifeRemain <= 0 && drop.claimedBy == 0 {
+			expired = append(expired, id)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, id := range expired {
+		_ = m.RemoveDrop(ctx, id)
+	}
+}
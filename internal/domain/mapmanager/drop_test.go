@@ -0,0 +1,119 @@
+package mapmanager
+
+import (
+	"context"
+	"testing"
+
+	character "greatestworks/internal/domain/character"
+)
+
+func newDropTestMap(t *testing.T) (*Map, *character.Actor, *character.Actor, *[][]EntityAppear) {
+	t.Helper()
+	m := NewMap(1, "test", 1000, 1000)
+	var appears [][]EntityAppear
+	m.SetBroadcaster(func(recipients []character.EntityID, topic string, payload interface{}) {
+		if topic == "entity_appear" {
+			appears = append(appears, payload.([]EntityAppear))
+		}
+	})
+	owner := character.NewActor(1, character.EntityTypePlayer, 1, character.NewVector3(10, 0, 10), character.NewVector3(1, 0, 0), "owner", 1)
+	other := character.NewActor(2, character.EntityTypePlayer, 1, character.NewVector3(11, 0, 10), character.NewVector3(1, 0, 0), "other", 1)
+	for _, a := range []*character.Actor{owner, other} {
+		if err := a.Start(context.Background()); err != nil {
+			t.Fatalf("actor start: %v", err)
+		}
+		if err := m.EnterActor(context.Background(), a); err != nil {
+			t.Fatalf("enter: %v", err)
+		}
+	}
+	return m, owner, other, &appears
+}
+
+func TestDropVisibleWithInfo(t *testing.T) {
+	m, _, _, appears := newDropTestMap(t)
+	*appears = nil
+	if _, err := m.DropItem(context.Background(), 100, 30001, 5, character.NewVector3(10, 0, 11), []character.EntityID{1}, 30, 120); err != nil {
+		t.Fatalf("drop: %v", err)
+	}
+	var found *DropInfo
+	for _, batch := range *appears {
+		for _, a := range batch {
+			if a.ID == 100 {
+				found = a.Drop
+			}
+		}
+	}
+	if found == nil || found.ItemID != 30001 || found.Count != 5 || len(found.Owners) != 1 {
+		t.Fatalf("expected drop info in appear payload, got %+v", found)
+	}
+}
+
+func TestDropOwnershipAndRange(t *testing.T) {
+	ctx := context.Background()
+	m, _, _, _ := newDropTestMap(t)
+	_, _ = m.DropItem(ctx, 100, 30001, 1, character.NewVector3(10, 0, 11), []character.EntityID{1}, 5, 60)
+
+	if _, err := m.ClaimDrop(2, 100, 3); err != ErrDropNotOwner {
+		t.Fatalf("expected not owner, got %v", err)
+	}
+	if _, err := m.ClaimDrop(1, 100, 0.5); err != ErrDropOutOfRange {
+		t.Fatalf("expected out of range, got %v", err)
+	}
+	if _, err := m.ClaimDrop(1, 100, 3); err != nil {
+		t.Fatalf("owner claim: %v", err)
+	}
+	if _, err := m.ClaimDrop(1, 100, 3); err != ErrDropClaimed {
+		t.Fatalf("expected claimed, got %v", err)
+	}
+	m.ReleaseDrop(100)
+
+	// 归属到期后任何人可拾取
+	_ = m.Update(ctx, 6)
+	if _, err := m.ClaimDrop(2, 100, 3); err != nil {
+		t.Fatalf("free-for-all claim: %v", err)
+	}
+	if err := m.RemoveDrop(ctx, 100); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if m.GetEntity(100) != nil || m.GetDrop(100) != nil {
+		t.Fatalf("picked drop should leave the map")
+	}
+}
+
+func TestDropExpires(t *testing.T) {
+	ctx := context.Background()
+	m, _, _, _ := newDropTestMap(t)
+	_, _ = m.DropItem(ctx, 100, 30001, 1, character.NewVector3(10, 0, 11), nil, 0, 10)
+	_ = m.Update(ctx, 5)
+	if m.GetDrop(100) == nil {
+		t.Fatalf("drop expired early")
+	}
+	_ = m.Update(ctx, 5.1)
+	if m.GetDrop(100) != nil || m.GetEntity(100) != nil {
+		t.Fatalf("expected drop despawned after lifetime")
+	}
+}
+
+func TestDeathHandlerOncePerDeath(t *testing.T) {
+	ctx := context.Background()
+	m, _, other, _ := newDropTestMap(t)
+	calls := 0
+	m.SetDeathHandler(func(ctx context.Context, gm *Map, actor *character.Actor) {
+		if actor.ID() == other.ID() {
+			calls++
+		}
+	})
+	other.ChangeHP(-other.HP())
+	_ = m.Update(ctx, 0.1)
+	_ = m.Update(ctx, 0.1)
+	if calls != 1 {
+		t.Fatalf("expected one death callback, got %d", calls)
+	}
+	other.ChangeHP(100)
+	_ = m.Update(ctx, 0.1)
+	other.ChangeHP(-other.HP())
+	_ = m.Update(ctx, 0.1)
+	if calls != 2 {
+		t.Fatalf("expected callback after second death, got %d", calls)
+	}
+}
@@ -3,6 +3,7 @@ package mapmanager
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
 
 	character "greatestworks/internal/domain/character"
@@ -17,6 +18,7 @@ type Map struct {
 	width    int32                                    // 地图宽度
 	height   int32                                    // 地图高度
 	entities map[character.EntityID]*character.Entity // 地图内的所有实体
+	actors   map[character.EntityID]*character.Actor  // 具有战斗属性的实体（实体的子集）
 
 	// AOI系统（简化实现）
 	aoiGrid *AOIGrid
@@ -25,6 +27,35 @@ type Map struct {
 	viewRadius  float32
 	visibleSets map[character.EntityID]map[character.EntityID]struct{}
 	broadcaster BroadcastFn
+
+	// 帧同步
+	tick      uint32                               // 当前帧号（客户端据此插值）
+	observers map[character.EntityID]*observerSync // 玩家观察者的快照同步状态
+	lastMoved map[character.EntityID]uint32        // 实体最近一次移动的帧号
+
+	// 刷怪
+	spawner  *spawner
+	spawnEnv SpawnEnvironment
+
+	// 死亡与掉落
+	deathHandler DeathHandler
+	deceased     map[character.EntityID]struct{} // 已触发死亡回调的角色
+	drops        map[character.EntityID]*DroppedItem
+
+	// 复活点与复活邀请回调
+	graveyards       []character.Vector3
+	resurrectHandler ResurrectHandler
+
+	// 阻挡视线的障碍物
+	obstacles []Obstacle
+
+	// 投射物与动态实体ID分配
+	missiles map[character.EntityID]*character.Missile
+	allocID  func() character.EntityID
+	localSeq character.EntityID
+
+	// PvP 规则与决斗
+	pvp *pvpRules
 }
 
 // NewMap 创建地图
@@ -35,9 +66,16 @@ func NewMap(id int32, name string, width, height int32) *Map {
 		width:       width,
 		height:      height,
 		entities:    make(map[character.EntityID]*character.Entity),
+		actors:      make(map[character.EntityID]*character.Actor),
 		aoiGrid:     NewAOIGrid(width, height, 100), // 100单位网格大小
 		viewRadius:  200,
 		visibleSets: make(map[character.EntityID]map[character.EntityID]struct{}),
+		observers:   make(map[character.EntityID]*observerSync),
+		lastMoved:   make(map[character.EntityID]uint32),
+		deceased:    make(map[character.EntityID]struct{}),
+		drops:       make(map[character.EntityID]*DroppedItem),
+		missiles:    make(map[character.EntityID]*character.Missile),
+		pvp:         newPvPRules(),
 	}
 }
 
@@ -67,6 +105,9 @@ func (m *Map) Enter(ctx context.Context, entity *character.Entity) error {
 
 	m.entities[entityID] = entity
 	entity.SetMap(m)
+	if entity.Type() == character.EntityTypePlayer {
+		m.observers[entityID] = newObserverSync()
+	}
 
 	// 添加到AOI网格
 	pos := entity.Position2D()
@@ -94,13 +135,90 @@ func (m *Map) Leave(ctx context.Context, entityID character.EntityID) error {
 	// 广播消失并清理可见集
 	m.broadcastDisappear(entityID)
 
+	if actor, ok := m.actors[entityID]; ok {
+		actor.SetEventPublisher(nil)
+		actor.SetTargetResolver(nil)
+		actor.SetMissileLauncher(nil)
+		actor.SetPvPJudge(nil)
+	}
 	delete(m.entities, entityID)
+	delete(m.actors, entityID)
+	delete(m.observers, entityID)
+	delete(m.lastMoved, entityID)
+	delete(m.deceased, entityID)
+	delete(m.drops, entityID)
+	delete(m.missiles, entityID)
 	entity.SetMap(nil)
+	return nil
+}
+
+// EnterActor 角色进入地图（除实体外同时登记其战斗属性，用于状态同步与战斗结算）
+func (m *Map) EnterActor(ctx context.Context, actor *character.Actor) error {
+	if actor == nil {
+		return fmt.Errorf("actor is nil")
+	}
+	if err := m.Enter(ctx, actor.Entity); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	m.actors[actor.ID()] = actor
+	m.mu.Unlock()
+	// 角色的战斗事件由地图转为 AOI 广播，范围技能目标由地图 AOI 解析，投射物由地图推进，PvP 按地图规则判定
+	actor.SetEventPublisher(m)
+	actor.SetTargetResolver(m)
+	actor.SetMissileLauncher(m)
+	actor.SetPvPJudge(m)
+	return nil
+}
+
+// GetActor 获取地图内的角色
+func (m *Map) GetActor(entityID character.EntityID) *character.Actor {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.actors[entityID]
+}
+
+// Update 地图帧更新：推进角色（技能/Buff）、投射物与决斗，处理死亡与掉落、推进刷怪、帧号并向观察者下发快照增量
+func (m *Map) Update(ctx context.Context, deltaTime float32) error {
+	m.updateActors(ctx, deltaTime)
+	m.updateMissiles(ctx, deltaTime)
+	m.updateDuels(ctx, deltaTime)
+	m.updateDeaths(ctx)
+	m.updateDrops(ctx, deltaTime)
+	m.updateSpawns(ctx, deltaTime)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.tick++
 
-	// TODO: 广播实体离开事件给周围玩家
+	// 上一帧之后未再移动的角色回到空闲动画
+	for id, actor := range m.actors {
+		if actor.AnimationState() == character.AnimationStateMove && m.lastMoved[id] < m.tick-1 {
+			actor.SetAnimationState(character.AnimationStateIdle)
+		}
+	}
+
+	m.syncSnapshots()
 	return nil
 }
 
+// updateActors 推进地图内角色的技能时序、Buff 与回复（不持有地图锁调用，技能效果会回调地图广播）
+func (m *Map) updateActors(ctx context.Context, deltaTime float32) {
+	m.mu.RLock()
+	actors := make([]*character.Actor, 0, len(m.actors))
+	for _, actor := range m.actors {
+		actors = append(actors, actor)
+	}
+	m.mu.RUnlock()
+
+	// 按实体ID顺序推进，保证同一帧内的结算顺序可复现
+	sort.Slice(actors, func(i, j int) bool { return actors[i].ID() < actors[j].ID() })
+	for _, actor := range actors {
+		_ = actor.Update(ctx, deltaTime)
+	}
+}
+
 // UpdatePosition 更新实体位置
 func (m *Map) UpdatePosition(entityID character.EntityID, newPos character.Vector3) error {
 	m.mu.Lock()
@@ -110,7 +228,21 @@ func (m *Map) UpdatePosition(entityID character.EntityID, newPos character.Vecto
 	if !exists {
 		return fmt.Errorf("entity not in map: %d", entityID)
 	}
+	// 死亡的角色不能移动，移动会打断吟唱与引导
+	if actor, ok := m.actors[entityID]; ok {
+		if actor.IsDeath() {
+			return ErrActorDead
+		}
+		if entity.Position() != newPos {
+			actor.GetSpell().Interrupt(character.CastCancelMove, 0)
+		}
+	}
+	m.moveLocked(entityID, entity, newPos)
+	return nil
+}
 
+// moveLocked 移动实体并刷新 AOI 与视野、广播移动（调用方持有锁）
+func (m *Map) moveLocked(entityID character.EntityID, entity *character.Entity, newPos character.Vector3) {
 	oldPos := entity.Position2D()
 	newPos2D := newPos.ToVector2()
 
@@ -119,11 +251,14 @@ func (m *Map) UpdatePosition(entityID character.EntityID, newPos character.Vecto
 
 	// 更新实体位置
 	entity.SetPosition(newPos)
+	m.lastMoved[entityID] = m.tick
+	if actor, ok := m.actors[entityID]; ok && actor.AnimationState() == character.AnimationStateIdle {
+		actor.SetAnimationState(character.AnimationStateMove)
+	}
 
 	// 刷新视野与广播移动
 	m.refreshVisibilityFor(entityID)
 	m.broadcastMove(entityID, newPos)
-	return nil
 }
 
 // GetEntitiesInRange 获取范围内的实体
@@ -150,6 +285,13 @@ func (m *Map) GetEntity(entityID character.EntityID) *character.Entity {
 	return m.entities[entityID]
 }
 
+// PlayerCount 获取地图内玩家数量
+func (m *Map) PlayerCount() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.observers)
+}
+
 // GetAllEntities 获取所有实体
 func (m *Map) GetAllEntities() []*character.Entity {
 	m.mu.RLock()
@@ -216,8 +358,19 @@ func (m *Map) refreshVisibilityFor(entityID character.EntityID) {
 		}
 	}
 
-	// 保存新集
+	// 保存新集，并同步维护对方的可见集（视野关系对称）
 	m.visibleSets[entityID] = newSet
+	for _, id := range appear {
+		set := m.visibleSets[id]
+		if set == nil {
+			set = make(map[character.EntityID]struct{})
+			m.visibleSets[id] = set
+		}
+		set[entityID] = struct{}{}
+	}
+	for _, id := range disappear {
+		delete(m.visibleSets[id], entityID)
+	}
 
 	// 广播给自身：别人出现/消失
 	if m.broadcaster != nil {
@@ -236,6 +389,10 @@ func (m *Map) refreshVisibilityFor(entityID character.EntityID) {
 		me := m.buildAppearPayload([]character.EntityID{entityID})
 		m.broadcaster(appear, "entity_appear", me)
 	}
+	if m.broadcaster != nil && len(disappear) > 0 {
+		me := m.buildDisappearPayload([]character.EntityID{entityID})
+		m.broadcaster(disappear, "entity_disappear", me)
+	}
 }
 
 // broadcastDisappear 在实体离开地图时通知其可见范围内的对象
@@ -281,7 +438,17 @@ func (m *Map) buildAppearPayload(ids []character.EntityID) []EntityAppear {
 	for _, id := range ids {
 		if e, ok := m.entities[id]; ok {
 			t := e.GetTransform()
-			res = append(res, EntityAppear{ID: id, Position: t.Position, Direction: t.Direction})
+			appear := EntityAppear{ID: id, Type: e.Type(), UnitID: e.UnitID(), Position: t.Position, Direction: t.Direction}
+			if drop, ok := m.drops[id]; ok {
+				appear.Drop = drop.info()
+			}
+			if missile, ok := m.missiles[id]; ok {
+				appear.Missile = &MissileInfo{CasterID: missile.CasterID(), SkillID: missile.SkillID(), TargetID: missile.TargetID(), Speed: missile.Speed()}
+			}
+			if actor, ok := m.actors[id]; ok {
+				appear.Equipment = actor.GetEquipmentManager().Appearance()
+			}
+			res = append(res, appear)
 		}
 	}
 	return res
@@ -319,8 +486,13 @@ func (m *Map) BroadcastTo(recipients []character.EntityID, topic string, payload
 // ===== 广播数据结构 =====
 type EntityAppear struct {
 	ID        character.EntityID
+	Type      character.EntityType
+	UnitID    int32
 	Position  character.Vector3
 	Direction character.Vector3
+	Drop      *DropInfo                     `json:",omitempty"` // 掉落物信息（仅掉落物实体）
+	Missile   *MissileInfo                  `json:",omitempty"` // 投射物信息（仅投射物实体）
+	Equipment map[character.EquipSlot]int32 `json:",omitempty"` // 装备外观（仅角色实体）
 }
 
 type EntityDisappear struct {
@@ -0,0 +1,166 @@
+package mapmanager
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	character "greatestworks/internal/domain/character"
+)
+
+// localEntityIDBase 未注入ID分配器时地图自行分配的实体ID起点
+const localEntityIDBase = character.EntityID(1 << 29)
+
+// MissileInfo 投射物下发信息（随实体出现消息一起下发，客户端据此预测飞行）
+type MissileInfo struct {
+	CasterID character.EntityID `json:"caster_id"`
+	SkillID  int32              `json:"skill_id"`
+	TargetID character.EntityID `json:"target_id,omitempty"`
+	Speed    float32            `json:"speed"`
+}
+
+// SetEntityIDAllocator 设置地图内动态生成实体（投射物等）的ID分配器
+func (m *Map) SetEntityIDAllocator(fn func() character.EntityID) {
+	m.mu.Lock()
+	m.allocID = fn
+	m.mu.Unlock()
+}
+
+// nextEntityIDLocked 分配动态实体ID（调用方持有锁）
+func (m *Map) nextEntityIDLocked() character.EntityID {
+	if m.allocID != nil {
+		return m.allocID()
+	}
+	m.localSeq++
+	return localEntityIDBase + m.localSeq
+}
+
+// LaunchMissile 实现 character.MissileLauncher：在施法者位置生成技能投射物并加入地图
+func (m *Map) LaunchMissile(caster *character.Actor, skill *character.Skill, target *character.Actor, point character.Vector3) (*character.Missile, error) {
+	m.mu.Lock()
+	missile := character.NewSkillMissile(m.nextEntityIDLocked(), caster, skill, target, point)
+	// 先登记投射物信息，使实体出现消息携带施法者与技能
+	m.missiles[missile.ID()] = missile
+	m.mu.Unlock()
+	if err := m.Enter(context.Background(), missile.Entity); err != nil {
+		m.mu.Lock()
+		delete(m.missiles, missile.ID())
+		m.mu.Unlock()
+		return nil, err
+	}
+	return missile, nil
+}
+
+// GetMissile 获取投射物
+func (m *Map) GetMissile(missileID character.EntityID) *character.Missile {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.missiles[missileID]
+}
+
+// updateMissiles 推进投射物飞行：检测障碍物与命中，命中或到达终点时结算技能效果并移除
+// （不持有地图锁调用命中结算，伤害事件会回调地图广播）
+func (m *Map) updateMissiles(ctx context.Context, deltaTime float32) {
+	m.mu.RLock()
+	missiles := make([]*character.Missile, 0, len(m.missiles))
+	for _, missile := range m.missiles {
+		missiles = append(missiles, missile)
+	}
+	m.mu.RUnlock()
+	sort.Slice(missiles, func(i, j int) bool { return missiles[i].ID() < missiles[j].ID() })
+
+	for _, missile := range missiles {
+		from := missile.Position()
+		to := missile.Advance(deltaTime)
+
+		m.mu.RLock()
+		blocked := !m.lineOfSightLocked(from, to)
+		hit := m.missileHitLocked(missile, from, to)
+		m.mu.RUnlock()
+
+		switch {
+		case hit != nil:
+			missile.SetPosition(to)
+			missile.Impact(hit)
+		case blocked:
+			missile.Invalidate()
+		case !missile.IsValid():
+			// 到达终点：范围技能在终点引爆
+			missile.SetPosition(to)
+			if missile.Detonates() {
+				missile.Impact(nil)
+			}
+		default:
+			_ = m.UpdatePosition(missile.ID(), to)
+			continue
+		}
+		m.removeMissile(ctx, missile.ID())
+	}
+}
+
+// missileHitLocked 计算本帧飞行线段上命中的角色（调用方持有锁）
+// 追踪投射物只命中追踪目标；直线投射物命中线段上最先接触的敌对角色（同位置按实体ID）
+func (m *Map) missileHitLocked(missile *character.Missile, from, to character.Vector3) *character.Actor {
+	radius := missile.HitRadius()
+	if homing := missile.Homing(); homing != nil {
+		if _, ok := m.actors[homing.ID()]; !ok || homing.IsDeath() {
+			missile.Invalidate()
+			return nil
+		}
+		if to.ToVector2().Distance(homing.Position().ToVector2()) <= radius {
+			return homing
+		}
+		return nil
+	}
+
+	caster := missile.Caster()
+	if caster == nil {
+		return nil
+	}
+	a, b := from.ToVector2(), to.ToVector2()
+	mid := a.Add(b).Mul(0.5)
+	var best *character.Actor
+	bestT := float32(math.MaxFloat32)
+	for _, id := range m.aoiGrid.GetNearby(mid.X, mid.Y, a.Distance(b)/2+radius) {
+		actor, ok := m.actors[id]
+		if !ok || actor.IsDeath() || !caster.IsHostileTo(actor) {
+			continue
+		}
+		t, dist := projectOnSegment(a, b, actor.Position().ToVector2())
+		if dist > radius {
+			continue
+		}
+		if best == nil || t < bestT || (t == bestT && actor.ID() < best.ID()) {
+			best, bestT = actor, t
+		}
+	}
+	return best
+}
+
+// projectOnSegment 点在线段上的投影参数 t∈[0,1] 及点到线段的距离
+func projectOnSegment(a, b, p character.Vector2) (float32, float32) {
+	ab := b.Sub(a)
+	lenSq := ab.X*ab.X + ab.Y*ab.Y
+	var t float32
+	if lenSq > 0 {
+		ap := p.Sub(a)
+		t = (ap.X*ab.X + ap.Y*ab.Y) / lenSq
+		if t < 0 {
+			t = 0
+		} else if t > 1 {
+			t = 1
+		}
+	}
+	return t, p.Distance(a.Add(ab.Mul(t)))
+}
+
+// removeMissile 移除投射物
+func (m *Map) removeMissile(ctx context.Context, missileID character.EntityID) {
+	m.mu.Lock()
+	_, ok := m.missiles[missileID]
+	delete(m.missiles, missileID)
+	m.mu.Unlock()
+	if ok {
+		_ = m.Leave(ctx, missileID)
+	}
+}
@@ -0,0 +1,136 @@
+package mapmanager
+
+import (
+	"context"
+	"testing"
+
+	character "greatestworks/internal/domain/character"
+)
+
+func addMonster(t *testing.T, m *Map, id character.EntityID, x, z float32) *character.Actor {
+	t.Helper()
+	a := character.NewActor(id, character.EntityTypeMonster, 2001, character.NewVector3(x, 0, z), character.NewVector3(1, 0, 0), "m", 1)
+	_ = a.Start(context.Background())
+	if err := m.EnterActor(context.Background(), a); err != nil {
+		t.Fatalf("enter: %v", err)
+	}
+	return a
+}
+
+func missileSkill(caster *character.Actor, id int32, cfg character.MissileConfig, area character.SkillArea) *character.Skill {
+	sk := character.NewSkill(id, caster)
+	sk.SetDamage(10, 0, 0, character.DamageTypePhysical)
+	sk.SetTimings(0, 0.1, 1)
+	sk.SetMissile(cfg)
+	sk.SetArea(area)
+	caster.GetSkillManager().AddSkill(sk)
+	return sk
+}
+
+func damaged(a *character.Actor) bool {
+	return a.HP() < a.GetAttributeManager().Final().MaxHP
+}
+
+func TestHomingMissileFliesAndHits(t *testing.T) {
+	ctx := context.Background()
+	m, got, caster, _ := newCombatTestMap(t)
+	target := addMonster(t, m, 10, 20, 10)
+	missileSkill(caster, 11, character.MissileConfig{Speed: 5, Range: 30, HitRadius: 0.5, Homing: true}, character.SkillArea{})
+
+	if !caster.GetSpell().Cast(11, target) {
+		t.Fatalf("cast rejected")
+	}
+	_ = m.Update(ctx, 0.1)
+	if len(m.missiles) != 1 {
+		t.Fatalf("expected missile launched, got %d", len(m.missiles))
+	}
+	var info *MissileInfo
+	for _, b := range *got {
+		if b.topic == "entity_appear" {
+			for _, a := range b.payload.([]EntityAppear) {
+				if a.Missile != nil {
+					info = a.Missile
+				}
+			}
+		}
+	}
+	if info == nil || info.CasterID != 1 || info.TargetID != 10 || info.SkillID != 11 {
+		t.Fatalf("expected missile info in appear payload, got %+v", info)
+	}
+	if damaged(target) {
+		t.Fatalf("missile should not hit instantly")
+	}
+
+	// 目标移动后仍被追踪命中
+	_ = m.UpdatePosition(10, character.NewVector3(20, 0, 14))
+	for i := 0; i < 30 && len(m.missiles) > 0; i++ {
+		_ = m.Update(ctx, 0.1)
+	}
+	if len(m.missiles) != 0 || !damaged(target) {
+		t.Fatalf("expected homing missile to hit and despawn")
+	}
+}
+
+func TestStraightMissileHitsFirstInPath(t *testing.T) {
+	ctx := context.Background()
+	m, _, caster, near := newCombatTestMap(t)
+	far := addMonster(t, m, 10, 16, 10)
+	missileSkill(caster, 12, character.MissileConfig{Speed: 40, Range: 20, HitRadius: 0.5}, character.SkillArea{})
+
+	if !caster.GetSpell().CastAt(12, nil, character.NewVector3(30, 0, 10)) {
+		t.Fatalf("cast rejected")
+	}
+	for i := 0; i < 10; i++ {
+		_ = m.Update(ctx, 0.1)
+	}
+	if !damaged(near) || damaged(far) {
+		t.Fatalf("expected only the nearest monster in path hit")
+	}
+	if len(m.missiles) != 0 {
+		t.Fatalf("missile should despawn after hit")
+	}
+}
+
+func TestMissileBlockedAndExpires(t *testing.T) {
+	ctx := context.Background()
+	m, _, caster, near := newCombatTestMap(t)
+	m.SetObstacles([]Obstacle{{MinX: 11, MinZ: 0, MaxX: 11.5, MaxZ: 20}})
+	missileSkill(caster, 12, character.MissileConfig{Speed: 10, Range: 20, HitRadius: 0.5}, character.SkillArea{})
+	_ = caster.GetSpell().CastAt(12, nil, character.NewVector3(30, 0, 10))
+	for i := 0; i < 10; i++ {
+		_ = m.Update(ctx, 0.1)
+	}
+	if damaged(near) || len(m.missiles) != 0 {
+		t.Fatalf("expected missile stopped by obstacle")
+	}
+
+	// 无阻挡且路径上无目标时按射程消失
+	m.SetObstacles(nil)
+	missileSkill(caster, 13, character.MissileConfig{Speed: 10, Range: 3, HitRadius: 0.5}, character.SkillArea{})
+	_ = caster.GetSpell().CastAt(13, nil, character.NewVector3(10, 0, 30))
+	for i := 0; i < 10; i++ {
+		_ = m.Update(ctx, 0.1)
+	}
+	if len(m.missiles) != 0 || m.GetEntity(localEntityIDBase+2) != nil {
+		t.Fatalf("expected missile expired after range")
+	}
+}
+
+func TestAreaMissileDetonatesAtPoint(t *testing.T) {
+	ctx := context.Background()
+	m, _, caster, near := newCombatTestMap(t)
+	a := addMonster(t, m, 10, 10, 20)
+	b := addMonster(t, m, 11, 11, 21)
+	missileSkill(caster, 15, character.MissileConfig{Speed: 20, Range: 30, HitRadius: 0.5},
+		character.SkillArea{Shape: character.AreaShapeCircleAtPoint, Radius: 3})
+
+	if !caster.GetSpell().CastAt(15, nil, character.NewVector3(10, 0, 20)) {
+		t.Fatalf("cast rejected")
+	}
+	for i := 0; i < 10; i++ {
+		_ = m.Update(ctx, 0.1)
+	}
+	if !damaged(a) || !damaged(b) || damaged(near) {
+		t.Fatalf("expected area missile to detonate at target point only")
+	}
+}
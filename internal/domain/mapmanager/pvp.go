@@ -0,0 +1,460 @@
+package mapmanager
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+
+	character "greatestworks/internal/domain/character"
+)
+
+// 决斗错误
+var (
+	ErrDuelNotFound    = errors.New("duel not found")
+	ErrAlreadyDueling  = errors.New("already in a duel")
+	ErrDuelInvalid     = errors.New("duel target must be another living player")
+	ErrDuelOutOfRange  = errors.New("duel target out of range")
+	ErrNotDuelTarget   = errors.New("only the challenged player can respond")
+	ErrDuelNotPending  = errors.New("duel is not pending")
+	ErrDuelistNotInMap = errors.New("player not in this map")
+)
+
+// PvPMode 区域 PvP 规则
+type PvPMode int32
+
+const (
+	PvPModeNormal  PvPMode = 0 // 普通：双方均开启 PvP 标记时可互相攻击
+	PvPModeSafe    PvPMode = 1 // 安全区：禁止 PvP（决斗除外）
+	PvPModeFree    PvPMode = 2 // 自由 PvP：所有玩家可互相攻击
+	PvPModeFaction PvPMode = 3 // 阵营战：不同阵营的玩家可互相攻击，同阵营按 PvP 标记
+)
+
+// PvPZone PvP 区域（XZ 平面矩形，覆盖地图默认规则，先配置的区域优先）
+type PvPZone struct {
+	ID   int32
+	Mode PvPMode
+	MinX float32
+	MinZ float32
+	MaxX float32
+	MaxZ float32
+}
+
+// contains 点是否在区域内
+func (z PvPZone) contains(p character.Vector3) bool {
+	return p.X >= z.MinX && p.X <= z.MaxX && p.Z >= z.MinZ && p.Z <= z.MaxZ
+}
+
+// 决斗参数
+const (
+	duelRequestRange     = float32(20)  // 发起决斗的最大距离
+	duelRequestTimeout   = float32(30)  // 邀请有效期（秒）
+	duelCountdown        = float32(3)   // 接受后的倒计时（秒）
+	duelRadius           = float32(30)  // 决斗边界半径（以接受时双方中点为圆心）
+	duelMaxDuration      = float32(180) // 决斗时长上限（秒），超时平局
+	duelOutOfBoundsGrace = float32(5)   // 离开边界超过该时间判负（秒）
+	duelDefeatHP         = float32(1)   // 生命降至该值及以下判负（决斗伤害不致死）
+)
+
+// DuelState 决斗阶段
+type DuelState int32
+
+const (
+	DuelStatePending   DuelState = 0 // 等待对方响应
+	DuelStateCountdown DuelState = 1 // 已接受，倒计时中
+	DuelStateActive    DuelState = 2 // 进行中
+	DuelStateEnded     DuelState = 3 // 已结束
+)
+
+// DuelEndReason 决斗结束原因
+type DuelEndReason int32
+
+const (
+	DuelEndNone        DuelEndReason = 0
+	DuelEndDefeat      DuelEndReason = 1 // 一方生命降至 1 或死亡
+	DuelEndForfeit     DuelEndReason = 2 // 认输或离开地图
+	DuelEndOutOfBounds DuelEndReason = 3 // 离开边界超时
+	DuelEndTimeout     DuelEndReason = 4 // 超时平局
+	DuelEndDeclined    DuelEndReason = 5 // 拒绝、取消或邀请过期
+)
+
+// Duel 决斗
+type Duel struct {
+	ID           int32
+	ChallengerID character.EntityID
+	TargetID     character.EntityID
+	Center       character.Vector3
+	Radius       float32
+	State        DuelState
+	WinnerID     character.EntityID // 0 表示平局或未开始
+	LoserID      character.EntityID
+	Reason       DuelEndReason
+
+	timer   float32                        // 当前阶段剩余时间
+	outside map[character.EntityID]float32 // 越界累计时间
+}
+
+// opponent 对手ID
+func (d *Duel) opponent(id character.EntityID) character.EntityID {
+	if id == d.ChallengerID {
+		return d.TargetID
+	}
+	return d.ChallengerID
+}
+
+// notice 构造下发信息（帧号由广播时填写，调用方持有 PvP 锁）
+func (d *Duel) notice() *DuelNotice {
+	n := &DuelNotice{
+		DuelID:       d.ID,
+		ChallengerID: d.ChallengerID,
+		TargetID:     d.TargetID,
+		State:        d.State,
+		WinnerID:     d.WinnerID,
+		Reason:       d.Reason,
+	}
+	if d.State != DuelStatePending {
+		n.Center, n.Radius = d.Center, d.Radius
+	}
+	if d.State == DuelStatePending || d.State == DuelStateCountdown {
+		n.Remain = d.timer
+	}
+	return n
+}
+
+// DuelNotice 决斗状态通知（duel_request/duel_start/duel_end）
+type DuelNotice struct {
+	DuelID       int32              `json:"duel_id"`
+	ChallengerID character.EntityID `json:"challenger_id"`
+	TargetID     character.EntityID `json:"target_id"`
+	State        DuelState          `json:"state"`
+	Center       character.Vector3  `json:"center"`
+	Radius       float32            `json:"radius,omitempty"`
+	Remain       float32            `json:"remain,omitempty"` // 邀请剩余时间或倒计时
+	WinnerID     character.EntityID `json:"winner_id,omitempty"`
+	Reason       DuelEndReason      `json:"reason,omitempty"`
+	Tick         uint32             `json:"tick"`
+}
+
+// PvPFlagNotice PvP 标记变化通知
+type PvPFlagNotice struct {
+	EntityID character.EntityID `json:"entity_id"`
+	Enabled  bool               `json:"enabled"`
+	Tick     uint32             `json:"tick"`
+}
+
+// DuelEndHandler 决斗结束回调（仅已开始的决斗，由应用层注入用于记录战绩）
+type DuelEndHandler func(ctx context.Context, m *Map, duel *Duel)
+
+// pvpRules 地图 PvP 规则与决斗（独立加锁：目标筛选在持有地图锁时回调判定，加锁顺序为地图锁在前）
+type pvpRules struct {
+	mu     sync.RWMutex
+	mode   PvPMode
+	zones  []PvPZone
+	duels  map[int32]*Duel
+	duelOf map[character.EntityID]*Duel
+	seq    int32
+	onEnd  DuelEndHandler
+}
+
+func newPvPRules() *pvpRules {
+	return &pvpRules{
+		duels:  make(map[int32]*Duel),
+		duelOf: make(map[character.EntityID]*Duel),
+	}
+}
+
+// modeAtLocked 某位置的 PvP 规则（调用方持有锁）
+func (r *pvpRules) modeAtLocked(pos character.Vector3) PvPMode {
+	for _, z := range r.zones {
+		if z.contains(pos) {
+			return z.Mode
+		}
+	}
+	return r.mode
+}
+
+// activeDuelLocked 两者之间进行中的决斗（调用方持有锁）
+func (r *pvpRules) activeDuelLocked(a, b character.EntityID) bool {
+	d, ok := r.duelOf[a]
+	return ok && d.State == DuelStateActive && d.opponent(a) == b
+}
+
+// SetPvPRules 设置地图默认 PvP 规则与区域
+func (m *Map) SetPvPRules(mode PvPMode, zones []PvPZone) {
+	m.pvp.mu.Lock()
+	m.pvp.mode = mode
+	m.pvp.zones = zones
+	m.pvp.mu.Unlock()
+}
+
+// PvPModeAt 某位置的 PvP 规则
+func (m *Map) PvPModeAt(pos character.Vector3) PvPMode {
+	m.pvp.mu.RLock()
+	defer m.pvp.mu.RUnlock()
+	return m.pvp.modeAtLocked(pos)
+}
+
+// SetDuelEndHandler 设置决斗结束回调
+func (m *Map) SetDuelEndHandler(fn DuelEndHandler) {
+	m.pvp.mu.Lock()
+	m.pvp.onEnd = fn
+	m.pvp.mu.Unlock()
+}
+
+// PvPAllowed 实现 character.PvPJudge：决斗双方可互相攻击；否则双方所在位置的规则都需允许
+func (m *Map) PvPAllowed(a, b *character.Actor) bool {
+	m.pvp.mu.RLock()
+	defer m.pvp.mu.RUnlock()
+	if m.pvp.activeDuelLocked(a.ID(), b.ID()) {
+		return true
+	}
+	return pvpAllowedIn(m.pvp.modeAtLocked(a.Position()), a, b) &&
+		pvpAllowedIn(m.pvp.modeAtLocked(b.Position()), a, b)
+}
+
+// Dueling 实现 character.PvPJudge：两者是否正在决斗
+func (m *Map) Dueling(a, b character.EntityID) bool {
+	m.pvp.mu.RLock()
+	defer m.pvp.mu.RUnlock()
+	return m.pvp.activeDuelLocked(a, b)
+}
+
+// pvpAllowedIn 某规则下两名玩家能否互相攻击
+func pvpAllowedIn(mode PvPMode, a, b *character.Actor) bool {
+	switch mode {
+	case PvPModeSafe:
+		return false
+	case PvPModeFree:
+		return true
+	case PvPModeFaction:
+		if fa, fb := a.Faction(), b.Faction(); fa != 0 && fb != 0 && fa != fb {
+			return true
+		}
+	}
+	return a.PvPFlag() && b.PvPFlag()
+}
+
+// GetDuel 获取玩家当前决斗的快照
+func (m *Map) GetDuel(entityID character.EntityID) (*DuelNotice, bool) {
+	m.pvp.mu.RLock()
+	defer m.pvp.mu.RUnlock()
+	d, ok := m.pvp.duelOf[entityID]
+	if !ok {
+		return nil, false
+	}
+	return d.notice(), true
+}
+
+// RequestDuel 向附近的玩家发起决斗邀请，返回决斗快照
+func (m *Map) RequestDuel(challengerID, targetID character.EntityID) (*DuelNotice, error) {
+	challenger, target := m.GetActor(challengerID), m.GetActor(targetID)
+	if challenger == nil {
+		return nil, ErrDuelistNotInMap
+	}
+	if target == nil || target == challenger || target.Type() != character.EntityTypePlayer || target.IsDeath() || challenger.IsDeath() {
+		return nil, ErrDuelInvalid
+	}
+	if challenger.DistanceTo(target.Entity) > duelRequestRange {
+		return nil, ErrDuelOutOfRange
+	}
+
+	m.pvp.mu.Lock()
+	if m.pvp.duelOf[challengerID] != nil || m.pvp.duelOf[targetID] != nil {
+		m.pvp.mu.Unlock()
+		return nil, ErrAlreadyDueling
+	}
+	m.pvp.seq++
+	d := &Duel{
+		ID:           m.pvp.seq,
+		ChallengerID: challengerID,
+		TargetID:     targetID,
+		State:        DuelStatePending,
+		timer:        duelRequestTimeout,
+		outside:      make(map[character.EntityID]float32),
+	}
+	m.pvp.duels[d.ID] = d
+	m.pvp.duelOf[challengerID] = d
+	m.pvp.duelOf[targetID] = d
+	notice := d.notice()
+	m.pvp.mu.Unlock()
+
+	m.broadcastDuel("duel_request", notice)
+	return notice, nil
+}
+
+// RespondDuel 被邀请者接受或拒绝决斗；接受后以双方中点为圆心划定边界并开始倒计时
+func (m *Map) RespondDuel(entityID character.EntityID, duelID int32, accept bool) (*DuelNotice, error) {
+	m.pvp.mu.RLock()
+	d, ok := m.pvp.duels[duelID]
+	m.pvp.mu.RUnlock()
+	if !ok {
+		return nil, ErrDuelNotFound
+	}
+	if d.TargetID != entityID {
+		return nil, ErrNotDuelTarget
+	}
+	challenger, target := m.GetActor(d.ChallengerID), m.GetActor(entityID)
+
+	m.pvp.mu.Lock()
+	if d.State != DuelStatePending {
+		m.pvp.mu.Unlock()
+		return nil, ErrDuelNotPending
+	}
+	topic := "duel_start"
+	if !accept || challenger == nil || target == nil {
+		topic = "duel_end"
+		m.endDuelLocked(d, 0, DuelEndDeclined)
+	} else {
+		a, b := challenger.Position(), target.Position()
+		d.Center = character.NewVector3((a.X+b.X)/2, (a.Y+b.Y)/2, (a.Z+b.Z)/2)
+		d.Radius = duelRadius
+		d.State, d.timer = DuelStateCountdown, duelCountdown
+	}
+	notice := d.notice()
+	m.pvp.mu.Unlock()
+
+	m.broadcastDuel(topic, notice)
+	return notice, nil
+}
+
+// ForfeitDuel 认输（进行中的决斗判负），尚未开始时取消决斗
+func (m *Map) ForfeitDuel(entityID character.EntityID) (*DuelNotice, error) {
+	m.pvp.mu.Lock()
+	d, ok := m.pvp.duelOf[entityID]
+	if !ok {
+		m.pvp.mu.Unlock()
+		return nil, ErrDuelNotFound
+	}
+	if d.State != DuelStateActive {
+		m.endDuelLocked(d, 0, DuelEndDeclined)
+	} else {
+		m.endDuelLocked(d, d.opponent(entityID), DuelEndForfeit)
+	}
+	handler, notice := m.pvp.onEnd, d.notice()
+	m.pvp.mu.Unlock()
+
+	m.broadcastDuel("duel_end", notice)
+	if handler != nil && d.Reason != DuelEndDeclined {
+		handler(context.Background(), m, d)
+	}
+	return notice, nil
+}
+
+// endDuelLocked 结束决斗并解除双方的决斗状态（调用方持有 PvP 锁）
+func (m *Map) endDuelLocked(d *Duel, winner character.EntityID, reason DuelEndReason) {
+	d.State, d.Reason, d.timer = DuelStateEnded, reason, 0
+	if winner != 0 {
+		d.WinnerID, d.LoserID = winner, d.opponent(winner)
+	}
+	delete(m.pvp.duels, d.ID)
+	if m.pvp.duelOf[d.ChallengerID] == d {
+		delete(m.pvp.duelOf, d.ChallengerID)
+	}
+	if m.pvp.duelOf[d.TargetID] == d {
+		delete(m.pvp.duelOf, d.TargetID)
+	}
+}
+
+// updateDuels 推进决斗：邀请过期、倒计时结束开始决斗，判定生命、越界、离开地图与超时
+func (m *Map) updateDuels(ctx context.Context, deltaTime float32) {
+	type change struct {
+		topic  string
+		duel   *Duel
+		notice *DuelNotice
+	}
+	var changes []change
+
+	m.mu.RLock()
+	m.pvp.mu.Lock()
+	ids := make([]int32, 0, len(m.pvp.duels))
+	for id := range m.pvp.duels {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		d := m.pvp.duels[id]
+		d.timer -= deltaTime
+		switch d.State {
+		case DuelStatePending:
+			if d.timer <= 0 {
+				m.endDuelLocked(d, 0, DuelEndDeclined)
+				changes = append(changes, change{"duel_end", d, d.notice()})
+			}
+		case DuelStateCountdown:
+			if d.timer <= 0 {
+				d.State, d.timer = DuelStateActive, duelMaxDuration
+				changes = append(changes, change{"duel_start", d, d.notice()})
+			}
+		case DuelStateActive:
+			if winner, reason := m.judgeDuelLocked(d, deltaTime); reason != DuelEndNone {
+				m.endDuelLocked(d, winner, reason)
+				changes = append(changes, change{"duel_end", d, d.notice()})
+			}
+		}
+	}
+	handler := m.pvp.onEnd
+	m.pvp.mu.Unlock()
+	m.mu.RUnlock()
+
+	for _, c := range changes {
+		m.broadcastDuel(c.topic, c.notice)
+		if handler != nil && c.topic == "duel_end" && c.duel.Reason != DuelEndDeclined {
+			handler(ctx, m, c.duel)
+		}
+	}
+}
+
+// judgeDuelLocked 判定进行中的决斗是否结束，返回胜者（0 为平局）与原因（调用方持有地图锁与 PvP 锁）
+func (m *Map) judgeDuelLocked(d *Duel, deltaTime float32) (character.EntityID, DuelEndReason) {
+	var lost [2]bool
+	reason := DuelEndNone
+	for i, id := range [2]character.EntityID{d.ChallengerID, d.TargetID} {
+		actor, ok := m.actors[id]
+		switch {
+		case !ok:
+			lost[i], reason = true, DuelEndForfeit
+		case actor.IsDeath() || actor.HP() <= duelDefeatHP:
+			lost[i], reason = true, DuelEndDefeat
+		case actor.Position().ToVector2().Distance(d.Center.ToVector2()) > d.Radius:
+			d.outside[id] += deltaTime
+			if d.outside[id] > duelOutOfBoundsGrace {
+				lost[i], reason = true, DuelEndOutOfBounds
+			}
+		default:
+			d.outside[id] = 0
+		}
+	}
+	switch {
+	case lost[0] && lost[1]:
+		return 0, reason
+	case lost[0]:
+		return d.TargetID, reason
+	case lost[1]:
+		return d.ChallengerID, reason
+	case d.timer <= 0:
+		return 0, DuelEndTimeout
+	}
+	return 0, DuelEndNone
+}
+
+// broadcastDuel 向决斗双方广播决斗状态；结束通知同时发给双方的 AOI 观察者
+func (m *Map) broadcastDuel(topic string, notice *DuelNotice) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.broadcaster == nil {
+		return
+	}
+	notice.Tick = m.tick
+	recipients := []character.EntityID{notice.ChallengerID, notice.TargetID}
+	if topic == "duel_end" {
+		seen := map[character.EntityID]struct{}{}
+		recipients = recipients[:0]
+		for _, id := range append(m.viewersLocked(notice.ChallengerID), m.viewersLocked(notice.TargetID)...) {
+			if _, dup := seen[id]; !dup {
+				seen[id] = struct{}{}
+				recipients = append(recipients, id)
+			}
+		}
+	}
+	m.broadcaster(recipients, topic, notice)
+}
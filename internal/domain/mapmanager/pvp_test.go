@@ -0,0 +1,143 @@
+package mapmanager
+
+import (
+	"context"
+	"testing"
+
+	character "greatestworks/internal/domain/character"
+)
+
+func newPvPTestMap(t *testing.T) (*Map, *[]capturedBroadcast, *character.Actor, *character.Actor) {
+	t.Helper()
+	m := NewMap(1, "pvp", 1000, 1000)
+	var got []capturedBroadcast
+	m.SetBroadcaster(func(recipients []character.EntityID, topic string, payload interface{}) {
+		got = append(got, capturedBroadcast{recipients: recipients, topic: topic, payload: payload})
+	})
+	var players []*character.Actor
+	for i, x := range []float32{10, 12} {
+		a := character.NewActor(character.EntityID(i+1), character.EntityTypePlayer, 1001, character.NewVector3(x, 0, 10), character.NewVector3(1, 0, 0), "player", 1)
+		a.GetAttributeManager().SetBase(character.Attributes{MaxHP: 100})
+		if err := a.Start(context.Background()); err != nil {
+			t.Fatalf("actor start: %v", err)
+		}
+		a.ChangeHP(100)
+		if err := m.EnterActor(context.Background(), a); err != nil {
+			t.Fatalf("enter: %v", err)
+		}
+		players = append(players, a)
+	}
+	return m, &got, players[0], players[1]
+}
+
+func TestPvPZoneRules(t *testing.T) {
+	m, _, a, b := newPvPTestMap(t)
+
+	if a.IsHostileTo(b) || !a.IsFriendlyTo(b) {
+		t.Fatalf("players should be friendly by default")
+	}
+	a.SetPvPFlag(true)
+	b.SetPvPFlag(true)
+	if !a.IsHostileTo(b) || a.IsFriendlyTo(b) {
+		t.Fatalf("flagged players in a normal zone should be hostile")
+	}
+
+	// 安全区覆盖默认规则（任意一方在安全区内即不可攻击）
+	m.SetPvPRules(PvPModeNormal, []PvPZone{{ID: 1, Mode: PvPModeSafe, MinX: 0, MinZ: 0, MaxX: 11, MaxZ: 20}})
+	if a.IsHostileTo(b) || b.IsHostileTo(a) {
+		t.Fatalf("safe zone should block pvp")
+	}
+
+	a.SetPvPFlag(false)
+	b.SetPvPFlag(false)
+	m.SetPvPRules(PvPModeFree, nil)
+	if !a.IsHostileTo(b) {
+		t.Fatalf("free pvp map should allow attacks without flags")
+	}
+
+	m.SetPvPRules(PvPModeFaction, nil)
+	a.SetFaction(1)
+	b.SetFaction(1)
+	if a.IsHostileTo(b) {
+		t.Fatalf("same faction should not be hostile in a faction zone")
+	}
+	b.SetFaction(2)
+	if !a.IsHostileTo(b) {
+		t.Fatalf("different factions should be hostile in a faction zone")
+	}
+}
+
+func TestDuelLifecycle(t *testing.T) {
+	ctx := context.Background()
+	m, got, a, b := newPvPTestMap(t)
+	m.SetPvPRules(PvPModeSafe, nil)
+	var ended []*Duel
+	m.SetDuelEndHandler(func(_ context.Context, _ *Map, d *Duel) { ended = append(ended, d) })
+
+	duel, err := m.RequestDuel(a.ID(), b.ID())
+	if err != nil {
+		t.Fatalf("request duel: %v", err)
+	}
+	if _, err := m.RequestDuel(b.ID(), a.ID()); err != ErrAlreadyDueling {
+		t.Fatalf("expected ErrAlreadyDueling, got %v", err)
+	}
+	if _, err := m.RespondDuel(a.ID(), duel.DuelID, true); err != ErrNotDuelTarget {
+		t.Fatalf("challenger must not accept own duel, got %v", err)
+	}
+	if _, err := m.RespondDuel(b.ID(), duel.DuelID, true); err != nil {
+		t.Fatalf("accept duel: %v", err)
+	}
+	// 倒计时期间不可攻击
+	if a.IsHostileTo(b) {
+		t.Fatalf("duelists should not be hostile during countdown")
+	}
+	_ = m.Update(ctx, duelCountdown)
+	if !a.IsHostileTo(b) || topicCount(*got, "duel_start") != 2 {
+		t.Fatalf("duel should start after countdown (even in a safe zone)")
+	}
+
+	// 决斗伤害不致死，生命降至 1 判负
+	_ = b.OnHurt(ctx, &character.DamageInfo{TargetID: b.ID(), AttackerInfo: character.AttackerInfo{AttackerID: a.ID()}, Amount: 500})
+	if b.IsDeath() || b.HP() != 1 {
+		t.Fatalf("duel damage should leave 1 hp, got %v", b.HP())
+	}
+	_ = m.Update(ctx, 0.1)
+	if len(ended) != 1 || ended[0].WinnerID != a.ID() || ended[0].LoserID != b.ID() || ended[0].Reason != DuelEndDefeat {
+		t.Fatalf("expected challenger to win by defeat, got %+v", ended)
+	}
+	if a.IsHostileTo(b) {
+		t.Fatalf("duel end should restore safe zone rules")
+	}
+	if _, ok := m.GetDuel(a.ID()); ok {
+		t.Fatalf("duel should be cleared")
+	}
+}
+
+func TestDuelOutOfBoundsAndExpiry(t *testing.T) {
+	ctx := context.Background()
+	m, _, a, b := newPvPTestMap(t)
+	var ended []*Duel
+	m.SetDuelEndHandler(func(_ context.Context, _ *Map, d *Duel) { ended = append(ended, d) })
+
+	// 邀请过期不记录战绩
+	if _, err := m.RequestDuel(a.ID(), b.ID()); err != nil {
+		t.Fatalf("request duel: %v", err)
+	}
+	_ = m.Update(ctx, duelRequestTimeout)
+	if _, ok := m.GetDuel(a.ID()); ok || len(ended) != 0 {
+		t.Fatalf("expired request should be cleared without a result")
+	}
+
+	duel, _ := m.RequestDuel(a.ID(), b.ID())
+	_, _ = m.RespondDuel(b.ID(), duel.DuelID, true)
+	_ = m.Update(ctx, duelCountdown)
+	_ = m.UpdatePosition(b.ID(), character.NewVector3(200, 0, 10))
+	_ = m.Update(ctx, duelOutOfBoundsGrace/2)
+	if len(ended) != 0 {
+		t.Fatalf("leaving bounds briefly should not end the duel")
+	}
+	_ = m.Update(ctx, duelOutOfBoundsGrace)
+	if len(ended) != 1 || ended[0].WinnerID != a.ID() || ended[0].Reason != DuelEndOutOfBounds {
+		t.Fatalf("expected out-of-bounds loss, got %+v", ended)
+	}
+}
@@ -0,0 +1,95 @@
+package mapmanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	character "greatestworks/internal/domain/character"
+)
+
+// 死亡与复活错误
+var (
+	ErrActorDead  = errors.New("actor is dead")
+	ErrActorAlive = errors.New("actor is alive")
+)
+
+// ResurrectHandler 复活邀请回调（复活技能命中地图内死亡的友方时触发，由应用层注入）
+type ResurrectHandler func(ctx context.Context, m *Map, caster, target *character.Actor, hpRatio float32)
+
+// EntityReviveNotice 复活通知
+type EntityReviveNotice struct {
+	EntityID character.EntityID `json:"entity_id"`
+	Position character.Vector3  `json:"position"`
+	HP       float32            `json:"hp"`
+	Tick     uint32             `json:"tick"`
+}
+
+// SetGraveyards 设置复活点
+func (m *Map) SetGraveyards(points []character.Vector3) {
+	m.mu.Lock()
+	m.graveyards = points
+	m.mu.Unlock()
+}
+
+// NearestGraveyard 距给定位置最近的复活点（XZ 平面）；未配置复活点时返回 false
+func (m *Map) NearestGraveyard(pos character.Vector3) (character.Vector3, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if len(m.graveyards) == 0 {
+		return character.Vector3{}, false
+	}
+	best := m.graveyards[0]
+	bestDist := pos.ToVector2().Distance(best.ToVector2())
+	for _, p := range m.graveyards[1:] {
+		if d := pos.ToVector2().Distance(p.ToVector2()); d < bestDist {
+			best, bestDist = p, d
+		}
+	}
+	return best, true
+}
+
+// SetResurrectHandler 设置复活邀请回调
+func (m *Map) SetResurrectHandler(fn ResurrectHandler) {
+	m.mu.Lock()
+	m.resurrectHandler = fn
+	m.mu.Unlock()
+}
+
+// offerResurrect 转发复活邀请（不持有地图锁调用回调）
+func (m *Map) offerResurrect(evt *character.ResurrectOfferedEvent) {
+	m.mu.RLock()
+	handler := m.resurrectHandler
+	caster, casterOK := m.actors[evt.CasterID]
+	target, targetOK := m.actors[evt.TargetID]
+	m.mu.RUnlock()
+	if handler == nil || !casterOK || !targetOK {
+		return
+	}
+	handler(context.Background(), m, caster, target, evt.HPRatio)
+}
+
+// Revive 按比例恢复生命与魔法复活死亡的角色并移动到指定位置，向观察者广播复活
+func (m *Map) Revive(ctx context.Context, actorID character.EntityID, position character.Vector3, hpRatio, mpRatio float32) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	actor, ok := m.actors[actorID]
+	if !ok {
+		return fmt.Errorf("actor not in map: %d", actorID)
+	}
+	if !actor.IsDeath() {
+		return ErrActorAlive
+	}
+	actor.ReviveWith(hpRatio, mpRatio)
+	delete(m.deceased, actorID)
+	if position != actor.Position() {
+		m.moveLocked(actorID, actor.Entity, position)
+	}
+
+	if m.broadcaster != nil {
+		notice := &EntityReviveNotice{EntityID: actorID, Position: position, HP: actor.HP(), Tick: m.tick}
+		m.broadcaster(m.viewersLocked(actorID), "entity_revive", notice)
+	}
+	return nil
+}
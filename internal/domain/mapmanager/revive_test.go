@@ -0,0 +1,110 @@
+package mapmanager
+
+import (
+	"context"
+	"testing"
+
+	character "greatestworks/internal/domain/character"
+)
+
+func newReviveTestMap(t *testing.T) (*Map, *[]capturedBroadcast, *character.Actor, *character.Actor) {
+	t.Helper()
+	m := NewMap(1, "test", 1000, 1000)
+	var got []capturedBroadcast
+	m.SetBroadcaster(func(recipients []character.EntityID, topic string, payload interface{}) {
+		got = append(got, capturedBroadcast{recipients: recipients, topic: topic, payload: payload})
+	})
+	healer := character.NewActor(1, character.EntityTypePlayer, 1002, character.NewVector3(10, 0, 10), character.NewVector3(1, 0, 0), "healer", 1)
+	fallen := character.NewActor(2, character.EntityTypePlayer, 1001, character.NewVector3(12, 0, 10), character.NewVector3(1, 0, 0), "fallen", 1)
+	for _, a := range []*character.Actor{healer, fallen} {
+		if err := a.Start(context.Background()); err != nil {
+			t.Fatalf("actor start: %v", err)
+		}
+		if err := m.EnterActor(context.Background(), a); err != nil {
+			t.Fatalf("enter: %v", err)
+		}
+	}
+	fallen.ChangeHP(-fallen.HP())
+	_ = m.Update(context.Background(), 0.05)
+	if topicCount(got, "entity_death") != 1 {
+		t.Fatalf("expected entity_death broadcast")
+	}
+	return m, &got, healer, fallen
+}
+
+func TestDeadActorCannotMoveOrPickup(t *testing.T) {
+	m, _, _, fallen := newReviveTestMap(t)
+	if err := m.UpdatePosition(fallen.ID(), character.NewVector3(20, 0, 10)); err != ErrActorDead {
+		t.Fatalf("expected dead actor move rejected, got %v", err)
+	}
+	if fallen.Position().X != 12 {
+		t.Fatalf("dead actor moved to %v", fallen.Position())
+	}
+	if _, err := m.DropItem(context.Background(), 100, 30001, 1, character.NewVector3(12, 0, 11), nil, 0, 60); err != nil {
+		t.Fatalf("drop: %v", err)
+	}
+	if _, err := m.ClaimDrop(fallen.ID(), 100, 3); err != ErrActorDead {
+		t.Fatalf("expected dead actor pickup rejected, got %v", err)
+	}
+}
+
+func TestReviveAtNearestGraveyard(t *testing.T) {
+	ctx := context.Background()
+	m, got, healer, fallen := newReviveTestMap(t)
+	if _, ok := m.NearestGraveyard(fallen.Position()); ok {
+		t.Fatalf("expected no graveyard configured")
+	}
+	m.SetGraveyards([]character.Vector3{character.NewVector3(500, 0, 500), character.NewVector3(40, 0, 10)})
+	pos, ok := m.NearestGraveyard(fallen.Position())
+	if !ok || pos.X != 40 {
+		t.Fatalf("unexpected nearest graveyard %v", pos)
+	}
+
+	if err := m.Revive(ctx, healer.ID(), pos, 0.5, 0.5); err != ErrActorAlive {
+		t.Fatalf("expected alive actor revive rejected, got %v", err)
+	}
+	if err := m.Revive(ctx, fallen.ID(), pos, 0.5, 0.5); err != nil {
+		t.Fatalf("revive: %v", err)
+	}
+	maxHP := fallen.GetAttributeManager().Final().MaxHP
+	if fallen.IsDeath() || fallen.HP() != maxHP*0.5 || fallen.Position() != pos {
+		t.Fatalf("unexpected revived state hp=%v pos=%v", fallen.HP(), fallen.Position())
+	}
+	if topicCount(*got, "entity_revive") != 1 {
+		t.Fatalf("expected entity_revive broadcast")
+	}
+
+	// 复活后再次死亡会重新触发死亡广播
+	fallen.ChangeHP(-fallen.HP())
+	_ = m.Update(ctx, 0.05)
+	if topicCount(*got, "entity_death") != 2 {
+		t.Fatalf("expected second entity_death broadcast")
+	}
+}
+
+func TestResurrectSkillOffersToDeadAlly(t *testing.T) {
+	ctx := context.Background()
+	m, _, healer, fallen := newReviveTestMap(t)
+	var offered *character.Actor
+	var ratio float32
+	m.SetResurrectHandler(func(ctx context.Context, m *Map, caster, target *character.Actor, hpRatio float32) {
+		if caster == healer {
+			offered, ratio = target, hpRatio
+		}
+	})
+
+	sk := character.NewSkill(23, healer)
+	sk.SetTimings(0.1, 0.1, 1)
+	sk.SetResurrect(0.3)
+	healer.GetSkillManager().AddSkill(sk)
+	if !healer.GetSpell().Cast(23, fallen) {
+		t.Fatalf("cast rejected")
+	}
+	_ = m.Update(ctx, 0.15)
+	if offered != fallen || ratio != 0.3 {
+		t.Fatalf("expected resurrect offer to fallen ally, got %v %v", offered, ratio)
+	}
+	if !fallen.IsDeath() {
+		t.Fatalf("offer must not revive before acceptance")
+	}
+}
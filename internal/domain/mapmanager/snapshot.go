@@ -0,0 +1,308 @@
+package mapmanager
+
+import (
+	"sort"
+
+	character "greatestworks/internal/domain/character"
+)
+
+// 快照同步参数
+const (
+	// snapshotHistorySize 每个观察者保留的未确认快照数量，超出后强制全量重同步
+	snapshotHistorySize = 32
+)
+
+// 实体状态字段掩码（增量中标识发生变化的字段）
+const (
+	FieldPosition  uint32 = 1 << 0 // 位置
+	FieldDirection uint32 = 1 << 1 // 朝向
+	FieldHP        uint32 = 1 << 2 // 生命值
+	FieldMP        uint32 = 1 << 3 // 魔法值
+	FieldAnimation uint32 = 1 << 4 // 动画状态
+	FieldBuffs     uint32 = 1 << 5 // Buff列表
+	FieldType      uint32 = 1 << 6 // 实体类型（仅在首次出现时下发）
+
+	FieldAll = FieldPosition | FieldDirection | FieldHP | FieldMP | FieldAnimation | FieldBuffs | FieldType
+)
+
+// EntityState 实体在某一帧的可同步状态
+type EntityState struct {
+	ID        character.EntityID
+	Type      character.EntityType
+	Position  character.Vector3
+	Direction character.Vector3
+	HP        float32
+	MaxHP     float32
+	MP        float32
+	MaxMP     float32
+	Animation character.AnimationState
+	Buffs     []int32
+}
+
+// diff 计算相对于旧状态发生变化的字段掩码
+func (s *EntityState) diff(old *EntityState) uint32 {
+	if old == nil {
+		return FieldAll
+	}
+	var mask uint32
+	if s.Position != old.Position {
+		mask |= FieldPosition
+	}
+	if s.Direction != old.Direction {
+		mask |= FieldDirection
+	}
+	if s.HP != old.HP || s.MaxHP != old.MaxHP {
+		mask |= FieldHP
+	}
+	if s.MP != old.MP || s.MaxMP != old.MaxMP {
+		mask |= FieldMP
+	}
+	if s.Animation != old.Animation {
+		mask |= FieldAnimation
+	}
+	if !equalBuffs(s.Buffs, old.Buffs) {
+		mask |= FieldBuffs
+	}
+	return mask
+}
+
+func equalBuffs(a, b []int32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Snapshot 某一观察者在某一帧看到的实体状态集合
+type Snapshot struct {
+	Tick     uint32
+	Entities map[character.EntityID]*EntityState
+}
+
+// EntityDelta 单个实体的增量状态，仅 Mask 中标记的字段有效
+type EntityDelta struct {
+	ID        character.EntityID
+	Mask      uint32
+	Type      character.EntityType
+	Position  character.Vector3
+	Direction character.Vector3
+	HP        float32
+	MaxHP     float32
+	MP        float32
+	MaxMP     float32
+	Animation character.AnimationState
+	Buffs     []int32
+}
+
+// SnapshotDelta 下发给观察者的快照增量
+// BaseTick 为增量所基于的已确认帧；Full 为 true 时表示全量快照，客户端应丢弃本地状态
+type SnapshotDelta struct {
+	Tick     uint32
+	BaseTick uint32
+	Full     bool
+	Entities []EntityDelta
+	Removed  []character.EntityID
+}
+
+// observerSync 观察者的快照同步状态
+type observerSync struct {
+	base     *Snapshot            // 最近一次被确认的快照
+	history  map[uint32]*Snapshot // 已发送但尚未确认的快照
+	needFull bool                 // 下一帧需要发送全量快照
+}
+
+func newObserverSync() *observerSync {
+	return &observerSync{
+		history:  make(map[uint32]*Snapshot),
+		needFull: true,
+	}
+}
+
+// AckSnapshot 观察者确认已收到某帧快照，后续增量将基于该帧计算
+func (m *Map) AckSnapshot(observerID character.EntityID, tick uint32) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	obs, ok := m.observers[observerID]
+	if !ok {
+		return
+	}
+	snap, ok := obs.history[tick]
+	if !ok {
+		// 确认的帧已不在历史中（过旧或未知），无法作为基准
+		return
+	}
+	if obs.base != nil && obs.base.Tick >= tick {
+		return
+	}
+	obs.base = snap
+	for t := range obs.history {
+		if t <= tick {
+			delete(obs.history, t)
+		}
+	}
+}
+
+// RequestFullSnapshot 要求下一帧向观察者发送全量快照（丢包或重连时使用）
+func (m *Map) RequestFullSnapshot(observerID character.EntityID) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if obs, ok := m.observers[observerID]; ok {
+		obs.needFull = true
+	}
+}
+
+// CurrentTick 获取地图当前帧号
+func (m *Map) CurrentTick() uint32 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.tick
+}
+
+// syncSnapshots 为所有观察者计算并下发本帧的快照增量（调用方持有写锁）
+func (m *Map) syncSnapshots() {
+	if len(m.observers) == 0 {
+		return
+	}
+
+	// 本帧所有实体状态只采集一次，供各观察者共享
+	states := make(map[character.EntityID]*EntityState, len(m.entities))
+	for id := range m.entities {
+		states[id] = m.captureState(id)
+	}
+
+	observerIDs := make([]character.EntityID, 0, len(m.observers))
+	for id := range m.observers {
+		observerIDs = append(observerIDs, id)
+	}
+	sort.Slice(observerIDs, func(i, j int) bool { return observerIDs[i] < observerIDs[j] })
+
+	for _, observerID := range observerIDs {
+		obs := m.observers[observerID]
+		snap := m.buildSnapshot(observerID, states)
+		delta := buildDelta(obs, snap)
+
+		obs.history[snap.Tick] = snap
+		if len(obs.history) > snapshotHistorySize {
+			// 客户端长期未确认，视为丢包：清空历史并在下一帧全量重同步
+			obs.history = make(map[uint32]*Snapshot)
+			obs.base = nil
+			obs.needFull = true
+		}
+
+		if m.broadcaster != nil && (delta.Full || len(delta.Entities) > 0 || len(delta.Removed) > 0) {
+			m.broadcaster([]character.EntityID{observerID}, "entity_snapshot", delta)
+		}
+	}
+}
+
+// buildSnapshot 基于观察者可见集构建本帧快照（包含观察者自身）
+func (m *Map) buildSnapshot(observerID character.EntityID, states map[character.EntityID]*EntityState) *Snapshot {
+	snap := &Snapshot{Tick: m.tick, Entities: make(map[character.EntityID]*EntityState)}
+	if st, ok := states[observerID]; ok {
+		snap.Entities[observerID] = st
+	}
+	for id := range m.visibleSets[observerID] {
+		if st, ok := states[id]; ok {
+			snap.Entities[id] = st
+		}
+	}
+	return snap
+}
+
+// buildDelta 计算快照相对于观察者已确认基准的增量
+func buildDelta(obs *observerSync, snap *Snapshot) *SnapshotDelta {
+	delta := &SnapshotDelta{Tick: snap.Tick}
+
+	var base *Snapshot
+	if obs.needFull || obs.base == nil {
+		delta.Full = true
+		obs.needFull = false
+		obs.base = nil
+	} else {
+		base = obs.base
+		delta.BaseTick = base.Tick
+	}
+
+	ids := make([]character.EntityID, 0, len(snap.Entities))
+	for id := range snap.Entities {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	for _, id := range ids {
+		st := snap.Entities[id]
+		var old *EntityState
+		if base != nil {
+			old = base.Entities[id]
+		}
+		mask := st.diff(old)
+		if mask == 0 {
+			continue
+		}
+		delta.Entities = append(delta.Entities, newEntityDelta(st, mask))
+	}
+
+	if base != nil {
+		for id := range base.Entities {
+			if _, ok := snap.Entities[id]; !ok {
+				delta.Removed = append(delta.Removed, id)
+			}
+		}
+		sort.Slice(delta.Removed, func(i, j int) bool { return delta.Removed[i] < delta.Removed[j] })
+	}
+	return delta
+}
+
+// newEntityDelta 按掩码提取需要下发的字段
+func newEntityDelta(st *EntityState, mask uint32) EntityDelta {
+	d := EntityDelta{ID: st.ID, Mask: mask}
+	if mask&FieldType != 0 {
+		d.Type = st.Type
+	}
+	if mask&FieldPosition != 0 {
+		d.Position = st.Position
+	}
+	if mask&FieldDirection != 0 {
+		d.Direction = st.Direction
+	}
+	if mask&FieldHP != 0 {
+		d.HP, d.MaxHP = st.HP, st.MaxHP
+	}
+	if mask&FieldMP != 0 {
+		d.MP, d.MaxMP = st.MP, st.MaxMP
+	}
+	if mask&FieldAnimation != 0 {
+		d.Animation = st.Animation
+	}
+	if mask&FieldBuffs != 0 {
+		d.Buffs = st.Buffs
+	}
+	return d
+}
+
+// captureState 采集实体当前状态（调用方持有锁）
+func (m *Map) captureState(id character.EntityID) *EntityState {
+	e := m.entities[id]
+	t := e.GetTransform()
+	st := &EntityState{
+		ID:        id,
+		Type:      e.Type(),
+		Position:  t.Position,
+		Direction: t.Direction,
+	}
+	if actor, ok := m.actors[id]; ok {
+		fin := actor.GetAttributeManager().Final()
+		st.HP, st.MaxHP = actor.HP(), fin.MaxHP
+		st.MP, st.MaxMP = actor.MP(), fin.MaxMP
+		st.Animation = actor.AnimationState()
+		st.Buffs = actor.GetBuffManager().BuffIDs()
+	}
+	return st
+}
@@ -0,0 +1,88 @@
+package mapmanager
+
+import (
+	"context"
+	"testing"
+
+	character "greatestworks/internal/domain/character"
+)
+
+type capturedSnapshot struct {
+	recipient character.EntityID
+	delta     *SnapshotDelta
+}
+
+func newSnapshotTestMap(t *testing.T) (*Map, *[]capturedSnapshot, *character.Actor, *character.Actor) {
+	t.Helper()
+	m := NewMap(1, "test", 1000, 1000)
+	var got []capturedSnapshot
+	m.SetBroadcaster(func(recipients []character.EntityID, topic string, payload interface{}) {
+		if topic != "entity_snapshot" {
+			return
+		}
+		got = append(got, capturedSnapshot{recipient: recipients[0], delta: payload.(*SnapshotDelta)})
+	})
+
+	a := character.NewActor(1, character.EntityTypePlayer, 1, character.NewVector3(10, 0, 10), character.NewVector3(1, 0, 0), "a", 1)
+	b := character.NewActor(2, character.EntityTypePlayer, 1, character.NewVector3(20, 0, 20), character.NewVector3(1, 0, 0), "b", 1)
+	for _, actor := range []*character.Actor{a, b} {
+		if err := actor.Start(context.Background()); err != nil {
+			t.Fatalf("actor start: %v", err)
+		}
+		if err := m.EnterActor(context.Background(), actor); err != nil {
+			t.Fatalf("enter: %v", err)
+		}
+	}
+	return m, &got, a, b
+}
+
+func lastFor(got []capturedSnapshot, id character.EntityID) *SnapshotDelta {
+	for i := len(got) - 1; i >= 0; i-- {
+		if got[i].recipient == id {
+			return got[i].delta
+		}
+	}
+	return nil
+}
+
+func TestSnapshotFullThenDeltaAfterAck(t *testing.T) {
+	m, got, _, b := newSnapshotTestMap(t)
+
+	_ = m.Update(context.Background(), 0.05)
+	first := lastFor(*got, 1)
+	if first == nil || !first.Full || len(first.Entities) != 2 {
+		t.Fatalf("expected full snapshot with 2 entities, got %+v", first)
+	}
+
+	m.AckSnapshot(1, first.Tick)
+	b.ChangeHP(-10)
+	_ = m.Update(context.Background(), 0.05)
+
+	delta := lastFor(*got, 1)
+	if delta.Full || delta.BaseTick != first.Tick {
+		t.Fatalf("expected delta against tick %d, got %+v", first.Tick, delta)
+	}
+	if len(delta.Entities) != 1 || delta.Entities[0].ID != 2 || delta.Entities[0].Mask != FieldHP {
+		t.Fatalf("expected only HP change for entity 2, got %+v", delta.Entities)
+	}
+}
+
+func TestSnapshotRemovedAndResync(t *testing.T) {
+	m, got, _, _ := newSnapshotTestMap(t)
+
+	_ = m.Update(context.Background(), 0.05)
+	m.AckSnapshot(1, lastFor(*got, 1).Tick)
+
+	_ = m.Leave(context.Background(), 2)
+	_ = m.Update(context.Background(), 0.05)
+	delta := lastFor(*got, 1)
+	if len(delta.Removed) != 1 || delta.Removed[0] != 2 {
+		t.Fatalf("expected entity 2 removed, got %+v", delta)
+	}
+
+	m.RequestFullSnapshot(1)
+	_ = m.Update(context.Background(), 0.05)
+	if full := lastFor(*got, 1); !full.Full {
+		t.Fatalf("expected full snapshot after resync request, got %+v", full)
+	}
+}
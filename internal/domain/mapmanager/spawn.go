@@ -0,0 +1,251 @@
+package mapmanager
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	character "greatestworks/internal/domain/character"
+)
+
+// SpawnFactory 根据单位ID在指定位置创建角色（由应用层注入，负责分配实体ID与初始化属性）
+type SpawnFactory func(unitID int32, position character.Vector3) *character.Actor
+
+// SpawnEnvironment 刷怪条件所需的世界环境
+type SpawnEnvironment interface {
+	Hour() int       // 当前游戏内小时（0-23）
+	Weather() string // 当前天气
+}
+
+// SpawnWave 波次：上一波清空后等待 Delay 秒刷出 Count 个
+type SpawnWave struct {
+	Delay float32
+	Count int32
+}
+
+// SpawnRegion 刷怪区域（XZ 平面矩形内随机位置）
+type SpawnRegion struct {
+	ID          int32
+	UnitID      int32
+	MinX, MinZ  float32
+	MaxX, MaxZ  float32
+	Y           float32
+	MaxCount    int32   // 同时存活上限（波次模式下不使用）
+	RespawnTime float32 // 死亡后重生时间（秒）
+
+	// 条件：StartHour == EndHour 表示全天；StartHour > EndHour 表示跨零点
+	StartHour, EndHour int
+	Weathers           []string // 为空表示任意天气
+
+	// 波次：非空时按波次刷出，不再按 MaxCount 补充
+	Waves     []SpawnWave
+	LoopWaves bool
+}
+
+// active 当前环境是否满足区域条件
+func (r *SpawnRegion) active(env SpawnEnvironment) bool {
+	if r.StartHour != r.EndHour {
+		hour := time.Now().Hour()
+		if env != nil {
+			hour = env.Hour()
+		}
+		if r.StartHour < r.EndHour {
+			if hour < r.StartHour || hour >= r.EndHour {
+				return false
+			}
+		} else if hour < r.StartHour && hour >= r.EndHour {
+			return false
+		}
+	}
+	if len(r.Weathers) > 0 {
+		if env == nil {
+			return false
+		}
+		weather := env.Weather()
+		for _, w := range r.Weathers {
+			if w == weather {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// regionState 区域运行时状态
+type regionState struct {
+	region   *SpawnRegion
+	alive    map[character.EntityID]*character.Actor
+	respawns []float32 // 待重生计时器
+	wave     int       // 下一波次序号
+	waveWait float32   // 距下一波的剩余等待
+	filled   bool      // 非波次区域是否已完成首次填充
+}
+
+// spawner 地图刷怪器
+type spawner struct {
+	mu      sync.Mutex
+	factory SpawnFactory
+	rng     *rand.Rand
+	regions []*regionState
+}
+
+// SetSpawnRegions 配置地图刷怪区域与工厂；刷怪在 Map.Update 中推进
+func (m *Map) SetSpawnRegions(regions []*SpawnRegion, factory SpawnFactory) {
+	sp := &spawner{
+		factory: factory,
+		rng:     rand.New(rand.NewSource(time.Now().UnixNano() + int64(m.id))),
+	}
+	for _, r := range regions {
+		st := &regionState{region: r, alive: make(map[character.EntityID]*character.Actor)}
+		if len(r.Waves) > 0 {
+			st.waveWait = r.Waves[0].Delay
+		}
+		sp.regions = append(sp.regions, st)
+	}
+
+	m.mu.Lock()
+	m.spawner = sp
+	m.mu.Unlock()
+}
+
+// SetSpawnEnvironment 设置刷怪条件使用的世界环境（时间、天气）
+func (m *Map) SetSpawnEnvironment(env SpawnEnvironment) {
+	m.mu.Lock()
+	m.spawnEnv = env
+	m.mu.Unlock()
+}
+
+// SpawnedCount 获取某刷怪区域当前存活数量
+func (m *Map) SpawnedCount(regionID int32) int {
+	m.mu.RLock()
+	sp := m.spawner
+	m.mu.RUnlock()
+	if sp == nil {
+		return 0
+	}
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	for _, st := range sp.regions {
+		if st.region.ID == regionID {
+			return len(st.alive)
+		}
+	}
+	return 0
+}
+
+// updateSpawns 推进刷怪：回收死亡/离开的实体、处理重生计时与波次、按条件补充或清除（不持有地图锁调用）
+func (m *Map) updateSpawns(ctx context.Context, deltaTime float32) {
+	m.mu.RLock()
+	sp, env := m.spawner, m.spawnEnv
+	m.mu.RUnlock()
+	if sp == nil || sp.factory == nil {
+		return
+	}
+
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+
+	for _, st := range sp.regions {
+		r := st.region
+
+		// 回收死亡或已被移出地图的实体
+		for id, actor := range st.alive {
+			if actor.IsDeath() || m.GetEntity(id) == nil {
+				_ = m.Leave(ctx, id)
+				delete(st.alive, id)
+				if len(r.Waves) == 0 {
+					st.respawns = append(st.respawns, r.RespawnTime)
+				}
+			}
+		}
+
+		// 条件不满足：清除存活实体并重置
+		if !r.active(env) {
+			for id := range st.alive {
+				_ = m.Leave(ctx, id)
+			}
+			st.alive = make(map[character.EntityID]*character.Actor)
+			st.respawns = nil
+			st.filled = false
+			st.wave = 0
+			if len(r.Waves) > 0 {
+				st.waveWait = r.Waves[0].Delay
+			}
+			continue
+		}
+
+		if len(r.Waves) > 0 {
+			sp.updateWaves(ctx, m, st, deltaTime)
+			continue
+		}
+
+		// 首次（或条件恢复后）直接填满
+		if !st.filled {
+			for int32(len(st.alive)) < r.MaxCount {
+				if !sp.spawnOne(ctx, m, st) {
+					break
+				}
+			}
+			st.filled = true
+			continue
+		}
+
+		// 重生计时
+		remaining := st.respawns[:0]
+		for _, t := range st.respawns {
+			t -= deltaTime
+			if t <= 0 && int32(len(st.alive)) < r.MaxCount {
+				if sp.spawnOne(ctx, m, st) {
+					continue
+				}
+				t = 0
+			}
+			remaining = append(remaining, t)
+		}
+		st.respawns = remaining
+	}
+}
+
+// updateWaves 波次推进：当前波清空后计时，到时刷出下一波
+func (sp *spawner) updateWaves(ctx context.Context, m *Map, st *regionState, deltaTime float32) {
+	r := st.region
+	if len(st.alive) > 0 {
+		return
+	}
+	if st.wave >= len(r.Waves) {
+		if !r.LoopWaves {
+			return
+		}
+		st.wave = 0
+		st.waveWait = r.Waves[0].Delay
+	}
+	st.waveWait -= deltaTime
+	if st.waveWait > 0 {
+		return
+	}
+	for i := int32(0); i < r.Waves[st.wave].Count; i++ {
+		sp.spawnOne(ctx, m, st)
+	}
+	st.wave++
+	if st.wave < len(r.Waves) {
+		st.waveWait = r.Waves[st.wave].Delay
+	}
+}
+
+// spawnOne 在区域内随机位置刷出一个实体
+func (sp *spawner) spawnOne(ctx context.Context, m *Map, st *regionState) bool {
+	r := st.region
+	x := r.MinX + sp.rng.Float32()*(r.MaxX-r.MinX)
+	z := r.MinZ + sp.rng.Float32()*(r.MaxZ-r.MinZ)
+	actor := sp.factory(r.UnitID, character.NewVector3(x, r.Y, z))
+	if actor == nil {
+		return false
+	}
+	if err := m.EnterActor(ctx, actor); err != nil {
+		return false
+	}
+	st.alive[actor.ID()] = actor
+	return true
+}
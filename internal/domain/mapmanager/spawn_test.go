@@ -0,0 +1,145 @@
+package mapmanager
+
+import (
+	"context"
+	"testing"
+
+	character "greatestworks/internal/domain/character"
+)
+
+type fakeEnv struct {
+	hour    int
+	weather string
+}
+
+func (e *fakeEnv) Hour() int       { return e.hour }
+func (e *fakeEnv) Weather() string { return e.weather }
+
+func newSpawnFactory(t *testing.T) (SpawnFactory, *[]*character.Actor) {
+	t.Helper()
+	var spawned []*character.Actor
+	next := character.EntityID(1000)
+	return func(unitID int32, position character.Vector3) *character.Actor {
+		next++
+		actor := character.NewActor(next, character.EntityTypeMonster, unitID, position, character.NewVector3(0, 0, 1), "mob", 1)
+		if err := actor.Start(context.Background()); err != nil {
+			t.Fatalf("actor start: %v", err)
+		}
+		spawned = append(spawned, actor)
+		return actor
+	}, &spawned
+}
+
+func TestSpawnRegionFillAndRespawn(t *testing.T) {
+	ctx := context.Background()
+	m := NewMap(1, "test", 1000, 1000)
+	factory, spawned := newSpawnFactory(t)
+	m.SetSpawnRegions([]*SpawnRegion{{
+		ID: 1, UnitID: 2001, MinX: 10, MinZ: 10, MaxX: 50, MaxZ: 50, MaxCount: 3, RespawnTime: 2,
+	}}, factory)
+
+	_ = m.Update(ctx, 0.1)
+	if n := m.SpawnedCount(1); n != 3 {
+		t.Fatalf("expected region filled to 3, got %d", n)
+	}
+	for _, a := range *spawned {
+		pos := a.Position()
+		if pos.X < 10 || pos.X > 50 || pos.Z < 10 || pos.Z > 50 {
+			t.Fatalf("spawned outside region: %+v", pos)
+		}
+	}
+
+	victim := (*spawned)[0]
+	victim.ChangeHP(-victim.HP())
+	_ = m.Update(ctx, 0.1)
+	if n := m.SpawnedCount(1); n != 2 {
+		t.Fatalf("expected dead monster reaped, got %d", n)
+	}
+	if m.GetEntity(victim.ID()) != nil {
+		t.Fatalf("dead monster should leave the map")
+	}
+
+	_ = m.Update(ctx, 1)
+	if n := m.SpawnedCount(1); n != 2 {
+		t.Fatalf("respawned before timer elapsed, got %d", n)
+	}
+	_ = m.Update(ctx, 1.1)
+	if n := m.SpawnedCount(1); n != 3 {
+		t.Fatalf("expected respawn after timer, got %d", n)
+	}
+}
+
+func TestSpawnRegionConditions(t *testing.T) {
+	ctx := context.Background()
+	m := NewMap(1, "test", 1000, 1000)
+	env := &fakeEnv{hour: 12, weather: "clear"}
+	m.SetSpawnEnvironment(env)
+	factory, _ := newSpawnFactory(t)
+	m.SetSpawnRegions([]*SpawnRegion{
+		{ID: 1, UnitID: 2001, MaxX: 100, MaxZ: 100, MaxCount: 2, StartHour: 20, EndHour: 4},
+		{ID: 2, UnitID: 2002, MaxX: 100, MaxZ: 100, MaxCount: 1, Weathers: []string{"rain"}},
+	}, factory)
+
+	_ = m.Update(ctx, 0.1)
+	if m.SpawnedCount(1) != 0 || m.SpawnedCount(2) != 0 {
+		t.Fatalf("inactive regions should not spawn")
+	}
+
+	env.hour, env.weather = 22, "rain"
+	_ = m.Update(ctx, 0.1)
+	if m.SpawnedCount(1) != 2 || m.SpawnedCount(2) != 1 {
+		t.Fatalf("expected night and rain regions to spawn, got %d/%d", m.SpawnedCount(1), m.SpawnedCount(2))
+	}
+
+	env.hour, env.weather = 5, "clear"
+	_ = m.Update(ctx, 0.1)
+	if m.SpawnedCount(1) != 0 || m.SpawnedCount(2) != 0 {
+		t.Fatalf("expected regions despawned when conditions end")
+	}
+	if len(m.GetAllEntities()) != 0 {
+		t.Fatalf("despawned monsters should leave the map")
+	}
+}
+
+func TestSpawnRegionWaves(t *testing.T) {
+	ctx := context.Background()
+	m := NewMap(1, "test", 1000, 1000)
+	factory, spawned := newSpawnFactory(t)
+	m.SetSpawnRegions([]*SpawnRegion{{
+		ID: 1, UnitID: 2001, MaxX: 100, MaxZ: 100,
+		Waves: []SpawnWave{{Delay: 1, Count: 2}, {Delay: 2, Count: 3}},
+	}}, factory)
+
+	_ = m.Update(ctx, 0.5)
+	if n := m.SpawnedCount(1); n != 0 {
+		t.Fatalf("first wave spawned early: %d", n)
+	}
+	_ = m.Update(ctx, 0.6)
+	if n := m.SpawnedCount(1); n != 2 {
+		t.Fatalf("expected first wave of 2, got %d", n)
+	}
+
+	// 未清空前不会推进下一波
+	_ = m.Update(ctx, 5)
+	if n := m.SpawnedCount(1); n != 2 {
+		t.Fatalf("next wave must wait for clear, got %d", n)
+	}
+
+	for _, a := range *spawned {
+		a.ChangeHP(-a.HP())
+	}
+	_ = m.Update(ctx, 0.1)
+	_ = m.Update(ctx, 2)
+	if n := m.SpawnedCount(1); n != 3 {
+		t.Fatalf("expected second wave of 3, got %d", n)
+	}
+
+	for _, a := range *spawned {
+		a.ChangeHP(-a.HP())
+	}
+	_ = m.Update(ctx, 0.1)
+	_ = m.Update(ctx, 10)
+	if n := m.SpawnedCount(1); n != 0 {
+		t.Fatalf("waves should not loop, got %d", n)
+	}
+}
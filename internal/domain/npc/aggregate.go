@@ -339,12 +339,17 @@ func (n *NPCAggregate) Trade(playerID string, tradeRequest *TradeRequest) (*Trad
 	if n.shop == nil {
 		return nil, ErrNPCHasNoShop
 	}
+	level := n.relationshipLevel(playerID)
+	if !level.CanTrade() {
+		return nil, ErrInsufficientReputation
+	}
 
 	// 执行交易
 	result, err := n.shop.ExecuteTrade(playerID, tradeRequest)
 	if err != nil {
 		return nil, err
 	}
+	result.TotalPrice = int(float64(result.TotalPrice) * level.ShopPriceRate())
 
 	n.updatedAt = time.Now()
 	n.version++
@@ -389,6 +394,14 @@ func (n *NPCAggregate) GetRelationship(playerID string) *Relationship {
 	return n.relationships[playerID]
 }
 
+// relationshipLevel 关系等级（无记录时为中立）
+func (n *NPCAggregate) relationshipLevel(playerID string) RelationshipLevel {
+	if relationship := n.relationships[playerID]; relationship != nil {
+		return relationship.GetLevel()
+	}
+	return RelationshipLevelNeutral
+}
+
 // GetDialogue 获取对话
 func (n *NPCAggregate) GetDialogue(dialogueID string) (*Dialogue, error) {
 	dialogue, exists := n.dialogues[dialogueID]
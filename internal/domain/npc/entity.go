@@ -851,37 +851,51 @@ func (s *Shop) GetAvailableItems() []*ShopItem {
 	return available
 }
 
+// SetSchedule 设置营业时间
+func (s *Shop) SetSchedule(schedule *ShopSchedule) {
+	s.schedule = schedule
+	s.updatedAt = time.Now()
+}
+
 // IsOpen 检查是否开放
 func (s *Shop) IsOpen() bool {
 	return s.schedule.IsOpen(time.Now())
 }
 
-// ExecuteTrade 执行交易
-func (s *Shop) ExecuteTrade(playerID string, request *TradeRequest) (*TradeResult, error) {
+// Quote 计算交易价格（包括折扣），不修改库存
+func (s *Shop) Quote(playerID string, request *TradeRequest) (int, error) {
 	if !s.IsOpen() {
-		return nil, fmt.Errorf("shop is closed")
+		return 0, ErrShopNotOpen
+	}
+	if request.Quantity <= 0 {
+		return 0, ErrShopInvalidQuantity
 	}
 
 	item := s.GetItem(request.ItemID)
 	if item == nil {
-		return nil, fmt.Errorf("item not found")
-	}
-
-	if !item.IsAvailable() {
-		return nil, fmt.Errorf("item not available")
+		return 0, ErrShopItemNotFound
 	}
 
-	if request.Quantity > item.GetStock() {
-		return nil, fmt.Errorf("insufficient stock")
+	if !item.IsAvailable() || !item.HasStock(request.Quantity) {
+		return 0, ErrShopItemOutOfStock
 	}
 
-	// 计算价格（包括折扣）
 	totalPrice := item.GetPrice() * request.Quantity
 	if discount := s.getDiscount(playerID, request.ItemID); discount != nil {
 		totalPrice = discount.Apply(totalPrice)
 	}
+	return totalPrice, nil
+}
+
+// ExecuteTrade 执行交易
+func (s *Shop) ExecuteTrade(playerID string, request *TradeRequest) (*TradeResult, error) {
+	totalPrice, err := s.Quote(playerID, request)
+	if err != nil {
+		return nil, err
+	}
 
 	// 执行交易
+	item := s.GetItem(request.ItemID)
 	item.Purchase(request.Quantity)
 	s.updatedAt = time.Now()
 
@@ -911,15 +925,17 @@ func (s *Shop) Update(deltaTime time.Duration) {
 
 // ShopItem 商店商品
 type ShopItem struct {
-	id          string
-	name        string
-	description string
-	price       int
-	stock       int
-	maxStock    int
-	restockRate int
-	lastRestock time.Time
-	available   bool
+	id              string
+	name            string
+	description     string
+	price           int
+	stock           int
+	maxStock        int
+	restockRate     int
+	restockInterval time.Duration
+	lastRestock     time.Time
+	available       bool
+	unlimited       bool
 }
 
 // NewShopItem 创建商店商品
@@ -936,6 +952,19 @@ func NewShopItem(id, name, description string, price, stock int) *ShopItem {
 	}
 }
 
+// NewUnlimitedShopItem 创建不限量的商店商品
+func NewUnlimitedShopItem(id, name, description string, price int) *ShopItem {
+	item := NewShopItem(id, name, description, price, 0)
+	item.unlimited = true
+	return item
+}
+
+// SetRestock 设置补货规则：每隔 interval 补充 amount 个，不超过初始库存
+func (si *ShopItem) SetRestock(amount int, interval time.Duration) {
+	si.restockRate = amount
+	si.restockInterval = interval
+}
+
 // GetID 获取ID
 func (si *ShopItem) GetID() string {
 	return si.id
@@ -956,19 +985,57 @@ func (si *ShopItem) GetStock() int {
 	return si.stock
 }
 
+// GetMaxStock 获取库存上限
+func (si *ShopItem) GetMaxStock() int {
+	return si.maxStock
+}
+
+// IsUnlimited 是否不限量
+func (si *ShopItem) IsUnlimited() bool {
+	return si.unlimited
+}
+
+// HasStock 库存是否足够
+func (si *ShopItem) HasStock(quantity int) bool {
+	return si.unlimited || quantity <= si.stock
+}
+
+// NextRestock 下次补货时间（库存已满或不补货时为零值）
+func (si *ShopItem) NextRestock() time.Time {
+	if si.unlimited || si.restockRate <= 0 || si.stock >= si.maxStock {
+		return time.Time{}
+	}
+	return si.lastRestock.Add(si.restockPeriod())
+}
+
 // IsAvailable 检查是否可用
 func (si *ShopItem) IsAvailable() bool {
-	return si.available && si.stock > 0
+	return si.available && (si.unlimited || si.stock > 0)
 }
 
 // Purchase 购买
 func (si *ShopItem) Purchase(quantity int) {
+	if si.unlimited {
+		return
+	}
+	if si.stock >= si.maxStock {
+		// 从满库存开始售出时重新计时补货
+		si.lastRestock = time.Now()
+	}
 	si.stock -= quantity
 	if si.stock < 0 {
 		si.stock = 0
 	}
 }
 
+// CancelPurchase 撤销未完成的购买，退回库存（不影响补货计时）
+func (si *ShopItem) CancelPurchase(quantity int) {
+	if si.unlimited {
+		return
+	}
+	si.stock = min(si.stock+quantity, si.maxStock)
+}
+
 // Restock 补货
 func (si *ShopItem) Restock(quantity int) {
 	si.stock += quantity
@@ -982,12 +1049,20 @@ func (si *ShopItem) Restock(quantity int) {
 func (si *ShopItem) Update(deltaTime time.Duration) {
 	// 自动补货逻辑
 	if si.restockRate > 0 && si.stock < si.maxStock {
-		if time.Since(si.lastRestock) >= time.Hour {
+		if time.Since(si.lastRestock) >= si.restockPeriod() {
 			si.Restock(si.restockRate)
 		}
 	}
 }
 
+// restockPeriod 补货间隔（未设置时为 1 小时）
+func (si *ShopItem) restockPeriod() time.Duration {
+	if si.restockInterval > 0 {
+		return si.restockInterval
+	}
+	return time.Hour
+}
+
 // TradeRequest 交易请求
 type TradeRequest struct {
 	ItemID   string
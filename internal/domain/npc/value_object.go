@@ -688,6 +688,29 @@ func (rl RelationshipLevel) String() string {
 	}
 }
 
+// ShopPriceRate 商店购买价格倍率：关系越好折扣越高，关系较差时加价
+func (rl RelationshipLevel) ShopPriceRate() float64 {
+	switch rl {
+	case RelationshipLevelRevered:
+		return 0.8
+	case RelationshipLevelFriendly:
+		return 0.9
+	case RelationshipLevelLiked:
+		return 0.95
+	case RelationshipLevelDisliked:
+		return 1.1
+	case RelationshipLevelUnfriendly:
+		return 1.25
+	default:
+		return 1.0
+	}
+}
+
+// CanTrade 是否愿意交易（敌对时拒绝）
+func (rl RelationshipLevel) CanTrade() bool {
+	return rl != RelationshipLevelHostile
+}
+
 // GetColor 获取关系等级颜色
 func (rl RelationshipLevel) GetColor() string {
 	switch rl {
@@ -868,6 +891,15 @@ func NewShopSchedule() *ShopSchedule {
 	}
 }
 
+// NewAlwaysOpenShopSchedule 创建全天营业的商店日程
+func NewAlwaysOpenShopSchedule() *ShopSchedule {
+	schedule := NewShopSchedule()
+	schedule.OpenTime = time.Date(0, 1, 1, 0, 0, 0, 0, time.UTC)
+	schedule.CloseTime = time.Date(0, 1, 1, 23, 59, 0, 0, time.UTC)
+	schedule.DaysOpen = []time.Weekday{time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday}
+	return schedule
+}
+
 // IsOpen 检查是否开放
 func (ss *ShopSchedule) IsOpen(currentTime time.Time) bool {
 	// 检查是否为假日
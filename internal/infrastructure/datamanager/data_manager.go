@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
 	"sync"
 )
 
@@ -26,9 +27,36 @@ type UnitDefine struct {
 	RES       int32   `json:"res"`
 	SPD       int32   `json:"spd"`
 	MoveSpeed float32 `json:"move_speed"`
+	GCD       float32 `json:"gcd,omitempty"`       // 公共冷却（秒，玩家职业）
+	CCImmune  bool    `json:"cc_immune,omitempty"` // 免疫眩晕/定身/沉默（首领）
 	Skills    []int32 `json:"skills"`
 	AIType    int32   `json:"ai_type"`
 	NPCType   int32   `json:"npc_type"`
+
+	// 击杀奖励（怪物）
+	ExpReward  int32 `json:"exp_reward,omitempty"`
+	GoldReward int32 `json:"gold_reward,omitempty"`
+
+	Loot   []LootDefine  `json:"loot,omitempty"`   // 掉落表（怪物）
+	Growth *GrowthDefine `json:"growth,omitempty"` // 每级属性成长（玩家职业）
+}
+
+// GrowthDefine 每级属性成长
+type GrowthDefine struct {
+	MaxHP int32 `json:"max_hp"`
+	MaxMP int32 `json:"max_mp"`
+	AD    int32 `json:"ad"`
+	AP    int32 `json:"ap"`
+	DEF   int32 `json:"def"`
+	RES   int32 `json:"res"`
+}
+
+// LootDefine 掉落表条目：按概率独立判定，数量在 [MinCount, MaxCount] 间随机
+type LootDefine struct {
+	ItemID   int32   `json:"item_id"`
+	Chance   float32 `json:"chance"` // 0-1
+	MinCount int32   `json:"min_count"`
+	MaxCount int32   `json:"max_count"`
 }
 
 // SkillDefine 技能定义
@@ -46,6 +74,78 @@ type SkillDefine struct {
 	MPCost     int32   `json:"mp_cost"`
 	TargetType int32   `json:"target_type"`
 	BuffID     int32   `json:"buff_id"`
+
+	// 作用范围：0=单体 1=自身周围圆 2=指定点圆 3=前方扇形 4=前方矩形
+	Shape      int32   `json:"shape"`
+	Radius     float32 `json:"radius"`      // 圆/扇形半径，矩形长度
+	Angle      float32 `json:"angle"`       // 扇形张角（度）
+	Width      float32 `json:"width"`       // 矩形宽度
+	MaxTargets int32   `json:"max_targets"` // 最大目标数，0 不限
+	Filter     int32   `json:"filter"`      // 0=敌方 1=友方 2=全部
+
+	// 投射物：速度为 0 表示即时生效
+	MissileSpeed float32 `json:"missile_speed"`
+	Homing       bool    `json:"homing"` // 是否追踪目标
+
+	// 复活：复活后的生命比例，大于 0 表示复活技能（目标为死亡的友方）
+	Resurrect float32 `json:"resurrect,omitempty"`
+
+	// 引导：引导时长与结算间隔（秒），时长大于 0 表示引导技能
+	Channel     float32 `json:"channel,omitempty"`
+	ChannelTick float32 `json:"channel_tick,omitempty"`
+	// 打断：命中时打断目标施法，被打断技能的冷却时长（秒）
+	Interrupt float32 `json:"interrupt,omitempty"`
+	// 不触发也不受公共冷却限制
+	OffGCD bool `json:"off_gcd,omitempty"`
+}
+
+// BuffDefine Buff定义
+type BuffDefine struct {
+	ID       int32                   `json:"id"`
+	Name     string                  `json:"name"`
+	Duration float32                 `json:"duration"` // 秒
+	Modifier AttributeModifierDefine `json:"modifier"`
+	Flags    int32                   `json:"flags"` // 状态标志位：1=眩晕 2=定身 4=沉默 8=无敌 16=隐身 32=缴械 64=减速
+
+	Stack    int32 `json:"stack"`     // 叠加规则：0=刷新 1=叠层 2=每施法者独立
+	MaxStack int32 `json:"max_stack"` // 叠层上限
+
+	// 周期效果（每层每跳）
+	TickInterval float32 `json:"tick_interval"`
+	TickDamage   float32 `json:"tick_damage"`
+	TickHeal     float32 `json:"tick_heal"`
+	DamageType   int32   `json:"damage_type"`
+
+	Harmful     bool    `json:"harmful"`     // 是否为减益
+	Category    int32   `json:"category"`    // 驱散类别：0=无 1=魔法 2=诅咒 3=毒 4=控制
+	Dispellable bool    `json:"dispellable"` // 是否可被驱散
+	Immune      []int32 `json:"immune"`      // 生效期间免疫的减益类别
+}
+
+// AttributeModifierDefine 属性修饰配置（Add 为加法，Mul 为乘法系数）
+type AttributeModifierDefine struct {
+	MaxHPAdd       float32 `json:"max_hp_add,omitempty"`
+	MaxHPMul       float32 `json:"max_hp_mul,omitempty"`
+	MaxMPAdd       float32 `json:"max_mp_add,omitempty"`
+	MaxMPMul       float32 `json:"max_mp_mul,omitempty"`
+	HPRegenAdd     float32 `json:"hp_regen_add,omitempty"`
+	MPRegenAdd     float32 `json:"mp_regen_add,omitempty"`
+	ADAdd          float32 `json:"ad_add,omitempty"`
+	ADMul          float32 `json:"ad_mul,omitempty"`
+	APAdd          float32 `json:"ap_add,omitempty"`
+	APMul          float32 `json:"ap_mul,omitempty"`
+	DefAdd         float32 `json:"def_add,omitempty"`
+	DefMul         float32 `json:"def_mul,omitempty"`
+	MDefAdd        float32 `json:"mdef_add,omitempty"`
+	MDefMul        float32 `json:"mdef_mul,omitempty"`
+	CriAdd         float32 `json:"cri_add,omitempty"`
+	CrdAdd         float32 `json:"crd_add,omitempty"`
+	HitRateAdd     float32 `json:"hit_rate_add,omitempty"`
+	DodgeRateAdd   float32 `json:"dodge_rate_add,omitempty"`
+	SpeedAdd       float32 `json:"speed_add,omitempty"`
+	SpeedMul       float32 `json:"speed_mul,omitempty"`
+	AttackSpeedAdd float32 `json:"attack_speed_add,omitempty"`
+	AttackSpeedMul float32 `json:"attack_speed_mul,omitempty"`
 }
 
 // ItemDefine 物品定义
@@ -59,14 +159,133 @@ type ItemDefine struct {
 	SellPrice   int32  `json:"sell_price"`
 	EquipSlot   int32  `json:"equip_slot"`
 	Description string `json:"description"`
+
+	// 使用效果（消耗品）：1=回复生命 2=回复魔法 3=附加Buff 4=获得经验 5=传送 6=开启宝箱 7=学习技能 8=召唤宠物
+	EffectType    int32        `json:"effect_type,omitempty"`
+	EffectValue   int32        `json:"effect_value,omitempty"`   // 回复量/BuffID/经验/目的地图ID（0 为绑定位置）/技能ID/宠物ID
+	Loot          []LootDefine `json:"loot,omitempty"`           // 宝箱掉落表
+	CooldownGroup int32        `json:"cooldown_group,omitempty"` // 冷却组：同组物品共享冷却，0 表示按物品单独冷却
+	Cooldown      float32      `json:"cooldown,omitempty"`       // 冷却（秒）
+	NoCombat      bool         `json:"no_combat,omitempty"`      // 战斗中不可使用
+	Target        int32        `json:"target,omitempty"`         // 目标要求：0=自身 1=友方（未指定时为自身） 2=敌方
+	UseRange      float32      `json:"use_range,omitempty"`      // 对目标使用的距离（0 使用默认值）
+
+	// 装备属性
+	RequiredLevel int32   `json:"required_level,omitempty"`
+	Classes       []int32 `json:"classes,omitempty"` // 可穿戴/使用的职业（unitID），为空表示不限
+	StrBonus      int32   `json:"str_bonus,omitempty"`
+	IntBonus      int32   `json:"int_bonus,omitempty"`
+	VitBonus      int32   `json:"vit_bonus,omitempty"`
+	AdBonus       int32   `json:"ad_bonus,omitempty"`
+	ApBonus       int32   `json:"ap_bonus,omitempty"`
+	DefBonus      int32   `json:"def_bonus,omitempty"`
 }
 
 // MapDefine 地图定义
 type MapDefine struct {
-	ID     int32  `json:"id"`
-	Name   string `json:"name"`
-	Width  int32  `json:"width"`
-	Height int32  `json:"height"`
+	ID      int32  `json:"id"`
+	Name    string `json:"name"`
+	Width   int32  `json:"width"`
+	Height  int32  `json:"height"`
+	SoftCap int32  `json:"soft_cap,omitempty"` // 单线软上限：达到后新玩家分配到其他线路（0 使用默认值）
+	HardCap int32  `json:"hard_cap,omitempty"` // 单线硬上限：组队/主动切线也不可超过（0 使用默认值）
+	Node    string `json:"node,omitempty"`     // 承载该地图的场景节点（空表示任意节点/本节点）
+
+	SpawnPoints  []SpawnPointDefine  `json:"spawn_points,omitempty"`
+	Portals      []PortalDefine      `json:"portals,omitempty"`
+	NPCs         []MapNPCDefine      `json:"npcs,omitempty"`
+	Monsters     []MapMonsterDefine  `json:"monsters,omitempty"`      // 简化配置：全图随机刷新
+	SpawnRegions []SpawnRegionDefine `json:"spawn_regions,omitempty"` // 区域刷新
+	Obstacles    []ObstacleDefine    `json:"obstacles,omitempty"`     // 阻挡视线的障碍物
+
+	PvPMode  int32           `json:"pvp_mode,omitempty"`  // 地图默认 PvP 规则：0=按标记 1=安全区 2=自由PK 3=阵营战
+	PvPZones []PvPZoneDefine `json:"pvp_zones,omitempty"` // 覆盖默认规则的区域（先配置者优先）
+}
+
+// 刷新点类型
+const (
+	SpawnPointTypeBirth     int32 = 1 // 出生点
+	SpawnPointTypeGraveyard int32 = 2 // 复活点
+)
+
+// SpawnPointDefine 刷新点定义
+type SpawnPointDefine struct {
+	ID   int32   `json:"id"`
+	X    float32 `json:"x"`
+	Y    float32 `json:"y"`
+	Z    float32 `json:"z"`
+	Type int32   `json:"type"` // 1=出生点 2=复活点
+}
+
+// ObstacleDefine 障碍物定义（XZ 平面矩形）
+type ObstacleDefine struct {
+	MinX float32 `json:"min_x"`
+	MinZ float32 `json:"min_z"`
+	MaxX float32 `json:"max_x"`
+	MaxZ float32 `json:"max_z"`
+}
+
+// PvPZoneDefine PvP 规则区域
+type PvPZoneDefine struct {
+	ID   int32   `json:"id"`
+	Mode int32   `json:"mode"`
+	MinX float32 `json:"min_x"`
+	MinZ float32 `json:"min_z"`
+	MaxX float32 `json:"max_x"`
+	MaxZ float32 `json:"max_z"`
+}
+
+// MapNPCDefine 地图NPC摆放
+type MapNPCDefine struct {
+	ID int32   `json:"id"` // 单位ID
+	X  float32 `json:"x"`
+	Y  float32 `json:"y"`
+	Z  float32 `json:"z"`
+}
+
+// MapMonsterDefine 全图怪物刷新（无区域限制）
+type MapMonsterDefine struct {
+	ID          int32   `json:"id"` // 单位ID
+	SpawnCount  int32   `json:"spawn_count"`
+	RespawnTime float32 `json:"respawn_time"` // 秒
+}
+
+// SpawnRegionDefine 刷怪区域定义
+type SpawnRegionDefine struct {
+	ID          int32             `json:"id"`
+	UnitID      int32             `json:"unit_id"`
+	MinX        float32           `json:"min_x"`
+	MinZ        float32           `json:"min_z"`
+	MaxX        float32           `json:"max_x"`
+	MaxZ        float32           `json:"max_z"`
+	Y           float32           `json:"y"`
+	MaxCount    int32             `json:"max_count"`
+	RespawnTime float32           `json:"respawn_time"`         // 秒
+	StartHour   int               `json:"start_hour,omitempty"` // 与 end_hour 相同表示全天
+	EndHour     int               `json:"end_hour,omitempty"`
+	Weathers    []string          `json:"weathers,omitempty"` // 为空表示任意天气
+	Waves       []SpawnWaveDefine `json:"waves,omitempty"`
+	LoopWaves   bool              `json:"loop_waves,omitempty"`
+}
+
+// SpawnWaveDefine 波次定义
+type SpawnWaveDefine struct {
+	Delay float32 `json:"delay"` // 上一波清空后的等待秒数
+	Count int32   `json:"count"`
+}
+
+// PortalDefine 传送门定义（触发区域为 XZ 平面上的圆形）
+type PortalDefine struct {
+	ID              int32   `json:"id"`
+	X               float32 `json:"x"`
+	Z               float32 `json:"z"`
+	Radius          float32 `json:"radius"`
+	TargetMapID     int32   `json:"target_map_id"`
+	TargetX         float32 `json:"target_x"`
+	TargetY         float32 `json:"target_y"`
+	TargetZ         float32 `json:"target_z"`
+	RequiredLevel   int32   `json:"required_level,omitempty"`
+	RequiredQuestID int32   `json:"required_quest_id,omitempty"` // 需已完成的任务
 }
 
 // QuestDefine 任务定义
@@ -85,15 +304,75 @@ type QuestObjective struct {
 	Required int32 `json:"required"`
 }
 
+// ShopDefine NPC 商店定义
+type ShopDefine struct {
+	NPCID int32            `json:"npc_id"` // NPC单位ID
+	Name  string           `json:"name"`
+	Items []ShopItemDefine `json:"items"`
+}
+
+// ShopItemDefine 商店商品：Stock 为 0 表示不限量；限量商品每隔 RestockInterval 秒补充 RestockAmount 个
+type ShopItemDefine struct {
+	ItemID          int32   `json:"item_id"`
+	Price           int32   `json:"price,omitempty"` // 为 0 时使用物品定义的 price
+	Stock           int32   `json:"stock,omitempty"`
+	RestockAmount   int32   `json:"restock_amount,omitempty"`
+	RestockInterval float32 `json:"restock_interval,omitempty"`
+}
+
+// RecipeDefine 制造配方定义
+type RecipeDefine struct {
+	ID            int32              `json:"id"`
+	Name          string             `json:"name"`
+	Category      int32              `json:"category"`       // 见 synthesis.RecipeCategory
+	Profession    int32              `json:"profession"`     // 所属专业
+	SkillLevel    int32              `json:"skill_level"`    // 所需专业技能
+	RequiredLevel int32              `json:"required_level"` // 所需角色等级
+	Materials     []RecipeItemDefine `json:"materials"`
+	Outputs       []RecipeItemDefine `json:"outputs"`
+	FailOutputs   []RecipeItemDefine `json:"fail_outputs,omitempty"` // 失败时返还的物品
+	SuccessRate   float64            `json:"success_rate"`
+	CraftTime     float32            `json:"craft_time"` // 每件耗时（秒）
+}
+
+// RecipeItemDefine 配方材料或产出
+type RecipeItemDefine struct {
+	ItemID int32 `json:"item_id"`
+	Count  int32 `json:"count"`
+}
+
+// 强化失败结果
+const (
+	EnhanceFailNone      int32 = 0 // 无变化
+	EnhanceFailDowngrade int32 = 1 // 降一级
+	EnhanceFailBreak     int32 = 2 // 装备损坏
+)
+
+// EnhanceDefine 强化等级定义（从上一级强化到 Level 级的消耗与规则）
+type EnhanceDefine struct {
+	Level         int32              `json:"level"`
+	SuccessRate   float64            `json:"success_rate"`
+	Gold          int64              `json:"gold"`
+	Materials     []RecipeItemDefine `json:"materials"`
+	Failure       int32              `json:"failure"`                   // 失败结果
+	ProtectItemID int32              `json:"protect_item_id,omitempty"` // 可免除失败惩罚的保护道具
+	StatBonus     float32            `json:"stat_bonus"`                // 达到该等级时装备属性的提升比例
+	Announce      bool               `json:"announce,omitempty"`        // 强化成功时全服公告
+}
+
 // DataManager 数据管理器
 type DataManager struct {
 	mu sync.RWMutex
 
 	unitDefines  map[int32]*UnitDefine
 	skillDefines map[int32]*SkillDefine
+	buffDefines  map[int32]*BuffDefine
 	itemDefines  map[int32]*ItemDefine
 	mapDefines   map[int32]*MapDefine
 	questDefines map[int32]*QuestDefine
+	shopDefines  map[int32]*ShopDefine
+	recipes      map[int32]*RecipeDefine
+	enhances     map[int32]*EnhanceDefine
 }
 
 var instance *DataManager
@@ -105,9 +384,13 @@ func GetInstance() *DataManager {
 		instance = &DataManager{
 			unitDefines:  make(map[int32]*UnitDefine),
 			skillDefines: make(map[int32]*SkillDefine),
+			buffDefines:  make(map[int32]*BuffDefine),
 			itemDefines:  make(map[int32]*ItemDefine),
 			mapDefines:   make(map[int32]*MapDefine),
 			questDefines: make(map[int32]*QuestDefine),
+			shopDefines:  make(map[int32]*ShopDefine),
+			recipes:      make(map[int32]*RecipeDefine),
+			enhances:     make(map[int32]*EnhanceDefine),
 		}
 	})
 	return instance
@@ -121,6 +404,9 @@ func (dm *DataManager) LoadAll(configPath string) error {
 	if err := dm.LoadSkills(configPath + "/skills.json"); err != nil {
 		return fmt.Errorf("load skills failed: %w", err)
 	}
+	if err := dm.LoadBuffs(configPath + "/buffs.json"); err != nil {
+		return fmt.Errorf("load buffs failed: %w", err)
+	}
 	if err := dm.LoadItems(configPath + "/items.json"); err != nil {
 		return fmt.Errorf("load items failed: %w", err)
 	}
@@ -130,6 +416,15 @@ func (dm *DataManager) LoadAll(configPath string) error {
 	if err := dm.LoadQuests(configPath + "/quests.json"); err != nil {
 		return fmt.Errorf("load quests failed: %w", err)
 	}
+	if err := dm.LoadShops(configPath + "/shops.json"); err != nil {
+		return fmt.Errorf("load shops failed: %w", err)
+	}
+	if err := dm.LoadRecipes(configPath + "/recipes.json"); err != nil {
+		return fmt.Errorf("load recipes failed: %w", err)
+	}
+	if err := dm.LoadEnhances(configPath + "/enhance.json"); err != nil {
+		return fmt.Errorf("load enhances failed: %w", err)
+	}
 	return nil
 }
 
@@ -177,6 +472,28 @@ func (dm *DataManager) LoadSkills(filePath string) error {
 	return nil
 }
 
+// LoadBuffs 加载Buff配置
+func (dm *DataManager) LoadBuffs(filePath string) error {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	var buffs []*BuffDefine
+	if err := json.Unmarshal(data, &buffs); err != nil {
+		return err
+	}
+
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	for _, buff := range buffs {
+		dm.buffDefines[buff.ID] = buff
+	}
+
+	return nil
+}
+
 // LoadItems 加载物品配置
 func (dm *DataManager) LoadItems(filePath string) error {
 	data, err := os.ReadFile(filePath)
@@ -243,6 +560,72 @@ func (dm *DataManager) LoadQuests(filePath string) error {
 	return nil
 }
 
+// LoadShops 加载NPC商店配置
+func (dm *DataManager) LoadShops(filePath string) error {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	var shops []*ShopDefine
+	if err := json.Unmarshal(data, &shops); err != nil {
+		return err
+	}
+
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	for _, shop := range shops {
+		dm.shopDefines[shop.NPCID] = shop
+	}
+
+	return nil
+}
+
+// LoadRecipes 加载制造配方配置
+func (dm *DataManager) LoadRecipes(filePath string) error {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	var recipes []*RecipeDefine
+	if err := json.Unmarshal(data, &recipes); err != nil {
+		return err
+	}
+
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	for _, recipe := range recipes {
+		dm.recipes[recipe.ID] = recipe
+	}
+
+	return nil
+}
+
+// LoadEnhances 加载装备强化配置
+func (dm *DataManager) LoadEnhances(filePath string) error {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	var enhances []*EnhanceDefine
+	if err := json.Unmarshal(data, &enhances); err != nil {
+		return err
+	}
+
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	for _, enhance := range enhances {
+		dm.enhances[enhance.Level] = enhance
+	}
+
+	return nil
+}
+
 // GetUnitDefine 获取单位定义
 func (dm *DataManager) GetUnitDefine(id int32) *UnitDefine {
 	dm.mu.RLock()
@@ -257,6 +640,13 @@ func (dm *DataManager) GetSkillDefine(id int32) *SkillDefine {
 	return dm.skillDefines[id]
 }
 
+// GetBuffDefine 获取Buff定义
+func (dm *DataManager) GetBuffDefine(id int32) *BuffDefine {
+	dm.mu.RLock()
+	defer dm.mu.RUnlock()
+	return dm.buffDefines[id]
+}
+
 // GetItemDefine 获取物品定义
 func (dm *DataManager) GetItemDefine(id int32) *ItemDefine {
 	dm.mu.RLock()
@@ -278,6 +668,39 @@ func (dm *DataManager) GetQuestDefine(id int32) *QuestDefine {
 	return dm.questDefines[id]
 }
 
+// GetShopDefine 获取NPC商店定义
+func (dm *DataManager) GetShopDefine(npcID int32) *ShopDefine {
+	dm.mu.RLock()
+	defer dm.mu.RUnlock()
+	return dm.shopDefines[npcID]
+}
+
+// GetRecipe 获取制造配方定义
+func (dm *DataManager) GetRecipe(id int32) *RecipeDefine {
+	dm.mu.RLock()
+	defer dm.mu.RUnlock()
+	return dm.recipes[id]
+}
+
+// GetRecipes 按ID顺序获取全部制造配方
+func (dm *DataManager) GetRecipes() []*RecipeDefine {
+	dm.mu.RLock()
+	defer dm.mu.RUnlock()
+	recipes := make([]*RecipeDefine, 0, len(dm.recipes))
+	for _, recipe := range dm.recipes {
+		recipes = append(recipes, recipe)
+	}
+	sort.Slice(recipes, func(i, j int) bool { return recipes[i].ID < recipes[j].ID })
+	return recipes
+}
+
+// GetEnhance 获取强化到指定等级的定义（超过最高等级时返回 nil）
+func (dm *DataManager) GetEnhance(level int32) *EnhanceDefine {
+	dm.mu.RLock()
+	defer dm.mu.RUnlock()
+	return dm.enhances[level]
+}
+
 // GetUnit 获取单位定义（简短别名）
 func (dm *DataManager) GetUnit(id int32) *UnitDefine {
 	return dm.GetUnitDefine(id)
@@ -288,6 +711,11 @@ func (dm *DataManager) GetSkill(id int32) *SkillDefine {
 	return dm.GetSkillDefine(id)
 }
 
+// GetBuff 获取Buff定义（简短别名）
+func (dm *DataManager) GetBuff(id int32) *BuffDefine {
+	return dm.GetBuffDefine(id)
+}
+
 // GetItem 获取物品定义（简短别名）
 func (dm *DataManager) GetItem(id int32) *ItemDefine {
 	return dm.GetItemDefine(id)
@@ -302,3 +730,8 @@ func (dm *DataManager) GetMap(id int32) *MapDefine {
 func (dm *DataManager) GetQuest(id int32) *QuestDefine {
 	return dm.GetQuestDefine(id)
 }
+
+// GetShop 获取NPC商店定义（简短别名）
+func (dm *DataManager) GetShop(npcID int32) *ShopDefine {
+	return dm.GetShopDefine(npcID)
+}
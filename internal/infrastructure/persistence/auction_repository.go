@@ -0,0 +1,216 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// 拍卖状态
+const (
+	AuctionStatusActive    int32 = 0 // 上架中
+	AuctionStatusSold      int32 = 1 // 已成交
+	AuctionStatusExpired   int32 = 2 // 流拍
+	AuctionStatusCancelled int32 = 3 // 卖家下架
+)
+
+// AuctionRepository 拍卖行仓储
+type AuctionRepository struct {
+	collection *mongo.Collection
+	counters   *mongo.Collection
+}
+
+// NewAuctionRepository 创建拍卖行仓储
+func NewAuctionRepository(db *mongo.Database) *AuctionRepository {
+	return &AuctionRepository{
+		collection: db.Collection("auctions"),
+		counters:   db.Collection("counters"),
+	}
+}
+
+// AuctionDocument 拍卖文档（物品类型/品质/等级冗余自 items.json 以便检索）
+type AuctionDocument struct {
+	AuctionID int64     `bson:"auction_id"`
+	SellerID  int64     `bson:"seller_id"`
+	ItemID    int32     `bson:"item_id"`
+	Count     int32     `bson:"count"`
+	ItemType  int32     `bson:"item_type"`
+	Quality   int32     `bson:"quality"`
+	Level     int32     `bson:"level"`
+	StartBid  int64     `bson:"start_bid"` // 起拍价（0 表示仅一口价）
+	Buyout    int64     `bson:"buyout"`    // 一口价（0 表示仅竞拍）
+	Bid       int64     `bson:"bid"`       // 当前最高出价
+	BidderID  int64     `bson:"bidder_id"` // 当前最高出价者
+	Fee       int64     `bson:"fee"`       // 上架手续费
+	Status    int32     `bson:"status"`
+	ExpireAt  time.Time `bson:"expire_at"`
+	CreatedAt time.Time `bson:"created_at"`
+
+	// 结算信息
+	BuyerID  int64     `bson:"buyer_id,omitempty"`
+	Price    int64     `bson:"price,omitempty"`
+	Tax      int64     `bson:"tax,omitempty"`
+	ClosedAt time.Time `bson:"closed_at,omitempty"`
+}
+
+// AuctionFilter 拍卖检索条件（零值表示不限）
+type AuctionFilter struct {
+	SellerID   int64
+	ItemID     int32
+	ItemType   int32
+	MinQuality int32
+	MinLevel   int32
+	MaxLevel   int32
+	Offset     int64
+	Limit      int64
+}
+
+// auctionIDCounter 拍卖ID计数器
+const auctionIDCounter = "auction_id"
+
+// NextAuctionID 原子分配拍卖ID
+func (r *AuctionRepository) NextAuctionID(ctx context.Context) (int64, error) {
+	var counter struct {
+		Seq int64 `bson:"seq"`
+	}
+	err := r.counters.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": auctionIDCounter},
+		bson.M{"$inc": bson.M{"seq": int64(1)}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&counter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to allocate auction id: %w", err)
+	}
+	return counter.Seq, nil
+}
+
+// Create 创建拍卖（可在事务内调用）
+func (r *AuctionRepository) Create(ctx context.Context, doc *AuctionDocument) error {
+	doc.CreatedAt = time.Now()
+	if _, err := r.collection.InsertOne(ctx, doc); err != nil {
+		return fmt.Errorf("failed to create auction: %w", err)
+	}
+	return nil
+}
+
+// FindByID 查找拍卖（不存在时返回 nil）
+func (r *AuctionRepository) FindByID(ctx context.Context, auctionID int64) (*AuctionDocument, error) {
+	var doc AuctionDocument
+	err := r.collection.FindOne(ctx, bson.M{"auction_id": auctionID}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find auction: %w", err)
+	}
+	return &doc, nil
+}
+
+// Search 检索上架中的拍卖（按剩余时间升序）
+func (r *AuctionRepository) Search(ctx context.Context, filter AuctionFilter) ([]*AuctionDocument, error) {
+	query := bson.M{"status": AuctionStatusActive, "expire_at": bson.M{"$gt": time.Now()}}
+	if filter.SellerID != 0 {
+		query["seller_id"] = filter.SellerID
+	}
+	if filter.ItemID != 0 {
+		query["item_id"] = filter.ItemID
+	}
+	if filter.ItemType != 0 {
+		query["item_type"] = filter.ItemType
+	}
+	if filter.MinQuality != 0 {
+		query["quality"] = bson.M{"$gte": filter.MinQuality}
+	}
+	level := bson.M{}
+	if filter.MinLevel != 0 {
+		level["$gte"] = filter.MinLevel
+	}
+	if filter.MaxLevel != 0 {
+		level["$lte"] = filter.MaxLevel
+	}
+	if len(level) > 0 {
+		query["level"] = level
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "expire_at", Value: 1}}).SetSkip(filter.Offset)
+	if filter.Limit > 0 {
+		opts.SetLimit(filter.Limit)
+	}
+	cursor, err := r.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search auctions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var docs []*AuctionDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("failed to decode auctions: %w", err)
+	}
+	return docs, nil
+}
+
+// CountActiveBySeller 卖家上架中的拍卖数
+func (r *AuctionRepository) CountActiveBySeller(ctx context.Context, sellerID int64) (int64, error) {
+	n, err := r.collection.CountDocuments(ctx, bson.M{"seller_id": sellerID, "status": AuctionStatusActive})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count auctions: %w", err)
+	}
+	return n, nil
+}
+
+// FindExpired 查找已到期但未结算的拍卖
+func (r *AuctionRepository) FindExpired(ctx context.Context, now time.Time, limit int64) ([]*AuctionDocument, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "expire_at", Value: 1}}).SetLimit(limit)
+	cursor, err := r.collection.Find(ctx, bson.M{"status": AuctionStatusActive, "expire_at": bson.M{"$lte": now}}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find expired auctions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var docs []*AuctionDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("failed to decode auctions: %w", err)
+	}
+	return docs, nil
+}
+
+// PlaceBid 出价：仅当拍卖仍在上架且最高出价未被他人更新时成功（可在事务内调用）
+func (r *AuctionRepository) PlaceBid(ctx context.Context, auctionID, prevBid, bidderID, bid int64) (bool, error) {
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{
+			"auction_id": auctionID,
+			"status":     AuctionStatusActive,
+			"bid":        prevBid,
+			"expire_at":  bson.M{"$gt": time.Now()},
+		},
+		bson.M{"$set": bson.M{"bid": bid, "bidder_id": bidderID}},
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to place bid: %w", err)
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// Close 结束拍卖：仅当拍卖仍在上架且最高出价未变化时成功（可在事务内调用）
+func (r *AuctionRepository) Close(ctx context.Context, auctionID, prevBid int64, status int32, buyerID, price, tax int64) (bool, error) {
+	set := bson.M{"status": status, "closed_at": time.Now()}
+	if buyerID != 0 {
+		set["buyer_id"], set["price"], set["tax"] = buyerID, price, tax
+	}
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"auction_id": auctionID, "status": AuctionStatusActive, "bid": prevBid},
+		bson.M{"$set": set},
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to close auction: %w", err)
+	}
+	return result.ModifiedCount == 1, nil
+}
@@ -0,0 +1,313 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"greatestworks/internal/domain/battle"
+	"greatestworks/internal/domain/player"
+)
+
+// BattleRepository 回合制战斗仓储（实现 battle.Repository）
+type BattleRepository struct {
+	collection *mongo.Collection
+}
+
+// NewBattleRepository 创建战斗仓储
+func NewBattleRepository(db *mongo.Database) *BattleRepository {
+	return &BattleRepository{collection: db.Collection("battles")}
+}
+
+var _ battle.Repository = (*BattleRepository)(nil)
+
+// BattleDocument 战斗文档
+type BattleDocument struct {
+	BattleID     string                 `bson:"battle_id"`
+	BattleType   int                    `bson:"battle_type"`
+	Status       int                    `bson:"status"`
+	Participants []BattleParticipantDoc `bson:"participants"`
+	PlayerIDs    []string               `bson:"player_ids"` // 参与者ID（按玩家查询）
+	Rounds       []BattleRoundDoc       `bson:"rounds"`
+	Winner       string                 `bson:"winner,omitempty"`
+	StartTime    time.Time              `bson:"start_time"`
+	EndTime      *time.Time             `bson:"end_time,omitempty"`
+	TurnIndex    int                    `bson:"turn_index"`
+	TurnDeadline time.Time              `bson:"turn_deadline"`
+	TurnTimeout  int64                  `bson:"turn_timeout"` // 毫秒
+	MaxRounds    int                    `bson:"max_rounds"`
+	CreatedAt    time.Time              `bson:"created_at"`
+	UpdatedAt    time.Time              `bson:"updated_at"`
+	Version      int64                  `bson:"version"`
+}
+
+// BattleParticipantDoc 战斗参与者文档
+type BattleParticipantDoc struct {
+	PlayerID    string    `bson:"player_id"`
+	Team        int       `bson:"team"`
+	CurrentHP   int       `bson:"current_hp"`
+	CurrentMP   int       `bson:"current_mp"`
+	MaxHP       int       `bson:"max_hp"`
+	Attack      int       `bson:"attack"`
+	IsAlive     bool      `bson:"is_alive"`
+	IsAI        bool      `bson:"is_ai,omitempty"`
+	Defending   bool      `bson:"defending,omitempty"`
+	DamageDealt int       `bson:"damage_dealt"`
+	DamageTaken int       `bson:"damage_taken"`
+	JoinedAt    time.Time `bson:"joined_at"`
+}
+
+// BattleRoundDoc 战斗回合文档
+type BattleRoundDoc struct {
+	RoundNumber int               `bson:"round_number"`
+	Actions     []BattleActionDoc `bson:"actions"`
+	StartTime   time.Time         `bson:"start_time"`
+	EndTime     *time.Time        `bson:"end_time,omitempty"`
+}
+
+// BattleActionDoc 战斗行动文档
+type BattleActionDoc struct {
+	ActionID   string    `bson:"action_id"`
+	ActorID    string    `bson:"actor_id"`
+	TargetID   string    `bson:"target_id,omitempty"`
+	ActionType int       `bson:"action_type"`
+	SkillID    string    `bson:"skill_id,omitempty"`
+	Damage     int       `bson:"damage"`
+	Healing    int       `bson:"healing"`
+	Critical   bool      `bson:"critical"`
+	Auto       bool      `bson:"auto,omitempty"`
+	Timestamp  time.Time `bson:"timestamp"`
+}
+
+// Save 保存战斗
+func (r *BattleRepository) Save(ctx context.Context, b *battle.Battle) error {
+	if _, err := r.collection.InsertOne(ctx, toBattleDocument(b)); err != nil {
+		return fmt.Errorf("保存战斗失败: %w", err)
+	}
+	return nil
+}
+
+// FindByID 根据ID查找战斗
+func (r *BattleRepository) FindByID(ctx context.Context, id battle.BattleID) (*battle.Battle, error) {
+	var doc BattleDocument
+	if err := r.collection.FindOne(ctx, bson.M{"battle_id": id.String()}).Decode(&doc); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, battle.ErrBattleNotFound
+		}
+		return nil, fmt.Errorf("查找战斗失败: %w", err)
+	}
+	return doc.toDomain(), nil
+}
+
+// Update 更新战斗
+func (r *BattleRepository) Update(ctx context.Context, b *battle.Battle) error {
+	res, err := r.collection.ReplaceOne(ctx, bson.M{"battle_id": b.ID().String()}, toBattleDocument(b))
+	if err != nil {
+		return fmt.Errorf("更新战斗失败: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return battle.ErrBattleNotFound
+	}
+	return nil
+}
+
+// Delete 删除战斗
+func (r *BattleRepository) Delete(ctx context.Context, id battle.BattleID) error {
+	if _, err := r.collection.DeleteOne(ctx, bson.M{"battle_id": id.String()}); err != nil {
+		return fmt.Errorf("删除战斗失败: %w", err)
+	}
+	return nil
+}
+
+// FindByPlayerID 根据玩家ID查找战斗（按创建时间倒序）
+func (r *BattleRepository) FindByPlayerID(ctx context.Context, playerID player.PlayerID, limit int) ([]*battle.Battle, error) {
+	return r.find(ctx, bson.M{"player_ids": playerID.String()}, limit)
+}
+
+// FindActiveBattles 查找进行中的战斗
+func (r *BattleRepository) FindActiveBattles(ctx context.Context, limit int) ([]*battle.Battle, error) {
+	return r.FindByStatus(ctx, battle.BattleStatusInProgress, limit)
+}
+
+// FindByStatus 根据状态查找战斗
+func (r *BattleRepository) FindByStatus(ctx context.Context, status battle.BattleStatus, limit int) ([]*battle.Battle, error) {
+	return r.find(ctx, bson.M{"status": int(status)}, limit)
+}
+
+// FindByType 根据类型查找战斗
+func (r *BattleRepository) FindByType(ctx context.Context, battleType battle.BattleType, limit int) ([]*battle.Battle, error) {
+	return r.find(ctx, bson.M{"battle_type": int(battleType)}, limit)
+}
+
+// CountByPlayerID 统计玩家参与的战斗数量
+func (r *BattleRepository) CountByPlayerID(ctx context.Context, playerID player.PlayerID) (int64, error) {
+	count, err := r.collection.CountDocuments(ctx, bson.M{"player_ids": playerID.String()})
+	if err != nil {
+		return 0, fmt.Errorf("统计战斗失败: %w", err)
+	}
+	return count, nil
+}
+
+// find 按条件查询战斗列表
+func (r *BattleRepository) find(ctx context.Context, filter bson.M, limit int) ([]*battle.Battle, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	if limit > 0 {
+		opts.SetLimit(int64(limit))
+	}
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("查询战斗失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var docs []BattleDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("解码战斗失败: %w", err)
+	}
+	battles := make([]*battle.Battle, 0, len(docs))
+	for i := range docs {
+		battles = append(battles, docs[i].toDomain())
+	}
+	return battles, nil
+}
+
+// toBattleDocument 领域对象转换为文档
+func toBattleDocument(b *battle.Battle) *BattleDocument {
+	doc := &BattleDocument{
+		BattleID:     b.ID().String(),
+		BattleType:   int(b.GetBattleType()),
+		Status:       int(b.Status()),
+		StartTime:    b.StartTime(),
+		EndTime:      b.EndTime(),
+		TurnIndex:    b.TurnIndex(),
+		TurnDeadline: b.TurnDeadline(),
+		TurnTimeout:  b.TurnTimeout().Milliseconds(),
+		MaxRounds:    b.MaxRounds(),
+		CreatedAt:    b.CreatedAt(),
+		UpdatedAt:    b.UpdatedAt(),
+		Version:      b.Version(),
+	}
+	if w := b.Winner(); w != nil {
+		doc.Winner = w.String()
+	}
+	for _, p := range b.Participants() {
+		doc.PlayerIDs = append(doc.PlayerIDs, p.PlayerID.String())
+		doc.Participants = append(doc.Participants, BattleParticipantDoc{
+			PlayerID:    p.PlayerID.String(),
+			Team:        p.Team,
+			CurrentHP:   p.CurrentHP,
+			CurrentMP:   p.CurrentMP,
+			MaxHP:       p.MaxHP,
+			Attack:      p.Attack,
+			IsAlive:     p.IsAlive,
+			IsAI:        p.IsAI,
+			Defending:   p.Defending,
+			DamageDealt: p.DamageDealt,
+			DamageTaken: p.DamageTaken,
+			JoinedAt:    p.JoinedAt,
+		})
+	}
+	for _, round := range b.Rounds() {
+		rd := BattleRoundDoc{RoundNumber: round.RoundNumber, StartTime: round.StartTime, EndTime: round.EndTime}
+		for _, a := range round.Actions {
+			ad := BattleActionDoc{
+				ActionID:   a.ActionID,
+				ActorID:    a.ActorID.String(),
+				ActionType: int(a.ActionType),
+				Damage:     a.Damage,
+				Healing:    a.Healing,
+				Critical:   a.Critical,
+				Auto:       a.Auto,
+				Timestamp:  a.Timestamp,
+			}
+			if a.TargetID != nil {
+				ad.TargetID = a.TargetID.String()
+			}
+			if a.SkillID != nil {
+				ad.SkillID = *a.SkillID
+			}
+			rd.Actions = append(rd.Actions, ad)
+		}
+		doc.Rounds = append(doc.Rounds, rd)
+	}
+	return doc
+}
+
+// toDomain 文档转换为领域对象
+func (doc *BattleDocument) toDomain() *battle.Battle {
+	participants := make([]*battle.BattleParticipant, 0, len(doc.Participants))
+	for _, p := range doc.Participants {
+		participants = append(participants, &battle.BattleParticipant{
+			PlayerID:    player.PlayerIDFromString(p.PlayerID),
+			Team:        p.Team,
+			CurrentHP:   p.CurrentHP,
+			CurrentMP:   p.CurrentMP,
+			IsAlive:     p.IsAlive,
+			DamageDealt: p.DamageDealt,
+			DamageTaken: p.DamageTaken,
+			JoinedAt:    p.JoinedAt,
+			MaxHP:       p.MaxHP,
+			Attack:      p.Attack,
+			IsAI:        p.IsAI,
+			Defending:   p.Defending,
+		})
+	}
+	rounds := make([]*battle.BattleRound, 0, len(doc.Rounds))
+	for _, rd := range doc.Rounds {
+		round := &battle.BattleRound{
+			RoundNumber: rd.RoundNumber,
+			Actions:     make([]*battle.BattleAction, 0, len(rd.Actions)),
+			StartTime:   rd.StartTime,
+			EndTime:     rd.EndTime,
+		}
+		for _, ad := range rd.Actions {
+			action := &battle.BattleAction{
+				ActionID:   ad.ActionID,
+				ActorID:    player.PlayerIDFromString(ad.ActorID),
+				ActionType: battle.ActionType(ad.ActionType),
+				Damage:     ad.Damage,
+				Healing:    ad.Healing,
+				Critical:   ad.Critical,
+				Auto:       ad.Auto,
+				Timestamp:  ad.Timestamp,
+			}
+			if ad.TargetID != "" {
+				target := player.PlayerIDFromString(ad.TargetID)
+				action.TargetID = &target
+			}
+			if ad.SkillID != "" {
+				skillID := ad.SkillID
+				action.SkillID = &skillID
+			}
+			round.Actions = append(round.Actions, action)
+		}
+		rounds = append(rounds, round)
+	}
+	var winner *player.PlayerID
+	if doc.Winner != "" {
+		w := player.PlayerIDFromString(doc.Winner)
+		winner = &w
+	}
+	return battle.ReconstructBattle(
+		doc.BattleID,
+		battle.BattleType(doc.BattleType),
+		battle.BattleStatus(doc.Status),
+		participants,
+		rounds,
+		winner,
+		doc.StartTime,
+		doc.EndTime,
+		doc.TurnIndex,
+		doc.TurnDeadline,
+		time.Duration(doc.TurnTimeout)*time.Millisecond,
+		doc.MaxRounds,
+		doc.CreatedAt,
+		doc.UpdatedAt,
+		doc.Version,
+	)
+}
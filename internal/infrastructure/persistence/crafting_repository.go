@@ -0,0 +1,118 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CraftingRepository 制造仓储：专业技能与制造记录
+type CraftingRepository struct {
+	professions *mongo.Collection
+	records     *mongo.Collection
+}
+
+// NewCraftingRepository 创建制造仓储
+func NewCraftingRepository(db *mongo.Database) *CraftingRepository {
+	return &CraftingRepository{
+		professions: db.Collection("professions"),
+		records:     db.Collection("craft_records"),
+	}
+}
+
+// ProfessionDocument 角色专业技能文档
+type ProfessionDocument struct {
+	CharacterID int64     `bson:"character_id"`
+	Profession  int32     `bson:"profession"`
+	Skill       int32     `bson:"skill"`
+	UpdatedAt   time.Time `bson:"updated_at"`
+}
+
+// CraftItemRecord 制造消耗或产出的物品
+type CraftItemRecord struct {
+	ItemID int32 `bson:"item_id"`
+	Count  int32 `bson:"count"`
+}
+
+// CraftRecordDocument 制造记录文档
+type CraftRecordDocument struct {
+	CharacterID int64             `bson:"character_id"`
+	RecipeID    int32             `bson:"recipe_id"`
+	Profession  int32             `bson:"profession"`
+	Success     bool              `bson:"success"`
+	Materials   []CraftItemRecord `bson:"materials"`
+	Outputs     []CraftItemRecord `bson:"outputs,omitempty"`
+	SuccessRate float64           `bson:"success_rate"`
+	Skill       int32             `bson:"skill"` // 制造后的专业技能
+	CreatedAt   time.Time         `bson:"created_at"`
+}
+
+// GetSkills 获取角色全部专业技能
+func (r *CraftingRepository) GetSkills(ctx context.Context, characterID int64) (map[int32]int32, error) {
+	cursor, err := r.professions.Find(ctx, bson.M{"character_id": characterID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find professions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var docs []*ProfessionDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("failed to decode professions: %w", err)
+	}
+	skills := make(map[int32]int32, len(docs))
+	for _, doc := range docs {
+		skills[doc.Profession] = doc.Skill
+	}
+	return skills, nil
+}
+
+// RaiseSkill 将专业技能提升到 skill（只增不减，可在事务内调用）
+func (r *CraftingRepository) RaiseSkill(ctx context.Context, characterID int64, profession, skill int32) error {
+	_, err := r.professions.UpdateOne(
+		ctx,
+		bson.M{"character_id": characterID, "profession": profession},
+		bson.M{
+			"$max": bson.M{"skill": skill},
+			"$set": bson.M{"updated_at": time.Now()},
+		},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update profession: %w", err)
+	}
+	return nil
+}
+
+// SaveRecord 保存制造记录（可在事务内调用）
+func (r *CraftingRepository) SaveRecord(ctx context.Context, doc *CraftRecordDocument) error {
+	if doc.CreatedAt.IsZero() {
+		doc.CreatedAt = time.Now()
+	}
+	if _, err := r.records.InsertOne(ctx, doc); err != nil {
+		return fmt.Errorf("failed to save craft record: %w", err)
+	}
+	return nil
+}
+
+// FindRecords 按时间倒序查询角色的制造记录
+func (r *CraftingRepository) FindRecords(ctx context.Context, characterID int64, limit int64) ([]*CraftRecordDocument, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+	cursor, err := r.records.Find(ctx, bson.M{"character_id": characterID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find craft records: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var records []*CraftRecordDocument
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, fmt.Errorf("failed to decode craft records: %w", err)
+	}
+	return records, nil
+}
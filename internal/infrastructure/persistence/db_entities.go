@@ -28,7 +28,7 @@ type DbCharacter struct {
 	Class       int32              `bson:"class"`
 	Level       int32              `bson:"level"`
 	Exp         int64              `bson:"exp"`
-	Gold        int64              `bson:"gold"`
+	Gold        int64              `bson:"gold,omitempty"` // 开户前的金币，首次使用钱包时转入（之后以钱包为准）
 
 	// 位置信息
 	MapID     int32   `bson:"map_id"`
@@ -62,6 +62,18 @@ type DbCharacter struct {
 	HitRate int32 `bson:"hit_rate"` // 命中率
 	Dodge   int32 `bson:"dodge"`    // 闪避率
 
+	// 死亡状态（未复活时下线，重新上线仍为死亡）
+	DeadAt time.Time `bson:"dead_at,omitempty"`
+
+	// 通过技能书学会的技能（职业技能按配置学习，不记录）
+	Skills []int32 `bson:"skills,omitempty"`
+
+	// 绑定位置（回城道具的目的地，未绑定时 BindMapID 为 0）
+	BindMapID int32   `bson:"bind_map_id,omitempty"`
+	BindX     float32 `bson:"bind_x,omitempty"`
+	BindY     float32 `bson:"bind_y,omitempty"`
+	BindZ     float32 `bson:"bind_z,omitempty"`
+
 	// 时间戳
 	CreatedAt time.Time `bson:"created_at"`
 	UpdatedAt time.Time `bson:"updated_at"`
@@ -79,6 +91,7 @@ type DbItem struct {
 	Location    int32              `bson:"location"`     // 位置（背包/装备/仓库）
 	Bound       bool               `bson:"bound"`        // 是否绑定
 	Expire      int64              `bson:"expire"`       // 过期时间戳
+	Enhance     int32              `bson:"enhance"`      // 强化等级
 	CreatedAt   time.Time          `bson:"created_at"`
 }
 
@@ -112,6 +125,7 @@ type DbMail struct {
 	IsRead      bool               `bson:"is_read"`
 	HasItems    bool               `bson:"has_items"`
 	Attachments []DbAttachment     `bson:"attachments"`
+	Gold        int64              `bson:"gold,omitempty"` // 附带金币
 	ExpireAt    time.Time          `bson:"expire_at"`
 	CreatedAt   time.Time          `bson:"created_at"`
 }
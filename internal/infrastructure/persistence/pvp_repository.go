@@ -0,0 +1,63 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// PvP 战绩类型
+const (
+	PvPRecordKill = "kill" // 野外/阵营击杀
+	PvPRecordDuel = "duel" // 决斗结果
+)
+
+// PvPRecordRepository PvP 战绩仓储（击杀与决斗记录，供荣誉与排行统计）
+type PvPRecordRepository struct {
+	collection *mongo.Collection
+}
+
+// NewPvPRecordRepository 创建 PvP 战绩仓储
+func NewPvPRecordRepository(db *mongo.Database) *PvPRecordRepository {
+	return &PvPRecordRepository{collection: db.Collection("pvp_records")}
+}
+
+// PvPRecordDocument PvP 战绩文档（平局时 WinnerID/LoserID 为参与双方，Draw 为 true）
+type PvPRecordDocument struct {
+	Kind      string    `bson:"kind"`
+	WinnerID  int64     `bson:"winner_id"`
+	LoserID   int64     `bson:"loser_id"`
+	Draw      bool      `bson:"draw,omitempty"`
+	MapID     int32     `bson:"map_id"`
+	Mode      int32     `bson:"mode"`             // 击杀位置的 PvP 规则
+	Reason    int32     `bson:"reason,omitempty"` // 决斗结束原因
+	SkillID   int32     `bson:"skill_id,omitempty"`
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+// Save 保存战绩
+func (r *PvPRecordRepository) Save(ctx context.Context, doc *PvPRecordDocument) error {
+	if doc.CreatedAt.IsZero() {
+		doc.CreatedAt = time.Now()
+	}
+	if _, err := r.collection.InsertOne(ctx, doc); err != nil {
+		return fmt.Errorf("failed to save pvp record: %w", err)
+	}
+	return nil
+}
+
+// CountWins 统计角色某类战绩的胜场（since 为零值时统计全部）
+func (r *PvPRecordRepository) CountWins(ctx context.Context, characterID int64, kind string, since time.Time) (int64, error) {
+	filter := bson.M{"kind": kind, "winner_id": characterID, "draw": bson.M{"$ne": true}}
+	if !since.IsZero() {
+		filter["created_at"] = bson.M{"$gte": since}
+	}
+	count, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count pvp records: %w", err)
+	}
+	return count, nil
+}
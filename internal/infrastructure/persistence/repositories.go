@@ -2,12 +2,16 @@ package persistence
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"greatestworks/internal/infrastructure/logging"
 )
 
 // UserRepository 用户仓储
@@ -170,16 +174,152 @@ func (r *CharacterRepository) UpdatePosition(ctx context.Context, characterID in
 	return err
 }
 
+// UpdateDeath 记录角色死亡
+func (r *CharacterRepository) UpdateDeath(ctx context.Context, characterID int64, deadAt time.Time) error {
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"character_id": characterID},
+		bson.M{"$set": bson.M{
+			"dead_at":    deadAt,
+			"hp":         0,
+			"updated_at": time.Now(),
+		}},
+	)
+	return err
+}
+
+// UpdateRespawn 记录角色复活：更新经验与生命/魔法并清除死亡状态
+func (r *CharacterRepository) UpdateRespawn(ctx context.Context, characterID int64, exp int64, hp, mp int32) error {
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"character_id": characterID},
+		bson.M{
+			"$set": bson.M{
+				"exp":        exp,
+				"hp":         hp,
+				"mp":         mp,
+				"updated_at": time.Now(),
+			},
+			"$unset": bson.M{"dead_at": ""},
+		},
+	)
+	return err
+}
+
+// AddSkill 记录学会的技能（可在事务内调用），已学会时 added 为 false
+func (r *CharacterRepository) AddSkill(ctx context.Context, characterID int64, skillID int32) (added bool, err error) {
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"character_id": characterID, "skills": bson.M{"$ne": skillID}},
+		bson.M{
+			"$push": bson.M{"skills": skillID},
+			"$set":  bson.M{"updated_at": time.Now()},
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// UpdateBindPoint 更新绑定位置
+func (r *CharacterRepository) UpdateBindPoint(ctx context.Context, characterID int64, mapID int32, x, y, z float32) error {
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"character_id": characterID},
+		bson.M{"$set": bson.M{
+			"bind_map_id": mapID,
+			"bind_x":      x,
+			"bind_y":      y,
+			"bind_z":      z,
+			"updated_at":  time.Now(),
+		}},
+	)
+	return err
+}
+
 // ItemRepository 物品仓储
 type ItemRepository struct {
 	collection *mongo.Collection
+	counters   *mongo.Collection
+	base       *BaseRepository
+	fallback   logging.Logger // 非空时允许无事务降级
 }
 
 // NewItemRepository 创建物品仓储
 func NewItemRepository(db *mongo.Database) *ItemRepository {
 	return &ItemRepository{
 		collection: db.Collection("items"),
+		counters:   db.Collection("counters"),
+		base:       NewBaseRepository(db, nil, nil, "items"),
+	}
+}
+
+// itemUIDCounter 物品唯一ID计数器
+const itemUIDCounter = "item_uid"
+
+// NextItemUIDs 原子分配 n 个连续的物品唯一ID，返回第一个（多进程并发不冲突）
+func (r *ItemRepository) NextItemUIDs(ctx context.Context, n int32) (int64, error) {
+	if n <= 0 {
+		return 0, nil
+	}
+	var counter struct {
+		Seq int64 `bson:"seq"`
+	}
+	err := r.counters.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": itemUIDCounter},
+		bson.M{"$inc": bson.M{"seq": int64(n)}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&counter)
+	if err != nil {
+		return 0, err
+	}
+	return counter.Seq - int64(n) + 1, nil
+}
+
+// WithTransaction 在事务内执行 fn（fn 内需使用传入的 ctx）；单机 MongoDB 不支持事务时返回错误（除非允许降级）
+func (r *ItemRepository) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return withTransaction(ctx, r.base, r.fallback, "items", fn)
+}
+
+// AllowNonTransactional 仅开发环境：单机 MongoDB 不支持事务时降级为无事务执行，每次降级记录告警
+func (r *ItemRepository) AllowNonTransactional(logger logging.Logger) {
+	r.fallback = logger
+}
+
+// LockInventory 在事务内写入角色背包版本号，使同一角色的并发背包事务互相冲突并重试
+func (r *ItemRepository) LockInventory(ctx context.Context, characterID int64) error {
+	_, err := r.counters.UpdateOne(
+		ctx,
+		bson.M{"_id": fmt.Sprintf("inventory:%d", characterID)},
+		bson.M{"$inc": bson.M{"seq": int64(1)}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// illegalOperationCode 非副本集执行事务时的错误码
+const illegalOperationCode = 20
+
+// withTransaction 在事务内执行 fn；ctx 已处于事务中时加入该事务。
+// MongoDB 不支持事务时，允许降级（fallback 非空）则记录告警后直接执行，否则返回错误
+func withTransaction(ctx context.Context, base *BaseRepository, fallback logging.Logger, name string, fn func(ctx context.Context) error) error {
+	if mongo.SessionFromContext(ctx) != nil {
+		return fn(ctx)
 	}
+	_, err := base.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
+	var se mongo.ServerError
+	if !errors.As(err, &se) || !se.HasErrorCode(illegalOperationCode) {
+		return err
+	}
+	if fallback == nil {
+		return fmt.Errorf("mongodb transactions unavailable (replica set required): %w", err)
+	}
+	fallback.Warn("MongoDB 不支持事务，降级为无事务执行（仅限开发环境）", logging.Fields{"repository": name})
+	return fn(ctx)
 }
 
 // Create 创建物品
@@ -288,15 +428,37 @@ func (r *QuestRepository) Update(ctx context.Context, quest *DbQuest) error {
 // MailRepository 邮件仓储
 type MailRepository struct {
 	collection *mongo.Collection
+	counters   *mongo.Collection
 }
 
 // NewMailRepository 创建邮件仓储
 func NewMailRepository(db *mongo.Database) *MailRepository {
 	return &MailRepository{
 		collection: db.Collection("mails"),
+		counters:   db.Collection("counters"),
 	}
 }
 
+// mailIDCounter 邮件ID计数器
+const mailIDCounter = "mail_id"
+
+// NextMailID 原子分配邮件ID（可在事务内调用，事务回滚时计数一并回滚）
+func (r *MailRepository) NextMailID(ctx context.Context) (int64, error) {
+	var counter struct {
+		Seq int64 `bson:"seq"`
+	}
+	err := r.counters.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": mailIDCounter},
+		bson.M{"$inc": bson.M{"seq": int64(1)}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&counter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to allocate mail id: %w", err)
+	}
+	return counter.Seq, nil
+}
+
 // Create 创建邮件
 func (r *MailRepository) Create(ctx context.Context, mail *DbMail) error {
 	mail.CreatedAt = time.Now()
@@ -324,20 +486,52 @@ func (r *MailRepository) FindByReceiverID(ctx context.Context, receiverID int64,
 	return mails, nil
 }
 
+// FindByID 查找收件人的某封邮件（不存在时返回 nil）
+func (r *MailRepository) FindByID(ctx context.Context, receiverID, mailID int64) (*DbMail, error) {
+	var mail DbMail
+	err := r.collection.FindOne(ctx, bson.M{"mail_id": mailID, "receiver_id": receiverID}).Decode(&mail)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &mail, nil
+}
+
 // MarkAsRead 标记为已读
-func (r *MailRepository) MarkAsRead(ctx context.Context, mailID int64) error {
+func (r *MailRepository) MarkAsRead(ctx context.Context, receiverID, mailID int64) error {
 	_, err := r.collection.UpdateOne(
 		ctx,
-		bson.M{"mail_id": mailID},
+		bson.M{"mail_id": mailID, "receiver_id": receiverID},
 		bson.M{"$set": bson.M{"is_read": true}},
 	)
 	return err
 }
 
-// Delete 删除邮件
-func (r *MailRepository) Delete(ctx context.Context, mailID int64) error {
-	_, err := r.collection.DeleteOne(ctx, bson.M{"mail_id": mailID})
-	return err
+// TakeAttachments 原子清空邮件附件与金币并标记已读（可在事务内调用），附件已被领取时返回 false
+func (r *MailRepository) TakeAttachments(ctx context.Context, receiverID, mailID int64) (bool, error) {
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"mail_id": mailID, "receiver_id": receiverID, "has_items": true},
+		bson.M{
+			"$set":   bson.M{"has_items": false, "attachments": []DbAttachment{}, "is_read": true},
+			"$unset": bson.M{"gold": ""},
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// Delete 删除邮件（附件未领取时不删除）
+func (r *MailRepository) Delete(ctx context.Context, receiverID, mailID int64) (bool, error) {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"mail_id": mailID, "receiver_id": receiverID, "has_items": false})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount == 1, nil
 }
 
 // DeleteExpired 删除过期邮件
@@ -0,0 +1,60 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ReputationRepository 角色对NPC的声望仓储
+type ReputationRepository struct {
+	collection *mongo.Collection
+}
+
+// NewReputationRepository 创建声望仓储
+func NewReputationRepository(db *mongo.Database) *ReputationRepository {
+	return &ReputationRepository{collection: db.Collection("npc_reputations")}
+}
+
+// ReputationDocument 声望文档
+type ReputationDocument struct {
+	CharacterID int64     `bson:"character_id"`
+	NPCID       int32     `bson:"npc_id"` // NPC单位ID
+	Value       int       `bson:"value"`
+	UpdatedAt   time.Time `bson:"updated_at"`
+}
+
+// Get 获取声望（无记录时为 0）
+func (r *ReputationRepository) Get(ctx context.Context, characterID int64, npcID int32) (int, error) {
+	var doc ReputationDocument
+	err := r.collection.FindOne(ctx, bson.M{"character_id": characterID, "npc_id": npcID}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to load reputation: %w", err)
+	}
+	return doc.Value, nil
+}
+
+// Add 增减声望（可在事务内调用）
+func (r *ReputationRepository) Add(ctx context.Context, characterID int64, npcID int32, delta int) error {
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"character_id": characterID, "npc_id": npcID},
+		bson.M{
+			"$inc": bson.M{"value": delta},
+			"$set": bson.M{"updated_at": time.Now()},
+		},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update reputation: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TradeRecordRepository 玩家交易审计仓储（供客服查询）
+type TradeRecordRepository struct {
+	collection *mongo.Collection
+}
+
+// NewTradeRecordRepository 创建交易审计仓储
+func NewTradeRecordRepository(db *mongo.Database) *TradeRecordRepository {
+	return &TradeRecordRepository{collection: db.Collection("trade_records")}
+}
+
+// TradeItemRecord 交易中转移的物品
+type TradeItemRecord struct {
+	ItemUID int64 `bson:"item_uid"`
+	ItemID  int32 `bson:"item_id"`
+	Count   int32 `bson:"count"`
+}
+
+// TradePartyRecord 交易一方付出的物品与金币
+type TradePartyRecord struct {
+	CharacterID int64             `bson:"character_id"`
+	Items       []TradeItemRecord `bson:"items,omitempty"`
+	Gold        int64             `bson:"gold,omitempty"`
+}
+
+// TradeRecordDocument 交易审计文档
+type TradeRecordDocument struct {
+	TradeID      int32               `bson:"trade_id"` // 进程内交易序号
+	MapID        int32               `bson:"map_id"`
+	Parties      [2]TradePartyRecord `bson:"parties"`
+	CharacterIDs []int64             `bson:"character_ids"` // 便于按角色查询
+	CompletedAt  time.Time           `bson:"completed_at"`
+}
+
+// Save 保存交易记录（可在事务内调用）
+func (r *TradeRecordRepository) Save(ctx context.Context, doc *TradeRecordDocument) error {
+	if doc.CompletedAt.IsZero() {
+		doc.CompletedAt = time.Now()
+	}
+	doc.CharacterIDs = []int64{doc.Parties[0].CharacterID, doc.Parties[1].CharacterID}
+	if _, err := r.collection.InsertOne(ctx, doc); err != nil {
+		return fmt.Errorf("failed to save trade record: %w", err)
+	}
+	return nil
+}
+
+// FindByCharacter 按时间倒序查询角色参与的交易
+func (r *TradeRecordRepository) FindByCharacter(ctx context.Context, characterID int64, limit int64) ([]*TradeRecordDocument, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "completed_at", Value: -1}})
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+	cursor, err := r.collection.Find(ctx, bson.M{"character_ids": characterID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find trade records: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var records []*TradeRecordDocument
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, fmt.Errorf("failed to decode trade records: %w", err)
+	}
+	return records, nil
+}
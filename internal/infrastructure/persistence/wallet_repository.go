@@ -0,0 +1,206 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"greatestworks/internal/infrastructure/logging"
+)
+
+// WalletRepository 钱包仓储：角色多货币余额与余额变动流水
+type WalletRepository struct {
+	wallets  *mongo.Collection
+	ledger   *mongo.Collection
+	base     *BaseRepository
+	fallback logging.Logger // 非空时允许无事务降级
+}
+
+// NewWalletRepository 创建钱包仓储
+func NewWalletRepository(db *mongo.Database) *WalletRepository {
+	return &WalletRepository{
+		wallets: db.Collection("wallets"),
+		ledger:  db.Collection("wallet_ledger"),
+		base:    NewBaseRepository(db, nil, nil, "wallets"),
+	}
+}
+
+// WalletDocument 角色钱包文档
+type WalletDocument struct {
+	CharacterID int64            `bson:"character_id"`
+	Balances    map[string]int64 `bson:"balances"`
+	UpdatedAt   time.Time        `bson:"updated_at"`
+}
+
+// LedgerEntryDocument 余额变动流水（entry_key 唯一，同一键只入账一次）
+type LedgerEntryDocument struct {
+	EntryKey    string    `bson:"entry_key"`
+	CharacterID int64     `bson:"character_id"`
+	Currency    string    `bson:"currency"`
+	Delta       int64     `bson:"delta"`
+	Balance     int64     `bson:"balance"` // 变动后的余额
+	Reason      string    `bson:"reason"`
+	Source      string    `bson:"source,omitempty"`
+	CreatedAt   time.Time `bson:"created_at"`
+}
+
+// LedgerFilter 流水查询条件（零值表示不限）
+type LedgerFilter struct {
+	CharacterID int64
+	Currency    string
+	Reason      string
+	Source      string
+	Since       time.Time
+	Until       time.Time
+	Offset      int64
+	Limit       int64
+}
+
+// EnsureIndexes 创建钱包与流水索引（流水键唯一索引是幂等入账的前提）
+func (r *WalletRepository) EnsureIndexes(ctx context.Context) error {
+	if _, err := r.wallets.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "character_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}); err != nil {
+		return fmt.Errorf("failed to create wallet indexes: %w", err)
+	}
+	if _, err := r.ledger.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "entry_key", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "character_id", Value: 1}, {Key: "created_at", Value: -1}},
+		},
+	}); err != nil {
+		return fmt.Errorf("failed to create ledger indexes: %w", err)
+	}
+	return nil
+}
+
+// WithTransaction 在事务中执行 fn；单机部署不支持事务时返回错误（除非允许降级）
+func (r *WalletRepository) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return withTransaction(ctx, r.base, r.fallback, "wallets", fn)
+}
+
+// AllowNonTransactional 仅开发环境：单机 MongoDB 不支持事务时降级为无事务执行，每次降级记录告警
+func (r *WalletRepository) AllowNonTransactional(logger logging.Logger) {
+	r.fallback = logger
+}
+
+// InTransaction ctx 是否已处于事务会话中
+func (r *WalletRepository) InTransaction(ctx context.Context) bool {
+	return mongo.SessionFromContext(ctx) != nil
+}
+
+// Balances 查询角色钱包余额；钱包不存在时 ok 为 false
+func (r *WalletRepository) Balances(ctx context.Context, characterID int64) (balances map[string]int64, ok bool, err error) {
+	var doc WalletDocument
+	err = r.wallets.FindOne(ctx, bson.M{"character_id": characterID}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to find wallet: %w", err)
+	}
+	return doc.Balances, true, nil
+}
+
+// FindEntry 按流水键查找流水（不存在时返回 nil）
+func (r *WalletRepository) FindEntry(ctx context.Context, entryKey string) (*LedgerEntryDocument, error) {
+	var doc LedgerEntryDocument
+	err := r.ledger.FindOne(ctx, bson.M{"entry_key": entryKey}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find ledger entry: %w", err)
+	}
+	return &doc, nil
+}
+
+// Apply 按流水增减余额并写入流水（可在事务内调用）：扣除时要求余额足够，增加时按需创建钱包。
+// 流水键已入账时不做修改并返回已有流水；余额不足时 ok 为 false。entry 的 EntryKey 为空时自动生成，Balance 与 CreatedAt 由此填写
+func (r *WalletRepository) Apply(ctx context.Context, entry *LedgerEntryDocument) (applied *LedgerEntryDocument, ok bool, err error) {
+	if entry.EntryKey == "" {
+		entry.EntryKey = primitive.NewObjectID().Hex()
+	} else if prev, err := r.FindEntry(ctx, entry.EntryKey); err != nil || prev != nil {
+		return prev, err == nil, err
+	}
+
+	field := "balances." + entry.Currency
+	filter := bson.M{"character_id": entry.CharacterID}
+	if entry.Delta < 0 {
+		filter[field] = bson.M{"$gte": -entry.Delta}
+	}
+	var wallet WalletDocument
+	err = r.wallets.FindOneAndUpdate(
+		ctx,
+		filter,
+		bson.M{"$inc": bson.M{field: entry.Delta}, "$set": bson.M{"updated_at": time.Now()}},
+		options.FindOneAndUpdate().SetUpsert(entry.Delta >= 0).SetReturnDocument(options.After),
+	).Decode(&wallet)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to update wallet: %w", err)
+	}
+
+	entry.Balance = wallet.Balances[entry.Currency]
+	entry.CreatedAt = time.Now()
+	if _, err := r.ledger.InsertOne(ctx, entry); err != nil {
+		// 事务外执行时撤销余额变动（事务内由回滚撤销）
+		if !r.InTransaction(ctx) {
+			_, _ = r.wallets.UpdateOne(ctx, bson.M{"character_id": entry.CharacterID}, bson.M{"$inc": bson.M{field: -entry.Delta}})
+		}
+		return nil, false, fmt.Errorf("failed to save ledger entry: %w", err)
+	}
+	return entry, true, nil
+}
+
+// FindEntries 按时间倒序查询流水
+func (r *WalletRepository) FindEntries(ctx context.Context, filter LedgerFilter) ([]*LedgerEntryDocument, error) {
+	query := bson.M{"character_id": filter.CharacterID}
+	if filter.Currency != "" {
+		query["currency"] = filter.Currency
+	}
+	if filter.Reason != "" {
+		query["reason"] = filter.Reason
+	}
+	if filter.Source != "" {
+		query["source"] = filter.Source
+	}
+	created := bson.M{}
+	if !filter.Since.IsZero() {
+		created["$gte"] = filter.Since
+	}
+	if !filter.Until.IsZero() {
+		created["$lt"] = filter.Until
+	}
+	if len(created) > 0 {
+		query["created_at"] = created
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).SetSkip(filter.Offset)
+	if filter.Limit > 0 {
+		opts.SetLimit(filter.Limit)
+	}
+	cursor, err := r.ledger.Find(ctx, query, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find ledger entries: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var entries []*LedgerEntryDocument
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, fmt.Errorf("failed to decode ledger entries: %w", err)
+	}
+	return entries, nil
+}
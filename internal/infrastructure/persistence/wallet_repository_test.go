@@ -0,0 +1,161 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+
+	"greatestworks/internal/infrastructure/logging"
+)
+
+func commandNames(mt *mtest.T) []string {
+	var names []string
+	for _, evt := range mt.GetAllStartedEvents() {
+		names = append(names, evt.CommandName)
+	}
+	return names
+}
+
+func TestWalletApplyIsIdempotent(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	ctx := context.Background()
+
+	mt.Run("replayed key returns stored entry without changing balance", func(mt *mtest.T) {
+		repo := NewWalletRepository(mt.DB)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.wallet_ledger", mtest.FirstBatch, bson.D{
+			{Key: "entry_key", Value: "trade:9:2"},
+			{Key: "character_id", Value: int64(2)},
+			{Key: "currency", Value: "gold"},
+			{Key: "delta", Value: int64(100)},
+			{Key: "balance", Value: int64(300)},
+		}))
+		applied, ok, err := repo.Apply(ctx, &LedgerEntryDocument{EntryKey: "trade:9:2", CharacterID: 2, Currency: "gold", Delta: 100, Reason: "trade"})
+		if err != nil || !ok {
+			mt.Fatalf("apply: ok=%v err=%v", ok, err)
+		}
+		if applied.Balance != 300 {
+			mt.Fatalf("balance %d, want the stored 300", applied.Balance)
+		}
+		if names := commandNames(mt); len(names) != 1 || names[0] != "find" {
+			mt.Fatalf("replay should only look up the entry, sent %v", names)
+		}
+	})
+
+	mt.Run("new key updates wallet and records entry", func(mt *mtest.T) {
+		repo := NewWalletRepository(mt.DB)
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "db.wallet_ledger", mtest.FirstBatch),
+			bson.D{{Key: "ok", Value: 1}, {Key: "value", Value: bson.D{
+				{Key: "character_id", Value: int64(2)},
+				{Key: "balances", Value: bson.D{{Key: "gold", Value: int64(400)}}},
+			}}},
+			mtest.CreateSuccessResponse(),
+		)
+		entry := &LedgerEntryDocument{EntryKey: "trade:9:2", CharacterID: 2, Currency: "gold", Delta: 100, Reason: "trade"}
+		applied, ok, err := repo.Apply(ctx, entry)
+		if err != nil || !ok {
+			mt.Fatalf("apply: ok=%v err=%v", ok, err)
+		}
+		if applied != entry || applied.Balance != 400 {
+			mt.Fatalf("expected entry with balance 400, got %+v", applied)
+		}
+		if names := strings.Join(commandNames(mt), ","); names != "find,findAndModify,insert" {
+			mt.Fatalf("unexpected commands %s", names)
+		}
+	})
+
+	mt.Run("insufficient balance changes nothing", func(mt *mtest.T) {
+		repo := NewWalletRepository(mt.DB)
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "db.wallet_ledger", mtest.FirstBatch),
+			bson.D{{Key: "ok", Value: 1}, {Key: "value", Value: nil}},
+		)
+		applied, ok, err := repo.Apply(ctx, &LedgerEntryDocument{EntryKey: "shop:5", CharacterID: 2, Currency: "gold", Delta: -100, Reason: "shop_buy"})
+		if err != nil || ok || applied != nil {
+			mt.Fatalf("expected ok=false without error, got ok=%v applied=%+v err=%v", ok, applied, err)
+		}
+		if names := strings.Join(commandNames(mt), ","); names != "find,findAndModify" {
+			mt.Fatalf("ledger entry must not be written, sent %s", names)
+		}
+	})
+}
+
+func TestWithTransactionWithoutReplicaSet(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	ctx := context.Background()
+	notSupported := mtest.CreateCommandErrorResponse(mtest.CommandError{
+		Code:    illegalOperationCode,
+		Name:    "IllegalOperation",
+		Message: "Transaction numbers are only allowed on a replica set member or mongos",
+	})
+
+	mt.Run("returns error by default", func(mt *mtest.T) {
+		repo := NewWalletRepository(mt.DB)
+		mt.AddMockResponses(notSupported)
+		calls := 0
+		err := repo.WithTransaction(ctx, func(txCtx context.Context) error {
+			calls++
+			_, _, err := repo.Balances(txCtx, 1)
+			return err
+		})
+		var se mongo.ServerError
+		if err == nil || !strings.Contains(err.Error(), "replica set required") {
+			mt.Fatalf("expected replica set error, got %v", err)
+		}
+		if !errors.As(err, &se) || !se.HasErrorCode(illegalOperationCode) {
+			mt.Fatalf("expected wrapped server error, got %v", err)
+		}
+		if calls != 1 {
+			mt.Fatalf("fn ran %d times without fallback, want 1", calls)
+		}
+	})
+
+	mt.Run("falls back when allowed", func(mt *mtest.T) {
+		repo := NewWalletRepository(mt.DB)
+		repo.AllowNonTransactional(logging.NewBaseLogger(logging.FatalLevel))
+		mt.AddMockResponses(
+			notSupported,
+			mtest.CreateSuccessResponse(), // abortTransaction
+			mtest.CreateCursorResponse(0, "db.wallets", mtest.FirstBatch),
+		)
+		calls := 0
+		err := repo.WithTransaction(ctx, func(txCtx context.Context) error {
+			calls++
+			_, _, err := repo.Balances(txCtx, 1)
+			return err
+		})
+		if err != nil {
+			mt.Fatalf("fallback: %v", err)
+		}
+		if calls != 2 {
+			mt.Fatalf("fn ran %d times, want once in the transaction and once without", calls)
+		}
+	})
+}
+
+func TestWithTransactionJoinsOuterSession(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("nested call runs in the caller's session", func(mt *mtest.T) {
+		repo := NewWalletRepository(mt.DB)
+		err := mt.Client.UseSession(context.Background(), func(sc mongo.SessionContext) error {
+			return repo.WithTransaction(sc, func(txCtx context.Context) error {
+				if txCtx != sc {
+					mt.Fatalf("nested transaction should reuse the caller's session context")
+				}
+				return nil
+			})
+		})
+		if err != nil {
+			mt.Fatalf("nested: %v", err)
+		}
+		if names := commandNames(mt); len(names) != 0 {
+			mt.Fatalf("nested call should not start its own transaction, sent %v", names)
+		}
+	})
+}
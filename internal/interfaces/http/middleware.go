@@ -0,0 +1,39 @@
+package http
+
+import (
+	"net/http"
+	"strings"
+
+	"greatestworks/internal/infrastructure/auth"
+	"greatestworks/internal/infrastructure/logging"
+)
+
+// RoleAdmin 管理员角色（令牌 role 声明）
+const RoleAdmin = "admin"
+
+// RequireRole 校验 Authorization 头中的 Bearer 令牌并要求指定角色：缺少或无效令牌返回 401，角色不符返回 403
+func RequireRole(jwtService *auth.JWTService, logger logging.Logger, role string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token == "" {
+			http.Error(w, "missing authorization header", http.StatusUnauthorized)
+			return
+		}
+		claims, err := jwtService.ValidateToken(token)
+		if err != nil {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+		if claims.Role != role {
+			logger.Warn("Insufficient permissions", logging.Fields{
+				"user_id":       claims.UserID,
+				"path":          r.URL.Path,
+				"required_role": role,
+				"user_role":     claims.Role,
+			})
+			http.Error(w, "insufficient permissions", http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,58 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"greatestworks/internal/infrastructure/auth"
+	"greatestworks/internal/infrastructure/logging"
+)
+
+func TestRequireRole(t *testing.T) {
+	logger := logging.NewBaseLogger(logging.FatalLevel)
+	jwtService := auth.NewJWTService(&auth.JWTConfig{
+		Secret:         "test-secret",
+		Issuer:         "greatestworks",
+		Audience:       "mmo-players",
+		AccessTokenTTL: time.Hour,
+		SigningMethod:  jwt.SigningMethodHS256,
+	}, logger)
+	token := func(role string) string {
+		s, _, err := jwtService.GenerateToken("1", "gm", role)
+		if err != nil {
+			t.Fatalf("generate token: %v", err)
+		}
+		return "Bearer " + s
+	}
+	handler := RequireRole(jwtService, logger, RoleAdmin, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name   string
+		header string
+		want   int
+	}{
+		{"missing token", "", http.StatusUnauthorized},
+		{"invalid token", "Bearer not-a-token", http.StatusUnauthorized},
+		{"player token", token("user"), http.StatusForbidden},
+		{"admin token", token(RoleAdmin), http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/wallet/balances?character_id=1", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+			if rec.Code != tt.want {
+				t.Fatalf("status %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
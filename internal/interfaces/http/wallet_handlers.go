@@ -0,0 +1,108 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"greatestworks/internal/application/services"
+	"greatestworks/internal/infrastructure/auth"
+	"greatestworks/internal/infrastructure/logging"
+	"greatestworks/internal/infrastructure/persistence"
+)
+
+// WalletHTTPHandlers 提供钱包余额与流水查询的HTTP处理器（客服排查争议使用）
+type WalletHTTPHandlers struct {
+	app    *services.WalletService
+	logger logging.Logger
+}
+
+func NewWalletHTTPHandlers(app *services.WalletService, logger logging.Logger) *WalletHTTPHandlers {
+	return &WalletHTTPHandlers{app: app, logger: logger}
+}
+
+// RegisterWalletRoutes 在给定服务器上注册钱包相关路由（仅管理员令牌可访问）
+func RegisterWalletRoutes(s *Server, h *WalletHTTPHandlers, jwtService *auth.JWTService) {
+	s.Handle("GET", "/wallet/balances", RequireRole(jwtService, h.logger, RoleAdmin, h.GetBalances))
+	s.Handle("GET", "/wallet/ledger", RequireRole(jwtService, h.logger, RoleAdmin, h.GetLedger))
+}
+
+// GetBalances 查询角色全部货币余额（通过查询参数传入character_id）
+func (h *WalletHTTPHandlers) GetBalances(w http.ResponseWriter, r *http.Request) {
+	characterID, err := strconv.ParseInt(r.URL.Query().Get("character_id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid character_id", http.StatusBadRequest)
+		return
+	}
+	balances, err := h.app.Balances(r.Context(), characterID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]interface{}{"character_id": characterID, "balances": balances})
+}
+
+// GetLedger 按时间倒序查询角色流水；可按 currency、reason、source、since/until（RFC3339）过滤并用 offset/limit 分页
+func (h *WalletHTTPHandlers) GetLedger(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := persistence.LedgerFilter{
+		Currency: q.Get("currency"),
+		Reason:   q.Get("reason"),
+		Source:   q.Get("source"),
+	}
+	var err error
+	if filter.CharacterID, err = strconv.ParseInt(q.Get("character_id"), 10, 64); err != nil {
+		http.Error(w, "invalid character_id", http.StatusBadRequest)
+		return
+	}
+	for name, dst := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if v := q.Get(name); v != "" {
+			if *dst, err = time.Parse(time.RFC3339, v); err != nil {
+				http.Error(w, "invalid "+name, http.StatusBadRequest)
+				return
+			}
+		}
+	}
+	for name, dst := range map[string]*int64{"offset": &filter.Offset, "limit": &filter.Limit} {
+		if v := q.Get(name); v != "" {
+			if *dst, err = strconv.ParseInt(v, 10, 64); err != nil || *dst < 0 {
+				http.Error(w, "invalid "+name, http.StatusBadRequest)
+				return
+			}
+		}
+	}
+
+	entries, err := h.app.History(r.Context(), filter)
+	if errors.Is(err, services.ErrInvalidCurrency) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	type ledgerEntry struct {
+		EntryKey  string    `json:"entry_key"`
+		Currency  string    `json:"currency"`
+		Delta     int64     `json:"delta"`
+		Balance   int64     `json:"balance"`
+		Reason    string    `json:"reason"`
+		Source    string    `json:"source,omitempty"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+	out := make([]ledgerEntry, 0, len(entries))
+	for _, e := range entries {
+		out = append(out, ledgerEntry{
+			EntryKey:  e.EntryKey,
+			Currency:  e.Currency,
+			Delta:     e.Delta,
+			Balance:   e.Balance,
+			Reason:    e.Reason,
+			Source:    e.Source,
+			CreatedAt: e.CreatedAt,
+		})
+	}
+	writeJSON(w, map[string]interface{}{"character_id": filter.CharacterID, "entries": out})
+}
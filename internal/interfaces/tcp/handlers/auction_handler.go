@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	appServices "greatestworks/internal/application/services"
+	"greatestworks/internal/infrastructure/persistence"
+	"greatestworks/internal/interfaces/tcp/connection"
+	"greatestworks/internal/interfaces/tcp/protocol"
+)
+
+// SetAuctionService 注入拍卖行服务
+func (h *GameHandler) SetAuctionService(as *appServices.AuctionService) { h.auctionService = as }
+
+// handleAuction 拍卖行：上架、检索、竞拍、一口价与下架
+func (h *GameHandler) handleAuction(session *connection.Session, message *protocol.Message) error {
+	if h.auctionService == nil || h.connManager == nil {
+		return fmt.Errorf("auction service or connection manager not ready")
+	}
+	var req protocol.AuctionRequest
+	if payloadMap, ok := message.Payload.(map[string]interface{}); ok {
+		if b, err := json.Marshal(payloadMap); err == nil {
+			_ = json.Unmarshal(b, &req)
+		}
+	}
+	entityID, ok := h.connManager.GetPlayerBySession(session.ID)
+	if !ok {
+		return fmt.Errorf("no bound entity for session")
+	}
+	// 实体ID即角色ID
+	characterID := int64(entityID)
+
+	ctx := context.Background()
+	var views []*appServices.AuctionView
+	var err error
+	single := func(v *appServices.AuctionView, e error) {
+		if e == nil {
+			views = []*appServices.AuctionView{v}
+		}
+		err = e
+	}
+	switch req.Action {
+	case protocol.AuctionActionList:
+		single(h.auctionService.List(ctx, characterID, appServices.AuctionListing{
+			ItemUID:  req.ItemUID,
+			Count:    req.Count,
+			StartBid: req.StartBid,
+			Buyout:   req.Buyout,
+			Duration: time.Duration(req.Hours) * time.Hour,
+		}))
+	case protocol.AuctionActionSearch, protocol.AuctionActionMine:
+		filter := persistence.AuctionFilter{
+			ItemID:     req.ItemID,
+			ItemType:   req.ItemType,
+			MinQuality: req.MinQuality,
+			MinLevel:   req.MinLevel,
+			MaxLevel:   req.MaxLevel,
+			Offset:     int64(req.Offset),
+			Limit:      int64(req.Limit),
+		}
+		if req.Action == protocol.AuctionActionMine {
+			filter.SellerID = characterID
+		}
+		views, err = h.auctionService.Search(ctx, filter)
+	case protocol.AuctionActionBid:
+		single(h.auctionService.Bid(ctx, characterID, req.AuctionID, req.Amount))
+	case protocol.AuctionActionBuyout:
+		single(h.auctionService.Buyout(ctx, characterID, req.AuctionID))
+	case protocol.AuctionActionCancel:
+		single(h.auctionService.Cancel(ctx, characterID, req.AuctionID))
+	default:
+		err = fmt.Errorf("unknown auction action: %s", req.Action)
+	}
+
+	payload := protocol.AuctionResponse{Action: req.Action}
+	if err != nil {
+		payload.BaseResponse = protocol.NewBaseResponse(false, err.Error())
+	} else {
+		payload.BaseResponse = protocol.NewBaseResponse(true, "auction "+req.Action)
+		for _, v := range views {
+			payload.Auctions = append(payload.Auctions, toAuctionInfo(v))
+		}
+	}
+	return h.sendBattleResponse(session, message, payload)
+}
+
+// toAuctionInfo 拍卖快照转换为协议结构
+func toAuctionInfo(v *appServices.AuctionView) *protocol.AuctionInfo {
+	return &protocol.AuctionInfo{
+		AuctionID: v.AuctionID,
+		SellerID:  v.SellerID,
+		ItemID:    v.ItemID,
+		Count:     v.Count,
+		StartBid:  v.StartBid,
+		Buyout:    v.Buyout,
+		Bid:       v.Bid,
+		BidderID:  v.BidderID,
+		MinBid:    v.MinBid,
+		Status:    v.Status,
+		ExpireAt:  v.ExpireAt,
+	}
+}
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	appServices "greatestworks/internal/application/services"
+	"greatestworks/internal/interfaces/tcp/connection"
+	"greatestworks/internal/interfaces/tcp/protocol"
+)
+
+// SetBattleService 注入回合制战斗服务
+func (h *GameHandler) SetBattleService(bs *appServices.BattleService) { h.battleService = bs }
+
+// handleCreateBattle 创建回合制战斗（指定 vs_unit_id 时与 AI 对战）
+func (h *GameHandler) handleCreateBattle(session *connection.Session, message *protocol.Message) error {
+	var req protocol.CreateBattleRequest
+	entityID, err := h.battleRequest(session, message, &req)
+	if err != nil {
+		return err
+	}
+
+	view, err := h.battleService.CreateBattle(context.Background(), entityID, req.VsUnitID)
+	payload := protocol.CreateBattleResponse{BattleType: req.BattleType}
+	if err != nil {
+		payload.BaseResponse = protocol.NewBaseResponse(false, err.Error())
+	} else {
+		payload.BaseResponse = protocol.NewBaseResponse(true, "battle created")
+		payload.BattleID = view.BattleID
+		payload.Status = view.Status
+		payload.CreatedAt = time.Now()
+		payload.State = toBattleState(view)
+	}
+	return h.sendBattleResponse(session, message, payload)
+}
+
+// handleJoinBattle 加入等待中的战斗
+func (h *GameHandler) handleJoinBattle(session *connection.Session, message *protocol.Message) error {
+	var req protocol.JoinBattleRequest
+	entityID, err := h.battleRequest(session, message, &req)
+	if err != nil {
+		return err
+	}
+
+	view, err := h.battleService.JoinBattle(context.Background(), entityID, req.BattleID, req.Team)
+	payload := protocol.JoinBattleResponse{BattleID: req.BattleID}
+	if err != nil {
+		payload.BaseResponse = protocol.NewBaseResponse(false, err.Error())
+	} else {
+		payload.BaseResponse = protocol.NewBaseResponse(true, "joined")
+		payload.State = toBattleState(view)
+		for _, p := range view.Participants {
+			if p.PlayerID == strconv.Itoa(int(entityID)) {
+				payload.PlayerTeam = p.Team
+			}
+		}
+	}
+	return h.sendBattleResponse(session, message, payload)
+}
+
+// handleBattleAction 执行回合行动（attack/skill/defend/heal/escape）
+func (h *GameHandler) handleBattleAction(session *connection.Session, message *protocol.Message) error {
+	var req protocol.BattleActionRequest
+	entityID, err := h.battleRequest(session, message, &req)
+	if err != nil {
+		return err
+	}
+
+	payload := protocol.BattleActionResponse{BattleID: req.BattleID}
+	actionType, err := appServices.ParseBattleAction(req.ActionType)
+	if err == nil {
+		var action *appServices.BattleActionView
+		var view *appServices.BattleView
+		action, view, err = h.battleService.Act(context.Background(), entityID, actionType, req.TargetID, req.SkillID)
+		if err == nil {
+			payload.BattleState = toBattleState(view)
+			payload.NextTurn = view.CurrentTurn
+			if action != nil {
+				payload.ActionResult = toActionResult(action)
+			}
+		}
+	}
+	if err != nil {
+		payload.BaseResponse = protocol.NewBaseResponse(false, err.Error())
+	} else {
+		payload.BaseResponse = protocol.NewBaseResponse(true, "action done")
+	}
+	return h.sendBattleResponse(session, message, payload)
+}
+
+// handleBattleStatus 开始、离开或查询战斗
+func (h *GameHandler) handleBattleStatus(session *connection.Session, message *protocol.Message) error {
+	var req protocol.BattleStatusRequest
+	entityID, err := h.battleRequest(session, message, &req)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	var view *appServices.BattleView
+	switch message.Header.MessageType {
+	case protocol.MsgStartBattle:
+		view, err = h.battleService.StartBattle(ctx, entityID)
+	case protocol.MsgLeaveBattle:
+		view, err = h.battleService.Leave(ctx, entityID)
+	default:
+		view, err = h.battleService.Status(ctx, entityID, req.BattleID)
+	}
+	payload := protocol.BattleStatusResponse{BattleID: req.BattleID}
+	if err != nil {
+		payload.BaseResponse = protocol.NewBaseResponse(false, err.Error())
+	} else {
+		payload.BaseResponse = protocol.NewBaseResponse(true, "ok")
+		payload.BattleID = view.BattleID
+		payload.State = toBattleState(view)
+	}
+	return h.sendBattleResponse(session, message, payload)
+}
+
+// battleRequest 解析战斗请求并取得会话绑定的实体
+func (h *GameHandler) battleRequest(session *connection.Session, message *protocol.Message, req interface{}) (int32, error) {
+	if h.battleService == nil || h.connManager == nil {
+		return 0, fmt.Errorf("battle service or connection manager not ready")
+	}
+	if payloadMap, ok := message.Payload.(map[string]interface{}); ok {
+		if b, err := json.Marshal(payloadMap); err == nil {
+			_ = json.Unmarshal(b, req)
+		}
+	}
+	entityID, ok := h.connManager.GetPlayerBySession(session.ID)
+	if !ok {
+		return 0, fmt.Errorf("no bound entity for session")
+	}
+	return entityID, nil
+}
+
+// sendBattleResponse 发送战斗响应（消息类型与请求相同）
+func (h *GameHandler) sendBattleResponse(session *connection.Session, message *protocol.Message, payload interface{}) error {
+	resp := &protocol.Message{
+		Header: protocol.MessageHeader{
+			Magic:       protocol.MessageMagic,
+			MessageID:   message.Header.MessageID,
+			MessageType: message.Header.MessageType,
+			Flags:       protocol.FlagResponse,
+			PlayerID:    message.Header.PlayerID,
+			Timestamp:   time.Now().Unix(),
+		},
+		Payload: payload,
+	}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("序列化战斗响应失败: %w", err)
+	}
+	return session.Send(data)
+}
+
+// toBattleState 战斗状态转换为协议结构
+func toBattleState(view *appServices.BattleView) *protocol.BattleState {
+	state := &protocol.BattleState{
+		Status:       view.Status,
+		CurrentRound: view.Round,
+		MaxRounds:    view.MaxRounds,
+		CurrentTurn:  view.CurrentTurn,
+		TurnEndsIn:   view.TurnEndsIn,
+		Winner:       view.Winner,
+		PlayerStates: make(map[string]*protocol.PlayerState, len(view.Participants)),
+	}
+	for i, p := range view.Participants {
+		state.TurnOrder = append(state.TurnOrder, p.PlayerID)
+		ps := &protocol.PlayerState{
+			PlayerID: p.PlayerID,
+			HP:       p.HP,
+			MaxHP:    p.MaxHP,
+			MP:       p.MP,
+			Position: i,
+			Team:     p.Team,
+			IsAlive:  p.IsAlive,
+		}
+		if p.Defending {
+			ps.Status = append(ps.Status, "defending")
+		}
+		if p.IsAI {
+			ps.Status = append(ps.Status, "ai")
+		}
+		state.PlayerStates[p.PlayerID] = ps
+	}
+	return state
+}
+
+// toActionResult 行动结果转换为协议结构
+func toActionResult(action *appServices.BattleActionView) *protocol.ActionResult {
+	return &protocol.ActionResult{
+		ActionID:   action.ActionID,
+		PlayerID:   action.ActorID,
+		ActionType: action.ActionType,
+		TargetID:   action.TargetID,
+		SkillID:    action.SkillID,
+		Damage:     action.Damage,
+		Healing:    action.Healing,
+		Success:    true,
+		Timestamp:  action.Timestamp,
+	}
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	appServices "greatestworks/internal/application/services"
+	"greatestworks/internal/infrastructure/persistence"
+	"greatestworks/internal/interfaces/tcp/connection"
+	"greatestworks/internal/interfaces/tcp/protocol"
+)
+
+// SetCraftService 注入制造服务
+func (h *GameHandler) SetCraftService(cs *appServices.CraftService) { h.craftService = cs }
+
+// handleCraft 制造：配方列表、开始/取消制造、进度与制造记录（逐件结果通过 craft_progress/craft_end 推送）
+func (h *GameHandler) handleCraft(session *connection.Session, message *protocol.Message) error {
+	if h.craftService == nil || h.connManager == nil {
+		return fmt.Errorf("craft service or connection manager not ready")
+	}
+	var req protocol.CraftRequest
+	if payloadMap, ok := message.Payload.(map[string]interface{}); ok {
+		if b, err := json.Marshal(payloadMap); err == nil {
+			_ = json.Unmarshal(b, &req)
+		}
+	}
+	entityID, ok := h.connManager.GetPlayerBySession(session.ID)
+	if !ok {
+		return fmt.Errorf("no bound entity for session")
+	}
+
+	ctx := context.Background()
+	payload := protocol.CraftResponse{Action: req.Action}
+	var status *appServices.CraftStatus
+	var err error
+	switch req.Action {
+	case protocol.CraftActionList:
+		var recipes []appServices.CraftRecipeView
+		if recipes, err = h.craftService.Recipes(ctx, entityID); err == nil {
+			for _, r := range recipes {
+				payload.Recipes = append(payload.Recipes, toCraftRecipeInfo(r))
+			}
+		}
+	case protocol.CraftActionStart:
+		status, err = h.craftService.Start(ctx, entityID, req.RecipeID, req.Count)
+	case protocol.CraftActionCancel:
+		status, err = h.craftService.Cancel(entityID)
+	case protocol.CraftActionStatus:
+		status, err = h.craftService.Status(entityID)
+	case protocol.CraftActionHistory:
+		var records []*persistence.CraftRecordDocument
+		if records, err = h.craftService.History(ctx, entityID, req.Limit); err == nil {
+			for _, r := range records {
+				payload.Records = append(payload.Records, toCraftRecordInfo(r))
+			}
+		}
+	default:
+		err = fmt.Errorf("unknown craft action: %s", req.Action)
+	}
+
+	if err != nil {
+		payload.BaseResponse = protocol.NewBaseResponse(false, err.Error())
+	} else {
+		payload.BaseResponse = protocol.NewBaseResponse(true, "craft "+req.Action)
+		if status != nil {
+			payload.Status = &protocol.CraftStatusInfo{
+				RecipeID:    status.RecipeID,
+				Total:       status.Total,
+				Done:        status.Done,
+				Succeeded:   status.Succeeded,
+				Elapsed:     status.Elapsed,
+				Duration:    status.Duration,
+				SuccessRate: status.SuccessRate,
+			}
+		}
+	}
+	return h.sendBattleResponse(session, message, payload)
+}
+
+// toCraftRecipeInfo 配方转换为协议结构
+func toCraftRecipeInfo(r appServices.CraftRecipeView) *protocol.CraftRecipeInfo {
+	info := &protocol.CraftRecipeInfo{
+		RecipeID:      r.RecipeID,
+		Name:          r.Name,
+		Profession:    int32(r.Profession),
+		SkillLevel:    r.SkillLevel,
+		RequiredLevel: r.RequiredLevel,
+		Skill:         r.Skill,
+		SuccessRate:   r.SuccessRate,
+		CraftTime:     r.CraftTime,
+	}
+	for _, m := range r.Materials {
+		info.Materials = append(info.Materials, protocol.CraftItemInfo{ItemID: m.ItemID, Count: m.Count})
+	}
+	for _, out := range r.Outputs {
+		info.Outputs = append(info.Outputs, protocol.CraftItemInfo{ItemID: out.ItemID, Count: out.Count})
+	}
+	return info
+}
+
+// toCraftRecordInfo 制造记录转换为协议结构
+func toCraftRecordInfo(doc *persistence.CraftRecordDocument) *protocol.CraftRecordInfo {
+	info := &protocol.CraftRecordInfo{
+		RecipeID:  doc.RecipeID,
+		Success:   doc.Success,
+		Skill:     doc.Skill,
+		CreatedAt: doc.CreatedAt.UnixMilli(),
+	}
+	for _, out := range doc.Outputs {
+		info.Outputs = append(info.Outputs, protocol.CraftItemInfo{ItemID: out.ItemID, Count: out.Count})
+	}
+	return info
+}
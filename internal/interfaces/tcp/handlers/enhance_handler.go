@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	appServices "greatestworks/internal/application/services"
+	"greatestworks/internal/interfaces/tcp/connection"
+	"greatestworks/internal/interfaces/tcp/protocol"
+)
+
+// SetEnhanceService 注入装备强化服务
+func (h *GameHandler) SetEnhanceService(es *appServices.EnhanceService) { h.enhanceService = es }
+
+// handleEnhance 强化背包或装备栏中的装备
+func (h *GameHandler) handleEnhance(session *connection.Session, message *protocol.Message) error {
+	if h.enhanceService == nil || h.connManager == nil {
+		return fmt.Errorf("enhance service or connection manager not ready")
+	}
+	var req protocol.EnhanceRequest
+	if payloadMap, ok := message.Payload.(map[string]interface{}); ok {
+		if b, err := json.Marshal(payloadMap); err == nil {
+			_ = json.Unmarshal(b, &req)
+		}
+	}
+	entityID, ok := h.connManager.GetPlayerBySession(session.ID)
+	if !ok {
+		return fmt.Errorf("no bound entity for session")
+	}
+
+	payload := protocol.EnhanceResponse{ItemUID: req.ItemUID}
+	result, err := h.enhanceService.Enhance(context.Background(), entityID, req.ItemUID, req.Protect)
+	if err != nil {
+		payload.BaseResponse = protocol.NewBaseResponse(false, err.Error())
+		return h.sendBattleResponse(session, message, payload)
+	}
+	if result.Success {
+		payload.BaseResponse = protocol.NewBaseResponse(true, "enhance succeeded")
+	} else {
+		payload.BaseResponse = protocol.NewBaseResponse(true, "enhance failed")
+	}
+	payload.ItemID = result.ItemID
+	payload.Success = result.Success
+	payload.Level = result.Level
+	payload.Failure = result.Failure
+	payload.Protected = result.Protected
+	payload.Gold = result.Gold
+	return h.sendBattleResponse(session, message, payload)
+}
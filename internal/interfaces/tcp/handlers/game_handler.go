@@ -21,6 +21,19 @@ type GameHandler struct {
 	mapService       *appServices.MapService
 	fightService     *appServices.FightService
 	characterService *appServices.CharacterService
+	portalService    *appServices.PortalService
+	lootService      *appServices.LootService
+	equipmentService *appServices.EquipmentService
+	respawnService   *appServices.RespawnService
+	battleService    *appServices.BattleService
+	pvpService       *appServices.PvPService
+	tradeService     *appServices.TradeService
+	auctionService   *appServices.AuctionService
+	mailService      *appServices.MailService
+	shopService      *appServices.ShopService
+	itemUseService   *appServices.ItemUseService
+	craftService     *appServices.CraftService
+	enhanceService   *appServices.EnhanceService
 }
 
 // NewGameHandler 创建游戏处理器
@@ -40,6 +53,18 @@ func (h *GameHandler) SetFightService(fs *appServices.FightService) { h.fightSer
 // SetCharacterService 注入角色服务
 func (h *GameHandler) SetCharacterService(cs *appServices.CharacterService) { h.characterService = cs }
 
+// SetPortalService 注入传送门服务
+func (h *GameHandler) SetPortalService(ps *appServices.PortalService) { h.portalService = ps }
+
+// SetLootService 注入掉落服务
+func (h *GameHandler) SetLootService(ls *appServices.LootService) { h.lootService = ls }
+
+// SetEquipmentService 注入装备服务
+func (h *GameHandler) SetEquipmentService(es *appServices.EquipmentService) { h.equipmentService = es }
+
+// SetRespawnService 注入复活服务
+func (h *GameHandler) SetRespawnService(rs *appServices.RespawnService) { h.respawnService = rs }
+
 // HandleMessage 处理消息
 func (h *GameHandler) HandleMessage(session *connection.Session, message *protocol.Message) error {
 	h.logger.Info("处理游戏消息", map[string]interface{}{
@@ -60,6 +85,44 @@ func (h *GameHandler) HandleMessage(session *connection.Session, message *protoc
 		return h.handlePlayerChat(session, message)
 	case protocol.MsgPlayerStats:
 		return h.handlePlayerAction(session, message)
+	case protocol.MsgPlayerStatusSync:
+		return h.handleSnapshotAck(session, message)
+	case protocol.MsgPlayerUpdate:
+		return h.handleChannelSwitch(session, message)
+	case protocol.MsgMapTransfer:
+		return h.handleMapTransfer(session, message)
+	case protocol.MsgItemPickup:
+		return h.handleItemPickup(session, message)
+	case protocol.MsgItemUse:
+		return h.handleItemUse(session, message)
+	case protocol.MsgItemCraft:
+		return h.handleCraft(session, message)
+	case protocol.MsgItemEnhance:
+		return h.handleEnhance(session, message)
+	case protocol.MsgItemTrade:
+		return h.handleTrade(session, message)
+	case protocol.MsgAuction:
+		return h.handleAuction(session, message)
+	case protocol.MsgMail:
+		return h.handleMail(session, message)
+	case protocol.MsgShop:
+		return h.handleShop(session, message)
+	case protocol.MsgItemEquip, protocol.MsgItemUnequip:
+		return h.handleItemEquip(session, message)
+	case protocol.MsgPlayerRespawn:
+		return h.handleRespawn(session, message)
+	case protocol.MsgPvPFlag:
+		return h.handlePvPFlag(session, message)
+	case protocol.MsgDuel:
+		return h.handleDuel(session, message)
+	case protocol.MsgCreateBattle:
+		return h.handleCreateBattle(session, message)
+	case protocol.MsgJoinBattle:
+		return h.handleJoinBattle(session, message)
+	case protocol.MsgBattleAction:
+		return h.handleBattleAction(session, message)
+	case protocol.MsgStartBattle, protocol.MsgLeaveBattle, protocol.MsgBattleStatus:
+		return h.handleBattleStatus(session, message)
 	case protocol.MsgChatMessage:
 		return h.handleChatMessage(session, message)
 	case protocol.MsgTeamCreate:
@@ -112,10 +175,12 @@ func (h *GameHandler) handlePlayerLogin(session *connection.Session, message *pr
 	// 推断地图ID与位置：优先从角色服务加载持久化位置
 	var mapID int32 = 1
 	var x, y, z float32 = 0, 0, 0
+	var deadAt time.Time
 	if h.characterService != nil && characterID != 0 {
 		if dbChar, err := h.characterService.GetCharacter(context.Background(), characterID); err == nil && dbChar != nil {
 			mapID = dbChar.MapID
 			x, y, z = dbChar.PositionX, dbChar.PositionY, dbChar.PositionZ
+			deadAt = dbChar.DeadAt
 		}
 	}
 	// 允许客户端覆盖map_id（可选协议字段）
@@ -133,11 +198,22 @@ func (h *GameHandler) handlePlayerLogin(session *connection.Session, message *pr
 	}
 	session.SetGroupID(fmt.Sprintf("map:%d", mapID))
 
-	// 确保地图加载并注册入地图（以便后续移动/AOI广播可用）
+	// 确保地图加载并注册入地图（以便后续移动/AOI广播与状态同步可用）
+	if h.mapService != nil && entityID != 0 {
+		player := h.loadPlayer(context.Background(), entityID, characterID)
+		// 死亡中下线：先恢复死亡记录，进入地图后继续死亡计时
+		if h.respawnService != nil && !deadAt.IsZero() && player.IsDeath() {
+			h.respawnService.RestoreDeath(entityID, characterID, deadAt)
+		}
+		if err := h.mapService.EnterMapActor(context.Background(), player.Actor, mapID, x, y, z); err != nil {
+			// 重连：实体仍在地图中，下一帧发送全量快照
+			_ = h.mapService.RequestFullSnapshot(context.Background(), mapID, entityID)
+		}
+	}
+
+	var channel int32
 	if h.mapService != nil && entityID != 0 {
-		_ = h.mapService.LoadMap(context.Background(), mapID)
-		e := character.NewEntity(character.EntityID(entityID), character.EntityTypePlayer, 1, character.NewVector3(0, 0, 0), character.NewVector3(0, 0, 1))
-		_ = h.mapService.EnterMap(context.Background(), e, mapID, x, y, z)
+		_, channel, _ = h.mapService.ChannelOf(entityID)
 	}
 
 	// 构造登录响应
@@ -155,6 +231,7 @@ func (h *GameHandler) handlePlayerLogin(session *connection.Session, message *pr
 			BaseResponse: protocol.NewBaseResponse(true, "login ok"),
 			SessionID:    session.ID,
 			ServerTime:   time.Now().Unix(),
+			Channel:      channel,
 		},
 	}
 
@@ -166,6 +243,20 @@ func (h *GameHandler) handlePlayerLogin(session *connection.Session, message *pr
 
 }
 
+// loadPlayer 加载玩家领域对象；角色服务不可用或加载失败时创建默认玩家
+func (h *GameHandler) loadPlayer(ctx context.Context, entityID int32, characterID int64) *character.Player {
+	if h.characterService != nil && characterID != 0 {
+		if player, err := h.characterService.LoadCharacter(ctx, characterID); err == nil {
+			return player
+		}
+	}
+	player := character.NewPlayer(character.EntityID(entityID), characterID, 0, 1,
+		character.NewVector3(0, 0, 0), character.NewVector3(0, 0, 1), "", 1)
+	_ = player.Start(ctx)
+	appServices.LearnUnitSkills(player.Actor)
+	return player
+}
+
 // handlePlayerLogout 处理玩家登出
 func (h *GameHandler) handlePlayerLogout(session *connection.Session, message *protocol.Message) error {
 	h.logger.Info("处理玩家登出", map[string]interface{}{
@@ -188,7 +279,7 @@ func (h *GameHandler) handlePlayerLogout(session *connection.Session, message *p
 				}
 				// 从地图中获取最终位置并保存
 				if h.characterService != nil && mapID > 0 {
-					if m, err := h.mapService.GetMap(mapID); err == nil && m != nil {
+					if m, err := h.mapService.GetEntityMap(mapID, entityID); err == nil && m != nil {
 						if e := m.GetEntity(character.EntityID(entityID)); e != nil {
 							pos := e.Position()
 							_ = h.characterService.UpdateLastLocation(
@@ -197,8 +288,33 @@ func (h *GameHandler) handlePlayerLogout(session *connection.Session, message *p
 						}
 					}
 				}
+				// 下线视为认输/取消决斗，并取消交易
+				if h.tradeService != nil {
+					h.tradeService.Forget(entityID)
+				}
+				if h.shopService != nil {
+					h.shopService.Forget(entityID)
+				}
+				if h.itemUseService != nil {
+					h.itemUseService.Forget(entityID)
+				}
+				if h.craftService != nil {
+					h.craftService.Forget(entityID)
+				}
+				if h.pvpService != nil {
+					_, _ = h.pvpService.ForfeitDuel(context.Background(), entityID)
+				}
 				_ = h.mapService.LeaveMapByID(context.Background(), mapID, entityID)
 			}
+			if h.portalService != nil {
+				h.portalService.Forget(entityID)
+			}
+			if h.respawnService != nil {
+				h.respawnService.Forget(entityID)
+			}
+			if h.battleService != nil {
+				_, _ = h.battleService.Leave(context.Background(), entityID)
+			}
 			h.connManager.UnbindPlayer(entityID)
 		} else if message.Header.PlayerID != 0 {
 			h.connManager.UnbindPlayer(int32(message.Header.PlayerID))
@@ -246,6 +362,11 @@ func (h *GameHandler) handlePlayerMove(session *connection.Session, message *pro
 		}
 	}
 
+	// 传送加载期间忽略移动
+	if h.portalService != nil && h.portalService.IsTransferring(entityID) {
+		return appServices.ErrTransferInProgress
+	}
+
 	// 执行位置更新
 	if err := h.mapService.UpdatePositionByID(
 		context.Background(),
@@ -254,6 +375,11 @@ func (h *GameHandler) handlePlayerMove(session *connection.Session, message *pro
 		return err
 	}
 
+	// 传送门区域检测（条件不满足时已通知客户端，不影响移动回执）
+	if h.portalService != nil {
+		_, _ = h.portalService.CheckPortals(context.Background(), mapID, entityID)
+	}
+
 	// 回执
 	resp := &protocol.Message{
 		Header: protocol.MessageHeader{
@@ -273,6 +399,309 @@ func (h *GameHandler) handlePlayerMove(session *connection.Session, message *pro
 	return session.Send(data)
 }
 
+// handleSnapshotAck 处理快照确认/重同步请求
+func (h *GameHandler) handleSnapshotAck(session *connection.Session, message *protocol.Message) error {
+	if h.mapService == nil || h.connManager == nil {
+		return fmt.Errorf("map service or connection manager not ready")
+	}
+
+	var req protocol.SnapshotAckRequest
+	if payloadMap, ok := message.Payload.(map[string]interface{}); ok {
+		if b, err := json.Marshal(payloadMap); err == nil {
+			_ = json.Unmarshal(b, &req)
+		}
+	}
+
+	entityID, ok := h.connManager.GetPlayerBySession(session.ID)
+	if !ok {
+		return fmt.Errorf("no bound entity for session")
+	}
+	mapID := sessionMapID(session)
+
+	if req.Resync {
+		return h.mapService.RequestFullSnapshot(context.Background(), mapID, entityID)
+	}
+	return h.mapService.AckSnapshot(context.Background(), mapID, entityID, req.AckTick)
+}
+
+// handleMapTransfer 处理传送确认（客户端加载完成）或取消
+func (h *GameHandler) handleMapTransfer(session *connection.Session, message *protocol.Message) error {
+	if h.portalService == nil || h.connManager == nil {
+		return fmt.Errorf("portal service or connection manager not ready")
+	}
+
+	var req protocol.MapTransferRequest
+	if payloadMap, ok := message.Payload.(map[string]interface{}); ok {
+		if b, err := json.Marshal(payloadMap); err == nil {
+			_ = json.Unmarshal(b, &req)
+		}
+	}
+
+	entityID, ok := h.connManager.GetPlayerBySession(session.ID)
+	if !ok {
+		return fmt.Errorf("no bound entity for session")
+	}
+
+	payload := protocol.MapTransferResponse{TicketID: req.TicketID}
+	if req.Cancel {
+		h.portalService.CancelTransfer(entityID)
+		payload.BaseResponse = protocol.NewBaseResponse(true, "transfer cancelled")
+	} else if ticket, err := h.portalService.ConfirmTransfer(context.Background(), entityID, req.TicketID); err != nil {
+		payload.BaseResponse = protocol.NewBaseResponse(false, err.Error())
+	} else {
+		payload.BaseResponse = protocol.NewBaseResponse(true, "transfer ok")
+		payload.MapID = ticket.ToMapID
+		payload.Node = ticket.Node
+		payload.Position = &protocol.Position{X: float64(ticket.X), Y: float64(ticket.Y), Z: float64(ticket.Z)}
+		if ticket.Node == "" {
+			session.SetGroupID(fmt.Sprintf("map:%d", ticket.ToMapID))
+			if h.mapService != nil {
+				_, payload.Channel, _ = h.mapService.ChannelOf(entityID)
+			}
+		}
+	}
+
+	resp := &protocol.Message{
+		Header: protocol.MessageHeader{
+			Magic:       protocol.MessageMagic,
+			MessageID:   message.Header.MessageID,
+			MessageType: uint32(protocol.MsgMapTransfer),
+			Flags:       protocol.FlagResponse,
+			PlayerID:    message.Header.PlayerID,
+			Timestamp:   time.Now().Unix(),
+		},
+		Payload: payload,
+	}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("序列化传送响应失败: %w", err)
+	}
+	return session.Send(data)
+}
+
+// handleItemPickup 处理拾取地面掉落物
+func (h *GameHandler) handleItemPickup(session *connection.Session, message *protocol.Message) error {
+	if h.lootService == nil || h.connManager == nil {
+		return fmt.Errorf("loot service or connection manager not ready")
+	}
+
+	var req protocol.ItemPickupRequest
+	if payloadMap, ok := message.Payload.(map[string]interface{}); ok {
+		if b, err := json.Marshal(payloadMap); err == nil {
+			_ = json.Unmarshal(b, &req)
+		}
+	}
+
+	entityID, ok := h.connManager.GetPlayerBySession(session.ID)
+	if !ok {
+		return fmt.Errorf("no bound entity for session")
+	}
+
+	payload := protocol.ItemPickupResponse{DropID: req.DropID}
+	drop, err := h.lootService.Pickup(context.Background(), sessionMapID(session), entityID, int64(entityID), req.DropID)
+	if err != nil {
+		payload.BaseResponse = protocol.NewBaseResponse(false, err.Error())
+	} else {
+		payload.BaseResponse = protocol.NewBaseResponse(true, "pickup ok")
+		payload.ItemID = drop.ItemID
+		payload.Count = drop.Count
+	}
+
+	resp := &protocol.Message{
+		Header: protocol.MessageHeader{
+			Magic:       protocol.MessageMagic,
+			MessageID:   message.Header.MessageID,
+			MessageType: uint32(protocol.MsgItemPickup),
+			Flags:       protocol.FlagResponse,
+			PlayerID:    message.Header.PlayerID,
+			Timestamp:   time.Now().Unix(),
+		},
+		Payload: payload,
+	}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("序列化拾取响应失败: %w", err)
+	}
+	return session.Send(data)
+}
+
+// handleItemEquip 处理穿戴/卸下装备
+func (h *GameHandler) handleItemEquip(session *connection.Session, message *protocol.Message) error {
+	if h.equipmentService == nil || h.connManager == nil {
+		return fmt.Errorf("equipment service or connection manager not ready")
+	}
+
+	var req protocol.ItemEquipRequest
+	if payloadMap, ok := message.Payload.(map[string]interface{}); ok {
+		if b, err := json.Marshal(payloadMap); err == nil {
+			_ = json.Unmarshal(b, &req)
+		}
+	}
+
+	entityID, ok := h.connManager.GetPlayerBySession(session.ID)
+	if !ok {
+		return fmt.Errorf("no bound entity for session")
+	}
+
+	ctx := context.Background()
+	var result *appServices.EquipResult
+	var err error
+	if message.Header.MessageType == protocol.MsgItemEquip {
+		result, err = h.equipmentService.Equip(ctx, entityID, int64(entityID), req.ItemUID)
+	} else {
+		result, err = h.equipmentService.Unequip(ctx, entityID, int64(entityID), character.EquipSlot(req.Slot))
+	}
+
+	payload := protocol.ItemEquipResponse{Slot: req.Slot}
+	if err != nil {
+		payload.BaseResponse = protocol.NewBaseResponse(false, err.Error())
+	} else {
+		payload.BaseResponse = protocol.NewBaseResponse(true, "equipment updated")
+		payload.Slot = int32(result.Slot)
+		if result.Equipped != nil {
+			payload.EquippedUID = result.Equipped.ItemUID
+		}
+		if result.Removed != nil {
+			payload.RemovedUID = result.Removed.ItemUID
+			payload.BagSlot = result.Removed.Slot
+		}
+	}
+
+	resp := &protocol.Message{
+		Header: protocol.MessageHeader{
+			Magic:       protocol.MessageMagic,
+			MessageID:   message.Header.MessageID,
+			MessageType: message.Header.MessageType,
+			Flags:       protocol.FlagResponse,
+			PlayerID:    message.Header.PlayerID,
+			Timestamp:   time.Now().Unix(),
+		},
+		Payload: payload,
+	}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("序列化装备响应失败: %w", err)
+	}
+	return session.Send(data)
+}
+
+// handleRespawn 处理死亡后复活
+func (h *GameHandler) handleRespawn(session *connection.Session, message *protocol.Message) error {
+	if h.respawnService == nil || h.connManager == nil {
+		return fmt.Errorf("respawn service or connection manager not ready")
+	}
+
+	var req protocol.RespawnRequest
+	if payloadMap, ok := message.Payload.(map[string]interface{}); ok {
+		if b, err := json.Marshal(payloadMap); err == nil {
+			_ = json.Unmarshal(b, &req)
+		}
+	}
+
+	entityID, ok := h.connManager.GetPlayerBySession(session.ID)
+	if !ok {
+		return fmt.Errorf("no bound entity for session")
+	}
+
+	result, err := h.respawnService.Respawn(context.Background(), entityID, appServices.RespawnOption(req.Option))
+	payload := protocol.RespawnResponse{Option: req.Option}
+	if err != nil {
+		payload.BaseResponse = protocol.NewBaseResponse(false, err.Error())
+	} else {
+		payload.BaseResponse = protocol.NewBaseResponse(true, "respawned")
+		payload.X, payload.Y, payload.Z = result.Position.X, result.Position.Y, result.Position.Z
+		payload.HP = result.HP
+		payload.ExpLost = result.ExpLost
+	}
+
+	resp := &protocol.Message{
+		Header: protocol.MessageHeader{
+			Magic:       protocol.MessageMagic,
+			MessageID:   message.Header.MessageID,
+			MessageType: protocol.MsgPlayerRespawn,
+			Flags:       protocol.FlagResponse,
+			PlayerID:    message.Header.PlayerID,
+			Timestamp:   time.Now().Unix(),
+		},
+		Payload: payload,
+	}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("序列化复活响应失败: %w", err)
+	}
+	return session.Send(data)
+}
+
+// handleChannelSwitch 处理切换线路（channel 为 0 时仅返回线路列表）
+func (h *GameHandler) handleChannelSwitch(session *connection.Session, message *protocol.Message) error {
+	if h.mapService == nil || h.connManager == nil {
+		return fmt.Errorf("map service or connection manager not ready")
+	}
+
+	var req protocol.ChannelSwitchRequest
+	if payloadMap, ok := message.Payload.(map[string]interface{}); ok {
+		if b, err := json.Marshal(payloadMap); err == nil {
+			_ = json.Unmarshal(b, &req)
+		}
+	}
+
+	entityID, ok := h.connManager.GetPlayerBySession(session.ID)
+	if !ok {
+		return fmt.Errorf("no bound entity for session")
+	}
+	mapID := sessionMapID(session)
+
+	result := protocol.NewBaseResponse(true, "channel ok")
+	if req.Channel != 0 {
+		if err := h.mapService.SwitchChannel(context.Background(), mapID, entityID, req.Channel); err != nil {
+			result = protocol.NewBaseResponse(false, err.Error())
+		}
+	}
+
+	payload := protocol.ChannelSwitchResponse{BaseResponse: result, MapID: mapID}
+	_, payload.Channel, _ = h.mapService.ChannelOf(entityID)
+	if infos, err := h.mapService.ListChannels(context.Background(), mapID); err == nil {
+		for _, info := range infos {
+			payload.Channels = append(payload.Channels, protocol.ChannelInfo{
+				Channel: info.Channel,
+				Players: info.Players,
+				SoftCap: info.SoftCap,
+				HardCap: info.HardCap,
+			})
+		}
+	}
+
+	resp := &protocol.Message{
+		Header: protocol.MessageHeader{
+			Magic:       protocol.MessageMagic,
+			MessageID:   message.Header.MessageID,
+			MessageType: uint32(protocol.MsgPlayerUpdate),
+			Flags:       protocol.FlagResponse,
+			PlayerID:    message.Header.PlayerID,
+			Timestamp:   time.Now().Unix(),
+		},
+		Payload: payload,
+	}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("序列化切线响应失败: %w", err)
+	}
+	return session.Send(data)
+}
+
+// sessionMapID 从会话GroupID（形如"map:<id>"）解析地图ID，默认1
+func sessionMapID(session *connection.Session) int32 {
+	var mapID int32 = 1
+	if gid := session.GetGroupID(); gid != "" {
+		if len(gid) > 4 && gid[:4] == "map:" {
+			if v, err := strconv.ParseInt(gid[4:], 10, 32); err == nil {
+				mapID = int32(v)
+			}
+		}
+	}
+	return mapID
+}
+
 // handlePlayerChat 处理玩家聊天
 func (h *GameHandler) handlePlayerChat(session *connection.Session, message *protocol.Message) error {
 	h.logger.Info("处理玩家聊天", map[string]interface{}{
@@ -301,7 +730,7 @@ func (h *GameHandler) handlePlayerAction(session *connection.Session, message *p
 	return nil
 }
 
-// handleSkillCast 处理技能释放（占位实现，待接FightService）
+// handleSkillCast 处理技能释放
 func (h *GameHandler) handleSkillCast(session *connection.Session, message *protocol.Message) error {
 	h.logger.Info("处理技能释放", logging.Fields{
 		"player_id":    message.Header.PlayerID,
@@ -309,11 +738,13 @@ func (h *GameHandler) handleSkillCast(session *connection.Session, message *prot
 		"message_type": message.Header.MessageType,
 	})
 
-	// 解析payload允许简单格式: { "skill_id": string|number, "target_id": string|number }
+	// 解析payload允许简单格式: { "skill_id": string|number, "target_id": string|number, "x": number, "z": number }
+	// x/z 为可选目标点（范围技能的圆心或朝向）
 	var skillIDStr string
 	var targetIDStr string
 	var skillID int32
 	var targetID int32
+	var point *character.Vector3
 	if payloadMap, ok := message.Payload.(map[string]interface{}); ok {
 		if v, ok := payloadMap["skill_id"]; ok {
 			switch vv := v.(type) {
@@ -331,6 +762,12 @@ func (h *GameHandler) handleSkillCast(session *connection.Session, message *prot
 				targetID = int32(vv)
 			}
 		}
+		x, hasX := payloadMap["x"].(float64)
+		z, hasZ := payloadMap["z"].(float64)
+		if hasX && hasZ {
+			p := character.NewVector3(float32(x), 0, float32(z))
+			point = &p
+		}
 	}
 
 	if skillID == 0 && skillIDStr != "" {
@@ -350,11 +787,11 @@ func (h *GameHandler) handleSkillCast(session *connection.Session, message *prot
 		casterID = int32(message.Header.PlayerID)
 	}
 
-	// 调用战斗服务计算伤害
+	// 调用战斗服务权威施法（施法/命中/死亡由地图向AOI观察者广播）
 	var castResult *appServices.SkillCastResult
 	var castErr error
 	if h.fightService != nil {
-		castResult, castErr = h.fightService.CastSkillByID(context.Background(), casterID, targetID, skillID)
+		castResult, castErr = h.fightService.CastSkillByID(context.Background(), casterID, targetID, skillID, point)
 	}
 
 	// 回执
@@ -387,18 +824,12 @@ func (h *GameHandler) handleSkillCast(session *connection.Session, message *prot
 				return targetIDStr
 			}(),
 			"caster_id": casterID,
-			"damage": func() int32 {
+			"cast_time": func() float32 {
 				if castResult != nil {
-					return castResult.Damage
+					return castResult.CastTime
 				}
 				return 0
 			}(),
-			"is_critical": func() bool {
-				if castResult != nil {
-					return castResult.IsCritical
-				}
-				return false
-			}(),
 		},
 	}
 	data, err := json.Marshal(resp)
@@ -409,43 +840,6 @@ func (h *GameHandler) handleSkillCast(session *connection.Session, message *prot
 		return err
 	}
 
-	// 施法广播给AOI内玩家
-	if h.mapService != nil {
-		// 推断mapID
-		var mapID int32 = 1
-		if gid := session.GetGroupID(); gid != "" {
-			if len(gid) > 4 && gid[:4] == "map:" {
-				if v, err := strconv.ParseInt(gid[4:], 10, 32); err == nil {
-					mapID = int32(v)
-				}
-			}
-		}
-		payload := map[string]interface{}{
-			"caster_id": casterID,
-			"skill_id": func() interface{} {
-				if skillID != 0 {
-					return skillID
-				}
-				return skillIDStr
-			}(),
-			"target_id": func() interface{} {
-				if targetID != 0 {
-					return targetID
-				}
-				return targetIDStr
-			}(),
-			"ts": time.Now().UnixMilli(),
-		}
-		if m, err := h.mapService.GetMap(mapID); err == nil {
-			ents := m.GetAllEntities()
-			recvs := make([]character.EntityID, 0, len(ents))
-			for _, e := range ents {
-				recvs = append(recvs, e.ID())
-			}
-			m.BroadcastTo(recvs, "skill_cast", payload)
-		}
-	}
-
 	return nil
 }
 
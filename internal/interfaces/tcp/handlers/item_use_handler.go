@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	appServices "greatestworks/internal/application/services"
+	"greatestworks/internal/interfaces/tcp/connection"
+	"greatestworks/internal/interfaces/tcp/protocol"
+)
+
+// SetItemUseService 注入物品使用服务
+func (h *GameHandler) SetItemUseService(us *appServices.ItemUseService) { h.itemUseService = us }
+
+// handleItemUse 使用背包中的物品
+func (h *GameHandler) handleItemUse(session *connection.Session, message *protocol.Message) error {
+	if h.itemUseService == nil || h.connManager == nil {
+		return fmt.Errorf("item use service or connection manager not ready")
+	}
+	var req protocol.ItemUseRequest
+	if payloadMap, ok := message.Payload.(map[string]interface{}); ok {
+		if b, err := json.Marshal(payloadMap); err == nil {
+			_ = json.Unmarshal(b, &req)
+		}
+	}
+	entityID, ok := h.connManager.GetPlayerBySession(session.ID)
+	if !ok {
+		return fmt.Errorf("no bound entity for session")
+	}
+
+	payload := protocol.ItemUseResponse{ItemUID: req.ItemUID}
+	result, err := h.itemUseService.UseItem(context.Background(), entityID, req.ItemUID, req.TargetID)
+	if err != nil {
+		payload.BaseResponse = protocol.NewBaseResponse(false, err.Error())
+	} else {
+		payload.BaseResponse = protocol.NewBaseResponse(true, "item used")
+	}
+	// 物品已扣除但效果执行失败时仍返回结果
+	if result != nil {
+		payload.ItemID = result.ItemID
+		payload.Effect = int32(result.Effect)
+		payload.TargetID = result.TargetID
+		payload.Amount = result.Amount
+		payload.BuffID = result.BuffID
+		payload.Exp = result.Exp
+		payload.Level = result.Level
+		payload.SkillID = result.SkillID
+		payload.PetID = result.PetID
+		payload.Cooldown = result.Cooldown
+		for _, r := range result.Items {
+			payload.Items = append(payload.Items, protocol.ItemUseGain{ItemID: r.ItemID, Count: r.Count})
+		}
+		if result.MapID != 0 && err == nil {
+			payload.MapID = result.MapID
+			payload.Position = &protocol.Position{X: float64(result.Position.X), Y: float64(result.Position.Y), Z: float64(result.Position.Z)}
+			session.SetGroupID(fmt.Sprintf("map:%d", result.MapID))
+		}
+	}
+	return h.sendBattleResponse(session, message, payload)
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	appServices "greatestworks/internal/application/services"
+	"greatestworks/internal/infrastructure/persistence"
+	"greatestworks/internal/interfaces/tcp/connection"
+	"greatestworks/internal/interfaces/tcp/protocol"
+)
+
+// SetMailService 注入邮件服务
+func (h *GameHandler) SetMailService(ms *appServices.MailService) { h.mailService = ms }
+
+// handleMail 邮件：列表、阅读、领取附件与删除
+func (h *GameHandler) handleMail(session *connection.Session, message *protocol.Message) error {
+	if h.mailService == nil || h.connManager == nil {
+		return fmt.Errorf("mail service or connection manager not ready")
+	}
+	var req protocol.MailRequest
+	if payloadMap, ok := message.Payload.(map[string]interface{}); ok {
+		if b, err := json.Marshal(payloadMap); err == nil {
+			_ = json.Unmarshal(b, &req)
+		}
+	}
+	entityID, ok := h.connManager.GetPlayerBySession(session.ID)
+	if !ok {
+		return fmt.Errorf("no bound entity for session")
+	}
+	// 实体ID即角色ID
+	characterID := int64(entityID)
+
+	ctx := context.Background()
+	var mails []*persistence.DbMail
+	var err error
+	switch req.Action {
+	case protocol.MailActionList:
+		mails, err = h.mailService.GetMails(ctx, characterID, 0)
+	case protocol.MailActionRead:
+		err = h.mailService.ReadMail(ctx, characterID, req.MailID)
+	case protocol.MailActionClaim:
+		var mail *persistence.DbMail
+		if mail, err = h.mailService.ClaimAttachments(ctx, characterID, req.MailID); err == nil {
+			mails = []*persistence.DbMail{mail}
+		}
+	case protocol.MailActionDelete:
+		err = h.mailService.DeleteMail(ctx, characterID, req.MailID)
+	default:
+		err = fmt.Errorf("unknown mail action: %s", req.Action)
+	}
+
+	payload := protocol.MailResponse{Action: req.Action}
+	if err != nil {
+		payload.BaseResponse = protocol.NewBaseResponse(false, err.Error())
+	} else {
+		payload.BaseResponse = protocol.NewBaseResponse(true, "mail "+req.Action)
+		for _, mail := range mails {
+			payload.Mails = append(payload.Mails, toMailInfo(mail))
+		}
+	}
+	return h.sendBattleResponse(session, message, payload)
+}
+
+// toMailInfo 邮件转换为协议结构（领取后返回领取前的附件）
+func toMailInfo(mail *persistence.DbMail) *protocol.MailInfo {
+	info := &protocol.MailInfo{
+		MailID:     mail.MailID,
+		SenderName: mail.SenderName,
+		Title:      mail.Title,
+		Content:    mail.Content,
+		IsRead:     mail.IsRead,
+		Gold:       mail.Gold,
+		ExpireAt:   mail.ExpireAt.UnixMilli(),
+	}
+	for _, att := range mail.Attachments {
+		info.Attachments = append(info.Attachments, protocol.MailAttachmentInfo{ItemID: att.ItemID, Count: att.Count})
+	}
+	return info
+}
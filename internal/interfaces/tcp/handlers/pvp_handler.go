@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	appServices "greatestworks/internal/application/services"
+	"greatestworks/internal/domain/mapmanager"
+	"greatestworks/internal/interfaces/tcp/connection"
+	"greatestworks/internal/interfaces/tcp/protocol"
+)
+
+// SetPvPService 注入 PvP 服务
+func (h *GameHandler) SetPvPService(ps *appServices.PvPService) { h.pvpService = ps }
+
+// handlePvPFlag 开启/关闭 PvP 标记
+func (h *GameHandler) handlePvPFlag(session *connection.Session, message *protocol.Message) error {
+	var req protocol.PvPFlagRequest
+	entityID, err := h.pvpRequest(session, message, &req)
+	if err != nil {
+		return err
+	}
+
+	result, err := h.pvpService.SetPvPFlag(context.Background(), entityID, req.Enabled)
+	payload := protocol.PvPFlagResponse{Enabled: req.Enabled}
+	if err != nil {
+		payload.BaseResponse = protocol.NewBaseResponse(false, err.Error())
+	} else {
+		payload.BaseResponse = protocol.NewBaseResponse(true, "pvp flag updated")
+	}
+	if result != nil {
+		payload.Enabled = result.Enabled
+		payload.CooldownMs = result.CooldownIn.Milliseconds()
+	}
+	return h.sendBattleResponse(session, message, payload)
+}
+
+// handleDuel 决斗邀请、响应与认输
+func (h *GameHandler) handleDuel(session *connection.Session, message *protocol.Message) error {
+	var req protocol.DuelRequest
+	entityID, err := h.pvpRequest(session, message, &req)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	var duel *mapmanager.DuelNotice
+	switch req.Action {
+	case protocol.DuelActionRequest:
+		duel, err = h.pvpService.RequestDuel(ctx, entityID, req.TargetID)
+	case protocol.DuelActionAccept, protocol.DuelActionDecline:
+		duel, err = h.pvpService.RespondDuel(ctx, entityID, req.DuelID, req.Action == protocol.DuelActionAccept)
+	case protocol.DuelActionForfeit:
+		duel, err = h.pvpService.ForfeitDuel(ctx, entityID)
+	default:
+		err = fmt.Errorf("unknown duel action: %s", req.Action)
+	}
+
+	payload := protocol.DuelResponse{Action: req.Action}
+	if err != nil {
+		payload.BaseResponse = protocol.NewBaseResponse(false, err.Error())
+	} else {
+		payload.BaseResponse = protocol.NewBaseResponse(true, "duel "+req.Action)
+		payload.Duel = toDuelInfo(duel)
+	}
+	return h.sendBattleResponse(session, message, payload)
+}
+
+// pvpRequest 解析 PvP 请求并返回会话绑定的实体
+func (h *GameHandler) pvpRequest(session *connection.Session, message *protocol.Message, req interface{}) (int32, error) {
+	if h.pvpService == nil || h.connManager == nil {
+		return 0, fmt.Errorf("pvp service or connection manager not ready")
+	}
+	if payloadMap, ok := message.Payload.(map[string]interface{}); ok {
+		if b, err := json.Marshal(payloadMap); err == nil {
+			_ = json.Unmarshal(b, req)
+		}
+	}
+	entityID, ok := h.connManager.GetPlayerBySession(session.ID)
+	if !ok {
+		return 0, fmt.Errorf("no bound entity for session")
+	}
+	return entityID, nil
+}
+
+// toDuelInfo 决斗通知转换为协议结构
+func toDuelInfo(n *mapmanager.DuelNotice) *protocol.DuelInfo {
+	if n == nil {
+		return nil
+	}
+	return &protocol.DuelInfo{
+		DuelID:       n.DuelID,
+		ChallengerID: int32(n.ChallengerID),
+		TargetID:     int32(n.TargetID),
+		State:        int32(n.State),
+		X:            n.Center.X,
+		Z:            n.Center.Z,
+		Radius:       n.Radius,
+		Remain:       n.Remain,
+		WinnerID:     int32(n.WinnerID),
+		Reason:       int32(n.Reason),
+	}
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	appServices "greatestworks/internal/application/services"
+	"greatestworks/internal/interfaces/tcp/connection"
+	"greatestworks/internal/interfaces/tcp/protocol"
+)
+
+// SetShopService 注入 NPC 商店服务
+func (h *GameHandler) SetShopService(ss *appServices.ShopService) { h.shopService = ss }
+
+// handleShop NPC 商店：打开、购买、出售与回购
+func (h *GameHandler) handleShop(session *connection.Session, message *protocol.Message) error {
+	if h.shopService == nil || h.connManager == nil {
+		return fmt.Errorf("shop service or connection manager not ready")
+	}
+	var req protocol.ShopRequest
+	if payloadMap, ok := message.Payload.(map[string]interface{}); ok {
+		if b, err := json.Marshal(payloadMap); err == nil {
+			_ = json.Unmarshal(b, &req)
+		}
+	}
+	entityID, ok := h.connManager.GetPlayerBySession(session.ID)
+	if !ok {
+		return fmt.Errorf("no bound entity for session")
+	}
+
+	ctx := context.Background()
+	var view *appServices.ShopView
+	var err error
+	switch req.Action {
+	case protocol.ShopActionOpen:
+		view, err = h.shopService.Open(ctx, entityID, req.NPCID)
+	case protocol.ShopActionBuy:
+		view, err = h.shopService.Buy(ctx, entityID, req.NPCID, req.ItemID, req.Count)
+	case protocol.ShopActionSell:
+		view, err = h.shopService.Sell(ctx, entityID, req.NPCID, req.ItemUID, req.Count)
+	case protocol.ShopActionBuyback:
+		view, err = h.shopService.Buyback(ctx, entityID, req.NPCID, req.BuybackID)
+	default:
+		err = fmt.Errorf("unknown shop action: %s", req.Action)
+	}
+
+	payload := protocol.ShopResponse{Action: req.Action}
+	if err != nil {
+		payload.BaseResponse = protocol.NewBaseResponse(false, err.Error())
+	} else {
+		payload.BaseResponse = protocol.NewBaseResponse(true, "shop "+req.Action)
+		payload.Shop = toShopInfo(view)
+	}
+	return h.sendBattleResponse(session, message, payload)
+}
+
+// toShopInfo 商店快照转换为协议结构
+func toShopInfo(v *appServices.ShopView) *protocol.ShopInfo {
+	info := &protocol.ShopInfo{
+		NPCID:      v.NPCID,
+		Name:       v.Name,
+		Reputation: int32(v.Reputation),
+		Standing:   v.Standing,
+		Items:      make([]protocol.ShopItemInfo, 0, len(v.Items)),
+	}
+	for _, item := range v.Items {
+		info.Items = append(info.Items, protocol.ShopItemInfo{
+			ItemID:    item.ItemID,
+			Price:     item.Price,
+			Stock:     item.Stock,
+			MaxStock:  item.MaxStock,
+			RestockAt: item.RestockAt,
+		})
+	}
+	for _, bb := range v.Buyback {
+		info.Buyback = append(info.Buyback, protocol.ShopBuybackInfo{
+			BuybackID: bb.BuybackID,
+			ItemID:    bb.ItemID,
+			Count:     bb.Count,
+			Enhance:   bb.Enhance,
+			Price:     bb.Price,
+		})
+	}
+	return info
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	appServices "greatestworks/internal/application/services"
+	"greatestworks/internal/interfaces/tcp/connection"
+	"greatestworks/internal/interfaces/tcp/protocol"
+)
+
+// SetTradeService 注入交易服务
+func (h *GameHandler) SetTradeService(ts *appServices.TradeService) { h.tradeService = ts }
+
+// handleTrade 玩家交易：邀请、响应、物品与金币、锁定、确认与取消
+func (h *GameHandler) handleTrade(session *connection.Session, message *protocol.Message) error {
+	if h.tradeService == nil || h.connManager == nil {
+		return fmt.Errorf("trade service or connection manager not ready")
+	}
+	var req protocol.TradeRequest
+	if payloadMap, ok := message.Payload.(map[string]interface{}); ok {
+		if b, err := json.Marshal(payloadMap); err == nil {
+			_ = json.Unmarshal(b, &req)
+		}
+	}
+	entityID, ok := h.connManager.GetPlayerBySession(session.ID)
+	if !ok {
+		return fmt.Errorf("no bound entity for session")
+	}
+
+	ctx := context.Background()
+	var view *appServices.TradeView
+	var err error
+	switch req.Action {
+	case protocol.TradeActionInvite:
+		view, err = h.tradeService.Invite(ctx, entityID, req.TargetID)
+	case protocol.TradeActionAccept, protocol.TradeActionDecline:
+		view, err = h.tradeService.Respond(ctx, entityID, req.TradeID, req.Action == protocol.TradeActionAccept)
+	case protocol.TradeActionItem:
+		view, err = h.tradeService.SetItem(ctx, entityID, req.ItemUID, req.Count)
+	case protocol.TradeActionGold:
+		view, err = h.tradeService.SetGold(ctx, entityID, req.Gold)
+	case protocol.TradeActionLock, protocol.TradeActionUnlock:
+		view, err = h.tradeService.Lock(ctx, entityID, req.Action == protocol.TradeActionLock)
+	case protocol.TradeActionConfirm:
+		view, err = h.tradeService.Confirm(ctx, entityID)
+	case protocol.TradeActionCancel:
+		view, err = h.tradeService.Cancel(ctx, entityID)
+	default:
+		err = fmt.Errorf("unknown trade action: %s", req.Action)
+	}
+
+	payload := protocol.TradeResponse{Action: req.Action}
+	if err != nil {
+		payload.BaseResponse = protocol.NewBaseResponse(false, err.Error())
+	} else {
+		payload.BaseResponse = protocol.NewBaseResponse(true, "trade "+req.Action)
+		payload.Trade = toTradeInfo(view)
+	}
+	return h.sendBattleResponse(session, message, payload)
+}
+
+// toTradeInfo 交易状态转换为协议结构
+func toTradeInfo(view *appServices.TradeView) *protocol.TradeInfo {
+	if view == nil {
+		return nil
+	}
+	info := &protocol.TradeInfo{TradeID: view.TradeID, State: int32(view.State), Reason: view.Reason}
+	for _, offer := range view.Offers {
+		o := &protocol.TradeOfferInfo{EntityID: offer.EntityID, Gold: offer.Gold, Locked: offer.Locked, Confirmed: offer.Confirmed}
+		for _, it := range offer.Items {
+			o.Items = append(o.Items, protocol.TradeItemInfo{ItemUID: it.ItemUID, ItemID: it.ItemID, Count: it.Count, Enhance: it.Enhance})
+		}
+		info.Offers = append(info.Offers, o)
+	}
+	return info
+}
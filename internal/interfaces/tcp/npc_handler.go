@@ -32,8 +32,6 @@ func (h *NPCHandler) HandleMessage(session *connection.Session, message *protoco
 		return h.handleNPCInteraction(session, message)
 	case uint32(protocol.MsgQuestAccept):
 		return h.handleNPCQuest(session, message)
-	case uint32(protocol.MsgItemTrade):
-		return h.handleNPCTrade(session, message)
 	default:
 		return fmt.Errorf("未知的NPC消息类型: %d", message.Header.MessageType)
 	}
@@ -69,17 +67,4 @@ func (h *NPCHandler) handleNPCQuest(session *connection.Session, message *protoc
 	return nil
 }
 
-// handleNPCTrade 处理NPC交易
-func (h *NPCHandler) handleNPCTrade(session *connection.Session, message *protocol.Message) error {
-	h.logger.Info("处理NPC交易", map[string]interface{}{
-		"player_id": message.Header.PlayerID,
-	})
-
-	// TODO: 实现NPC交易逻辑
-	// 1. 验证交易物品
-	// 2. 检查交易条件
-	// 3. 执行交易逻辑
-	// 4. 发送响应
-
-	return nil
-}
+// NPC 商店由 GameHandler 处理（protocol.MsgShop）
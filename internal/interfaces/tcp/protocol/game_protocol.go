@@ -21,6 +21,12 @@ const (
 	MsgPlayerStatusSync uint32 = uint32(messages.PlayerMessageID_MSG_PLAYER_SYNC)
 	MsgPlayerStatus     uint32 = uint32(messages.PlayerMessageID_MSG_PLAYER_STATUS)
 	MsgPlayerStats      uint32 = uint32(messages.PlayerMessageID_MSG_PLAYER_STATS)
+	MsgMapTransfer      uint32 = uint32(messages.AdminMessageID_MSG_ADMIN_TELEPORT)   // 使用传送消息代替：传送门加载握手
+	MsgPlayerRespawn    uint32 = uint32(messages.BattleMessageID_MSG_BATTLE_SYNC)     // 使用战斗同步消息代替：死亡与复活
+	MsgPvPFlag          uint32 = uint32(messages.BattleMessageID_MSG_BATTLE_SPECTATE) // 使用观战消息代替：PvP 标记
+	MsgDuel             uint32 = uint32(messages.BattleMessageID_MSG_END_BATTLE)      // 使用结束战斗消息代替：决斗
+	MsgAuction          uint32 = uint32(messages.ItemMessageID_MSG_ITEM_STACK)        // 使用物品堆叠消息代替：拍卖行
+	MsgShop             uint32 = uint32(messages.ItemMessageID_MSG_ITEM_BUY)          // 使用购买物品消息代替：NPC 商店
 
 	// 战斗相关协议 (0x2000 - 0x2FFF) - 使用proto生成的常量
 	MsgCreateBattle uint32 = uint32(messages.BattleMessageID_MSG_CREATE_BATTLE)
@@ -87,6 +93,7 @@ type PlayerLoginResponse struct {
 	SessionID   string      `json:"session_id,omitempty"`
 	ServerTime  int64       `json:"server_time,omitempty"`
 	Permissions []string    `json:"permissions,omitempty"`
+	Channel     int32       `json:"channel,omitempty"`
 }
 
 // PlayerCreateRequest 创建玩家请求
@@ -121,6 +128,470 @@ type PlayerMoveResponse struct {
 	MoveTime    int64    `json:"move_time,omitempty"`
 }
 
+// SnapshotAckRequest 快照确认请求（客户端确认已收到的帧，或在丢包时请求全量重同步）
+type SnapshotAckRequest struct {
+	BaseRequest
+	AckTick uint32 `json:"ack_tick"`
+	Resync  bool   `json:"resync,omitempty"`
+}
+
+// MapTransferRequest 传送确认请求（客户端加载完成后确认票据，或取消传送）
+type MapTransferRequest struct {
+	BaseRequest
+	TicketID string `json:"ticket_id"`
+	Cancel   bool   `json:"cancel,omitempty"`
+}
+
+// MapTransferResponse 传送结果响应；Node 非空时客户端需连接到目标场景节点
+type MapTransferResponse struct {
+	BaseResponse
+	TicketID string    `json:"ticket_id,omitempty"`
+	MapID    int32     `json:"map_id,omitempty"`
+	Channel  int32     `json:"channel,omitempty"`
+	Node     string    `json:"node,omitempty"`
+	Position *Position `json:"position,omitempty"`
+}
+
+// ChannelSwitchRequest 切换线路请求（Channel 为 0 时仅查询线路列表）
+type ChannelSwitchRequest struct {
+	BaseRequest
+	Channel int32 `json:"channel"`
+}
+
+// ChannelInfo 线路信息
+type ChannelInfo struct {
+	Channel int32 `json:"channel"`
+	Players int   `json:"players"`
+	SoftCap int32 `json:"soft_cap"`
+	HardCap int32 `json:"hard_cap"`
+}
+
+// ChannelSwitchResponse 切换线路响应
+type ChannelSwitchResponse struct {
+	BaseResponse
+	MapID    int32         `json:"map_id"`
+	Channel  int32         `json:"channel"`
+	Channels []ChannelInfo `json:"channels,omitempty"`
+}
+
+// ItemPickupRequest 拾取地面掉落物请求
+type ItemPickupRequest struct {
+	BaseRequest
+	DropID int32 `json:"drop_id"`
+}
+
+// ItemPickupResponse 拾取响应
+type ItemPickupResponse struct {
+	BaseResponse
+	DropID int32 `json:"drop_id"`
+	ItemID int32 `json:"item_id,omitempty"`
+	Count  int32 `json:"count,omitempty"`
+}
+
+// ItemUseRequest 使用物品请求（target_id 为 0 表示对自身使用）
+type ItemUseRequest struct {
+	BaseRequest
+	ItemUID  int64 `json:"item_uid"`
+	TargetID int32 `json:"target_id,omitempty"`
+}
+
+// ItemUseGain 使用物品获得的物品（宝箱）
+type ItemUseGain struct {
+	ItemID int32 `json:"item_id"`
+	Count  int32 `json:"count"`
+}
+
+// ItemUseResponse 使用物品响应
+type ItemUseResponse struct {
+	BaseResponse
+	ItemUID  int64         `json:"item_uid"`
+	ItemID   int32         `json:"item_id,omitempty"`
+	Effect   int32         `json:"effect,omitempty"`
+	TargetID int32         `json:"target_id,omitempty"`
+	Amount   float32       `json:"amount,omitempty"` // 实际回复量
+	BuffID   int32         `json:"buff_id,omitempty"`
+	Exp      int64         `json:"exp,omitempty"`
+	Level    int32         `json:"level,omitempty"`
+	MapID    int32         `json:"map_id,omitempty"` // 传送目的地
+	Position *Position     `json:"position,omitempty"`
+	Items    []ItemUseGain `json:"items,omitempty"`
+	SkillID  int32         `json:"skill_id,omitempty"`
+	PetID    int32         `json:"pet_id,omitempty"`
+	Cooldown float32       `json:"cooldown,omitempty"` // 冷却秒数
+}
+
+// ItemEquipRequest 穿戴/卸下装备请求（穿戴使用 item_uid，卸下使用 slot）
+type ItemEquipRequest struct {
+	BaseRequest
+	ItemUID int64 `json:"item_uid,omitempty"`
+	Slot    int32 `json:"slot,omitempty"`
+}
+
+// ItemEquipResponse 穿戴/卸下装备响应
+type ItemEquipResponse struct {
+	BaseResponse
+	Slot        int32 `json:"slot"`
+	EquippedUID int64 `json:"equipped_uid,omitempty"` // 穿上的物品
+	RemovedUID  int64 `json:"removed_uid,omitempty"`  // 放回背包的物品
+	BagSlot     int32 `json:"bag_slot,omitempty"`     // 放回背包的格子
+}
+
+// RespawnRequest 复活请求（option: 1=复活点 2=道具原地 3=接受他人复活）
+type RespawnRequest struct {
+	BaseRequest
+	Option int32 `json:"option"`
+}
+
+// RespawnResponse 复活响应
+type RespawnResponse struct {
+	BaseResponse
+	Option  int32   `json:"option"`
+	X       float32 `json:"x,omitempty"`
+	Y       float32 `json:"y,omitempty"`
+	Z       float32 `json:"z,omitempty"`
+	HP      float32 `json:"hp,omitempty"`
+	ExpLost int64   `json:"exp_lost,omitempty"`
+}
+
+// PvPFlagRequest 切换 PvP 标记请求
+type PvPFlagRequest struct {
+	BaseRequest
+	Enabled bool `json:"enabled"`
+}
+
+// PvPFlagResponse 切换 PvP 标记响应
+type PvPFlagResponse struct {
+	BaseResponse
+	Enabled    bool  `json:"enabled"`
+	CooldownMs int64 `json:"cooldown_ms,omitempty"` // 距下次可切换的毫秒数
+}
+
+// 决斗操作
+const (
+	DuelActionRequest = "request" // 发起邀请
+	DuelActionAccept  = "accept"  // 接受邀请
+	DuelActionDecline = "decline" // 拒绝邀请
+	DuelActionForfeit = "forfeit" // 认输/取消
+)
+
+// DuelRequest 决斗请求
+type DuelRequest struct {
+	BaseRequest
+	Action   string `json:"action"`
+	TargetID int32  `json:"target_id,omitempty"` // 发起邀请时的目标
+	DuelID   int32  `json:"duel_id,omitempty"`   // 接受/拒绝时的决斗
+}
+
+// DuelInfo 决斗信息（state: 0=邀请中 1=倒计时 2=进行中 3=已结束）
+type DuelInfo struct {
+	DuelID       int32   `json:"duel_id"`
+	ChallengerID int32   `json:"challenger_id"`
+	TargetID     int32   `json:"target_id"`
+	State        int32   `json:"state"`
+	X            float32 `json:"x,omitempty"` // 决斗场地中心
+	Z            float32 `json:"z,omitempty"`
+	Radius       float32 `json:"radius,omitempty"`
+	Remain       float32 `json:"remain,omitempty"` // 邀请剩余时间或倒计时
+	WinnerID     int32   `json:"winner_id,omitempty"`
+	Reason       int32   `json:"reason,omitempty"`
+}
+
+// DuelResponse 决斗响应
+type DuelResponse struct {
+	BaseResponse
+	Action string    `json:"action"`
+	Duel   *DuelInfo `json:"duel,omitempty"`
+}
+
+// 交易操作
+const (
+	TradeActionInvite  = "invite"  // 邀请
+	TradeActionAccept  = "accept"  // 接受邀请
+	TradeActionDecline = "decline" // 拒绝邀请
+	TradeActionItem    = "item"    // 放入/调整/取回物品（count 为 0 取回）
+	TradeActionGold    = "gold"    // 设置金币
+	TradeActionLock    = "lock"    // 锁定
+	TradeActionUnlock  = "unlock"  // 解锁
+	TradeActionConfirm = "confirm" // 确认
+	TradeActionCancel  = "cancel"  // 取消
+)
+
+// TradeRequest 玩家交易请求
+type TradeRequest struct {
+	BaseRequest
+	Action   string `json:"action"`
+	TargetID int32  `json:"target_id,omitempty"` // 邀请的玩家
+	TradeID  int32  `json:"trade_id,omitempty"`  // 接受/拒绝的交易
+	ItemUID  int64  `json:"item_uid,omitempty"`
+	Count    int32  `json:"count,omitempty"`
+	Gold     int64  `json:"gold,omitempty"`
+}
+
+// TradeItemInfo 交易栏物品
+type TradeItemInfo struct {
+	ItemUID int64 `json:"item_uid"`
+	ItemID  int32 `json:"item_id"`
+	Count   int32 `json:"count"`
+	Enhance int32 `json:"enhance,omitempty"` // 强化等级
+}
+
+// TradeOfferInfo 交易一方的出价
+type TradeOfferInfo struct {
+	EntityID  int32           `json:"entity_id"`
+	Items     []TradeItemInfo `json:"items,omitempty"`
+	Gold      int64           `json:"gold,omitempty"`
+	Locked    bool            `json:"locked,omitempty"`
+	Confirmed bool            `json:"confirmed,omitempty"`
+}
+
+// TradeInfo 交易状态（state: 0=邀请中 1=交易中 2=已完成 3=已取消）
+type TradeInfo struct {
+	TradeID int32             `json:"trade_id"`
+	State   int32             `json:"state"`
+	Offers  []*TradeOfferInfo `json:"offers"`
+	Reason  string            `json:"reason,omitempty"`
+}
+
+// TradeResponse 玩家交易响应
+type TradeResponse struct {
+	BaseResponse
+	Action string     `json:"action"`
+	Trade  *TradeInfo `json:"trade,omitempty"`
+}
+
+// 拍卖行操作
+const (
+	AuctionActionList   = "list"   // 上架
+	AuctionActionSearch = "search" // 检索
+	AuctionActionMine   = "mine"   // 我的拍卖
+	AuctionActionBid    = "bid"    // 竞拍
+	AuctionActionBuyout = "buyout" // 一口价购买
+	AuctionActionCancel = "cancel" // 下架
+)
+
+// AuctionRequest 拍卖行请求
+type AuctionRequest struct {
+	BaseRequest
+	Action    string `json:"action"`
+	AuctionID int64  `json:"auction_id,omitempty"`
+	ItemUID   int64  `json:"item_uid,omitempty"` // 上架的物品
+	Count     int32  `json:"count,omitempty"`
+	StartBid  int64  `json:"start_bid,omitempty"`
+	Buyout    int64  `json:"buyout,omitempty"`
+	Hours     int32  `json:"hours,omitempty"`  // 上架时长
+	Amount    int64  `json:"amount,omitempty"` // 出价
+	// 检索条件
+	ItemID     int32 `json:"item_id,omitempty"`
+	ItemType   int32 `json:"item_type,omitempty"`
+	MinQuality int32 `json:"min_quality,omitempty"`
+	MinLevel   int32 `json:"min_level,omitempty"`
+	MaxLevel   int32 `json:"max_level,omitempty"`
+	Offset     int32 `json:"offset,omitempty"`
+	Limit      int32 `json:"limit,omitempty"`
+}
+
+// AuctionInfo 拍卖信息（status: 0=上架中 1=已成交 2=流拍 3=已下架）
+type AuctionInfo struct {
+	AuctionID int64 `json:"auction_id"`
+	SellerID  int64 `json:"seller_id"`
+	ItemID    int32 `json:"item_id"`
+	Count     int32 `json:"count"`
+	StartBid  int64 `json:"start_bid,omitempty"`
+	Buyout    int64 `json:"buyout,omitempty"`
+	Bid       int64 `json:"bid,omitempty"`
+	BidderID  int64 `json:"bidder_id,omitempty"`
+	MinBid    int64 `json:"min_bid,omitempty"`
+	Status    int32 `json:"status"`
+	ExpireAt  int64 `json:"expire_at"` // 毫秒时间戳
+}
+
+// AuctionResponse 拍卖行响应
+type AuctionResponse struct {
+	BaseResponse
+	Action   string         `json:"action"`
+	Auctions []*AuctionInfo `json:"auctions,omitempty"`
+}
+
+// NPC 商店操作
+const (
+	ShopActionOpen    = "open"    // 打开商店
+	ShopActionBuy     = "buy"     // 购买
+	ShopActionSell    = "sell"    // 出售
+	ShopActionBuyback = "buyback" // 回购
+)
+
+// ShopRequest NPC 商店请求
+type ShopRequest struct {
+	BaseRequest
+	Action    string `json:"action"`
+	NPCID     int32  `json:"npc_id"`               // NPC实体ID
+	ItemID    int32  `json:"item_id,omitempty"`    // 购买的商品
+	ItemUID   int64  `json:"item_uid,omitempty"`   // 出售的物品
+	Count     int32  `json:"count,omitempty"`      // 购买/出售数量
+	BuybackID int32  `json:"buyback_id,omitempty"` // 回购条目
+}
+
+// ShopItemInfo 商店商品（stock 为 -1 表示不限量）
+type ShopItemInfo struct {
+	ItemID    int32 `json:"item_id"`
+	Price     int64 `json:"price"`
+	Stock     int32 `json:"stock"`
+	MaxStock  int32 `json:"max_stock,omitempty"`
+	RestockAt int64 `json:"restock_at,omitempty"` // 毫秒时间戳
+}
+
+// ShopBuybackInfo 回购条目
+type ShopBuybackInfo struct {
+	BuybackID int32 `json:"buyback_id"`
+	ItemID    int32 `json:"item_id"`
+	Count     int32 `json:"count"`
+	Enhance   int32 `json:"enhance,omitempty"` // 强化等级
+	Price     int64 `json:"price"`
+}
+
+// ShopInfo 商店信息
+type ShopInfo struct {
+	NPCID      int32             `json:"npc_id"`
+	Name       string            `json:"name"`
+	Reputation int32             `json:"reputation"`
+	Standing   string            `json:"standing"`
+	Items      []ShopItemInfo    `json:"items"`
+	Buyback    []ShopBuybackInfo `json:"buyback,omitempty"`
+}
+
+// ShopResponse NPC 商店响应
+type ShopResponse struct {
+	BaseResponse
+	Action string    `json:"action"`
+	Shop   *ShopInfo `json:"shop,omitempty"`
+}
+
+// 制造操作
+const (
+	CraftActionList    = "list"    // 配方列表
+	CraftActionStart   = "start"   // 开始制造
+	CraftActionCancel  = "cancel"  // 取消制造
+	CraftActionStatus  = "status"  // 制造进度
+	CraftActionHistory = "history" // 制造记录
+)
+
+// CraftRequest 制造请求
+type CraftRequest struct {
+	BaseRequest
+	Action   string `json:"action"`
+	RecipeID int32  `json:"recipe_id,omitempty"`
+	Count    int32  `json:"count,omitempty"` // 批量制造数量
+	Limit    int64  `json:"limit,omitempty"` // 制造记录条数
+}
+
+// CraftItemInfo 制造消耗或产出的物品
+type CraftItemInfo struct {
+	ItemID int32 `json:"item_id"`
+	Count  int32 `json:"count"`
+}
+
+// CraftRecipeInfo 配方信息
+type CraftRecipeInfo struct {
+	RecipeID      int32           `json:"recipe_id"`
+	Name          string          `json:"name"`
+	Profession    int32           `json:"profession"`
+	SkillLevel    int32           `json:"skill_level"`
+	RequiredLevel int32           `json:"required_level"`
+	Skill         int32           `json:"skill"`
+	SuccessRate   float64         `json:"success_rate"`
+	CraftTime     float32         `json:"craft_time"`
+	Materials     []CraftItemInfo `json:"materials"`
+	Outputs       []CraftItemInfo `json:"outputs"`
+}
+
+// CraftStatusInfo 制造进度
+type CraftStatusInfo struct {
+	RecipeID    int32   `json:"recipe_id"`
+	Total       int32   `json:"total"`
+	Done        int32   `json:"done"`
+	Succeeded   int32   `json:"succeeded"`
+	Elapsed     float32 `json:"elapsed"`
+	Duration    float32 `json:"duration"`
+	SuccessRate float64 `json:"success_rate"`
+}
+
+// CraftRecordInfo 制造记录
+type CraftRecordInfo struct {
+	RecipeID  int32           `json:"recipe_id"`
+	Success   bool            `json:"success"`
+	Outputs   []CraftItemInfo `json:"outputs,omitempty"`
+	Skill     int32           `json:"skill"`
+	CreatedAt int64           `json:"created_at"` // 毫秒时间戳
+}
+
+// CraftResponse 制造响应
+type CraftResponse struct {
+	BaseResponse
+	Action  string             `json:"action"`
+	Recipes []*CraftRecipeInfo `json:"recipes,omitempty"`
+	Status  *CraftStatusInfo   `json:"status,omitempty"`
+	Records []*CraftRecordInfo `json:"records,omitempty"`
+}
+
+// EnhanceRequest 装备强化请求
+type EnhanceRequest struct {
+	BaseRequest
+	ItemUID int64 `json:"item_uid"`
+	Protect bool  `json:"protect,omitempty"` // 使用保护道具
+}
+
+// EnhanceResponse 装备强化响应
+type EnhanceResponse struct {
+	BaseResponse
+	ItemUID   int64 `json:"item_uid"`
+	ItemID    int32 `json:"item_id,omitempty"`
+	Success   bool  `json:"success"`
+	Level     int32 `json:"level"`
+	Failure   int32 `json:"failure,omitempty"` // 1 降级 2 损坏
+	Protected bool  `json:"protected,omitempty"`
+	Gold      int64 `json:"gold,omitempty"`
+}
+
+// 邮件操作
+const (
+	MailActionList   = "list"   // 邮件列表
+	MailActionRead   = "read"   // 阅读
+	MailActionClaim  = "claim"  // 领取附件
+	MailActionDelete = "delete" // 删除
+)
+
+// MailRequest 邮件请求
+type MailRequest struct {
+	BaseRequest
+	Action string `json:"action"`
+	MailID int64  `json:"mail_id,omitempty"`
+}
+
+// MailAttachmentInfo 邮件附件
+type MailAttachmentInfo struct {
+	ItemID int32 `json:"item_id"`
+	Count  int32 `json:"count"`
+}
+
+// MailInfo 邮件信息
+type MailInfo struct {
+	MailID      int64                `json:"mail_id"`
+	SenderName  string               `json:"sender_name"`
+	Title       string               `json:"title"`
+	Content     string               `json:"content,omitempty"`
+	IsRead      bool                 `json:"is_read"`
+	Attachments []MailAttachmentInfo `json:"attachments,omitempty"`
+	Gold        int64                `json:"gold,omitempty"`
+	ExpireAt    int64                `json:"expire_at"` // 毫秒时间戳
+}
+
+// MailResponse 邮件响应
+type MailResponse struct {
+	BaseResponse
+	Action string      `json:"action"`
+	Mails  []*MailInfo `json:"mails,omitempty"`
+}
+
 // PlayerInfoRequest 获取玩家信息请求
 type PlayerInfoRequest struct {
 	BaseRequest
@@ -142,6 +613,7 @@ type CreateBattleRequest struct {
 	Settings   *BattleSettings   `json:"settings,omitempty"`
 	Players    []string          `json:"players,omitempty"`
 	Options    map[string]string `json:"options,omitempty"`
+	VsUnitID   int32             `json:"vs_unit_id,omitempty"` // 对手单位ID（AI 对手，战斗立即开始）
 }
 
 // CreateBattleResponse 创建战斗响应
@@ -152,6 +624,7 @@ type CreateBattleResponse struct {
 	Status     string          `json:"status,omitempty"`
 	Settings   *BattleSettings `json:"settings,omitempty"`
 	CreatedAt  time.Time       `json:"created_at,omitempty"`
+	State      *BattleState    `json:"state,omitempty"`
 }
 
 // JoinBattleRequest 加入战斗请求
@@ -170,6 +643,7 @@ type JoinBattleResponse struct {
 	PlayerPos    int           `json:"player_position,omitempty"`
 	BattleInfo   *BattleInfo   `json:"battle_info,omitempty"`
 	OtherPlayers []*PlayerInfo `json:"other_players,omitempty"`
+	State        *BattleState  `json:"state,omitempty"`
 }
 
 // BattleActionRequest 战斗行动请求
@@ -191,6 +665,19 @@ type BattleActionResponse struct {
 	NextTurn     string        `json:"next_turn,omitempty"`
 }
 
+// BattleStatusRequest 开始/离开/查询战斗请求（battle_id 为空时为当前所在战斗）
+type BattleStatusRequest struct {
+	BaseRequest
+	BattleID string `json:"battle_id,omitempty"`
+}
+
+// BattleStatusResponse 开始/离开/查询战斗响应
+type BattleStatusResponse struct {
+	BaseResponse
+	BattleID string       `json:"battle_id,omitempty"`
+	State    *BattleState `json:"state,omitempty"`
+}
+
 // 查询协议结构
 
 // GetOnlinePlayersRequest 获取在线玩家请求
@@ -287,7 +774,11 @@ type BattleSettings struct {
 
 // BattleState 战斗状态
 type BattleState struct {
+	Status       string                  `json:"status,omitempty"`
 	CurrentRound int                     `json:"current_round"`
+	MaxRounds    int                     `json:"max_rounds,omitempty"`
+	TurnEndsIn   float32                 `json:"turn_ends_in,omitempty"` // 当前行动剩余秒数
+	Winner       string                  `json:"winner,omitempty"`
 	CurrentTurn  string                  `json:"current_turn"`
 	TurnOrder    []string                `json:"turn_order"`
 	PlayerStates map[string]*PlayerState `json:"player_states"`
@@ -298,6 +789,7 @@ type BattleState struct {
 type PlayerState struct {
 	PlayerID   string         `json:"player_id"`
 	HP         int            `json:"hp"`
+	MaxHP      int            `json:"max_hp,omitempty"`
 	MP         int            `json:"mp"`
 	Status     []string       `json:"status,omitempty"`
 	Buffs      []*Buff        `json:"buffs,omitempty"`
@@ -61,6 +61,7 @@ const (
 	MsgTeamJoin      uint32 = uint32(messages.SocialMessageID_MSG_TEAM_JOIN)      // 加入队伍
 	MsgTeamLeave     uint32 = uint32(messages.SocialMessageID_MSG_TEAM_LEAVE)     // 离开队伍
 	MsgTeamInfo      uint32 = uint32(messages.SocialMessageID_MSG_TEAM_INFO)      // 队伍信息
+	MsgMail          uint32 = uint32(messages.SocialMessageID_MSG_MAIL_RECEIVE)   // 邮件（列表/阅读/领取/删除）
 
 	// 物品相关消息 (0x0600 - 0x06FF) - 使用proto生成的常量
 	MsgItemUse       uint32 = uint32(messages.ItemMessageID_MSG_ITEM_USE)       // 使用物品
@@ -61,6 +61,10 @@ func (r *Router) RegisterGameHandler(handler *handlers.GameHandler) {
 	r.RegisterHandler(uint16(protocol.MsgPlayerCreate), handler)
 	r.RegisterHandler(uint16(protocol.MsgPlayerStatus), handler)
 	r.RegisterHandler(uint16(protocol.MsgPlayerStats), handler)
+	r.RegisterHandler(uint16(protocol.MsgPlayerStatusSync), handler)
+	r.RegisterHandler(uint16(protocol.MsgPlayerUpdate), handler)
+	r.RegisterHandler(uint16(protocol.MsgMapTransfer), handler)
+	r.RegisterHandler(uint16(protocol.MsgPlayerRespawn), handler)
 	//r.RegisterHandler(uint16(protocol.MsgPlayerInventory), handler)
 	//r.RegisterHandler(uint16(protocol.MsgPlayerSkills), handler)
 	//r.RegisterHandler(uint16(protocol.MsgPlayerQuests), handler)
@@ -74,6 +78,8 @@ func (r *Router) RegisterGameHandler(handler *handlers.GameHandler) {
 	r.RegisterHandler(uint16(protocol.MsgLeaveBattle), handler)
 	r.RegisterHandler(uint16(protocol.MsgBattleStatus), handler)
 	r.RegisterHandler(uint16(protocol.MsgBattleResult), handler)
+	r.RegisterHandler(uint16(protocol.MsgPvPFlag), handler)
+	r.RegisterHandler(uint16(protocol.MsgDuel), handler)
 
 	// 宠物相关消息
 	r.RegisterHandler(uint16(protocol.MsgPetSummon), handler)
@@ -103,14 +109,20 @@ func (r *Router) RegisterGameHandler(handler *handlers.GameHandler) {
 	r.RegisterHandler(uint16(protocol.MsgTeamJoin), handler)
 	r.RegisterHandler(uint16(protocol.MsgTeamLeave), handler)
 	r.RegisterHandler(uint16(protocol.MsgTeamInfo), handler)
+	r.RegisterHandler(uint16(protocol.MsgMail), handler)
 
 	// 物品相关消息
 	r.RegisterHandler(uint16(protocol.MsgItemUse), handler)
 	//r.RegisterHandler(uint16(protocol.MsgItemMove), handler)
 	r.RegisterHandler(uint16(protocol.MsgItemDrop), handler)
 	r.RegisterHandler(uint16(protocol.MsgItemPickup), handler)
+	r.RegisterHandler(uint16(protocol.MsgItemEquip), handler)
+	r.RegisterHandler(uint16(protocol.MsgItemUnequip), handler)
 	r.RegisterHandler(uint16(protocol.MsgItemTrade), handler)
+	r.RegisterHandler(uint16(protocol.MsgAuction), handler)
+	r.RegisterHandler(uint16(protocol.MsgShop), handler)
 	r.RegisterHandler(uint16(protocol.MsgItemCraft), handler)
+	r.RegisterHandler(uint16(protocol.MsgItemEnhance), handler)
 
 	// 任务相关消息
 	r.RegisterHandler(uint16(protocol.MsgQuestAccept), handler)
@@ -60,6 +60,19 @@ type TCPServer struct {
 	mapService       *appServices.MapService
 	fightService     *appServices.FightService
 	characterService *appServices.CharacterService
+	portalService    *appServices.PortalService
+	lootService      *appServices.LootService
+	equipmentService *appServices.EquipmentService
+	respawnService   *appServices.RespawnService
+	battleService    *appServices.BattleService
+	pvpService       *appServices.PvPService
+	tradeService     *appServices.TradeService
+	auctionService   *appServices.AuctionService
+	mailService      *appServices.MailService
+	shopService      *appServices.ShopService
+	itemUseService   *appServices.ItemUseService
+	craftService     *appServices.CraftService
+	enhanceService   *appServices.EnhanceService
 }
 
 // NewTCPServer 创建TCP服务器
@@ -122,6 +135,110 @@ func (s *TCPServer) SetCharacterService(cs *appServices.CharacterService) {
 	}
 }
 
+// SetPortalService allows injecting PortalService for handler usage.
+func (s *TCPServer) SetPortalService(ps *appServices.PortalService) {
+	s.portalService = ps
+	if s.gameHandler != nil {
+		s.gameHandler.SetPortalService(ps)
+	}
+}
+
+// SetLootService allows injecting LootService for handler usage.
+func (s *TCPServer) SetLootService(ls *appServices.LootService) {
+	s.lootService = ls
+	if s.gameHandler != nil {
+		s.gameHandler.SetLootService(ls)
+	}
+}
+
+// SetEquipmentService allows injecting EquipmentService for handler usage.
+func (s *TCPServer) SetEquipmentService(es *appServices.EquipmentService) {
+	s.equipmentService = es
+	if s.gameHandler != nil {
+		s.gameHandler.SetEquipmentService(es)
+	}
+}
+
+// SetRespawnService allows injecting RespawnService for handler usage.
+func (s *TCPServer) SetRespawnService(rs *appServices.RespawnService) {
+	s.respawnService = rs
+	if s.gameHandler != nil {
+		s.gameHandler.SetRespawnService(rs)
+	}
+}
+
+// SetBattleService allows injecting BattleService for handler usage.
+func (s *TCPServer) SetBattleService(bs *appServices.BattleService) {
+	s.battleService = bs
+	if s.gameHandler != nil {
+		s.gameHandler.SetBattleService(bs)
+	}
+}
+
+// SetPvPService allows injecting PvPService for handler usage.
+func (s *TCPServer) SetPvPService(ps *appServices.PvPService) {
+	s.pvpService = ps
+	if s.gameHandler != nil {
+		s.gameHandler.SetPvPService(ps)
+	}
+}
+
+// SetTradeService allows injecting TradeService for handler usage.
+func (s *TCPServer) SetTradeService(ts *appServices.TradeService) {
+	s.tradeService = ts
+	if s.gameHandler != nil {
+		s.gameHandler.SetTradeService(ts)
+	}
+}
+
+// SetAuctionService allows injecting AuctionService for handler usage.
+func (s *TCPServer) SetAuctionService(as *appServices.AuctionService) {
+	s.auctionService = as
+	if s.gameHandler != nil {
+		s.gameHandler.SetAuctionService(as)
+	}
+}
+
+// SetMailService allows injecting MailService for handler usage.
+func (s *TCPServer) SetMailService(ms *appServices.MailService) {
+	s.mailService = ms
+	if s.gameHandler != nil {
+		s.gameHandler.SetMailService(ms)
+	}
+}
+
+// SetShopService allows injecting ShopService for handler usage.
+func (s *TCPServer) SetShopService(ss *appServices.ShopService) {
+	s.shopService = ss
+	if s.gameHandler != nil {
+		s.gameHandler.SetShopService(ss)
+	}
+}
+
+// SetItemUseService allows injecting ItemUseService for handler usage.
+func (s *TCPServer) SetItemUseService(us *appServices.ItemUseService) {
+	s.itemUseService = us
+	if s.gameHandler != nil {
+		s.gameHandler.SetItemUseService(us)
+	}
+}
+
+// SetCraftService allows injecting CraftService for handler usage.
+func (s *TCPServer) SetCraftService(cs *appServices.CraftService) {
+	s.craftService = cs
+	if s.gameHandler != nil {
+		s.gameHandler.SetCraftService(cs)
+	}
+}
+
+// SetEnhanceService allows injecting EnhanceService for handler usage.
+func (s *TCPServer) SetEnhanceService(es *appServices.EnhanceService) {
+	s.enhanceService = es
+	if s.gameHandler != nil {
+		s.gameHandler.SetEnhanceService(es)
+	}
+}
+
 // GetConnectionManager exposes the underlying connection manager for wiring.
 func (s *TCPServer) GetConnectionManager() *connection.Manager { return s.connManager }
 
@@ -374,7 +491,7 @@ func (s *TCPServer) cleanupConnection(session *connection.Session) {
 			}
 			// 保存位置
 			if s.characterService != nil && mapID > 0 {
-				if m, err := s.mapService.GetMap(mapID); err == nil && m != nil {
+				if m, err := s.mapService.GetEntityMap(mapID, entityID); err == nil && m != nil {
 					if e := m.GetEntity(character.EntityID(entityID)); e != nil {
 						pos := e.Position()
 						_ = s.characterService.UpdateLastLocation(
@@ -383,7 +500,22 @@ func (s *TCPServer) cleanupConnection(session *connection.Session) {
 					}
 				}
 			}
+			if s.tradeService != nil {
+				s.tradeService.Forget(entityID)
+			}
+			if s.shopService != nil {
+				s.shopService.Forget(entityID)
+			}
+			if s.itemUseService != nil {
+				s.itemUseService.Forget(entityID)
+			}
+			if s.craftService != nil {
+				s.craftService.Forget(entityID)
+			}
 			_ = s.mapService.LeaveMapByID(s.ctx, mapID, entityID)
+			if s.portalService != nil {
+				s.portalService.Forget(entityID)
+			}
 		}
 	}
 
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"greatestworks/internal/infrastructure/datamanager"
+	"greatestworks/tools/combatsim"
+)
+
+func main() {
+	opts := combatsim.DefaultOptions()
+	var (
+		dataDir      string
+		scenarioPath string
+		name         string
+		teamA        string
+		teamB        string
+		format       string
+		outPath      string
+		step         float64
+		maxDuration  float64
+		reaction     float64
+		distance     float64
+	)
+
+	flag.StringVar(&dataDir, "data", "configs/data", "Directory containing units.json/skills.json/buffs.json")
+	flag.StringVar(&scenarioPath, "scenario", "", "Scenario JSON file (options + scenarios); overrides -a/-b")
+	flag.StringVar(&name, "name", "", "Scenario name for -a/-b fights")
+	flag.StringVar(&teamA, "a", "", "Player side, e.g. 1001 (1v1), 1001x2,1002x2 (party), 1001@10 (level 10)")
+	flag.StringVar(&teamB, "b", "", "Monster side, e.g. 2001, 2001x5 (NvM), 2002 (boss)")
+	flag.IntVar(&opts.Iterations, "n", opts.Iterations, "Iterations per scenario")
+	flag.Int64Var(&opts.Seed, "seed", opts.Seed, "Base random seed (iteration i uses seed+i)")
+	flag.Float64Var(&step, "step", float64(opts.Step), "Simulation step in seconds")
+	flag.Float64Var(&maxDuration, "max", float64(opts.MaxDuration), "Max fight duration in seconds (timeouts count as draws)")
+	flag.Float64Var(&reaction, "reaction", float64(opts.Reaction), "Max random reaction delay after each cast in seconds")
+	flag.Float64Var(&distance, "distance", float64(opts.Distance), "Distance between the two sides")
+	flag.StringVar(&format, "format", "json", "Output format: json or csv")
+	flag.StringVar(&outPath, "out", "", "Output file (default stdout)")
+	flag.Parse()
+
+	set := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	if err := datamanager.GetInstance().LoadAll(dataDir); err != nil {
+		fail("load data: %v", err)
+	}
+
+	var scenarios []*combatsim.Scenario
+	if scenarioPath != "" {
+		file, err := combatsim.LoadFile(scenarioPath)
+		if err != nil {
+			fail("%v", err)
+		}
+		// 命令行显式指定的参数覆盖场景文件
+		fileOpts := file.Options
+		if set["n"] {
+			fileOpts.Iterations = opts.Iterations
+		}
+		if set["seed"] {
+			fileOpts.Seed = opts.Seed
+		}
+		opts = fileOpts
+		scenarios = file.Scenarios
+	} else {
+		if teamA == "" || teamB == "" {
+			fail("either -scenario or both -a and -b are required")
+		}
+		a, err := combatsim.ParseTeam(teamA)
+		if err != nil {
+			fail("team a: %v", err)
+		}
+		b, err := combatsim.ParseTeam(teamB)
+		if err != nil {
+			fail("team b: %v", err)
+		}
+		if name == "" {
+			name = teamA + " vs " + teamB
+		}
+		scenarios = []*combatsim.Scenario{{Name: name, TeamA: a, TeamB: b}}
+	}
+	if set["step"] || scenarioPath == "" {
+		opts.Step = float32(step)
+	}
+	if set["max"] || scenarioPath == "" {
+		opts.MaxDuration = float32(maxDuration)
+	}
+	if set["reaction"] || scenarioPath == "" {
+		opts.Reaction = float32(reaction)
+	}
+	if set["distance"] || scenarioPath == "" {
+		opts.Distance = float32(distance)
+	}
+
+	reports := make([]*combatsim.Report, 0, len(scenarios))
+	failed := false
+	for _, sc := range scenarios {
+		report, err := combatsim.Run(sc, opts)
+		if err != nil {
+			fail("scenario %q: %v", sc.Name, err)
+		}
+		if len(report.Failures) > 0 {
+			failed = true
+			fmt.Fprintf(os.Stderr, "scenario %q failed: %s\n", sc.Name, strings.Join(report.Failures, "; "))
+		}
+		reports = append(reports, report)
+	}
+
+	if err := writeReports(outPath, format, reports); err != nil {
+		fail("write report: %v", err)
+	}
+	if failed {
+		os.Exit(2)
+	}
+}
+
+// writeReports 按格式输出报告；写入文件时在返回前关闭文件，确保退出前数据已落盘
+func writeReports(outPath, format string, reports []*combatsim.Report) (err error) {
+	var out io.Writer = os.Stdout
+	if outPath != "" {
+		f, err := os.Create(outPath)
+		if err != nil {
+			return fmt.Errorf("create output: %w", err)
+		}
+		defer func() {
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+		}()
+		out = f
+	}
+	switch strings.ToLower(format) {
+	case "csv":
+		return combatsim.WriteCSV(out, reports)
+	case "json":
+		return combatsim.WriteJSON(out, reports)
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
+
+func fail(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
@@ -0,0 +1,74 @@
+package combatsim
+
+import (
+	"reflect"
+	"testing"
+
+	"greatestworks/internal/infrastructure/datamanager"
+)
+
+func loadTestData(t *testing.T) {
+	t.Helper()
+	if err := datamanager.GetInstance().LoadAll("../../configs/data"); err != nil {
+		t.Fatalf("load data: %v", err)
+	}
+}
+
+func TestParseTeam(t *testing.T) {
+	members, err := ParseTeam("1001x2, 1002@10")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	want := []Member{{UnitID: 1001, Count: 2}, {UnitID: 1002, Level: 10}}
+	if !reflect.DeepEqual(members, want) {
+		t.Fatalf("got %+v, want %+v", members, want)
+	}
+	if _, err := ParseTeam("abc"); err == nil {
+		t.Fatalf("expected invalid unit id rejected")
+	}
+}
+
+func TestRunIsDeterministicPerSeed(t *testing.T) {
+	loadTestData(t)
+	sc := &Scenario{
+		Name:  "party vs boss",
+		TeamA: []Member{{UnitID: 1001, Count: 2}, {UnitID: 1002, Count: 2}},
+		TeamB: []Member{{UnitID: 2002}},
+	}
+	opts := DefaultOptions()
+	opts.Iterations = 5
+
+	first, err := Run(sc, opts)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	second, err := Run(sc, opts)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Fatalf("same seed produced different reports:\n%+v\n%+v", first, second)
+	}
+	if first.Iterations != 5 || first.DPSA <= 0 || first.AvgTTK <= 0 {
+		t.Fatalf("unexpected report %+v", first)
+	}
+}
+
+func TestExpectFailures(t *testing.T) {
+	loadTestData(t)
+	sc := &Scenario{
+		Name:   "1v1",
+		TeamA:  []Member{{UnitID: 1001}},
+		TeamB:  []Member{{UnitID: 2001}},
+		Expect: &Expect{MaxWinRateA: 0.01},
+	}
+	opts := DefaultOptions()
+	opts.Iterations = 3
+	report, err := Run(sc, opts)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if len(report.Failures) != 1 {
+		t.Fatalf("expected win rate threshold failure, got %+v", report)
+	}
+}
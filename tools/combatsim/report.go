@@ -0,0 +1,138 @@
+package combatsim
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+)
+
+// Report 单个场景 N 次模拟的统计
+type Report struct {
+	Scenario   string   `json:"scenario"`
+	Iterations int      `json:"iterations"`
+	Seed       int64    `json:"seed"`
+	WinRateA   float64  `json:"win_rate_a"`
+	WinRateB   float64  `json:"win_rate_b"`
+	DrawRate   float64  `json:"draw_rate"`
+	AvgTTK     float64  `json:"avg_ttk"` // 分出胜负的场次的平均耗时（秒）
+	MinTTK     float64  `json:"min_ttk"`
+	MaxTTK     float64  `json:"max_ttk"`
+	P50TTK     float64  `json:"p50_ttk"`
+	DPSA       float64  `json:"dps_a"` // A 方总 DPS（平均每场）
+	DPSB       float64  `json:"dps_b"`
+	CastsA     float64  `json:"casts_a"` // A 方平均每场施法次数
+	CastsB     float64  `json:"casts_b"`
+	Failures   []string `json:"failures,omitempty"` // 未通过的 expect 校验
+}
+
+// Run 运行场景 N 次；第 i 次使用种子 seed+i，结果可复现
+func Run(sc *Scenario, opts Options) (*Report, error) {
+	if opts.Iterations <= 0 {
+		opts.Iterations = 1
+	}
+	if opts.Step <= 0 || opts.MaxDuration <= 0 {
+		return nil, fmt.Errorf("step and max_duration must be positive")
+	}
+	r := &Report{Scenario: sc.Name, Iterations: opts.Iterations, Seed: opts.Seed}
+	var wins [2]int
+	var ttks []float64
+	var dps [2]float64
+	var casts [2]float64
+	for i := 0; i < opts.Iterations; i++ {
+		res, err := RunFight(sc, opts, rand.New(rand.NewSource(opts.Seed+int64(i))))
+		if err != nil {
+			return nil, err
+		}
+		if res.Winner >= 0 {
+			wins[res.Winner]++
+			ttks = append(ttks, float64(res.Duration))
+		}
+		for team := range dps {
+			if res.Duration > 0 {
+				dps[team] += float64(res.Damage[team]) / float64(res.Duration)
+			}
+			casts[team] += float64(res.Casts[team])
+		}
+	}
+
+	n := float64(opts.Iterations)
+	r.WinRateA = round(float64(wins[teamA]) / n)
+	r.WinRateB = round(float64(wins[teamB]) / n)
+	r.DrawRate = round(1 - r.WinRateA - r.WinRateB)
+	r.DPSA, r.DPSB = round(dps[teamA]/n), round(dps[teamB]/n)
+	r.CastsA, r.CastsB = round(casts[teamA]/n), round(casts[teamB]/n)
+	if len(ttks) > 0 {
+		sort.Float64s(ttks)
+		var sum float64
+		for _, t := range ttks {
+			sum += t
+		}
+		r.AvgTTK = round(sum / float64(len(ttks)))
+		r.MinTTK, r.MaxTTK = round(ttks[0]), round(ttks[len(ttks)-1])
+		r.P50TTK = round(ttks[len(ttks)/2])
+	}
+	r.Failures = check(sc.Expect, r)
+	return r, nil
+}
+
+// check 按阈值校验报告
+func check(e *Expect, r *Report) []string {
+	if e == nil {
+		return nil
+	}
+	var failures []string
+	if e.MinWinRateA > 0 && r.WinRateA < e.MinWinRateA {
+		failures = append(failures, fmt.Sprintf("win_rate_a %.3f < %.3f", r.WinRateA, e.MinWinRateA))
+	}
+	if e.MaxWinRateA > 0 && r.WinRateA > e.MaxWinRateA {
+		failures = append(failures, fmt.Sprintf("win_rate_a %.3f > %.3f", r.WinRateA, e.MaxWinRateA))
+	}
+	if e.MaxAvgTTK > 0 && r.AvgTTK > e.MaxAvgTTK {
+		failures = append(failures, fmt.Sprintf("avg_ttk %.2f > %.2f", r.AvgTTK, e.MaxAvgTTK))
+	}
+	return failures
+}
+
+// WriteJSON 以 JSON 输出报告
+func WriteJSON(w io.Writer, reports []*Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(reports)
+}
+
+// WriteCSV 以 CSV 输出报告（每个场景一行）
+func WriteCSV(w io.Writer, reports []*Report) error {
+	cw := csv.NewWriter(w)
+	header := []string{"scenario", "iterations", "seed", "win_rate_a", "win_rate_b", "draw_rate",
+		"avg_ttk", "min_ttk", "max_ttk", "p50_ttk", "dps_a", "dps_b", "casts_a", "casts_b", "failures"}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	f := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	for _, r := range reports {
+		failures := ""
+		for i, msg := range r.Failures {
+			if i > 0 {
+				failures += "; "
+			}
+			failures += msg
+		}
+		if err := cw.Write([]string{r.Scenario, strconv.Itoa(r.Iterations), strconv.FormatInt(r.Seed, 10),
+			f(r.WinRateA), f(r.WinRateB), f(r.DrawRate), f(r.AvgTTK), f(r.MinTTK), f(r.MaxTTK), f(r.P50TTK),
+			f(r.DPSA), f(r.DPSB), f(r.CastsA), f(r.CastsB), failures}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// round 保留 3 位小数，便于比较输出
+func round(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
@@ -0,0 +1,121 @@
+package combatsim
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Member 一方的参战单位
+type Member struct {
+	UnitID int32 `json:"unit_id"`
+	Count  int   `json:"count,omitempty"` // 0 视为 1
+	Level  int32 `json:"level,omitempty"` // 高于单位等级时按职业成长提升属性
+}
+
+// Expect CI 校验阈值（0 表示不校验）
+type Expect struct {
+	MinWinRateA float64 `json:"min_win_rate_a,omitempty"`
+	MaxWinRateA float64 `json:"max_win_rate_a,omitempty"`
+	MaxAvgTTK   float64 `json:"max_avg_ttk,omitempty"` // 平均击杀耗时上限（秒）
+}
+
+// Scenario 一组对战配置：A 方为玩家阵营，B 方为怪物阵营
+type Scenario struct {
+	Name   string   `json:"name"`
+	TeamA  []Member `json:"team_a"`
+	TeamB  []Member `json:"team_b"`
+	Expect *Expect  `json:"expect,omitempty"`
+}
+
+// Options 模拟参数
+type Options struct {
+	Iterations  int     `json:"iterations"`
+	Seed        int64   `json:"seed"`
+	Step        float32 `json:"step"`         // 帧间隔（秒）
+	MaxDuration float32 `json:"max_duration"` // 单场上限（秒），超时记为平局
+	Reaction    float32 `json:"reaction"`     // 施法后的随机反应延迟上限（秒）
+	Distance    float32 `json:"distance"`     // 两方站位间距
+}
+
+// DefaultOptions 默认模拟参数
+func DefaultOptions() Options {
+	return Options{
+		Iterations:  100,
+		Seed:        1,
+		Step:        0.05,
+		MaxDuration: 180,
+		Reaction:    0.2,
+		Distance:    1.5,
+	}
+}
+
+// File 场景文件：共享参数与场景列表
+type File struct {
+	Options   Options     `json:"options"`
+	Scenarios []*Scenario `json:"scenarios"`
+}
+
+// LoadFile 读取场景文件，未填写的参数取默认值
+func LoadFile(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read scenario file: %w", err)
+	}
+	f := &File{Options: DefaultOptions()}
+	if err := json.Unmarshal(data, f); err != nil {
+		return nil, fmt.Errorf("parse scenario file: %w", err)
+	}
+	if len(f.Scenarios) == 0 {
+		return nil, fmt.Errorf("scenario file %s has no scenarios", path)
+	}
+	return f, nil
+}
+
+// ParseTeam 解析队伍描述，如 "1001x2,1002@10"：单位ID，可选 x数量 与 @等级
+func ParseTeam(spec string) ([]Member, error) {
+	var members []Member
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		var m Member
+		if i := strings.IndexByte(part, '@'); i >= 0 {
+			level, err := strconv.ParseInt(part[i+1:], 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid level in %q", part)
+			}
+			m.Level = int32(level)
+			part = part[:i]
+		}
+		if i := strings.IndexByte(part, 'x'); i >= 0 {
+			count, err := strconv.Atoi(part[i+1:])
+			if err != nil || count <= 0 {
+				return nil, fmt.Errorf("invalid count in %q", part)
+			}
+			m.Count = count
+			part = part[:i]
+		}
+		id, err := strconv.ParseInt(part, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid unit id in %q", part)
+		}
+		m.UnitID = int32(id)
+		members = append(members, m)
+	}
+	if len(members) == 0 {
+		return nil, fmt.Errorf("empty team %q", spec)
+	}
+	return members, nil
+}
+
+// count 数量（0 视为 1）
+func (m Member) count() int {
+	if m.Count <= 0 {
+		return 1
+	}
+	return m.Count
+}
@@ -0,0 +1,250 @@
+// Package combatsim 无界面战斗模拟：按单位配置创建角色，使用真实的施法、Buff 与伤害结算进行可复现的种子化对战，用于数值平衡
+package combatsim
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+
+	appServices "greatestworks/internal/application/services"
+	"greatestworks/internal/domain/character"
+	"greatestworks/internal/domain/mapmanager"
+	"greatestworks/internal/infrastructure/datamanager"
+)
+
+const (
+	teamA = 0
+	teamB = 1
+
+	arenaSize    = 200
+	arenaOrigin  = float32(100)
+	memberSpread = float32(1) // 同一方成员的站位间隔
+
+	basicAttackSkillID = int32(1) // 普通攻击
+	skillTargetAlly    = int32(3) // 友方单体技能
+	skillTargetSelf    = int32(4) // 自身技能
+	allyHealRatio      = 0.7      // 友方生命低于该比例时释放友方技能
+)
+
+// FightResult 单场结果
+type FightResult struct {
+	Winner   int     // teamA / teamB，-1 为平局（超时或同归于尽）
+	Duration float32 // 结束时间（秒）
+	Damage   [2]int64
+	Casts    [2]int
+}
+
+// fighter 参战者与其施法决策状态
+type fighter struct {
+	actor     *character.Actor
+	team      int
+	skills    []*datamanager.SkillDefine // 释放优先级（靠后配置的技能优先，普通攻击兜底）
+	target    *fighter
+	nextThink float32
+}
+
+// arena 单场战斗
+type arena struct {
+	gameMap  *mapmanager.Map
+	fighters []*fighter
+	rng      *rand.Rand
+	opts     Options
+	casts    [2]int
+}
+
+// RunFight 以给定随机源进行一场战斗；相同场景、参数与随机源结果一致
+func RunFight(sc *Scenario, opts Options, rng *rand.Rand) (*FightResult, error) {
+	a, err := newArena(sc, opts, rng)
+	if err != nil {
+		return nil, err
+	}
+	return a.run(), nil
+}
+
+// newArena 按场景创建地图与双方角色
+func newArena(sc *Scenario, opts Options, rng *rand.Rand) (*arena, error) {
+	a := &arena{
+		gameMap: mapmanager.NewMap(0, "combatsim", arenaSize, arenaSize),
+		rng:     rng,
+		opts:    opts,
+	}
+	nextID := character.EntityID(1)
+	for team, members := range [][]Member{sc.TeamA, sc.TeamB} {
+		slot := 0
+		for _, m := range members {
+			for i := 0; i < m.count(); i++ {
+				f, err := a.spawn(nextID, team, m, slot)
+				if err != nil {
+					return nil, err
+				}
+				a.fighters = append(a.fighters, f)
+				nextID++
+				slot++
+			}
+		}
+	}
+	return a, nil
+}
+
+// spawn 按单位配置创建角色并进入地图：A 方为玩家阵营，B 方为怪物阵营
+func (a *arena) spawn(id character.EntityID, team int, m Member, slot int) (*fighter, error) {
+	unit := datamanager.GetInstance().GetUnit(m.UnitID)
+	if unit == nil {
+		return nil, fmt.Errorf("unknown unit %d", m.UnitID)
+	}
+	entityType, x := character.EntityTypePlayer, arenaOrigin
+	if team == teamB {
+		entityType, x = character.EntityTypeMonster, arenaOrigin+a.opts.Distance
+	}
+	pos := character.NewVector3(x, 0, arenaOrigin+float32(slot)*memberSpread)
+	dir := character.NewVector3(1, 0, 0)
+	if team == teamB {
+		dir = character.NewVector3(-1, 0, 0)
+	}
+
+	actor := character.NewActor(id, entityType, unit.ID, pos, dir, unit.Name, unit.Level)
+	actor.GetAttributeManager().SetBase(appServices.UnitAttributes(unit))
+	appServices.LearnUnitSkills(actor)
+	if err := actor.Start(context.Background()); err != nil {
+		return nil, err
+	}
+	actor.GetSpell().SetRandom(a.rng.Float32)
+	if m.Level > unit.Level {
+		actor.ApplyLevelUp(m.Level, appServices.UnitLevelGrowth(unit, m.Level-unit.Level))
+	}
+	if err := a.gameMap.EnterActor(context.Background(), actor); err != nil {
+		return nil, err
+	}
+
+	f := &fighter{actor: actor, team: team, nextThink: a.rng.Float32() * a.opts.Reaction}
+	for i := len(unit.Skills) - 1; i >= 0; i-- {
+		if def := datamanager.GetInstance().GetSkill(unit.Skills[i]); def != nil && def.Resurrect == 0 {
+			f.skills = append(f.skills, def)
+		}
+	}
+	if len(f.skills) == 0 {
+		// 未配置技能的单位使用普通攻击（与 LearnUnitSkills 一致）
+		if def := datamanager.GetInstance().GetSkill(basicAttackSkillID); def != nil {
+			f.skills = append(f.skills, def)
+		}
+	}
+	return f, nil
+}
+
+// run 逐帧推进直到一方全灭或超时
+func (a *arena) run() *FightResult {
+	ctx := context.Background()
+	var now float32
+	for now < a.opts.MaxDuration && a.alive(teamA) > 0 && a.alive(teamB) > 0 {
+		for _, f := range a.fighters {
+			a.think(f, now)
+		}
+		_ = a.gameMap.Update(ctx, a.opts.Step)
+		now += a.opts.Step
+	}
+
+	result := &FightResult{Winner: -1, Duration: now, Casts: a.casts}
+	switch aliveA, aliveB := a.alive(teamA), a.alive(teamB); {
+	case aliveA > 0 && aliveB == 0:
+		result.Winner = teamA
+	case aliveB > 0 && aliveA == 0:
+		result.Winner = teamB
+	}
+	teamOf := make(map[character.EntityID]int, len(a.fighters))
+	for _, f := range a.fighters {
+		teamOf[f.actor.ID()] = f.team
+	}
+	for _, f := range a.fighters {
+		for attacker, dmg := range f.actor.DamageContributors() {
+			if team, ok := teamOf[attacker]; ok && team != f.team {
+				result.Damage[team] += dmg
+			}
+		}
+	}
+	return result
+}
+
+// think 施法决策：空闲时按优先级释放第一个可用技能
+func (a *arena) think(f *fighter, now float32) {
+	if f.actor.IsDeath() || now < f.nextThink || f.actor.GetSpell().IsCasting() {
+		return
+	}
+	if f.target == nil || f.target.actor.IsDeath() {
+		f.target = a.pickEnemy(f)
+		if f.target == nil {
+			return
+		}
+	}
+	for _, def := range f.skills {
+		sk := f.actor.GetSkillManager().GetSkill(def.ID)
+		if sk == nil || sk.State() != character.SkillStateReady || f.actor.MP() < float32(def.MPCost) {
+			continue
+		}
+		var target *character.Actor
+		switch def.TargetType {
+		case skillTargetSelf:
+			target = f.actor
+		case skillTargetAlly:
+			ally := a.weakestAlly(f)
+			if ally == nil {
+				continue
+			}
+			target = ally.actor
+		default:
+			target = f.target.actor
+		}
+		if !f.actor.GetSpell().Cast(def.ID, target) {
+			continue
+		}
+		if def.MPCost > 0 {
+			f.actor.ChangeMP(-float32(def.MPCost))
+		}
+		a.casts[f.team]++
+		f.nextThink = now + a.rng.Float32()*a.opts.Reaction
+		return
+	}
+}
+
+// pickEnemy 随机选择一个存活的敌方
+func (a *arena) pickEnemy(f *fighter) *fighter {
+	var enemies []*fighter
+	for _, other := range a.fighters {
+		if other.team != f.team && !other.actor.IsDeath() {
+			enemies = append(enemies, other)
+		}
+	}
+	if len(enemies) == 0 {
+		return nil
+	}
+	return enemies[a.rng.Intn(len(enemies))]
+}
+
+// weakestAlly 生命比例最低且低于阈值的存活友方（含自身）
+func (a *arena) weakestAlly(f *fighter) *fighter {
+	var best *fighter
+	bestRatio := float32(allyHealRatio)
+	for _, other := range a.fighters {
+		if other.team != f.team || other.actor.IsDeath() {
+			continue
+		}
+		maxHP := other.actor.GetAttributeManager().Final().MaxHP
+		if maxHP <= 0 {
+			continue
+		}
+		if ratio := other.actor.HP() / maxHP; ratio < bestRatio {
+			best, bestRatio = other, ratio
+		}
+	}
+	return best
+}
+
+// alive 一方存活人数
+func (a *arena) alive(team int) int {
+	n := 0
+	for _, f := range a.fighters {
+		if f.team == team && !f.actor.IsDeath() {
+			n++
+		}
+	}
+	return n
+}